@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/autoupdate"
+	"github.com/obentoo/bentoolkit/internal/common/cache"
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+	"github.com/obentoo/bentoolkit/internal/common/provider"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cacheKinds     []string
+	cacheOlderThan time.Duration
+	cacheYes       bool
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clean bentoo's caches",
+	Long: `Inspect and clean the caches bentoo keeps under ~/.cache/bentoo
+(following XDG_CACHE_HOME):
+
+  autoupdate  version check results of 'overlay autoupdate'
+  analysis    LLM analysis results of 'overlay analyze'
+  compare     GitHub and GitLab tree, ebuild and profiles/updates caches
+  repos       git clones of 'overlay compare --clone'
+  legacy      per-package files of the retired GitHub client
+
+Every subcommand takes --kind to select some kinds only.`,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every cache with its entries, size and age",
+	Args:  cobra.NoArgs,
+	Run:   runCacheList,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show cache totals per kind",
+	Args:  cobra.NoArgs,
+	Run:   runCacheStats,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired cache entries",
+	Long: `Remove the expired entries of every cache: autoupdate and analysis
+entries past their TTL, tree indexes of an older format and legacy files.
+
+With --older-than, caches not updated for that long are cleared too, e.g.
+--older-than 720h for clones and API caches unused for 30 days.`,
+	Args: cobra.NoArgs,
+	Run:  runCachePrune,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cache entry",
+	Long: `Remove every entry of the selected caches. Clones are deleted and
+fetched again on next use.
+
+Examples:
+  bentoo cache clear --kind analysis     # Clear the LLM analysis cache
+  bentoo cache clear --kind repos --yes  # Delete the clones without asking`,
+	Args: cobra.NoArgs,
+	Run:  runCacheClear,
+}
+
+func init() {
+	cacheCmd.PersistentFlags().StringSliceVar(&cacheKinds, "kind", nil,
+		"Cache kinds to act on: "+strings.Join(cache.Kinds, ", ")+" (default: all)")
+	cachePruneCmd.Flags().DurationVar(&cacheOlderThan, "older-than", 0, "Also clear caches not updated for this long")
+	cacheClearCmd.Flags().BoolVarP(&cacheYes, "yes", "y", false, "Skip the confirmation prompt")
+
+	cacheCmd.AddCommand(cacheListCmd, cacheStatsCmd, cachePruneCmd, cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// cacheStores returns the caches selected by --kind. Autoupdate caches left
+// in the config directory by older versions are moved first.
+func cacheStores() []cache.Store {
+	for _, kind := range cacheKinds {
+		valid := false
+		for _, k := range cache.Kinds {
+			valid = valid || kind == k
+		}
+		if !valid {
+			logger.Error("unknown cache kind '%s' (valid: %s)", kind, strings.Join(cache.Kinds, ", "))
+			os.Exit(1)
+		}
+	}
+
+	root, err := config.CacheDir()
+	if err != nil {
+		logger.Error("locating cache directory: %v", err)
+		os.Exit(1)
+	}
+
+	// The version cache TTL is configurable; a missing config uses the default
+	ttl := time.Duration(config.DefaultCacheTTL) * time.Second
+	if cfg, err := config.Load(); err == nil {
+		ttl = time.Duration(cfg.Autoupdate.GetCacheTTL()) * time.Second
+	}
+
+	cacheDir := autoupdate.DefaultCacheDir()
+	autoupdate.MigrateCaches(filepath.Join(os.Getenv("HOME"), ".config", "bentoo", "autoupdate"), cacheDir)
+	stores := autoupdate.CacheStores(cacheDir, ttl)
+
+	providerStores, err := provider.CacheStores(root)
+	if err != nil {
+		logger.Error("listing provider caches: %v", err)
+		os.Exit(1)
+	}
+	stores = append(stores, providerStores...)
+
+	return cache.Filter(stores, cacheKinds...)
+}
+
+func runCacheList(cmd *cobra.Command, args []string) {
+	fmt.Print(cache.FormatList(cache.Collect(cacheStores()), time.Now()))
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) {
+	fmt.Print(cache.FormatSummary(cache.Collect(cacheStores()), time.Now()))
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) {
+	entries := cache.Collect(cacheStores())
+	cutoff := time.Now().Add(-cacheOlderThan)
+
+	failed := false
+	for _, e := range entries {
+		if e.Err != nil {
+			logger.Warn("%s %s: %v", e.Store.Kind(), e.Store.Name(), e.Err)
+			failed = true
+			continue
+		}
+
+		var err error
+		switch {
+		case cacheOlderThan > 0 && e.Stats.Entries > 0 && e.Stats.Newest.Before(cutoff):
+			logger.Info("Clearing %s %s (%d entries, %s)", e.Store.Kind(), e.Store.Name(),
+				e.Stats.Entries, cache.FormatSize(e.Stats.Size))
+			err = e.Store.Clear()
+		case e.Stats.Expired > 0:
+			logger.Info("Pruning %s %s (%d expired)", e.Store.Kind(), e.Store.Name(), e.Stats.Expired)
+			err = e.Store.Prune()
+		}
+		if err != nil {
+			logger.Error("%s %s: %v", e.Store.Kind(), e.Store.Name(), err)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+	logger.Info("%s", output.Sprintf(output.Success, "Caches pruned"))
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) {
+	entries := cache.Collect(cacheStores())
+
+	var total cache.Stats
+	for _, e := range entries {
+		total.Add(e.Stats)
+	}
+	if total.Entries == 0 {
+		logger.Info("Nothing to clear")
+		return
+	}
+
+	kinds := "all"
+	if len(cacheKinds) > 0 {
+		kinds = strings.Join(cacheKinds, ", ")
+	}
+	if !cacheYes && !confirmAction(fmt.Sprintf("Clear %d entries (%s) from %s caches?",
+		total.Entries, cache.FormatSize(total.Size), kinds)) {
+		logger.Info("Aborted")
+		return
+	}
+
+	failed := false
+	for _, e := range entries {
+		if e.Stats.Entries == 0 && e.Err == nil {
+			continue
+		}
+		if err := e.Store.Clear(); err != nil {
+			logger.Error("%s %s: %v", e.Store.Kind(), e.Store.Name(), err)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+	logger.Info("%s", output.Sprintf(output.Success, "Cleared %d entries", total.Entries))
+}
@@ -39,6 +39,13 @@ func TestRootCommand(t *testing.T) {
 	if !found {
 		t.Error("overlay subcommand should exist")
 	}
+
+	// Global flags apply to every command
+	for _, name := range []string{"verbose", "quiet", "no-color", "offline"} {
+		if rootCmd.PersistentFlags().Lookup(name) == nil {
+			t.Errorf("root command should have a persistent --%s flag", name)
+		}
+	}
 }
 
 // TestOverlaySubcommands tests that all overlay subcommands are registered
@@ -13,6 +13,7 @@ var (
 	verbose bool
 	quiet   bool
 	noColor bool
+	offline bool
 )
 
 var rootCmd = &cobra.Command{
@@ -44,10 +45,20 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress non-error output")
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Work from caches only, without network access")
 
 	rootCmd.AddCommand(overlayCmd)
 }
 
+// requireNetwork exits when --offline is set, for operations that cannot
+// work from caches
+func requireNetwork(operation string) {
+	if offline {
+		logger.Error("%s needs network access and cannot run with --offline", operation)
+		os.Exit(1)
+	}
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
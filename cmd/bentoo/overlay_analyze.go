@@ -94,7 +94,10 @@ func runAnalyze(cmd *cobra.Command, args []string) {
 	}
 
 	// Create analyzer
-	analyzer, err := autoupdate.NewAnalyzer(overlayPath, autoupdate.WithAnalyzerConfigDir(configDir))
+	analyzer, err := autoupdate.NewAnalyzer(overlayPath,
+		autoupdate.WithAnalyzerConfigDir(configDir),
+		autoupdate.WithAnalyzerOffline(offline),
+	)
 	if err != nil {
 		logger.Error("failed to initialize analyzer: %v", err)
 		os.Exit(1)
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/obentoo/bentoolkit/internal/autoupdate"
+	"github.com/obentoo/bentoolkit/internal/common/cache"
 	"github.com/obentoo/bentoolkit/internal/common/config"
 	"github.com/obentoo/bentoolkit/internal/common/logger"
 	"github.com/obentoo/bentoolkit/internal/common/output"
@@ -35,6 +37,7 @@ Examples:
   bentoo overlay autoupdate --check              Check all packages for updates
   bentoo overlay autoupdate --check net-misc/foo Check specific package
   bentoo overlay autoupdate --check --force      Check ignoring cache
+  bentoo --offline overlay autoupdate --check    Check from cache only
   bentoo overlay autoupdate --list               List pending updates
   bentoo overlay autoupdate --apply net-misc/foo Apply update for package
   bentoo overlay autoupdate --apply net-misc/foo --compile  Apply and compile test`,
@@ -91,9 +94,18 @@ func runAutoupdate(cmd *cobra.Command, args []string) {
 	}
 }
 
-// runCheck handles the --check flag
+// runCheck handles the --check flag. Offline, results come from the cache,
+// expired entries included.
 func runCheck(overlayPath, configDir string, args []string) {
-	checker, err := autoupdate.NewChecker(overlayPath, autoupdate.WithConfigDir(configDir))
+	if offline && autoupdateForce {
+		logger.Error("--force cannot be combined with --offline, which only reads the cache")
+		os.Exit(1)
+	}
+
+	checker, err := autoupdate.NewChecker(overlayPath,
+		autoupdate.WithConfigDir(configDir),
+		autoupdate.WithOffline(offline),
+	)
 	if err != nil {
 		logger.Error("failed to initialize checker: %v", err)
 		os.Exit(1)
@@ -132,6 +144,8 @@ func displayCheckResults(results []autoupdate.CheckResult) {
 
 	var updatesFound int
 	var errorsFound int
+	var staleFound int
+	now := time.Now()
 
 	fmt.Println()
 	output.Header.Println("Version Check Results")
@@ -144,16 +158,22 @@ func displayCheckResults(results []autoupdate.CheckResult) {
 			continue
 		}
 
+		// Stale results are flagged so they are not taken for live ones
+		cacheIndicator := ""
+		switch r.Freshness {
+		case cache.Cached:
+			cacheIndicator = output.Sprintf(output.Dim, " (cached)")
+		case cache.Stale:
+			staleFound++
+			cacheIndicator = output.Sprintf(output.Warning, " (%s)", cache.Describe(r.Freshness, r.FetchedAt, now))
+		}
+
 		if r.HasUpdate {
 			updatesFound++
-			cacheIndicator := ""
-			if r.FromCache {
-				cacheIndicator = output.Sprintf(output.Dim, " (cached)")
-			}
 			output.Success.Printf("  %s: %s → %s%s\n",
 				r.Package, r.CurrentVersion, r.UpstreamVersion, cacheIndicator)
 		} else {
-			output.Dim.Printf("  %s: %s (up to date)\n", r.Package, r.CurrentVersion)
+			output.Dim.Printf("  %s: %s (up to date)%s\n", r.Package, r.CurrentVersion, cacheIndicator)
 		}
 	}
 
@@ -168,6 +188,9 @@ func displayCheckResults(results []autoupdate.CheckResult) {
 	if errorsFound > 0 {
 		output.Warning.Printf("%d package(s) had errors\n", errorsFound)
 	}
+	if staleFound > 0 {
+		output.Warning.Printf("%d result(s) are stale: their cache entries expired and could not be refreshed offline\n", staleFound)
+	}
 }
 
 // runList handles the --list flag
@@ -193,12 +216,16 @@ func displayPendingUpdates(updates []autoupdate.PendingUpdate) {
 	output.Header.Println("Pending Updates")
 	fmt.Println()
 
+	now := time.Now()
 	for _, u := range updates {
 		statusColor := getStatusColor(u.Status)
 		statusStr := output.Sprintf(statusColor, "[%s]", u.Status)
 
 		output.Package.Printf("  %s\n", u.Package)
 		fmt.Printf("    Version: %s → %s\n", u.CurrentVersion, u.NewVersion)
+		if u.Freshness == cache.Stale {
+			output.Warning.Printf("    Upstream: %s\n", cache.Describe(u.Freshness, u.FetchedAt, now))
+		}
 		fmt.Printf("    Status:  %s\n", statusStr)
 		if u.Error != "" {
 			output.Error.Printf("    Error:   %s\n", u.Error)
@@ -229,6 +256,9 @@ func getStatusColor(status autoupdate.UpdateStatus) *color.Color {
 
 // runApply handles the --apply flag
 func runApply(overlayPath, configDir, pkg string) {
+	// The manifest step downloads the new distfiles
+	requireNetwork("applying an update")
+
 	applier, err := autoupdate.NewApplier(overlayPath, configDir)
 	if err != nil {
 		logger.Error("failed to initialize applier: %v", err)
@@ -258,6 +288,10 @@ func displayApplyResult(result *autoupdate.ApplyResult) {
 
 	output.Package.Printf("  %s\n", result.Package)
 	fmt.Printf("    Version: %s → %s\n", result.OldVersion, result.NewVersion)
+	if result.Freshness == cache.Stale {
+		output.Warning.Printf("    Upstream: %s; the version may be outdated\n",
+			cache.Describe(result.Freshness, result.FetchedAt, time.Now()))
+	}
 
 	if result.Success {
 		output.Success.Println("    Status:  Success")
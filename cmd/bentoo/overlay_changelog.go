@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+	"github.com/obentoo/bentoolkit/internal/overlay"
+	"github.com/spf13/cobra"
+)
+
+var (
+	changelogSince  string
+	changelogFormat string
+	changelogTitle  string
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Generate a changelog of package changes",
+	Long: `Generate release notes from the overlay history.
+
+Commits since a ref or date are classified by their bentoo commit subject
+(add/del/mod/up/down(...)), or by the files they change when the subject is
+hand-written. Changes are grouped by date, category and change type, and
+several bumps of the same package are merged into a single old -> newest entry.
+
+Examples:
+  # What changed this week, as Markdown
+  bentoo overlay changelog --since 2025-06-02
+
+  # Everything since a release tag, as an Atom feed
+  bentoo overlay changelog --since v2025.06 --format atom > feed.xml`,
+	Run: runChangelog,
+}
+
+func init() {
+	changelogCmd.Flags().StringVar(&changelogSince, "since", "", "Starting ref or date (YYYY-MM-DD)")
+	changelogCmd.Flags().StringVarP(&changelogFormat, "format", "f", "markdown", "Output format: markdown, html or atom")
+	changelogCmd.Flags().StringVar(&changelogTitle, "title", "Bentoo overlay changes", "Title of the changelog or feed")
+	changelogCmd.MarkFlagRequired("since")
+	overlayCmd.AddCommand(changelogCmd)
+}
+
+func runChangelog(cmd *cobra.Command, args []string) {
+	if changelogFormat != "markdown" && changelogFormat != "html" && changelogFormat != "atom" {
+		logger.Error("invalid format %q: expected markdown, html or atom", changelogFormat)
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("loading config: %v", err)
+		os.Exit(1)
+	}
+
+	cl, err := overlay.BuildChangelog(cfg, changelogSince)
+	if err != nil {
+		logger.Error("%v", err)
+		os.Exit(1)
+	}
+
+	var out string
+	switch changelogFormat {
+	case "html":
+		out, err = overlay.FormatChangelogHTML(cl, changelogTitle)
+	case "atom":
+		out, err = overlay.FormatChangelogAtom(cl, changelogTitle)
+	default:
+		out = overlay.FormatChangelogMarkdown(cl, changelogTitle)
+	}
+	if err != nil {
+		logger.Error("rendering changelog: %v", err)
+		os.Exit(1)
+	}
+
+	fmt.Print(out)
+	if changelogFormat == "markdown" {
+		fmt.Println()
+	}
+}
@@ -15,8 +15,12 @@ import (
 )
 
 var (
-	commitMessage string
-	commitDryRun  bool
+	commitMessage  string
+	commitDryRun   bool
+	commitNoVerify bool
+	commitSplit    bool
+	commitStyle    string
+	commitSignOff  bool
 )
 
 var commitCmd = &cobra.Command{
@@ -24,13 +28,43 @@ var commitCmd = &cobra.Command{
 	Short: "Commit staged changes with auto-generated message",
 	Long: `Commit staged changes to the overlay repository.
 If no message is provided with -m, an automatic commit message is generated
-based on the ebuild changes and a confirmation prompt is shown.`,
+based on the ebuild changes and a confirmation prompt is shown.
+
+Before committing, pre-commit checks run against the staged packages:
+  - manifest: new ebuilds with SRC_URI have a tracked, staged Manifest
+  - filename: staged ebuild paths parse as <category>/<package>/<package>-<version>.ebuild
+  - files:    files referenced via ${FILESDIR} exist and are tracked
+  - hooks:    external commands from overlay.qa.hooks in the config
+
+Checks can be disabled with overlay.qa.skip in the config, or skipped for a
+single commit with --no-verify.
+
+The generated message style is set with overlay.commit.style in the config
+or --style:
+  - bentoo:   add(cat/pkg-1.0), up(cat/pkg-1.0 -> 2.0)  (default)
+  - gentoo:   cat/pkg: add 2.0, drop 1.0
+  - template: Go text/template from overlay.commit.template, executed with
+              .Changes (Type, Category, Package, Version, OldVersion) and
+              .Files, plus the join, bentoo and gentoo functions
+
+overlay.commit.body appends a list of changed files, and overlay.commit.signoff
+(or --signoff) appends a Signed-off-by trailer.
+
+With --split, staged changes are grouped by package and committed as one
+commit per package, each with its own generated message. All planned commits
+are shown before anything is committed. If any commit fails, the commits
+already created by the split are undone and the staging area is left intact.`,
 	Run: runCommit,
 }
 
 func init() {
 	commitCmd.Flags().StringVarP(&commitMessage, "message", "m", "", "Custom commit message (bypasses auto-generation)")
 	commitCmd.Flags().BoolVarP(&commitDryRun, "dry-run", "n", false, "Show what would be committed without committing")
+	commitCmd.Flags().BoolVar(&commitNoVerify, "no-verify", false, "Skip pre-commit checks")
+	commitCmd.Flags().BoolVar(&commitSplit, "split", false, "Create one commit per staged package")
+	commitCmd.Flags().StringVar(&commitStyle, "style", "", "Message style: bentoo, gentoo or template (overrides config)")
+	commitCmd.Flags().BoolVarP(&commitSignOff, "signoff", "s", false, "Add a Signed-off-by trailer")
+	commitCmd.MarkFlagsMutuallyExclusive("message", "split")
 	overlayCmd.AddCommand(commitCmd)
 }
 
@@ -51,9 +85,20 @@ func runCommit(cmd *cobra.Command, args []string) {
 	cfg.Git.User = user
 	cfg.Git.Email = email
 
+	msgOpts := overlay.MessageOptionsFromConfig(cfg)
+	if commitStyle != "" {
+		msgOpts.Style = commitStyle
+	}
+	if commitSignOff {
+		msgOpts.SignOff = true
+	}
+
 	// If custom message provided, use it directly
 	if commitMessage != "" {
-		if err := overlay.Commit(cfg, commitMessage); err != nil {
+		if !runPreCommitChecks(cfg) {
+			os.Exit(1)
+		}
+		if err := overlay.Commit(cfg, overlay.ApplySignOff(commitMessage, msgOpts)); err != nil {
 			logger.Error("%v", err)
 			os.Exit(1)
 		}
@@ -61,14 +106,18 @@ func runCommit(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	// Get staged changes for auto-generation
-	overlayPath, err := cfg.GetOverlayPath()
+	// Get staged changes for auto-generation; --split needs path-limited
+	// commits, so refuse it up front on backends without them
+	var features []git.Feature
+	if commitSplit {
+		features = append(features, git.FeatureCommitPaths)
+	}
+	runner, err := overlay.NewExecutor(cfg, features...)
 	if err != nil {
 		logger.Error("%v", err)
 		os.Exit(1)
 	}
 
-	runner := git.NewGitRunner(overlayPath)
 	entries, err := runner.Status()
 	if err != nil {
 		logger.Error("getting status: %v", err)
@@ -76,37 +125,47 @@ func runCommit(cmd *cobra.Command, args []string) {
 	}
 
 	// Filter to only staged entries
-	var stagedEntries []git.StatusEntry
-	for _, e := range entries {
-		status := strings.TrimSpace(e.Status)
-		if len(status) > 0 && status[0] != ' ' && status != "??" {
-			stagedEntries = append(stagedEntries, e)
-		}
-	}
+	stagedEntries := overlay.StagedEntries(entries)
 
 	if len(stagedEntries) == 0 {
 		logger.Warn("No staged changes to commit.")
 		os.Exit(0)
 	}
 
-	// Analyze changes and generate message
-	changes := overlay.AnalyzeChanges(stagedEntries)
-	generatedMessage := overlay.GenerateMessage(changes)
+	if commitSplit {
+		runSplitCommit(cfg, stagedEntries, msgOpts)
+		return
+	}
+
+	// Generate message in the configured style
+	generatedMessage, err := overlay.FormatMessage(stagedEntries, msgOpts)
+	if err != nil {
+		logger.Error("generating commit message: %v", err)
+		os.Exit(1)
+	}
 
 	// Dry-run mode: just show what would be committed
 	if commitDryRun {
 		logger.Info("Dry-run mode - would commit with message:")
-		fmt.Printf("  %s\n\n", output.Sprint(output.Info, generatedMessage))
+		fmt.Printf("%s\n\n", indentMessage(generatedMessage))
 		logger.Info("Staged files:")
 		for _, e := range stagedEntries {
 			fmt.Printf("  %s %s\n", output.FormatStatus(overlay.StatusLabel(e.Status)), e.FilePath)
 		}
+		if !commitNoVerify {
+			fmt.Println()
+			runPreCommitChecks(cfg)
+		}
 		return
 	}
 
+	if !runPreCommitChecks(cfg) {
+		os.Exit(1)
+	}
+
 	// Show preview and prompt
 	logger.Info("Generated commit message:")
-	fmt.Printf("  %s\n\n", output.Sprint(output.Info, generatedMessage))
+	fmt.Printf("%s\n\n", indentMessage(generatedMessage))
 	fmt.Print("Proceed? [y]es / [e]dit / [c]ancel: ")
 
 	reader := bufio.NewReader(os.Stdin)
@@ -140,7 +199,21 @@ func runCommit(cmd *cobra.Command, args []string) {
 			logger.Warn("Commit cancelled (empty message).")
 			os.Exit(0)
 		}
-		if err := overlay.Commit(cfg, customMessage); err != nil {
+		if err := overlay.LintMessage(customMessage, overlay.AnalyzeChanges(stagedEntries)); err != nil {
+			logger.Warn("%v", err)
+			fmt.Print("Commit anyway? [y/N]: ")
+			confirm, err := reader.ReadString('\n')
+			if err != nil {
+				logger.Error("reading input: %v", err)
+				os.Exit(1)
+			}
+			confirm = strings.TrimSpace(strings.ToLower(confirm))
+			if confirm != "y" && confirm != "yes" {
+				logger.Info("Commit cancelled.")
+				os.Exit(0)
+			}
+		}
+		if err := overlay.Commit(cfg, overlay.ApplySignOff(customMessage, msgOpts)); err != nil {
 			logger.Error("%v", err)
 			os.Exit(1)
 		}
@@ -155,3 +228,90 @@ func runCommit(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 }
+
+// runSplitCommit previews and creates one commit per staged package
+func runSplitCommit(cfg *config.Config, stagedEntries []git.StatusEntry, msgOpts overlay.MessageOptions) {
+	plans, err := overlay.PlanSplitCommits(stagedEntries, msgOpts)
+	if err != nil {
+		logger.Error("generating commit messages: %v", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(overlay.FormatSplitPlan(plans))
+	fmt.Println()
+
+	if commitDryRun {
+		logger.Info("Dry-run mode - no commits created.")
+		if !commitNoVerify {
+			fmt.Println()
+			runPreCommitChecks(cfg)
+		}
+		return
+	}
+
+	if !runPreCommitChecks(cfg) {
+		os.Exit(1)
+	}
+
+	fmt.Printf("Create %d commit(s)? [y/N]: ", len(plans))
+	reader := bufio.NewReader(os.Stdin)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		logger.Error("reading input: %v", err)
+		os.Exit(1)
+	}
+
+	input = strings.TrimSpace(strings.ToLower(input))
+	if input != "y" && input != "yes" {
+		logger.Info("Commit cancelled.")
+		os.Exit(0)
+	}
+
+	result, err := overlay.CommitSplit(cfg, plans)
+	if err != nil {
+		logger.Error("%v", err)
+		os.Exit(1)
+	}
+
+	logger.Info("Created %d commit(s).", len(result.Committed))
+}
+
+// indentMessage colors the subject of a commit message and indents every line for preview
+func indentMessage(message string) string {
+	lines := strings.Split(message, "\n")
+	lines[0] = output.Sprint(output.Info, lines[0])
+	for i := range lines {
+		if lines[i] != "" {
+			lines[i] = "  " + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runPreCommitChecks runs the QA gate against staged packages and prints the report.
+// Returns false if the commit must be blocked. Always returns true with --no-verify.
+func runPreCommitChecks(cfg *config.Config) bool {
+	if commitNoVerify {
+		logger.Debug("Skipping pre-commit checks (--no-verify)")
+		return true
+	}
+
+	report, err := overlay.RunQA(cfg)
+	if err != nil {
+		logger.Error("running pre-commit checks: %v", err)
+		return false
+	}
+
+	if report.HasErrors() {
+		logger.Error("%s", overlay.FormatQAReport(report))
+		logger.Error("%v; fix the issues above or use --no-verify to skip", overlay.ErrQAFailed)
+		return false
+	}
+
+	if len(report.Issues) > 0 {
+		logger.Warn("%s", overlay.FormatQAReport(report))
+	} else {
+		logger.Debug("%s", overlay.FormatQAReport(report))
+	}
+	return true
+}
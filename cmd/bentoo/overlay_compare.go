@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -21,10 +23,16 @@ var (
 	compareTimeout       int
 	compareToken         string
 	compareIncludeSynced bool
+	compareAllRepos      bool
+	compareSlots         bool
+	compareArch          string
+	compareDiff          string
+	compareDiffVersion   string
+	compareImport        bool
 )
 
 var compareCmd = &cobra.Command{
-	Use:   "compare [repository]",
+	Use:   "compare [repository...]",
 	Short: "Compare overlay packages with upstream repository",
 	Long: `Compare package versions in your local Bentoo overlay against
 an upstream repository.
@@ -40,14 +48,35 @@ based on the repository configuration. Use --clone to force git clone.
 By default, only outdated packages are shown. Use --include-synced to also
 display packages that have the same version in both repositories.
 
+With several repositories, or --all-repos, one report is produced with a
+version column per repository. Packages are compared against the highest
+upstream version, and the repository holding it is shown. --all-repos uses
+the built-in, configured and repos.conf repositories.
+
+With --slots, SLOT and KEYWORDS are read from the local and upstream ebuilds
+and each slot is compared separately. The Keywords column tells an update
+that is only ~arch (or unkeyworded) upstream from a stable one. This reads
+every upstream ebuild of the compared packages; in API mode the ebuilds are
+cached, but the first run costs one request per ebuild.
+
+With --diff <category/package>, the upstream ebuild, metadata.xml and files/
+of the package are fetched and shown as a unified diff against the overlay's
+latest ebuild, instead of the report. --import then copies the upstream
+ebuild into the overlay as a new version, with the files/ entries the overlay
+lacks.
+
 Examples:
   bentoo overlay compare                    # Compare with gentoo (API)
   bentoo overlay compare guru               # Compare with GURU (API)
   bentoo overlay compare --clone            # Compare with gentoo (git clone)
   bentoo overlay compare guru --clone       # Compare with GURU (git clone)
-  bentoo overlay compare --include-synced   # Include up-to-date packages`,
-	Args: cobra.MaximumNArgs(1),
-	Run:  runCompare,
+  bentoo overlay compare --include-synced   # Include up-to-date packages
+  bentoo overlay compare gentoo guru        # Compare with both at once
+  bentoo overlay compare --all-repos        # Compare with every known repository
+  bentoo overlay compare --slots --clone    # Compare per slot and keyword
+  bentoo overlay compare --diff app-misc/hello           # Diff against upstream
+  bentoo overlay compare --diff app-misc/hello --import  # ...and import it`,
+	Run: runCompare,
 }
 
 func init() {
@@ -57,6 +86,12 @@ func init() {
 	compareCmd.Flags().IntVar(&compareTimeout, "timeout", 30, "HTTP request timeout in seconds")
 	compareCmd.Flags().StringVar(&compareToken, "token", "", "Auth token for API provider")
 	compareCmd.Flags().BoolVar(&compareIncludeSynced, "include-synced", false, "Include packages with same version in both repositories")
+	compareCmd.Flags().BoolVar(&compareAllRepos, "all-repos", false, "Compare against every known repository")
+	compareCmd.Flags().BoolVar(&compareSlots, "slots", false, "Compare each slot and report upstream keywords")
+	compareCmd.Flags().StringVar(&compareArch, "arch", "amd64", "Architecture to check keywords on with --slots (empty for any)")
+	compareCmd.Flags().StringVar(&compareDiff, "diff", "", "Diff a package (category/package) against upstream")
+	compareCmd.Flags().StringVar(&compareDiffVersion, "diff-version", "", "Upstream version to diff against (default: latest)")
+	compareCmd.Flags().BoolVar(&compareImport, "import", false, "Import the upstream ebuild diffed with --diff as a new version")
 	overlayCmd.AddCommand(compareCmd)
 }
 
@@ -73,80 +108,35 @@ func runCompare(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Determine repository name (default: gentoo)
-	repoName := "gentoo"
-	if len(args) > 0 {
-		repoName = args[0]
-	}
-
 	// Convert config repos to provider.RepositoryInfo map
 	configRepos := convertConfigRepos(cfg)
 
-	// Resolve repository info
-	repoInfo, err := provider.ResolveRepository(repoName, configRepos)
-	if err != nil {
-		logger.Error("Repository '%s' not found.", repoName)
-		logger.Info("Available repositories: %s", strings.Join(provider.ListAvailableRepositories(configRepos), ", "))
+	// Determine repository names (default: gentoo)
+	repoNames := args
+	switch {
+	case compareAllRepos && len(args) > 0:
+		logger.Error("--all-repos cannot be combined with repository names")
 		os.Exit(1)
+	case compareAllRepos:
+		repoNames = allCompareRepos(configRepos, overlayPath)
+	case len(args) == 0:
+		repoNames = []string{"gentoo"}
 	}
 
-	// Apply token (priority: flag > env > config > repo-specific)
-	token := compareToken
-	if token == "" {
-		token = os.Getenv("GITHUB_TOKEN")
-	}
-	if token == "" {
-		token = cfg.GitHub.Token
-	}
-	if token != "" && repoInfo.Token == "" {
-		repoInfo.Token = token
-	}
-
-	// Create provider
-	prov, err := provider.NewProvider(repoInfo, compareClone)
-	if err != nil {
-		logger.Error("Failed to create provider: %v", err)
+	if compareDiff == "" && (compareImport || compareDiffVersion != "") {
+		logger.Error("--import and --diff-version require --diff")
 		os.Exit(1)
 	}
-	defer prov.Close()
-
-	// Set timeout for API providers
-	if ghProv, ok := prov.(*provider.GitHubProvider); ok {
-		ghProv.HTTPClient.Timeout = time.Duration(compareTimeout) * time.Second
-		if compareNoCache {
-			ghProv.CacheDir = ""
-		}
+	if compareDiff != "" && len(repoNames) > 1 {
+		logger.Error("--diff compares against a single repository")
+		os.Exit(1)
 	}
 
-	// Check rate limit for GitHub provider - block if exhausted
-	if ghProv, ok := prov.(*provider.GitHubProvider); ok {
-		remaining, resetTime, err := ghProv.GetRateLimitInfo()
-		if err == nil {
-			if remaining == 0 {
-				logger.Error("GitHub API rate limit exceeded (resets at %s)", resetTime.Format("15:04:05"))
-				logger.Info("")
-				logger.Info("Options:")
-				logger.Info("  1. Use --clone to download the repository:")
-				logger.Info("     bentoo overlay compare %s --clone", repoName)
-				logger.Info("")
-				logger.Info("  2. Configure a local repository path in ~/.config/bentoo/config.yaml:")
-				logger.Info("     repositories:")
-				logger.Info("       gentoo-local:")
-				logger.Info("         provider: git")
-				logger.Info("         url: /var/db/repos/gentoo")
-				logger.Info("")
-				logger.Info("  3. Wait until %s for rate limit reset", resetTime.Format("15:04:05"))
-				os.Exit(1)
-			} else if remaining < 10 {
-				logger.Warn("GitHub API rate limit low: %d requests remaining (resets at %s)",
-					remaining, resetTime.Format("15:04:05"))
-				if !compareClone {
-					logger.Info("Tip: Use --clone flag to avoid rate limits")
-				}
-			} else if verbose {
-				logger.Debug("GitHub API rate limit: %d requests remaining", remaining)
-			}
-		}
+	var repos []overlay.UpstreamRepo
+	for _, name := range repoNames {
+		prov := openCompareRepo(cfg, configRepos, name)
+		defer prov.Close()
+		repos = append(repos, overlay.UpstreamRepo{Name: name, Provider: prov})
 	}
 
 	// Scan local overlay
@@ -162,6 +152,11 @@ func runCompare(cmd *cobra.Command, args []string) {
 		os.Exit(0)
 	}
 
+	if compareDiff != "" {
+		runCompareDiff(scanResult, repos[0])
+		return
+	}
+
 	logger.Info("Found %s packages in Bentoo overlay",
 		output.Sprint(output.Info, fmt.Sprintf("%d", len(scanResult.Packages))))
 
@@ -174,24 +169,33 @@ func runCompare(cmd *cobra.Command, args []string) {
 	}
 
 	// Compare with upstream
-	logger.Info("Comparing with %s using %s...", repoInfo.Name, prov.GetName())
+	for _, repo := range repos {
+		logger.Info("Comparing with %s using %s...", repo.Name, repo.Provider.GetName())
+	}
 
 	opts := overlay.CompareOptions{
 		OnlyOutdated:  !compareIncludeSynced,
 		IncludeSynced: compareIncludeSynced,
+		Slots:         compareSlots,
+		Arch:          compareArch,
 		ProgressCallback: func(current, total int, pkg string) {
 			percent := (current * 100) / total
 			fmt.Printf("\r  Checking: [%3d%%] %s", percent, truncatePkgName(pkg, 40))
 		},
 	}
 
-	report, err := overlay.CompareWithProvider(scanResult.Packages, prov, opts)
+	var report *overlay.CompareReport
+	if len(repos) == 1 {
+		report, err = overlay.CompareWithProvider(scanResult.Packages, repos[0].Provider, opts)
+	} else {
+		report, err = overlay.CompareMulti(scanResult.Packages, repos, opts)
+	}
 	if err != nil {
 		// Check if it's a rate limit error and suggest --clone
 		if strings.Contains(err.Error(), "rate limit") && !compareClone {
 			logger.Error("GitHub API rate limit exceeded.")
 			logger.Info("Try using --clone flag to download the repository instead:")
-			logger.Info("  bentoo overlay compare %s --clone", repoName)
+			logger.Info("  bentoo overlay compare %s --clone", strings.Join(repoNames, " "))
 			os.Exit(1)
 		}
 		logger.Error("comparing packages: %v", err)
@@ -202,9 +206,10 @@ func runCompare(cmd *cobra.Command, args []string) {
 	fmt.Printf("\r%s\r", "                                                                  ")
 
 	// Display results
+	upstream := strings.Join(repoNames, ", ")
 	if len(report.Results) == 0 {
-		logger.Info("%s", output.Sprintf(output.Success, "All packages are up-to-date with %s!", repoInfo.Name))
-		printComparisonSummary(report, repoInfo.Name)
+		logger.Info("%s", output.Sprintf(output.Success, "All packages are up-to-date with %s!", upstream))
+		printComparisonSummary(report, upstream)
 		return
 	}
 
@@ -212,7 +217,157 @@ func runCompare(cmd *cobra.Command, args []string) {
 	fmt.Print(overlay.FormatReport(report))
 
 	// Print summary
-	printComparisonSummary(report, repoInfo.Name)
+	printComparisonSummary(report, upstream)
+}
+
+// runCompareDiff shows the --diff of a package against the repository and
+// imports the upstream ebuild with --import
+func runCompareDiff(scanResult *overlay.ScanResult, repo overlay.UpstreamRepo) {
+	var pkg *overlay.PackageInfo
+	for i := range scanResult.Packages {
+		if scanResult.Packages[i].FullName() == compareDiff {
+			pkg = &scanResult.Packages[i]
+			break
+		}
+	}
+	if pkg == nil {
+		logger.Error("Package '%s' not found in overlay", compareDiff)
+		os.Exit(1)
+	}
+
+	logger.Info("Fetching %s from %s using %s...", compareDiff, repo.Name, repo.Provider.GetName())
+	d, err := overlay.DiffPackage(*pkg, repo.Provider, overlay.DiffOptions{Version: compareDiffVersion})
+	if err != nil {
+		logger.Error("diffing %s: %v", compareDiff, err)
+		os.Exit(1)
+	}
+
+	fmt.Print(overlay.FormatPackageDiff(d))
+
+	if !compareImport {
+		return
+	}
+	result, err := overlay.ImportUpstreamEbuild(pkg.Path, d)
+	if err != nil {
+		logger.Error("importing %s-%s: %v", compareDiff, d.RemoteVersion, err)
+		os.Exit(1)
+	}
+
+	logger.Info("%s", output.Sprintf(output.Success, "Imported %s", result.Ebuild))
+	for _, name := range result.Added {
+		logger.Info("  Added files/%s", name)
+	}
+	for _, name := range result.Kept {
+		logger.Warn("  Kept local files/%s, which differs from upstream", name)
+	}
+	logger.Info("Review the ebuild and run 'pkgdev manifest' in %s", pkg.Path)
+}
+
+// openCompareRepo resolves a repository name and creates its provider,
+// applying the compare flags. It exits when the repository is unknown or the
+// GitHub rate limit is exhausted. With --offline the provider answers from
+// its cache or an existing clone only.
+func openCompareRepo(cfg *config.Config, configRepos map[string]*provider.RepositoryInfo, repoName string) provider.Provider {
+	if offline && compareNoCache {
+		logger.Error("--no-cache cannot be combined with --offline, which only reads caches")
+		os.Exit(1)
+	}
+
+	// Resolve repository info
+	repoInfo, err := provider.ResolveRepository(repoName, configRepos)
+	if err != nil {
+		logger.Error("Repository '%s' not found.", repoName)
+		logger.Info("Available repositories: %s", strings.Join(provider.ListAvailableRepositories(configRepos), ", "))
+		os.Exit(1)
+	}
+
+	// Apply token (priority: flag > env > config > repo-specific)
+	token := compareToken
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token == "" {
+		token = cfg.GitHub.Token
+	}
+	if token != "" && repoInfo.Token == "" {
+		repoInfo.Token = token
+	}
+
+	// Create provider
+	prov, err := provider.NewProvider(repoInfo, compareClone)
+	if err != nil {
+		logger.Error("Failed to create provider for %s: %v", repoName, err)
+		os.Exit(1)
+	}
+
+	if cloneProv, ok := prov.(*provider.GitCloneProvider); ok {
+		cloneProv.Backend = cfg.Git.Backend
+		cloneProv.Offline = offline
+	}
+
+	// Set timeout for API providers
+	if ghProv, ok := prov.(*provider.GitHubProvider); ok {
+		ghProv.HTTPClient.Timeout = time.Duration(compareTimeout) * time.Second
+		ghProv.Offline = offline
+		if compareNoCache {
+			ghProv.CacheDir = ""
+		}
+	}
+	if glProv, ok := prov.(*provider.GitLabProvider); ok {
+		glProv.HTTPClient.Timeout = time.Duration(compareTimeout) * time.Second
+		glProv.Offline = offline
+		if compareNoCache {
+			glProv.CacheDir = ""
+		}
+	}
+
+	// Check rate limit for GitHub provider - block if exhausted
+	if ghProv, ok := prov.(*provider.GitHubProvider); ok && !offline {
+		remaining, resetTime, err := ghProv.GetRateLimitInfo()
+		if err == nil {
+			if remaining == 0 {
+				logger.Error("GitHub API rate limit exceeded (resets at %s)", resetTime.Format("15:04:05"))
+				logger.Info("")
+				logger.Info("Options:")
+				logger.Info("  1. Use --clone to download the repository:")
+				logger.Info("     bentoo overlay compare %s --clone", repoName)
+				logger.Info("")
+				logger.Info("  2. Configure a local repository path in ~/.config/bentoo/config.yaml:")
+				logger.Info("     repositories:")
+				logger.Info("       gentoo-local:")
+				logger.Info("         provider: local")
+				logger.Info("         url: /var/db/repos/gentoo")
+				logger.Info("")
+				logger.Info("  3. Wait until %s for rate limit reset", resetTime.Format("15:04:05"))
+				os.Exit(1)
+			} else if remaining < 10 {
+				logger.Warn("GitHub API rate limit low: %d requests remaining (resets at %s)",
+					remaining, resetTime.Format("15:04:05"))
+				if !compareClone {
+					logger.Info("Tip: Use --clone flag to avoid rate limits")
+				}
+			} else if verbose {
+				logger.Debug("GitHub API rate limit: %d requests remaining", remaining)
+			}
+		}
+	}
+
+	return prov
+}
+
+// allCompareRepos returns every known repository for --all-repos, sorted,
+// leaving out the overlay itself when Portage registers it in repos.conf
+func allCompareRepos(configRepos map[string]*provider.RepositoryInfo, overlayPath string) []string {
+	var names []string
+	for _, name := range provider.ListAvailableRepositories(configRepos) {
+		repoInfo, err := provider.ResolveRepository(name, configRepos)
+		if err == nil && repoInfo.Provider == "local" && filepath.Clean(repoInfo.URL) == filepath.Clean(overlayPath) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func truncatePkgName(name string, maxLen int) string {
@@ -230,6 +385,9 @@ func printComparisonSummary(report *overlay.CompareReport, repoName string) {
 
 	if report.ErrorCount > 0 {
 		logger.Warn("  Errors (API issues): %d", report.ErrorCount)
+		if offline {
+			logger.Info("  Offline, only repositories with a cached tree or an existing clone can be compared")
+		}
 	}
 }
 
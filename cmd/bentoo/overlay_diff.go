@@ -1,11 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"os"
-	"os/exec"
 
 	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/git"
 	"github.com/obentoo/bentoolkit/internal/common/logger"
+	"github.com/obentoo/bentoolkit/internal/overlay"
 	"github.com/spf13/cobra"
 )
 
@@ -33,30 +35,15 @@ func runDiff(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	overlayPath, err := cfg.GetOverlayPath()
+	diff, err := overlay.Diff(cfg, git.DiffOptions{
+		Staged: diffStaged,
+		Color:  true,
+		Paths:  args,
+	})
 	if err != nil {
-		logger.Error("%v", err)
-		os.Exit(1)
-	}
-
-	// Build git diff command
-	gitArgs := []string{"diff", "--color=always"}
-	if diffStaged {
-		gitArgs = append(gitArgs, "--staged")
-	}
-	gitArgs = append(gitArgs, args...)
-
-	gitCmd := exec.Command("git", gitArgs...)
-	gitCmd.Dir = overlayPath
-	gitCmd.Stdout = os.Stdout
-	gitCmd.Stderr = os.Stderr
-
-	if err := gitCmd.Run(); err != nil {
-		// git diff returns exit code 1 if there are differences, which is not an error
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			return
-		}
 		logger.Error("running git diff: %v", err)
 		os.Exit(1)
 	}
+
+	fmt.Print(diff)
 }
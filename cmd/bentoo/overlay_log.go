@@ -1,61 +1,134 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
+	"time"
 
 	"github.com/obentoo/bentoolkit/internal/common/config"
 	"github.com/obentoo/bentoolkit/internal/common/logger"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+	"github.com/obentoo/bentoolkit/internal/overlay"
 	"github.com/spf13/cobra"
 )
 
 var (
-	logCount   int
-	logOneline bool
+	logCount    int
+	logOneline  bool
+	logPackage  string
+	logCategory string
+	logTypes    string
+	logAuthor   string
+	logSince    string
+	logUntil    string
+	logOutput   string
 )
 
 var logCmd = &cobra.Command{
 	Use:   "log",
 	Short: "Show commit history",
-	Long:  `Show the commit history of the overlay repository.`,
-	Run:   runLog,
+	Long: `Show the commit history of the overlay repository.
+
+Commit subjects written in the bentoo grammar (add/del/mod/up/down(...))
+are parsed back into package changes, so history can be filtered by
+package, category and change type. Commits with other subjects are shown
+as-is and only match filters on author and date.
+
+Examples:
+  # Every bump of firefox in 2025
+  bentoo overlay log --package www-client/firefox --type up --since 2025-01-01 --until 2026-01-01
+
+  # Packages added to dev-python by a given author, as JSON
+  bentoo overlay log --category dev-python --type add --author alice --output json`,
+	Run: runLog,
 }
 
 func init() {
-	logCmd.Flags().IntVarP(&logCount, "count", "n", 10, "Number of commits to show")
+	logCmd.Flags().IntVarP(&logCount, "count", "n", 10, "Number of commits to show (0 for all)")
 	logCmd.Flags().BoolVarP(&logOneline, "oneline", "o", false, "Show one line per commit")
+	logCmd.Flags().StringVar(&logPackage, "package", "", "Only show changes to a package (category/package or package name)")
+	logCmd.Flags().StringVar(&logCategory, "category", "", "Only show changes in a category")
+	logCmd.Flags().StringVar(&logTypes, "type", "", "Only show change types, comma-separated (add, del, mod, up, down)")
+	logCmd.Flags().StringVar(&logAuthor, "author", "", "Only show commits whose author name or email contains this text")
+	logCmd.Flags().StringVar(&logSince, "since", "", "Only show commits on or after this date (YYYY-MM-DD)")
+	logCmd.Flags().StringVar(&logUntil, "until", "", "Only show commits before this date (YYYY-MM-DD)")
+	logCmd.Flags().StringVar(&logOutput, "output", "table", "Output format: table or json")
 	overlayCmd.AddCommand(logCmd)
 }
 
 func runLog(cmd *cobra.Command, args []string) {
+	if logOutput != "table" && logOutput != "json" {
+		logger.Error("invalid output format %q: expected table or json", logOutput)
+		os.Exit(1)
+	}
+
+	filter, err := buildLogFilter()
+	if err != nil {
+		logger.Error("%v", err)
+		os.Exit(1)
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		logger.Error("loading config: %v", err)
 		os.Exit(1)
 	}
 
-	overlayPath, err := cfg.GetOverlayPath()
+	entries, err := overlay.Log(cfg, filter)
 	if err != nil {
-		logger.Error("%v", err)
+		logger.Error("reading history: %v", err)
 		os.Exit(1)
 	}
 
-	// Build git log command
-	gitArgs := []string{"log", fmt.Sprintf("-%d", logCount), "--color=always"}
+	if logOutput == "json" {
+		if entries == nil {
+			entries = []overlay.LogEntry{}
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			logger.Error("encoding log: %v", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
 	if logOneline {
-		gitArgs = append(gitArgs, "--oneline")
-	} else {
-		gitArgs = append(gitArgs, "--pretty=format:%C(yellow)%h%C(reset) %C(green)%ad%C(reset) %C(blue)%an%C(reset)%n  %s%n", "--date=short")
+		for _, e := range entries {
+			fmt.Printf("%s %s\n", output.Sprint(output.Warning, e.Hash[:min(7, len(e.Hash))]), e.Subject)
+		}
+		return
 	}
 
-	gitCmd := exec.Command("git", gitArgs...)
-	gitCmd.Dir = overlayPath
-	gitCmd.Stdout = os.Stdout
-	gitCmd.Stderr = os.Stderr
+	fmt.Println(overlay.FormatLogTable(entries))
+}
 
-	if err := gitCmd.Run(); err != nil {
-		logger.Error("running git log: %v", err)
-		os.Exit(1)
+// buildLogFilter builds the log filter from command-line flags
+func buildLogFilter() (overlay.LogFilter, error) {
+	filter := overlay.LogFilter{
+		Package:  logPackage,
+		Category: logCategory,
+		Author:   logAuthor,
+		Limit:    logCount,
 	}
+
+	var err error
+	if logTypes != "" {
+		if filter.Types, err = overlay.ParseChangeTypes(logTypes); err != nil {
+			return filter, err
+		}
+	}
+	if logSince != "" {
+		if filter.Since, err = time.ParseInLocation(time.DateOnly, logSince, time.Local); err != nil {
+			return filter, fmt.Errorf("invalid --since date %q: expected YYYY-MM-DD", logSince)
+		}
+	}
+	if logUntil != "" {
+		if filter.Until, err = time.ParseInLocation(time.DateOnly, logUntil, time.Local); err != nil {
+			return filter, fmt.Errorf("invalid --until date %q: expected YYYY-MM-DD", logUntil)
+		}
+	}
+
+	return filter, nil
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+	"github.com/obentoo/bentoolkit/internal/overlay"
+	"github.com/spf13/cobra"
+)
+
+var movesCmd = &cobra.Command{
+	Use:   "moves [repository]",
+	Short: "Find overlay packages that upstream has renamed or moved",
+	Long: `List the packages of your local Bentoo overlay whose name an upstream
+repository has moved, according to the move entries of its profiles/updates.
+
+For each moved package the new name is shown, noting whether the overlay
+already has a package under it and whether the overlay's own profiles/updates
+records the move. The entries still missing are printed so they can be added
+when renaming the packages.
+
+The repository defaults to gentoo and is accessed as in 'bentoo overlay
+compare', with the same provider flags.
+
+Examples:
+  bentoo overlay moves                 # Check against gentoo
+  bentoo overlay moves guru            # Check against guru
+  bentoo overlay moves --clone         # Use a git clone instead of the API`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runMoves,
+}
+
+func init() {
+	movesCmd.Flags().BoolVar(&compareClone, "clone", false, "Use git clone instead of API")
+	movesCmd.Flags().BoolVar(&compareNoCache, "no-cache", false, "Disable caching")
+	movesCmd.Flags().IntVar(&compareTimeout, "timeout", 30, "HTTP request timeout in seconds")
+	movesCmd.Flags().StringVar(&compareToken, "token", "", "Auth token for API provider")
+	overlayCmd.AddCommand(movesCmd)
+}
+
+func runMoves(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("loading config: %v", err)
+		os.Exit(1)
+	}
+
+	overlayPath, err := cfg.GetOverlayPath()
+	if err != nil {
+		logger.Error("%v", err)
+		os.Exit(1)
+	}
+
+	repoName := "gentoo"
+	if len(args) > 0 {
+		repoName = args[0]
+	}
+
+	prov := openCompareRepo(cfg, convertConfigRepos(cfg), repoName)
+	defer prov.Close()
+
+	// Scan local overlay
+	logger.Info("Scanning Bentoo overlay at %s...", overlayPath)
+	scanResult, err := overlay.ScanOverlay(overlayPath)
+	if err != nil {
+		logger.Error("scanning overlay: %v", err)
+		os.Exit(1)
+	}
+
+	if len(scanResult.Packages) == 0 {
+		logger.Warn("No packages found in overlay")
+		os.Exit(0)
+	}
+
+	logger.Info("Found %s packages in Bentoo overlay",
+		output.Sprint(output.Info, fmt.Sprintf("%d", len(scanResult.Packages))))
+	logger.Info("Reading package moves of %s...", repoName)
+
+	moved, err := overlay.FindMovedPackages(scanResult.Packages, prov, scanResult.Updates)
+	if err != nil {
+		logger.Error("reading package moves: %v", err)
+		os.Exit(1)
+	}
+
+	fmt.Print(overlay.FormatMovedPackages(moved, repoName))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+	"github.com/obentoo/bentoolkit/internal/overlay"
+	"github.com/spf13/cobra"
+)
+
+var overlapAllRepos bool
+
+var overlapCmd = &cobra.Command{
+	Use:   "overlap [repository...]",
+	Short: "Find overlay packages that upstream repositories also carry",
+	Long: `List the packages of your local Bentoo overlay that upstream repositories
+have picked up, and suggest what to do with each overlay copy:
+
+  drop         upstream has the same or a newer version
+  keep-newer   the overlay is ahead of upstream
+  keep-custom  upstream has the same version, but the ebuilds differ
+
+Packages that upstream renamed or moved to another category are found through
+the move entries of its profiles/updates.
+
+By default the overlay is checked against gentoo and guru. Repositories are
+resolved and accessed as in 'bentoo overlay compare', with the same provider
+flags.
+
+Examples:
+  bentoo overlay overlap                    # Check against gentoo and guru
+  bentoo overlay overlap gentoo             # Check against gentoo only
+  bentoo overlay overlap --clone            # Use git clones instead of the API
+  bentoo overlay overlap --all-repos        # Check against every known repository`,
+	Run: runOverlap,
+}
+
+func init() {
+	overlapCmd.Flags().BoolVar(&compareClone, "clone", false, "Use git clone instead of API")
+	overlapCmd.Flags().BoolVar(&compareNoCache, "no-cache", false, "Disable caching")
+	overlapCmd.Flags().IntVar(&compareTimeout, "timeout", 30, "HTTP request timeout in seconds")
+	overlapCmd.Flags().StringVar(&compareToken, "token", "", "Auth token for API provider")
+	overlapCmd.Flags().BoolVar(&overlapAllRepos, "all-repos", false, "Check against every known repository")
+	overlayCmd.AddCommand(overlapCmd)
+}
+
+func runOverlap(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("loading config: %v", err)
+		os.Exit(1)
+	}
+
+	overlayPath, err := cfg.GetOverlayPath()
+	if err != nil {
+		logger.Error("%v", err)
+		os.Exit(1)
+	}
+
+	configRepos := convertConfigRepos(cfg)
+
+	// Determine repository names (default: gentoo and guru)
+	repoNames := args
+	switch {
+	case overlapAllRepos && len(args) > 0:
+		logger.Error("--all-repos cannot be combined with repository names")
+		os.Exit(1)
+	case overlapAllRepos:
+		repoNames = allCompareRepos(configRepos, overlayPath)
+	case len(args) == 0:
+		repoNames = []string{"gentoo", "guru"}
+	}
+
+	var repos []overlay.UpstreamRepo
+	for _, name := range repoNames {
+		prov := openCompareRepo(cfg, configRepos, name)
+		defer prov.Close()
+		repos = append(repos, overlay.UpstreamRepo{Name: name, Provider: prov})
+	}
+
+	// Scan local overlay
+	logger.Info("Scanning Bentoo overlay at %s...", overlayPath)
+	scanResult, err := overlay.ScanOverlay(overlayPath)
+	if err != nil {
+		logger.Error("scanning overlay: %v", err)
+		os.Exit(1)
+	}
+
+	if len(scanResult.Packages) == 0 {
+		logger.Warn("No packages found in overlay")
+		os.Exit(0)
+	}
+
+	logger.Info("Found %s packages in Bentoo overlay",
+		output.Sprint(output.Info, fmt.Sprintf("%d", len(scanResult.Packages))))
+	logger.Info("Looking for them in %s...", strings.Join(repoNames, ", "))
+
+	report, err := overlay.FindOverlap(scanResult.Packages, repos, overlay.OverlapOptions{
+		ProgressCallback: func(current, total int, pkg string) {
+			percent := (current * 100) / total
+			fmt.Printf("\r  Checking: [%3d%%] %s", percent, truncatePkgName(pkg, 40))
+		},
+	})
+	if err != nil {
+		logger.Error("checking overlap: %v", err)
+		os.Exit(1)
+	}
+
+	// Clear progress line
+	fmt.Printf("\r%s\r", "                                                                  ")
+
+	for _, w := range report.Warnings {
+		logger.Warn("%s", w)
+	}
+
+	fmt.Print(overlay.FormatOverlapReport(report))
+
+	if report.ErrorCount > 0 {
+		logger.Warn("Could not check %d packages (API issues)", report.ErrorCount)
+	}
+}
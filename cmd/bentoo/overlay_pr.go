@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/logger"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+	"github.com/obentoo/bentoolkit/internal/common/provider"
+	"github.com/obentoo/bentoolkit/internal/overlay"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prDraft    bool
+	prDryRun   bool
+	prNoVerify bool
+	prBranch   string
+	prRemote   string
+	prBase     string
+)
+
+var prCmd = &cobra.Command{
+	Use:   "pr",
+	Short: "Open a pull request with local changes",
+	Long: `Open a pull request (a merge request on GitLab) with local changes.
+
+The commits ahead of the upstream branch, plus a commit of any staged changes
+with an auto-generated message, are placed on a new topic branch. The branch
+is pushed to the fork remote and a pull request is opened against the
+upstream repository. The working tree is left on the topic branch.
+
+Staged changes must pass the same pre-commit checks as 'bentoo overlay commit'
+(see overlay.qa in the config) before they are committed; use --no-verify to
+skip them.
+
+The title is the commit subject, or a message generated from all changes when
+the branch has several commits. The body lists the package changes and the
+commits.
+
+Configure the target in the config file:
+  overlay.pr.provider:   github (default) or gitlab
+  overlay.pr.repository: upstream repository (owner/repo, or GitLab project URL)
+  overlay.pr.fork:       fork holding topic branches (owner/repo)
+  overlay.pr.remote:     git remote of the fork (default: fork)
+  overlay.pr.base:       target branch (default: the current upstream branch)
+  overlay.pr.token:      API token (default: github.token)`,
+	Run: runPR,
+}
+
+func init() {
+	prCmd.Flags().BoolVar(&prDraft, "draft", false, "Open the pull request as a draft")
+	prCmd.Flags().BoolVarP(&prDryRun, "dry-run", "n", false, "Show the pull request without creating it")
+	prCmd.Flags().BoolVar(&prNoVerify, "no-verify", false, "Skip pre-commit checks of staged changes")
+	prCmd.Flags().StringVarP(&prBranch, "branch", "b", "", "Topic branch name (default: generated from the title)")
+	prCmd.Flags().StringVar(&prRemote, "remote", "", "Remote to push the topic branch to (default: overlay.pr.remote)")
+	prCmd.Flags().StringVar(&prBase, "base", "", "Target branch (default: overlay.pr.base or the upstream branch)")
+	overlayCmd.AddCommand(prCmd)
+}
+
+func runPR(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("loading config: %v", err)
+		os.Exit(1)
+	}
+
+	opts := overlay.PROptionsFromConfig(cfg)
+	opts.Draft = prDraft
+	opts.DryRun = prDryRun
+	opts.NoVerify = prNoVerify
+	opts.Branch = prBranch
+	if prRemote != "" {
+		opts.Remote = prRemote
+	}
+	if prBase != "" {
+		opts.Base = prBase
+	}
+
+	var creator provider.PullRequestCreator
+	if !prDryRun {
+		requireNetwork("opening a pull request")
+		repoInfo, err := overlay.PRRepositoryInfo(cfg)
+		if err != nil {
+			logger.Error("%v", err)
+			os.Exit(1)
+		}
+		if creator, err = provider.NewPullRequestCreator(repoInfo); err != nil {
+			logger.Error("%v", err)
+			os.Exit(1)
+		}
+	}
+
+	result, err := overlay.CreatePR(cfg, creator, opts)
+	if err != nil {
+		if result != nil && errors.Is(err, overlay.ErrQAFailed) {
+			logger.Error("%s", overlay.FormatQAReport(result.QA))
+			logger.Error("%v; fix the issues above or use --no-verify to skip", err)
+			os.Exit(1)
+		}
+		if result != nil {
+			logger.Error("Branch %s was pushed, but opening the pull request failed: %v", result.Branch, err)
+		} else {
+			logger.Error("%v", err)
+		}
+		os.Exit(1)
+	}
+
+	if prDryRun {
+		logger.Info("Dry-run mode - would open:")
+	}
+	fmt.Printf("%s %s -> %s\n", output.Sprint(output.Header, "Branch:"), result.Branch, result.Base)
+	fmt.Printf("%s %s\n\n", output.Sprint(output.Header, "Title:"), result.Title)
+	fmt.Println(result.Body)
+	fmt.Println()
+	if result.QA != nil && len(result.QA.Issues) > 0 {
+		fmt.Println(overlay.FormatQAReport(result.QA))
+		fmt.Println()
+	}
+
+	if !prDryRun {
+		logger.Info("Opened pull request #%d: %s", result.Number, result.URL)
+	}
+}
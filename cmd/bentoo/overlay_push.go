@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
 	"github.com/obentoo/bentoolkit/internal/common/config"
@@ -10,18 +12,27 @@ import (
 )
 
 var (
-	pushDryRun bool
+	pushDryRun   bool
+	pushNoVerify bool
 )
 
 var pushCmd = &cobra.Command{
 	Use:   "push",
 	Short: "Push committed changes to remote",
-	Long:  `Push committed changes to the remote repository.`,
-	Run:   runPush,
+	Long: `Push committed changes to the remote repository.
+
+Before pushing, the upstream branch is fetched. The push is refused if the
+remote has commits that are not in the local branch; run 'bentoo overlay sync'
+first. The outgoing commits are listed with their package changes, and the
+pre-commit checks (see overlay.qa in the config) are run against every package
+they touch, including a check for malformed Manifest files. Any blocking issue
+refuses the push; use --no-verify to skip the checks.`,
+	Run: runPush,
 }
 
 func init() {
 	pushCmd.Flags().BoolVarP(&pushDryRun, "dry-run", "n", false, "Show what would be pushed without pushing")
+	pushCmd.Flags().BoolVar(&pushNoVerify, "no-verify", false, "Skip pre-push checks")
 	overlayCmd.AddCommand(pushCmd)
 }
 
@@ -32,22 +43,48 @@ func runPush(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	opts := overlay.PushOptionsFromConfig(cfg)
+	opts.NoVerify = pushNoVerify
+
 	if pushDryRun {
-		result, err := overlay.PushDryRun(cfg)
+		result, err := overlay.PreparePush(cfg, opts)
 		if err != nil {
 			logger.Error("%v", err)
 			os.Exit(1)
 		}
+		if result.UpToDate {
+			logger.Info("%s", result.Message)
+			return
+		}
 		logger.Info("Dry-run mode - would push:")
-		logger.Info("%s", result)
+		printPushPlan(result)
 		return
 	}
 
-	result, err := overlay.Push(cfg)
+	requireNetwork("push")
+	result, err := overlay.PushWithOptions(cfg, opts)
 	if err != nil {
+		if result != nil && errors.Is(err, overlay.ErrQAFailed) {
+			printPushPlan(result)
+			logger.Error("Pre-push checks failed; fix the issues or use --no-verify to skip.")
+			os.Exit(1)
+		}
 		logger.Error("%v", err)
 		os.Exit(1)
 	}
 
+	if !result.UpToDate {
+		printPushPlan(result)
+	}
 	logger.Info("%s", result.Message)
 }
+
+// printPushPlan prints the outgoing commits and the QA report, if any
+func printPushPlan(result *overlay.PushResult) {
+	fmt.Println(overlay.FormatPushPlan(result))
+	if result.QA != nil {
+		fmt.Println()
+		fmt.Println(overlay.FormatQAReport(result.QA))
+	}
+	fmt.Println()
+}
@@ -15,16 +15,18 @@ import (
 
 // RenameFlags holds command-line flags for the rename operation
 type RenameFlags struct {
-	DryRun     bool // --dry-run: simulate without executing
-	Yes        bool // -y, --yes: skip confirmation prompts
-	NoManifest bool // --no-manifest: skip Manifest updates
-	Force      bool // --force: proceed despite warnings
+	DryRun      bool   // --dry-run: simulate without executing
+	Yes         bool   // -y, --yes: skip confirmation prompts
+	NoManifest  bool   // --no-manifest: skip Manifest updates
+	Force       bool   // --force: proceed despite warnings
+	FromFile    string // -f, --from-file: read a rename mapping file
+	KeepPartial bool   // --keep-partial: don't roll back on failure
 }
 
 var renameFlags RenameFlags
 
 var renameCmd = &cobra.Command{
-	Use:   "rename <category>:<package-pattern>:<old-version> => <new-version>",
+	Use:   "rename <category>:<package-pattern>:<old-version> => <new-version> | --from-file <mapping>",
 	Short: "Bulk rename ebuilds from old version to new version",
 	Long: `Rename multiple ebuild files matching a pattern from an old version to a new version.
 
@@ -34,9 +36,20 @@ The command accepts a pattern in the format:
 Where:
   - category: specific category name or "*" for all categories
   - package-pattern: glob pattern for package names (e.g., "gst-*", "python-*")
-  - old-version: exact version to match (without revision suffix)
+  - old-version: version to match (without revision suffix); either an exact
+    version, a glob such as "1.24.*", or a constraint such as "<1.26" or
+    ">=1.24,<1.26". For globs and constraints, the highest matching ebuild
+    of each package is renamed.
   - new-version: target version to rename to
 
+With --from-file, renames are read from a mapping file (or "-" for stdin)
+with one "<category>/<package> <old-version> <new-version>" entry per line.
+All entries are previewed and executed as a single rename operation.
+
+Renames are transactional: if any rename or Manifest update fails, every
+renamed ebuild and touched Manifest is restored. Use --keep-partial to keep
+completed renames instead.
+
 Examples:
   # Rename all gst-* packages in media-plugins from 1.24.11 to 1.26.10
   bentoo overlay rename media-plugins:gst-*:1.24.11 => 1.26.10
@@ -50,9 +63,18 @@ Examples:
   # Skip confirmation prompt
   bentoo overlay rename -y media-plugins:gst-*:1.24.11 => 1.26.10
 
+  # Rename the highest 1.24.x ebuild of each gst-* package
+  bentoo overlay rename 'media-plugins:gst-*:1.24.*' => 1.26.10
+
+  # Rename anything older than 1.26 (quote to protect '<' from the shell)
+  bentoo overlay rename 'media-plugins:gst-*:<1.26' => 1.26.10
+
+  # Bulk rename from a mapping file
+  bentoo overlay rename --from-file bumps.txt
+
   # Force rename even if version-specific files exist
   bentoo overlay rename --force media-plugins:gst-*:1.24.11 => 1.26.10`,
-	Args: cobra.ExactArgs(3),
+	Args: validateRenameArgs,
 	Run:  runRename,
 }
 
@@ -61,15 +83,43 @@ func init() {
 	renameCmd.Flags().BoolVarP(&renameFlags.Yes, "yes", "y", false, "Skip confirmation prompts (except for global search without --force)")
 	renameCmd.Flags().BoolVar(&renameFlags.NoManifest, "no-manifest", false, "Skip Manifest updates after renaming")
 	renameCmd.Flags().BoolVar(&renameFlags.Force, "force", false, "Proceed despite version-specific files or conflicts")
+	renameCmd.Flags().BoolVar(&renameFlags.KeepPartial, "keep-partial", false, "Keep completed renames if a later rename or Manifest update fails")
+	renameCmd.Flags().StringVarP(&renameFlags.FromFile, "from-file", "f", "", "Read '<category>/<package> <old> <new>' lines from a mapping file (- for stdin)")
 	overlayCmd.AddCommand(renameCmd)
 }
 
+// validateRenameArgs requires either a rename expression or --from-file, but not both.
+func validateRenameArgs(cmd *cobra.Command, args []string) error {
+	if renameFlags.FromFile != "" {
+		if len(args) != 0 {
+			return errors.New("positional arguments cannot be combined with --from-file")
+		}
+		return nil
+	}
+	return cobra.ExactArgs(3)(cmd, args)
+}
+
 func runRename(cmd *cobra.Command, args []string) {
-	// Parse command arguments
-	spec, err := ParseRenameArgs(args)
-	if err != nil {
-		logger.Error("%v", err)
-		os.Exit(1)
+	// Parse command arguments or mapping file
+	var specs []*overlay.RenameSpec
+	if renameFlags.FromFile != "" {
+		loaded, err := overlay.LoadRenameMapping(renameFlags.FromFile)
+		if err != nil {
+			logger.Error("%v", err)
+			os.Exit(1)
+		}
+		if len(loaded) == 0 {
+			logger.Info("Mapping file contains no entries")
+			return
+		}
+		specs = loaded
+	} else {
+		spec, err := ParseRenameArgs(args)
+		if err != nil {
+			logger.Error("%v", err)
+			os.Exit(1)
+		}
+		specs = []*overlay.RenameSpec{spec}
 	}
 
 	// Load configuration
@@ -81,14 +131,15 @@ func runRename(cmd *cobra.Command, args []string) {
 
 	// Convert flags to options
 	opts := &overlay.RenameOptions{
-		DryRun:     renameFlags.DryRun,
-		SkipPrompt: renameFlags.Yes,
-		NoManifest: renameFlags.NoManifest,
-		Force:      renameFlags.Force,
+		DryRun:      renameFlags.DryRun,
+		SkipPrompt:  renameFlags.Yes,
+		NoManifest:  renameFlags.NoManifest,
+		Force:       renameFlags.Force,
+		KeepPartial: renameFlags.KeepPartial,
 	}
 
 	// Preview mode: find matches first without executing
-	previewResult, err := overlay.RenamePreview(cfg, spec)
+	previewResult, err := overlay.RenamePreviewBatch(cfg, specs)
 	if err != nil {
 		logger.Error("%v", err)
 		os.Exit(1)
@@ -100,12 +151,19 @@ func runRename(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	// Global search if any spec spans all categories
+	isGlobalSearch := false
+	for _, spec := range specs {
+		if spec.Category == "*" {
+			isGlobalSearch = true
+		}
+	}
+
 	// Display preview
-	logger.Info("%s", overlay.FormatRenamePreview(previewResult, spec.Category == "*"))
+	logger.Info("%s", overlay.FormatRenamePreview(previewResult, isGlobalSearch))
 
 	// Check if confirmation is needed
 	needsConfirmation := !opts.SkipPrompt
-	isGlobalSearch := spec.Category == "*"
 
 	// Global search requires confirmation even with -y, unless --force
 	if isGlobalSearch && !opts.Force {
@@ -130,8 +188,12 @@ func runRename(cmd *cobra.Command, args []string) {
 	}
 
 	// Execute rename operation
-	result, err := overlay.Rename(cfg, spec, opts)
+	result, err := overlay.RenameBatch(cfg, specs, opts)
 	if err != nil {
+		var rbErr *overlay.RollbackError
+		if errors.As(err, &rbErr) && result != nil {
+			logger.Info("%s", overlay.FormatRenameResult(result, false))
+		}
 		logger.Error("%v", err)
 		os.Exit(1)
 	}
@@ -201,6 +263,9 @@ func ParseRenameArgs(args []string) (*overlay.RenameSpec, error) {
 	if oldVersion == "" {
 		return nil, ErrEmptyOldVersion
 	}
+	if _, err := overlay.ParseVersionPattern(oldVersion); err != nil {
+		return nil, err
+	}
 	if newVersion == "" {
 		return nil, ErrEmptyNewVersion
 	}
@@ -154,6 +154,28 @@ func TestParseRenameArgsUnit(t *testing.T) {
 				category, pattern, oldVer, newVer string
 			}{"app-misc", "hello", "1.0", "2.0"},
 		},
+		{
+			name:    "valid glob old version",
+			args:    []string{"media-plugins:gst-*:1.24.*", "=>", "1.26.10"},
+			wantErr: false,
+			wantSpec: &struct {
+				category, pattern, oldVer, newVer string
+			}{"media-plugins", "gst-*", "1.24.*", "1.26.10"},
+		},
+		{
+			name:    "valid constraint old version",
+			args:    []string{"media-plugins:gst-*:>=1.24,<1.26", "=>", "1.26.10"},
+			wantErr: false,
+			wantSpec: &struct {
+				category, pattern, oldVer, newVer string
+			}{"media-plugins", "gst-*", ">=1.24,<1.26", "1.26.10"},
+		},
+		{
+			name:        "invalid constraint old version",
+			args:        []string{"media-plugins:gst-*:<", "=>", "1.26.10"},
+			wantErr:     true,
+			errContains: "invalid version pattern",
+		},
 		{
 			name:        "missing separator",
 			args:        []string{"media-plugins:gst-*:1.24.11", "->", "1.26.10"},
@@ -33,5 +33,8 @@ func runStatus(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if branch, err := overlay.GetBranchStatus(cfg); err == nil {
+		logger.Info("%s", overlay.FormatBranchStatus(branch))
+	}
 	logger.Info("%s", overlay.FormatStatus(statuses))
 }
@@ -1,50 +1,158 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"path"
 
 	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/git"
 	"github.com/obentoo/bentoolkit/internal/common/logger"
 	"github.com/obentoo/bentoolkit/internal/overlay"
 	"github.com/spf13/cobra"
 )
 
+var (
+	syncStrategy    string
+	syncAutostash   bool
+	syncNoAutostash bool
+	syncAbort       bool
+	syncContinue    bool
+	syncOutput      string
+)
+
 var syncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Sync overlay with upstream",
-	Long:  `Fetch and merge changes from the upstream repository.`,
-	Run:   runSync,
+	Long: `Fetch and integrate changes from the upstream repository.
+
+Strategies (overlay.sync.strategy in the config, or --strategy):
+  - merge:   merge upstream, creating a merge commit if histories diverged (default)
+  - rebase:  replay local commits on top of upstream, keeping history linear
+  - ff-only: only fast-forward; fail if there are local commits
+
+Uncommitted changes are stashed before the sync and reapplied afterwards
+(overlay.sync.autostash, default true; override with --autostash/--no-autostash).
+
+If the sync stops on conflicts, resolve them, stage the result and run
+'bentoo overlay sync --continue', or restore the pre-sync state with
+'bentoo overlay sync --abort'. Conflicting Manifest files should be
+regenerated with 'pkgdev manifest' rather than merged by hand.
+
+After a successful sync, a summary of the packages added, removed and bumped
+upstream is printed. Use --output json to get the full result, including the
+per-commit classification, for scripts and notification hooks.`,
+	Run: runSync,
 }
 
 func init() {
+	syncCmd.Flags().StringVar(&syncStrategy, "strategy", "", "Sync strategy: merge, rebase or ff-only (overrides config)")
+	syncCmd.Flags().BoolVar(&syncAutostash, "autostash", false, "Stash local changes around the sync")
+	syncCmd.Flags().BoolVar(&syncNoAutostash, "no-autostash", false, "Do not stash local changes")
+	syncCmd.Flags().BoolVar(&syncAbort, "abort", false, "Abort a sync stopped on conflicts")
+	syncCmd.Flags().BoolVar(&syncContinue, "continue", false, "Continue a sync after resolving conflicts")
+	syncCmd.Flags().StringVarP(&syncOutput, "output", "o", "text", "Output format: text or json")
+	syncCmd.MarkFlagsMutuallyExclusive("autostash", "no-autostash")
+	syncCmd.MarkFlagsMutuallyExclusive("abort", "continue", "strategy")
 	overlayCmd.AddCommand(syncCmd)
 }
 
 func runSync(cmd *cobra.Command, args []string) {
+	if syncOutput != "text" && syncOutput != "json" {
+		logger.Error("invalid output format %q: expected text or json", syncOutput)
+		os.Exit(1)
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		logger.Error("loading config: %v", err)
 		os.Exit(1)
 	}
 
-	result, err := overlay.Sync(cfg)
+	if syncAbort {
+		if err := overlay.SyncAbort(cfg); err != nil {
+			logger.Error("%v", err)
+			os.Exit(1)
+		}
+		logger.Info("Sync aborted; overlay restored to its pre-sync state.")
+		return
+	}
+
+	var result *overlay.SyncResult
+	if syncContinue {
+		result, err = overlay.SyncContinue(cfg)
+	} else {
+		requireNetwork("sync")
+		opts, optsErr := overlay.SyncOptionsFromConfig(cfg)
+		if optsErr != nil {
+			logger.Error("%v", optsErr)
+			os.Exit(1)
+		}
+		if syncStrategy != "" {
+			if opts.Strategy, err = overlay.ParseSyncStrategy(syncStrategy); err != nil {
+				logger.Error("%v", err)
+				os.Exit(1)
+			}
+		}
+		if syncAutostash {
+			opts.Autostash = true
+		}
+		if syncNoAutostash {
+			opts.Autostash = false
+		}
+		result, err = overlay.SyncWithOptions(cfg, opts)
+	}
 	if err != nil {
 		logger.Error("%v", err)
 		os.Exit(1)
 	}
 
+	if syncOutput == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			logger.Error("encoding result: %v", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		if !result.Success {
+			os.Exit(1)
+		}
+		return
+	}
+
 	if !result.Success {
 		logger.Error("Sync failed: %s", result.Message)
 		if len(result.Conflicts) > 0 {
-			logger.Error("Conflicting files:")
-			for _, conflict := range result.Conflicts {
-				logger.Error("  - %s", conflict)
-			}
-			logger.Info("Resolve conflicts manually, then run 'git add' and 'git commit'")
-			logger.Info("Or abort the merge with 'git merge --abort'")
+			printSyncConflicts(result)
 		}
 		os.Exit(1)
 	}
 
 	logger.Info("%s", result.Message)
+	if result.Upstream != nil {
+		fmt.Println(overlay.FormatUpstreamSummary(result.Upstream))
+	}
+}
+
+// printSyncConflicts lists conflicting files and how to resolve them
+func printSyncConflicts(result *overlay.SyncResult) {
+	logger.Error("Conflicting files:")
+	for _, conflict := range result.Conflicts {
+		logger.Error("  - %s", conflict)
+	}
+
+	if manifests := result.ManifestConflicts(); len(manifests) > 0 {
+		logger.Warn("Manifest conflicts should be regenerated, not merged by hand:")
+		for _, manifest := range manifests {
+			logger.Warn("  (cd %s && pkgdev manifest) && git add %s", path.Dir(manifest), manifest)
+		}
+	}
+
+	if result.Operation == git.OperationNone {
+		logger.Info("Resolve conflicts manually, then run 'git add' and 'git commit'")
+		return
+	}
+	logger.Info("Resolve conflicts, 'git add' the files, then run 'bentoo overlay sync --continue'")
+	logger.Info("Or restore the pre-sync state with 'bentoo overlay sync --abort'")
 }
@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
 )
 
 // Error variables for analysis cache errors
@@ -22,6 +24,9 @@ var (
 // DefaultAnalysisCacheTTL is the default time-to-live for analysis cache entries (24 hours)
 const DefaultAnalysisCacheTTL = 24 * time.Hour
 
+// analysisCacheFileName is the name of the analysis cache file in the cache directory
+const analysisCacheFileName = "analysis_cache.json"
+
 // AnalysisCacheEntry represents a cached LLM analysis result.
 // It stores the generated schema, when it was cached, and the source URL.
 type AnalysisCacheEntry struct {
@@ -40,7 +45,7 @@ type analysisCacheFile struct {
 
 // AnalysisCache manages LLM analysis result caching with TTL-based expiration.
 // It persists cache entries to disk and supports concurrent access.
-// Cache is stored in ~/.config/bentoo/autoupdate/analysis_cache.json
+// Cache is stored in ~/.cache/bentoo/autoupdate/analysis_cache.json
 type AnalysisCache struct {
 	// Entries holds all cached analysis entries, keyed by package name
 	Entries map[string]AnalysisCacheEntry `json:"entries"`
@@ -74,14 +79,14 @@ func WithAnalysisCacheNowFunc(fn func() time.Time) AnalysisCacheOption {
 // NewAnalysisCache creates or loads an analysis cache from disk.
 // If the cache file exists, it loads existing entries.
 // If the cache file doesn't exist or is corrupted, it creates a new empty cache.
-// The configDir should be the bentoo config directory (e.g., ~/.config/bentoo/autoupdate).
-func NewAnalysisCache(configDir string, opts ...AnalysisCacheOption) (*AnalysisCache, error) {
-	// Ensure config directory exists
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+// The cacheDir should be the autoupdate cache directory (see DefaultCacheDir).
+func NewAnalysisCache(cacheDir string, opts ...AnalysisCacheOption) (*AnalysisCache, error) {
+	// Ensure cache directory exists
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create analysis cache directory: %w", err)
 	}
 
-	cachePath := filepath.Join(configDir, "analysis_cache.json")
+	cachePath := filepath.Join(cacheDir, analysisCacheFileName)
 
 	cache := &AnalysisCache{
 		Entries: make(map[string]AnalysisCacheEntry),
@@ -256,6 +261,23 @@ func (c *AnalysisCache) Cleanup() error {
 	return c.saveUnsafe()
 }
 
+// Stats describes the entries of the analysis cache. Size is left to the
+// caller, as the cache only knows its file.
+func (c *AnalysisCache) Stats() cache.Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var stats cache.Stats
+	for _, entry := range c.Entries {
+		s := cache.Stats{Entries: 1, Oldest: entry.Timestamp, Newest: entry.Timestamp}
+		if c.isExpired(entry) {
+			s.Expired = 1
+		}
+		stats.Add(s)
+	}
+	return stats
+}
+
 // GetWithBypass retrieves a cached schema, optionally bypassing the cache.
 // If bypass is true (--no-cache flag), always returns cache miss.
 // Returns the schema and true if found and valid (and not bypassed), nil and false otherwise.
@@ -76,8 +76,12 @@ type Analyzer struct {
 	cache *AnalysisCache
 	// rateLimiter manages request rate limiting
 	rateLimiter *RateLimiter
-	// configDir is the directory for storing cache files
+	// configDir is the autoupdate configuration directory
 	configDir string
+	// cacheDir is the directory for storing the analysis cache
+	cacheDir string
+	// offline refuses every network fetch
+	offline bool
 }
 
 // AnalyzerOption is a functional option for configuring Analyzer.
@@ -123,6 +127,26 @@ func WithAnalyzerConfigDir(dir string) AnalyzerOption {
 	}
 }
 
+// WithAnalyzerCacheDir sets the directory of the analysis cache. By default
+// it is DefaultCacheDir, or the configuration directory when
+// WithAnalyzerConfigDir moves it.
+func WithAnalyzerCacheDir(dir string) AnalyzerOption {
+	return func(a *Analyzer) error {
+		a.cacheDir = dir
+		return nil
+	}
+}
+
+// WithAnalyzerOffline makes the analyzer refuse network fetches with
+// ErrOffline. Analysis fetches the data sources, queries the LLM and
+// validates the schema upstream, so it cannot run offline.
+func WithAnalyzerOffline(offline bool) AnalyzerOption {
+	return func(a *Analyzer) error {
+		a.offline = offline
+		return nil
+	}
+}
+
 // WithAnalyzerPackagesConfig sets a custom packages configuration.
 func WithAnalyzerPackagesConfig(config *PackagesConfig) AnalyzerOption {
 	return func(a *Analyzer) error {
@@ -148,6 +172,8 @@ func NewAnalyzer(overlayPath string, opts ...AnalyzerOption) (*Analyzer, error)
 		}
 	}
 
+	analyzer.cacheDir = resolveCacheDir(configDir, analyzer.configDir, analyzer.cacheDir)
+
 	// Load packages configuration if not provided
 	if analyzer.config == nil {
 		config, err := LoadPackagesConfig(overlayPath)
@@ -167,7 +193,7 @@ func NewAnalyzer(overlayPath string, opts ...AnalyzerOption) (*Analyzer, error)
 
 	// Initialize analysis cache if not provided
 	if analyzer.cache == nil {
-		cache, err := NewAnalysisCache(analyzer.configDir)
+		cache, err := NewAnalysisCache(analyzer.cacheDir)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize analysis cache: %w", err)
 		}
@@ -201,6 +227,12 @@ func (a *Analyzer) Analyze(pkg string, opts AnalyzeOptions) (*AnalyzeResult, err
 		}
 	}
 
+	// Even a cached schema is validated upstream
+	if a.offline {
+		result.Error = fmt.Errorf("%w: analyzing %s fetches its upstream sources", ErrOffline, pkg)
+		return result, result.Error
+	}
+
 	// Check analysis cache first (unless NoCache is set)
 	if !opts.NoCache {
 		if cachedSchema, ok := a.cache.GetWithBypass(pkg, opts.NoCache); ok {
@@ -317,6 +349,10 @@ func (a *Analyzer) fetchContent(source DataSource) ([]byte, error) {
 
 // fetchContentFromURL fetches content from a URL.
 func (a *Analyzer) fetchContentFromURL(url string) ([]byte, error) {
+	if a.offline {
+		return nil, fmt.Errorf("%w: cannot fetch %s", ErrOffline, url)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -457,6 +493,10 @@ func detectJSONPath(content []byte) string {
 // AnalyzeAll analyzes all packages without schemas.
 // It processes packages in parallel with a maximum of 3 concurrent analyses.
 func (a *Analyzer) AnalyzeAll(opts AnalyzeOptions) ([]AnalyzeResult, error) {
+	if a.offline {
+		return nil, fmt.Errorf("%w: analysis fetches upstream sources", ErrOffline)
+	}
+
 	// Find packages without schemas
 	packagesToAnalyze, err := a.findPackagesWithoutSchemas()
 	if err != nil {
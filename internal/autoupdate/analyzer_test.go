@@ -2,6 +2,7 @@ package autoupdate
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -617,6 +618,44 @@ HOMEPAGE="https://example.com"
 	}
 }
 
+// TestAnalyzeOffline tests that an offline analyzer refuses network fetches
+func TestAnalyzeOffline(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"version": "1.0.0"}`))
+	}))
+	defer server.Close()
+
+	pkgDir := filepath.Join(tmpDir, "app-misc", "test")
+	os.MkdirAll(pkgDir, 0755)
+	os.WriteFile(filepath.Join(pkgDir, "test-1.0.0.ebuild"), []byte("EAPI=8\nHOMEPAGE=\""+server.URL+"\"\n"), 0644)
+
+	analyzer, err := createTestAnalyzer(t, tmpDir,
+		WithAnalyzerConfigDir(filepath.Join(tmpDir, "config")),
+		WithAnalyzerOffline(true),
+	)
+	if err != nil {
+		t.Fatalf("NewAnalyzer failed: %v", err)
+	}
+
+	result, err := analyzer.Analyze("app-misc/test", AnalyzeOptions{URL: server.URL})
+	if !errors.Is(err, ErrOffline) || !errors.Is(result.Error, ErrOffline) {
+		t.Errorf("Analyze() error = %v, want ErrOffline", err)
+	}
+	if _, err := analyzer.AnalyzeAll(AnalyzeOptions{}); !errors.Is(err, ErrOffline) {
+		t.Errorf("AnalyzeAll() error = %v, want ErrOffline", err)
+	}
+	if _, _, err := analyzer.FetchContent(DataSource{URL: server.URL}); !errors.Is(err, ErrOffline) {
+		t.Errorf("FetchContent() error = %v, want ErrOffline", err)
+	}
+	if requests != 0 {
+		t.Errorf("offline analyzer made %d requests", requests)
+	}
+}
+
 // TestAnalyzeForceOverwrite tests that Force option allows overwriting
 func TestAnalyzeForceOverwrite(t *testing.T) {
 	// Create mock server
@@ -11,6 +11,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
 )
 
 // Error variables for applier errors
@@ -35,6 +37,10 @@ type ApplyResult struct {
 	OldVersion string
 	// NewVersion is the version after the update
 	NewVersion string
+	// Freshness and FetchedAt tell how current NewVersion was when it was
+	// added to the pending list
+	Freshness cache.Freshness
+	FetchedAt time.Time
 	// Success indicates whether the apply operation succeeded
 	Success bool
 	// Error contains any error that occurred during application
@@ -140,6 +146,8 @@ func (a *Applier) Apply(pkg string, compile bool) (*ApplyResult, error) {
 
 	result.OldVersion = update.CurrentVersion
 	result.NewVersion = update.NewVersion
+	result.Freshness = update.Freshness
+	result.FetchedAt = update.FetchedAt
 
 	// Copy ebuild to new version
 	if err := a.copyEbuild(pkg, update.CurrentVersion, update.NewVersion); err != nil {
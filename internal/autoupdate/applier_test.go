@@ -6,10 +6,12 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"github.com/obentoo/bentoolkit/internal/common/cache"
 )
 
 // =============================================================================
@@ -688,12 +690,15 @@ func TestApplyResultFields(t *testing.T) {
 	// Create source ebuild
 	createTestEbuildFile(t, overlayDir, pkg, oldVersion)
 
+	fetchedAt := time.Date(2026, 1, 20, 12, 0, 0, 0, time.UTC)
 	pending, _ := NewPendingList(configDir)
 	pending.Add(PendingUpdate{
 		Package:        pkg,
 		CurrentVersion: oldVersion,
 		NewVersion:     newVersion,
 		Status:         StatusPending,
+		Freshness:      cache.Stale,
+		FetchedAt:      fetchedAt,
 	})
 
 	applier, err := NewApplier(overlayDir, configDir,
@@ -709,6 +714,9 @@ func TestApplyResultFields(t *testing.T) {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 
+	if result.Freshness != cache.Stale || !result.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("Expected the stale fetch time %v, got %s at %v", fetchedAt, result.Freshness, result.FetchedAt)
+	}
 	if result.Package != pkg {
 		t.Errorf("Expected package %q, got %q", pkg, result.Package)
 	}
@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
 )
 
 // Error variables for cache errors
@@ -22,6 +24,9 @@ var (
 // DefaultCacheTTL is the default time-to-live for cache entries (1 hour)
 const DefaultCacheTTL = time.Hour
 
+// cacheFileName is the name of the version cache file in the cache directory
+const cacheFileName = "cache.json"
+
 // CacheEntry represents a cached version query result.
 // It stores the version, when it was cached, and the source URL.
 type CacheEntry struct {
@@ -73,14 +78,14 @@ func WithNowFunc(fn func() time.Time) CacheOption {
 // NewCache creates or loads a cache from disk.
 // If the cache file exists, it loads existing entries.
 // If the cache file doesn't exist or is corrupted, it creates a new empty cache.
-// The configDir should be the bentoo config directory (e.g., ~/.config/bentoo/autoupdate).
-func NewCache(configDir string, opts ...CacheOption) (*Cache, error) {
-	// Ensure config directory exists
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+// The cacheDir should be the autoupdate cache directory (see DefaultCacheDir).
+func NewCache(cacheDir string, opts ...CacheOption) (*Cache, error) {
+	// Ensure cache directory exists
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create cache directory: %w", err)
 	}
 
-	cachePath := filepath.Join(configDir, "cache.json")
+	cachePath := filepath.Join(cacheDir, cacheFileName)
 
 	cache := &Cache{
 		Entries: make(map[string]CacheEntry),
@@ -145,6 +150,22 @@ func (c *Cache) Get(pkg string) (string, bool) {
 	return entry.Version, true
 }
 
+// Lookup retrieves a cache entry whether or not it has expired, with its
+// freshness. Offline checks use it, as a stale version beats none.
+func (c *Cache) Lookup(pkg string) (CacheEntry, cache.Freshness, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.Entries[pkg]
+	if !exists {
+		return CacheEntry{}, cache.Stale, false
+	}
+	if c.isExpired(entry) {
+		return entry, cache.Stale, true
+	}
+	return entry, cache.Cached, true
+}
+
 // GetWithForce retrieves a cached version, optionally ignoring the cache.
 // If force is true, always returns cache miss.
 // Returns the version and true if found and valid (and not forced), empty string and false otherwise.
@@ -264,3 +285,20 @@ func (c *Cache) Cleanup() error {
 
 	return c.saveUnsafe()
 }
+
+// Stats describes the entries of the cache. Size is left to the caller, as
+// the cache only knows its file.
+func (c *Cache) Stats() cache.Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var stats cache.Stats
+	for _, entry := range c.Entries {
+		s := cache.Stats{Entries: 1, Oldest: entry.Timestamp, Newest: entry.Timestamp}
+		if c.isExpired(entry) {
+			s.Expired = 1
+		}
+		stats.Add(s)
+	}
+	return stats
+}
@@ -0,0 +1,144 @@
+package autoupdate
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
+	"github.com/obentoo/bentoolkit/internal/common/config"
+)
+
+// DefaultCacheDir returns the directory of the autoupdate caches,
+// $XDG_CACHE_HOME/bentoo/autoupdate by default
+func DefaultCacheDir() string {
+	root, err := config.CacheDir()
+	if err != nil {
+		root = filepath.Join(os.Getenv("HOME"), ".cache", "bentoo")
+	}
+	return filepath.Join(root, "autoupdate")
+}
+
+// MigrateCaches moves the cache files older versions kept in the config
+// directory to cacheDir. A file already in cacheDir is kept, and the old one
+// left alone. Failures are ignored, as the caches can be rebuilt.
+func MigrateCaches(configDir, cacheDir string) {
+	if filepath.Clean(configDir) == filepath.Clean(cacheDir) {
+		return
+	}
+
+	for _, name := range []string{cacheFileName, analysisCacheFileName} {
+		oldPath := filepath.Join(configDir, name)
+		newPath := filepath.Join(cacheDir, name)
+		if _, err := os.Stat(oldPath); err != nil {
+			continue
+		}
+		if _, err := os.Stat(newPath); err == nil {
+			continue
+		}
+		if os.MkdirAll(cacheDir, 0755) == nil {
+			_ = os.Rename(oldPath, newPath)
+		}
+	}
+}
+
+// resolveCacheDir returns the cache directory of a checker or analyzer.
+// An explicit cacheDir wins. Otherwise the caches follow a configuration
+// directory moved away from the default, as they lived there before, and
+// move from the default one to DefaultCacheDir.
+func resolveCacheDir(defaultConfigDir, configDir, cacheDir string) string {
+	switch {
+	case cacheDir != "":
+		return cacheDir
+	case configDir != defaultConfigDir:
+		return configDir
+	}
+
+	cacheDir = DefaultCacheDir()
+	MigrateCaches(configDir, cacheDir)
+	return cacheDir
+}
+
+// entryCache is what the version and analysis caches have in common
+type entryCache interface {
+	Stats() cache.Stats
+	Cleanup() error
+	Clear() error
+}
+
+// fileStore exposes an autoupdate cache file as a cache.Store. The cache is
+// only opened when the file exists, so listing caches creates nothing.
+type fileStore struct {
+	kind string
+	path string
+	open func() (entryCache, error)
+}
+
+// CacheStores returns the autoupdate caches in cacheDir as cache stores.
+// ttl is the version cache TTL; zero means DefaultCacheTTL.
+func CacheStores(cacheDir string, ttl time.Duration) []cache.Store {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return []cache.Store{
+		&fileStore{
+			kind: cache.KindAutoupdate,
+			path: filepath.Join(cacheDir, cacheFileName),
+			open: func() (entryCache, error) { return NewCache(cacheDir, WithTTL(ttl)) },
+		},
+		&fileStore{
+			kind: cache.KindAnalysis,
+			path: filepath.Join(cacheDir, analysisCacheFileName),
+			open: func() (entryCache, error) { return NewAnalysisCache(cacheDir) },
+		},
+	}
+}
+
+// Kind returns the kind of the cache
+func (s *fileStore) Kind() string { return s.kind }
+
+// Name returns the name of the cache file
+func (s *fileStore) Name() string { return filepath.Base(s.path) }
+
+// Path returns the cache file
+func (s *fileStore) Path() string { return s.path }
+
+// Stats describes the entries of the cache file
+func (s *fileStore) Stats() (cache.Stats, error) {
+	c, err := s.openExisting()
+	if c == nil || err != nil {
+		return cache.Stats{}, err
+	}
+
+	stats := c.Stats()
+	if info, err := os.Stat(s.path); err == nil {
+		stats.Size = info.Size()
+	}
+	return stats, nil
+}
+
+// Prune removes the expired entries with Cleanup
+func (s *fileStore) Prune() error {
+	c, err := s.openExisting()
+	if c == nil || err != nil {
+		return err
+	}
+	return c.Cleanup()
+}
+
+// Clear removes every entry with Clear
+func (s *fileStore) Clear() error {
+	c, err := s.openExisting()
+	if c == nil || err != nil {
+		return err
+	}
+	return c.Clear()
+}
+
+// openExisting opens the cache, or returns nil when its file does not exist
+func (s *fileStore) openExisting() (entryCache, error) {
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return s.open()
+}
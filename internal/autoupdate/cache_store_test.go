@@ -0,0 +1,103 @@
+package autoupdate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
+)
+
+func TestCacheStores(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "autoupdate")
+	stores := CacheStores(dir, time.Hour)
+	if len(stores) != 2 || stores[0].Kind() != cache.KindAutoupdate || stores[1].Kind() != cache.KindAnalysis {
+		t.Fatalf("CacheStores() = %v", stores)
+	}
+
+	// Missing files are empty caches, and nothing is created
+	for _, s := range stores {
+		if stats, err := s.Stats(); err != nil || stats.Entries != 0 {
+			t.Errorf("%s Stats() = %+v, %v", s.Kind(), stats, err)
+		}
+		if err := s.Prune(); err != nil {
+			t.Errorf("%s Prune() error = %v", s.Kind(), err)
+		}
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("empty stores created %s", dir)
+	}
+
+	now := time.Now()
+	c, err := NewCache(dir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	c.Entries["app-misc/old"] = CacheEntry{Version: "1", Timestamp: now.Add(-2 * time.Hour)}
+	c.Entries["app-misc/new"] = CacheEntry{Version: "2", Timestamp: now}
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	versions := stores[0]
+	stats, err := versions.Stats()
+	if err != nil || stats.Entries != 2 || stats.Expired != 1 || stats.Size == 0 {
+		t.Errorf("Stats() = %+v, %v", stats, err)
+	}
+
+	// Prune drops the expired entry, Clear the rest
+	if err := versions.Prune(); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if stats, _ := versions.Stats(); stats.Entries != 1 || stats.Expired != 0 {
+		t.Errorf("after Prune: %+v", stats)
+	}
+	if err := versions.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if stats, _ := versions.Stats(); stats.Entries != 0 {
+		t.Errorf("after Clear: %+v", stats)
+	}
+}
+
+func TestMigrateCaches(t *testing.T) {
+	configDir := t.TempDir()
+	cacheDir := filepath.Join(t.TempDir(), "autoupdate")
+	os.WriteFile(filepath.Join(configDir, cacheFileName), []byte(`{"entries":{}}`), 0644)
+	os.WriteFile(filepath.Join(configDir, analysisCacheFileName), []byte(`old`), 0644)
+	os.MkdirAll(cacheDir, 0755)
+	os.WriteFile(filepath.Join(cacheDir, analysisCacheFileName), []byte(`new`), 0644)
+	os.WriteFile(filepath.Join(configDir, "pending.json"), []byte(`{}`), 0644)
+
+	MigrateCaches(configDir, cacheDir)
+
+	if _, err := os.Stat(filepath.Join(cacheDir, cacheFileName)); err != nil {
+		t.Errorf("version cache not moved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, cacheFileName)); !os.IsNotExist(err) {
+		t.Errorf("version cache left in config dir: %v", err)
+	}
+	// An existing cache is kept, and the pending list stays
+	if data, _ := os.ReadFile(filepath.Join(cacheDir, analysisCacheFileName)); string(data) != "new" {
+		t.Errorf("analysis cache = %q, want the existing one", data)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "pending.json")); err != nil {
+		t.Errorf("pending list moved: %v", err)
+	}
+}
+
+func TestResolveCacheDir(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	defaultConfig := t.TempDir()
+
+	if got := resolveCacheDir(defaultConfig, defaultConfig, "/explicit"); got != "/explicit" {
+		t.Errorf("explicit = %s", got)
+	}
+	if got := resolveCacheDir(defaultConfig, "/custom/config", ""); got != "/custom/config" {
+		t.Errorf("moved config = %s, want the config dir", got)
+	}
+	if got := resolveCacheDir(defaultConfig, defaultConfig, ""); got != DefaultCacheDir() {
+		t.Errorf("default = %s, want %s", got, DefaultCacheDir())
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/obentoo/bentoolkit/internal/common/cache"
 	"github.com/obentoo/bentoolkit/internal/common/ebuild"
 )
 
@@ -23,6 +24,8 @@ var (
 	ErrNoEbuildFound = errors.New("no ebuild file found for package")
 	// ErrFetchFailed is returned when fetching upstream version fails
 	ErrFetchFailed = errors.New("failed to fetch upstream version")
+	// ErrOffline is returned when an operation needs the network in offline mode
+	ErrOffline = errors.New("network access disabled in offline mode")
 )
 
 // CheckResult represents the result of checking a single package for updates.
@@ -39,6 +42,11 @@ type CheckResult struct {
 	Error error
 	// FromCache is true if the upstream version was retrieved from cache
 	FromCache bool
+	// Freshness tells a live upstream version from a cached one, and a
+	// cached one from an expired entry used in offline mode
+	Freshness cache.Freshness
+	// FetchedAt is when a cached upstream version was fetched
+	FetchedAt time.Time
 }
 
 // Checker handles version checking operations for packages.
@@ -56,8 +64,12 @@ type Checker struct {
 	llmClient *LLMClient
 	// httpClient handles HTTP requests with retry logic
 	httpClient *RetryableHTTPClient
-	// configDir is the directory for storing cache and pending files
+	// configDir is the directory for storing pending files
 	configDir string
+	// cacheDir is the directory for storing the version cache
+	cacheDir string
+	// offline answers from the cache only, expired entries included
+	offline bool
 }
 
 // CheckerOption is a functional option for configuring Checker
@@ -95,7 +107,7 @@ func WithHTTPClient(client *RetryableHTTPClient) CheckerOption {
 	}
 }
 
-// WithConfigDir sets the configuration directory for cache and pending files
+// WithConfigDir sets the configuration directory for pending files
 func WithConfigDir(dir string) CheckerOption {
 	return func(c *Checker) error {
 		c.configDir = dir
@@ -103,6 +115,25 @@ func WithConfigDir(dir string) CheckerOption {
 	}
 }
 
+// WithCacheDir sets the directory of the version cache. By default it is
+// DefaultCacheDir, or the configuration directory when WithConfigDir moves it.
+func WithCacheDir(dir string) CheckerOption {
+	return func(c *Checker) error {
+		c.cacheDir = dir
+		return nil
+	}
+}
+
+// WithOffline makes the checker answer from the cache only. Expired entries
+// are used too and reported as stale; packages without an entry fail with
+// ErrOffline. The cache is not bypassed even when forced.
+func WithOffline(offline bool) CheckerOption {
+	return func(c *Checker) error {
+		c.offline = offline
+		return nil
+	}
+}
+
 // WithPackagesConfig sets a custom packages configuration
 func WithPackagesConfig(config *PackagesConfig) CheckerOption {
 	return func(c *Checker) error {
@@ -129,6 +160,8 @@ func NewChecker(overlayPath string, opts ...CheckerOption) (*Checker, error) {
 		}
 	}
 
+	checker.cacheDir = resolveCacheDir(configDir, checker.configDir, checker.cacheDir)
+
 	// Load packages configuration if not provided
 	if checker.config == nil {
 		config, err := LoadPackagesConfig(overlayPath)
@@ -140,7 +173,7 @@ func NewChecker(overlayPath string, opts ...CheckerOption) (*Checker, error) {
 
 	// Initialize cache if not provided
 	if checker.cache == nil {
-		cache, err := NewCache(checker.configDir)
+		cache, err := NewCache(checker.cacheDir)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize cache: %w", err)
 		}
@@ -186,16 +219,23 @@ func (c *Checker) CheckPackage(pkg string, force bool) (*CheckResult, error) {
 	}
 	result.CurrentVersion = currentVersion
 
+	if c.offline {
+		return c.checkOffline(result)
+	}
+
 	// Check cache first (unless force is true)
 	if !force {
-		if cachedVersion, ok := c.cache.Get(pkg); ok {
+		if entry, freshness, ok := c.cache.Lookup(pkg); ok && freshness == cache.Cached {
+			cachedVersion := entry.Version
 			result.UpstreamVersion = cachedVersion
 			result.FromCache = true
+			result.Freshness = cache.Cached
+			result.FetchedAt = entry.Timestamp
 			result.HasUpdate = c.compareVersions(cachedVersion, currentVersion)
 
 			// Add to pending if update available
 			if result.HasUpdate {
-				if err := c.addToPending(pkg, currentVersion, cachedVersion); err != nil {
+				if err := c.addToPending(result); err != nil {
 					// Log but don't fail the check
 					result.Error = fmt.Errorf("failed to add to pending: %w", err)
 				}
@@ -224,7 +264,7 @@ func (c *Checker) CheckPackage(pkg string, force bool) (*CheckResult, error) {
 
 	// Add to pending if update available
 	if result.HasUpdate {
-		if err := c.addToPending(pkg, currentVersion, upstreamVersion); err != nil {
+		if err := c.addToPending(result); err != nil {
 			// Log but don't fail the check
 			if result.Error == nil {
 				result.Error = fmt.Errorf("failed to add to pending: %w", err)
@@ -235,6 +275,30 @@ func (c *Checker) CheckPackage(pkg string, force bool) (*CheckResult, error) {
 	return result, nil
 }
 
+// checkOffline completes a check from the cache alone, expired entries
+// included
+func (c *Checker) checkOffline(result *CheckResult) (*CheckResult, error) {
+	entry, freshness, ok := c.cache.Lookup(result.Package)
+	if !ok {
+		result.Error = fmt.Errorf("%w: no cached version of %s", ErrOffline, result.Package)
+		return result, result.Error
+	}
+
+	result.UpstreamVersion = entry.Version
+	result.FromCache = true
+	result.Freshness = freshness
+	result.FetchedAt = entry.Timestamp
+	result.HasUpdate = c.compareVersions(entry.Version, result.CurrentVersion)
+
+	if result.HasUpdate {
+		if err := c.addToPending(result); err != nil {
+			// Log but don't fail the check
+			result.Error = fmt.Errorf("failed to add to pending: %w", err)
+		}
+	}
+	return result, nil
+}
+
 // getCurrentVersion finds the current version of a package in the overlay.
 // It looks for ebuild files in the package directory and returns the highest version.
 func (c *Checker) getCurrentVersion(pkg string) (string, error) {
@@ -301,14 +365,20 @@ func (c *Checker) compareVersions(upstream, current string) bool {
 	return ebuild.CompareVersions(upstream, current) > 0
 }
 
-// addToPending adds an update to the pending list.
-func (c *Checker) addToPending(pkg, currentVersion, newVersion string) error {
+// addToPending adds the update found by a check to the pending list, with
+// the freshness of the upstream version so a stale one is shown as such.
+func (c *Checker) addToPending(result *CheckResult) error {
 	update := PendingUpdate{
-		Package:        pkg,
-		CurrentVersion: currentVersion,
-		NewVersion:     newVersion,
+		Package:        result.Package,
+		CurrentVersion: result.CurrentVersion,
+		NewVersion:     result.UpstreamVersion,
 		Status:         StatusPending,
 		DetectedAt:     time.Now(),
+		Freshness:      result.Freshness,
+		FetchedAt:      result.FetchedAt,
+	}
+	if update.FetchedAt.IsZero() {
+		update.FetchedAt = update.DetectedAt
 	}
 	return c.pending.Add(update)
 }
@@ -2,6 +2,7 @@ package autoupdate
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"github.com/obentoo/bentoolkit/internal/common/cache"
 )
 
 // =============================================================================
@@ -611,6 +613,79 @@ func TestCheckPackageBypassesCache(t *testing.T) {
 	}
 }
 
+// TestCheckPackageOffline tests that offline checks answer from the cache
+// only, marking expired entries stale
+func TestCheckPackageOffline(t *testing.T) {
+	tmpDir := t.TempDir()
+	overlayDir := filepath.Join(tmpDir, "overlay")
+	configDir := filepath.Join(tmpDir, "config")
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(map[string]string{"version": "9.0.0"})
+	}))
+	defer server.Close()
+
+	createTestEbuild(t, overlayDir, "test-cat/fresh", "1.0.0")
+	createTestEbuild(t, overlayDir, "test-cat/expired", "1.0.0")
+	createTestEbuild(t, overlayDir, "test-cat/missing", "1.0.0")
+
+	config := &PackagesConfig{Packages: map[string]PackageConfig{}}
+	for _, pkg := range []string{"test-cat/fresh", "test-cat/expired", "test-cat/missing"} {
+		config.Packages[pkg] = PackageConfig{URL: server.URL, Parser: "json", Path: "version"}
+	}
+
+	now := time.Date(2026, 1, 22, 12, 0, 0, 0, time.UTC)
+	versionCache, _ := NewCache(configDir, WithNowFunc(func() time.Time { return now }))
+	versionCache.Entries["test-cat/fresh"] = CacheEntry{Version: "2.0.0", Timestamp: now.Add(-time.Minute)}
+	versionCache.Entries["test-cat/expired"] = CacheEntry{Version: "1.5.0", Timestamp: now.Add(-48 * time.Hour)}
+
+	checker, err := NewChecker(overlayDir,
+		WithConfigDir(configDir),
+		WithPackagesConfig(config),
+		WithCache(versionCache),
+		WithOffline(true),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Forcing does not reach the network either
+	result, err := checker.CheckPackage("test-cat/fresh", true)
+	if err != nil || result.UpstreamVersion != "2.0.0" || result.Freshness != cache.Cached || !result.HasUpdate {
+		t.Errorf("fresh: %+v, %v", result, err)
+	}
+
+	result, err = checker.CheckPackage("test-cat/expired", false)
+	if err != nil || result.UpstreamVersion != "1.5.0" || result.Freshness != cache.Stale ||
+		!result.FetchedAt.Equal(now.Add(-48*time.Hour)) {
+		t.Errorf("expired: %+v, %v", result, err)
+	}
+
+	// The stale version is queued with its freshness, so it is not later
+	// taken for a live one
+	pending, err := NewPendingList(configDir)
+	if err != nil {
+		t.Fatalf("NewPendingList() error = %v", err)
+	}
+	update, ok := pending.Get("test-cat/expired")
+	if !ok || update.NewVersion != "1.5.0" || update.Freshness != cache.Stale ||
+		!update.FetchedAt.Equal(now.Add(-48*time.Hour)) {
+		t.Errorf("pending expired = %+v, %v; want stale 1.5.0 with its fetch time", update, ok)
+	}
+	if update, ok := pending.Get("test-cat/fresh"); !ok || update.Freshness != cache.Cached {
+		t.Errorf("pending fresh = %+v, %v; want cached", update, ok)
+	}
+
+	if _, err := checker.CheckPackage("test-cat/missing", false); !errors.Is(err, ErrOffline) {
+		t.Errorf("missing: error = %v, want ErrOffline", err)
+	}
+	if requests != 0 {
+		t.Errorf("offline checks made %d requests", requests)
+	}
+}
+
 // TestCheckPackageDetectsUpdate tests that updates are correctly detected
 func TestCheckPackageDetectsUpdate(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -8,8 +8,10 @@
 //   - Pending updates tracking and application
 //
 // Configuration is read from overlay/.autoupdate/packages.toml which defines
-// how to check upstream versions for each package. Local state is maintained
-// in ~/.config/bentoo/autoupdate/ for caching and pending updates.
+// how to check upstream versions for each package. Pending updates are kept
+// in ~/.config/bentoo/autoupdate/, and the caches in ~/.cache/bentoo/autoupdate/
+// (following XDG_CACHE_HOME). With WithOffline, the checker answers from the
+// cache alone and marks expired entries stale.
 //
 // Usage:
 //
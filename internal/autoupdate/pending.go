@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
 )
 
 // Error variables for pending list errors
@@ -63,6 +65,11 @@ type PendingUpdate struct {
 	Status UpdateStatus `json:"status"`
 	// DetectedAt is when this update was first detected
 	DetectedAt time.Time `json:"detected_at"`
+	// Freshness tells whether NewVersion was fetched live, read from the
+	// cache, or read from an expired cache entry in offline mode
+	Freshness cache.Freshness `json:"freshness,omitempty"`
+	// FetchedAt is when NewVersion was fetched from upstream
+	FetchedAt time.Time `json:"fetched_at,omitzero"`
 	// Error contains error message if status is failed
 	Error string `json:"error,omitempty"`
 }
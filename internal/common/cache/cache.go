@@ -0,0 +1,245 @@
+// Package cache provides a common view of the caches bentoo keeps on disk,
+// so they can be listed, pruned and cleared in one place.
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/output"
+)
+
+// Kinds of caches
+const (
+	// KindAutoupdate is the autoupdate version check results
+	KindAutoupdate = "autoupdate"
+	// KindAnalysis is the autoupdate LLM analysis results
+	KindAnalysis = "analysis"
+	// KindCompare is the tree, ebuild and profiles/updates caches of the
+	// GitHub and GitLab providers
+	KindCompare = "compare"
+	// KindRepos is the git clones of the clone provider
+	KindRepos = "repos"
+	// KindLegacy is the per-package files of the retired GitHub client
+	KindLegacy = "legacy"
+)
+
+// Kinds lists every kind of cache, in display order
+var Kinds = []string{KindAutoupdate, KindAnalysis, KindCompare, KindRepos, KindLegacy}
+
+// Stats describes the content of a cache
+type Stats struct {
+	Entries int
+	Expired int   // Entries that are no longer used and Prune removes
+	Size    int64 // Bytes on disk
+
+	// Oldest and Newest are the times of the oldest and latest entries,
+	// zero when the cache is empty
+	Oldest time.Time
+	Newest time.Time
+}
+
+// Add accumulates the stats of another cache
+func (s *Stats) Add(other Stats) {
+	s.Entries += other.Entries
+	s.Expired += other.Expired
+	s.Size += other.Size
+	if !other.Oldest.IsZero() && (s.Oldest.IsZero() || other.Oldest.Before(s.Oldest)) {
+		s.Oldest = other.Oldest
+	}
+	if other.Newest.After(s.Newest) {
+		s.Newest = other.Newest
+	}
+}
+
+// Store is one cache on disk
+type Store interface {
+	// Kind returns the kind of the cache, one of Kinds
+	Kind() string
+	// Name tells caches of the same kind apart, e.g. by repository
+	Name() string
+	// Path returns the file or directory holding the cache
+	Path() string
+	// Stats describes the current content of the cache
+	Stats() (Stats, error)
+	// Prune removes the expired entries
+	Prune() error
+	// Clear removes every entry
+	Clear() error
+}
+
+// Filter returns the stores of the given kinds; no kinds means all
+func Filter(stores []Store, kinds ...string) []Store {
+	if len(kinds) == 0 {
+		return stores
+	}
+
+	var filtered []Store
+	for _, s := range stores {
+		for _, kind := range kinds {
+			if s.Kind() == kind {
+				filtered = append(filtered, s)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// PathStats returns the size of a file, or of every file under a directory,
+// with the oldest and latest modification times. A missing path is empty.
+func PathStats(path string) (Stats, error) {
+	var stats Stats
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == path {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		mod := info.ModTime()
+		stats.Add(Stats{Size: info.Size(), Oldest: mod, Newest: mod})
+		return nil
+	})
+	return stats, err
+}
+
+// FormatSize formats a byte count for display, e.g. "1.5 MiB"
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// FormatAge formats the time since t for display, e.g. "3h" or "12d", or "-"
+// for a zero time
+func FormatAge(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// Entry is a store with its stats, or the error reading them
+type Entry struct {
+	Store Store
+	Stats Stats
+	Err   error
+}
+
+// Collect reads the stats of every store
+func Collect(stores []Store) []Entry {
+	entries := make([]Entry, 0, len(stores))
+	for _, s := range stores {
+		stats, err := s.Stats()
+		entries = append(entries, Entry{Store: s, Stats: stats, Err: err})
+	}
+	return entries
+}
+
+// FormatList formats one row per cache, with the age of its latest entry
+func FormatList(entries []Entry, now time.Time) string {
+	if len(entries) == 0 {
+		return output.Sprintf(output.Info, "No caches found.\n")
+	}
+
+	rows := [][]string{{"Kind", "Name", "Entries", "Expired", "Size", "Updated", "Path"}}
+	for _, e := range entries {
+		if e.Err != nil {
+			rows = append(rows, []string{e.Store.Kind(), e.Store.Name(), "-", "-", "-", "-", "error: " + e.Err.Error()})
+			continue
+		}
+		rows = append(rows, []string{
+			e.Store.Kind(), e.Store.Name(),
+			fmt.Sprintf("%d", e.Stats.Entries), fmt.Sprintf("%d", e.Stats.Expired),
+			FormatSize(e.Stats.Size), FormatAge(e.Stats.Newest, now), e.Store.Path(),
+		})
+	}
+	return formatRows(rows)
+}
+
+// FormatSummary formats one row per kind of cache with the totals of its
+// caches, the age of the oldest and latest entries, and a grand total
+func FormatSummary(entries []Entry, now time.Time) string {
+	byKind := make(map[string]*Stats)
+	counts := make(map[string]int)
+	var total Stats
+	for _, e := range entries {
+		kind := e.Store.Kind()
+		if byKind[kind] == nil {
+			byKind[kind] = &Stats{}
+		}
+		byKind[kind].Add(e.Stats)
+		counts[kind]++
+		total.Add(e.Stats)
+	}
+
+	rows := [][]string{{"Kind", "Caches", "Entries", "Expired", "Size", "Oldest", "Updated"}}
+	for _, kind := range Kinds {
+		s := byKind[kind]
+		if s == nil {
+			s = &Stats{}
+		}
+		rows = append(rows, []string{
+			kind, fmt.Sprintf("%d", counts[kind]),
+			fmt.Sprintf("%d", s.Entries), fmt.Sprintf("%d", s.Expired),
+			FormatSize(s.Size), FormatAge(s.Oldest, now), FormatAge(s.Newest, now),
+		})
+	}
+
+	var sb strings.Builder
+	sb.WriteString(formatRows(rows))
+	sb.WriteString(fmt.Sprintf("\nTotal: %d entries (%d expired), %s\n", total.Entries, total.Expired, FormatSize(total.Size)))
+	return sb.String()
+}
+
+// formatRows aligns rows in columns, the first row being the header
+func formatRows(rows [][]string) string {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	var sb strings.Builder
+	for r, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			if i == len(row)-1 {
+				line.WriteString(cell)
+			} else {
+				line.WriteString(fmt.Sprintf("%-*s  ", widths[i], cell))
+			}
+		}
+		text := strings.TrimRight(line.String(), " ") + "\n"
+		if r == 0 {
+			text = output.Sprint(output.Header, text)
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
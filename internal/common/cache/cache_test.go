@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeStore is a store with fixed stats
+type fakeStore struct {
+	kind, name string
+	stats      Stats
+}
+
+func (s *fakeStore) Kind() string          { return s.kind }
+func (s *fakeStore) Name() string          { return s.name }
+func (s *fakeStore) Path() string          { return "/cache/" + s.name }
+func (s *fakeStore) Stats() (Stats, error) { return s.stats, nil }
+func (s *fakeStore) Prune() error          { return nil }
+func (s *fakeStore) Clear() error          { return nil }
+
+func TestStatsAdd(t *testing.T) {
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := old.Add(48 * time.Hour)
+
+	var s Stats
+	s.Add(Stats{Entries: 2, Expired: 1, Size: 10, Oldest: recent, Newest: recent})
+	s.Add(Stats{Entries: 1, Size: 5, Oldest: old, Newest: old})
+	s.Add(Stats{}) // An empty cache does not reset the times
+
+	if s.Entries != 3 || s.Expired != 1 || s.Size != 15 || !s.Oldest.Equal(old) || !s.Newest.Equal(recent) {
+		t.Errorf("Add() = %+v", s)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	stores := []Store{
+		&fakeStore{kind: KindAutoupdate, name: "a"},
+		&fakeStore{kind: KindRepos, name: "b"},
+		&fakeStore{kind: KindCompare, name: "c"},
+	}
+	if got := Filter(stores); len(got) != 3 {
+		t.Errorf("Filter() = %d stores, want all", len(got))
+	}
+	got := Filter(stores, KindRepos, KindCompare)
+	if len(got) != 2 || got[0].Name() != "b" || got[1].Name() != "c" {
+		t.Errorf("Filter(repos, compare) = %v", got)
+	}
+}
+
+func TestPathStats(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	os.WriteFile(filepath.Join(dir, "a"), []byte("12345"), 0644)
+	os.WriteFile(filepath.Join(dir, "sub", "b"), []byte("123"), 0644)
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(filepath.Join(dir, "a"), old, old)
+
+	stats, err := PathStats(dir)
+	if err != nil || stats.Size != 8 || !stats.Oldest.Equal(old) || !stats.Newest.After(old) {
+		t.Errorf("PathStats(dir) = %+v, %v", stats, err)
+	}
+
+	stats, err = PathStats(filepath.Join(dir, "missing"))
+	if err != nil || stats.Size != 0 {
+		t.Errorf("PathStats(missing) = %+v, %v", stats, err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	sizes := map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 30: "5.0 GiB"}
+	for size, want := range sizes {
+		if got := FormatSize(size); got != want {
+			t.Errorf("FormatSize(%d) = %s, want %s", size, got, want)
+		}
+	}
+	ages := map[time.Duration]string{30 * time.Second: "now", 5 * time.Minute: "5m", 3 * time.Hour: "3h", 72 * time.Hour: "3d"}
+	for age, want := range ages {
+		if got := FormatAge(now.Add(-age), now); got != want {
+			t.Errorf("FormatAge(%s) = %s, want %s", age, got, want)
+		}
+	}
+	if got := FormatAge(time.Time{}, now); got != "-" {
+		t.Errorf("FormatAge(zero) = %s", got)
+	}
+
+	entries := Collect([]Store{
+		&fakeStore{kind: KindRepos, name: "gentoo", stats: Stats{Entries: 1, Size: 2048, Newest: now.Add(-time.Hour)}},
+		&fakeStore{kind: KindRepos, name: "guru", stats: Stats{Entries: 1, Size: 1024}},
+		&fakeStore{kind: KindAutoupdate, name: "cache.json", stats: Stats{Entries: 4, Expired: 3}},
+	})
+	list := FormatList(entries, now)
+	if !strings.Contains(list, "gentoo") || !strings.Contains(list, "2.0 KiB") || !strings.Contains(list, "1h") {
+		t.Errorf("FormatList() =\n%s", list)
+	}
+	summary := FormatSummary(entries, now)
+	for _, want := range []string{"repos       2", "Total: 6 entries (3 expired), 3.0 KiB"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("FormatSummary() missing %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		f         Freshness
+		fetchedAt time.Time
+		want      string
+	}{
+		{Live, now.Add(-time.Hour), ""},
+		{Cached, now.Add(-10 * time.Minute), "cached, fetched 10m ago"},
+		{Stale, now.Add(-72 * time.Hour), "stale, fetched 3d ago"},
+		{Stale, now, "stale, fetched just now"},
+		{Stale, time.Time{}, "stale"},
+	}
+	for _, tc := range tests {
+		if got := Describe(tc.f, tc.fetchedAt, now); got != tc.want {
+			t.Errorf("Describe(%s, %v) = %q, want %q", tc.f, tc.fetchedAt, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// Freshness tells how current a result read through a cache is, so that data
+// from an expired cache is never taken for an upstream answer
+type Freshness int
+
+const (
+	// Live results were fetched from upstream, or revalidated against it,
+	// during the run
+	Live Freshness = iota
+	// Cached results come from a cache entry still within its lifetime
+	Cached
+	// Stale results come from an expired cache entry, or one that could not
+	// be revalidated, as in offline mode
+	Stale
+)
+
+// String returns a human-readable freshness
+func (f Freshness) String() string {
+	switch f {
+	case Live:
+		return "live"
+	case Cached:
+		return "cached"
+	case Stale:
+		return "stale"
+	default:
+		return "unknown"
+	}
+}
+
+// Describe formats a freshness with the time the data was fetched, e.g.
+// "stale, fetched 3d ago". Live data needs no description and gives "".
+func Describe(f Freshness, fetchedAt, now time.Time) string {
+	if f == Live {
+		return ""
+	}
+	if fetchedAt.IsZero() {
+		return f.String()
+	}
+	age := FormatAge(fetchedAt, now)
+	if age == "now" {
+		return fmt.Sprintf("%s, fetched just now", f)
+	}
+	return fmt.Sprintf("%s, fetched %s ago", f, age)
+}
@@ -28,14 +28,57 @@ type Config struct {
 
 // OverlayConfig holds overlay-specific settings
 type OverlayConfig struct {
-	Path   string `yaml:"path"`
-	Remote string `yaml:"remote"`
+	Path   string       `yaml:"path"`
+	Remote string       `yaml:"remote"`
+	QA     QAConfig     `yaml:"qa,omitempty"`
+	Commit CommitConfig `yaml:"commit,omitempty"`
+	Sync   SyncConfig   `yaml:"sync,omitempty"`
+	PR     PRConfig     `yaml:"pr,omitempty"`
+}
+
+// PRConfig holds settings for opening pull requests with overlay pr
+type PRConfig struct {
+	Provider   string `yaml:"provider,omitempty"`   // "github" (default) or "gitlab"
+	Repository string `yaml:"repository,omitempty"` // Upstream repository: owner/repo on GitHub, project URL on GitLab
+	Fork       string `yaml:"fork,omitempty"`       // Fork holding topic branches (owner/repo); empty to use the upstream repository
+	Remote     string `yaml:"remote,omitempty"`     // Git remote of the fork (default: "fork")
+	Base       string `yaml:"base,omitempty"`       // Target branch (default: the current upstream branch)
+	Token      string `yaml:"token,omitempty"`      // API token (default: github.token for GitHub)
+}
+
+// SyncConfig holds settings for overlay sync
+type SyncConfig struct {
+	Strategy  string `yaml:"strategy,omitempty"`  // "merge" (default), "rebase", or "ff-only"
+	Autostash *bool  `yaml:"autostash,omitempty"` // Stash local changes around the sync (default: true)
+}
+
+// AutostashEnabled returns whether local changes are stashed during sync
+func (s *SyncConfig) AutostashEnabled() bool {
+	return s.Autostash == nil || *s.Autostash
+}
+
+// QAConfig holds pre-commit check settings for overlay commit
+type QAConfig struct {
+	Skip  []string `yaml:"skip,omitempty"`  // Built-in checks to skip: "manifest", "filename", "files"
+	Hooks []string `yaml:"hooks,omitempty"` // External commands run with staged packages as arguments
+}
+
+// CommitConfig holds commit message settings for overlay commit
+type CommitConfig struct {
+	Style    string `yaml:"style,omitempty"`    // Message style: "bentoo" (default), "gentoo", or "template"
+	Template string `yaml:"template,omitempty"` // Go text/template used by the "template" style
+	Body     bool   `yaml:"body,omitempty"`     // Append a body listing the changed files
+	SignOff  bool   `yaml:"signoff,omitempty"`  // Append a Signed-off-by trailer
 }
 
 // GitConfig holds git user settings
 type GitConfig struct {
 	User  string `yaml:"user"`
 	Email string `yaml:"email"`
+	// Backend is "cli" (default) or "go-git". go-git cannot merge, rebase,
+	// stash, produce textual diffs, dry-run pushes or commit selected paths, so
+	// overlay sync, diff and commit --split refuse to start under it.
+	Backend string `yaml:"backend,omitempty"`
 }
 
 // GitHubConfig holds GitHub API settings
@@ -45,7 +88,7 @@ type GitHubConfig struct {
 
 // RepoConfig holds configuration for a custom repository
 type RepoConfig struct {
-	Provider string `yaml:"provider"` // "github", "gitlab", or "git"
+	Provider string `yaml:"provider"` // "github", "gitlab", "git", or "local"
 	URL      string `yaml:"url"`      // Full URL or org/repo for GitHub/GitLab
 	Token    string `yaml:"token"`    // Optional auth token
 	Branch   string `yaml:"branch"`   // Branch to use (default: master/main)
@@ -101,6 +144,20 @@ func DefaultConfigPath() (string, error) {
 	return paths[0], nil
 }
 
+// CacheDir returns the root directory of bentoo's caches
+// ($XDG_CACHE_HOME/bentoo, by default ~/.cache/bentoo)
+func CacheDir() (string, error) {
+	xdgCache := os.Getenv("XDG_CACHE_HOME")
+	if xdgCache == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		xdgCache = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(xdgCache, "bentoo"), nil
+}
+
 // FindConfigPath returns the first existing config file path
 // Returns the default path if no config file exists yet
 func FindConfigPath() (string, error) {
@@ -0,0 +1,188 @@
+package ebuild
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MetadataVariables are the ebuild variables read by ParseMetadata
+var MetadataVariables = []string{"SLOT", "KEYWORDS"}
+
+// Metadata holds the slot and keywords of an ebuild
+type Metadata struct {
+	Slot     string   // e.g., "0", "3.12"; the sub-slot is dropped
+	Keywords []string // e.g., ["amd64", "~arm64"]
+}
+
+// KeywordLevel is how far an ebuild is keyworded on an architecture
+type KeywordLevel int
+
+const (
+	// Unkeyworded means the ebuild has no keyword for the architecture
+	Unkeyworded KeywordLevel = iota
+	// Testing means the ebuild is keyworded ~arch
+	Testing
+	// Stable means the ebuild is keyworded arch
+	Stable
+)
+
+// String returns a human-readable keyword level
+func (l KeywordLevel) String() string {
+	switch l {
+	case Stable:
+		return "stable"
+	case Testing:
+		return "testing"
+	default:
+		return "unkeyworded"
+	}
+}
+
+// assignmentRegex matches the start of a variable assignment, which may be
+// indented inside a conditional block
+var assignmentRegex = regexp.MustCompile(`^\s*(?:export\s+)?([A-Z_][A-Z0-9_]*)=(.*)$`)
+
+// ReadVariables returns the values assigned to the named variables in
+// ebuild content. Ebuilds are bash, so this is an approximation: quoted
+// values may span lines, and the last non-empty assignment wins, which picks
+// the release branch of the usual live/release conditional.
+func ReadVariables(content []byte, names ...string) map[string]string {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	vars := make(map[string]string)
+	lines := strings.Split(string(content), "\n")
+	for i := 0; i < len(lines); i++ {
+		m := assignmentRegex.FindStringSubmatch(lines[i])
+		if m == nil || !wanted[m[1]] {
+			continue
+		}
+
+		value := m[2]
+		if quote := value[:min(len(value), 1)]; quote == `"` || quote == `'` {
+			// Collect lines until the closing quote
+			value = value[1:]
+			for !strings.Contains(value, quote) && i+1 < len(lines) {
+				i++
+				value += "\n" + lines[i]
+			}
+			value, _, _ = strings.Cut(value, quote)
+		} else if fields := strings.Fields(value); len(fields) > 0 {
+			value = fields[0]
+		}
+
+		if value = strings.TrimSpace(value); value != "" {
+			vars[m[1]] = value
+		}
+	}
+	return vars
+}
+
+// NewMetadata builds the metadata of the ebuild of version from its
+// variables. References to the package version in SLOT are expanded; SLOT
+// defaults to "0".
+func NewMetadata(vars map[string]string, version string) *Metadata {
+	slot := expandVersionRefs(vars["SLOT"], version)
+	slot, _, _ = strings.Cut(slot, "/")
+	if slot == "" {
+		slot = "0"
+	}
+
+	return &Metadata{
+		Slot:     slot,
+		Keywords: strings.Fields(vars["KEYWORDS"]),
+	}
+}
+
+// ParseMetadata reads the slot and keywords from the content of the ebuild
+// of version
+func ParseMetadata(content []byte, version string) *Metadata {
+	return NewMetadata(ReadVariables(content, MetadataVariables...), version)
+}
+
+// ReadMetadata reads the slot and keywords of an ebuild file
+func ReadMetadata(path, version string) (*Metadata, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMetadata(content, version), nil
+}
+
+// KeywordLevel returns how far the ebuild is keyworded on arch. With an
+// empty arch the best level on any architecture is returned.
+func (m *Metadata) KeywordLevel(arch string) KeywordLevel {
+	level := Unkeyworded
+	for _, kw := range m.Keywords {
+		switch {
+		case kw == "*" || kw == "~*" || strings.HasPrefix(kw, "-"):
+			continue
+		case strings.HasPrefix(kw, "~"):
+			if arch == "" || kw[1:] == arch {
+				level = max(level, Testing)
+			}
+		case arch == "" || kw == arch:
+			return Stable
+		}
+	}
+	return level
+}
+
+// verCutRegex matches $(ver_cut RANGE) command substitutions
+var verCutRegex = regexp.MustCompile(`\$\(ver_cut\s+([0-9]+(?:-[0-9]*)?)\s*\)`)
+
+// versionComponentRegex matches the components of a version, as split by
+// ver_cut: runs of digits or of letters
+var versionComponentRegex = regexp.MustCompile(`[0-9]+|[A-Za-z]+`)
+
+// expandVersionRefs expands the common references to the package version
+// in a SLOT value: ${PV}, ${PV%%.*}, ${PV%.*} and $(ver_cut RANGE)
+func expandVersionRefs(value, version string) string {
+	if !strings.Contains(value, "$") {
+		return value
+	}
+
+	// PV is the version without its revision
+	pv := revisionRegex.ReplaceAllString(version, "")
+
+	major, _, _ := strings.Cut(pv, ".")
+	minus := pv
+	if i := strings.LastIndex(pv, "."); i >= 0 {
+		minus = pv[:i]
+	}
+
+	value = verCutRegex.ReplaceAllStringFunc(value, func(s string) string {
+		return verCut(verCutRegex.FindStringSubmatch(s)[1], pv)
+	})
+	return strings.NewReplacer(
+		"${PV%%.*}", major,
+		"${PV%.*}", minus,
+		"${PV}", pv,
+		"$PV", pv,
+	).Replace(value)
+}
+
+// verCut implements ver_cut: it returns components start to end of version,
+// with the separators between them, for a range "N", "N-M" or "N-"
+func verCut(spec, version string) string {
+	comps := versionComponentRegex.FindAllStringIndex(version, -1)
+
+	from, to, isRange := strings.Cut(spec, "-")
+	start, _ := strconv.Atoi(from)
+	end := start
+	if isRange {
+		end = len(comps)
+		if to != "" {
+			end, _ = strconv.Atoi(to)
+		}
+	}
+	end = min(end, len(comps))
+	if start < 1 || start > end {
+		return ""
+	}
+	return version[comps[start-1][0]:comps[end-1][1]]
+}
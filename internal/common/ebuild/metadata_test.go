@@ -0,0 +1,99 @@
+package ebuild
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadVariables(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected map[string]string
+	}{
+		{
+			name:     "quoted",
+			content:  "EAPI=8\nSLOT=\"0/1.2\"\nKEYWORDS=\"amd64 ~arm64\"\n",
+			expected: map[string]string{"SLOT": "0/1.2", "KEYWORDS": "amd64 ~arm64"},
+		},
+		{
+			name:     "unquoted with comment",
+			content:  "SLOT=2 # major version\n",
+			expected: map[string]string{"SLOT": "2"},
+		},
+		{
+			name:     "multi-line",
+			content:  "KEYWORDS=\"amd64\n\t~x86\"\nSLOT='0'\n",
+			expected: map[string]string{"SLOT": "0", "KEYWORDS": "amd64\n\t~x86"},
+		},
+		{
+			name: "live conditional",
+			content: "if [[ ${PV} == 9999 ]]; then\n\tinherit git-r3\n\tKEYWORDS=\"\"\n" +
+				"else\n\tSRC_URI=\"https://example.org/${P}.tar.gz\"\n\tKEYWORDS=\"~amd64\"\nfi\nSLOT=\"0\"\n",
+			expected: map[string]string{"SLOT": "0", "KEYWORDS": "~amd64"},
+		},
+		{
+			name:     "unset",
+			content:  "EAPI=8\nDESCRIPTION=\"Hello\"\n",
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vars := ReadVariables([]byte(tc.content), MetadataVariables...)
+			if !reflect.DeepEqual(vars, tc.expected) {
+				t.Errorf("ReadVariables() = %q, want %q", vars, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNewMetadataSlot(t *testing.T) {
+	tests := []struct {
+		slot     string
+		version  string
+		expected string
+	}{
+		{"", "1.0", "0"},
+		{"0/1.2", "1.2", "0"},
+		{"${PV}", "3.12.1-r1", "3.12.1"},
+		{"${PV%%.*}", "3.12.1", "3"},
+		{"${PV%.*}", "3.12.1", "3.12"},
+		{"$(ver_cut 1)", "2.4.6", "2"},
+		{"$(ver_cut 1-2)/$(ver_cut 3)", "2.4.6", "2.4"},
+		{"$(ver_cut 2-)", "2.4.6_rc1", "4.6_rc1"},
+		{"$(ver_cut 1-2)", "128", "128"},
+	}
+
+	for _, tc := range tests {
+		m := NewMetadata(map[string]string{"SLOT": tc.slot}, tc.version)
+		if m.Slot != tc.expected {
+			t.Errorf("SLOT=%q for %s: got %q, want %q", tc.slot, tc.version, m.Slot, tc.expected)
+		}
+	}
+}
+
+func TestKeywordLevel(t *testing.T) {
+	tests := []struct {
+		keywords string
+		arch     string
+		expected KeywordLevel
+	}{
+		{"amd64 ~arm64", "amd64", Stable},
+		{"amd64 ~arm64", "arm64", Testing},
+		{"amd64 ~arm64", "x86", Unkeyworded},
+		{"~amd64 ~arm64", "", Testing},
+		{"~amd64 arm64", "", Stable},
+		{"-* ~amd64", "amd64", Testing},
+		{"-amd64", "amd64", Unkeyworded},
+		{"", "", Unkeyworded},
+	}
+
+	for _, tc := range tests {
+		m := NewMetadata(map[string]string{"KEYWORDS": tc.keywords}, "1.0")
+		if got := m.KeywordLevel(tc.arch); got != tc.expected {
+			t.Errorf("KEYWORDS=%q on %q: got %s, want %s", tc.keywords, tc.arch, got, tc.expected)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ebuild
 
 import (
 	"errors"
+	"os"
 	"regexp"
 	"strings"
 )
@@ -64,3 +65,28 @@ func (e *Ebuild) FullName() string {
 func (e *Ebuild) String() string {
 	return e.Category + "/" + e.Package + "/" + e.Name + "-" + e.Version + ".ebuild"
 }
+
+// ScanPackageDir returns the versions of the ebuilds in a package directory.
+// Files that are not ebuilds of the named package are skipped.
+func ScanPackageDir(pkgDir, category, pkg string) ([]string, error) {
+	entries, err := os.ReadDir(pkgDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".ebuild") {
+			continue
+		}
+
+		// ParsePath also checks that the file name matches the package directory
+		eb, err := ParsePath(category + "/" + pkg + "/" + entry.Name())
+		if err != nil {
+			continue
+		}
+		versions = append(versions, eb.Version)
+	}
+
+	return versions, nil
+}
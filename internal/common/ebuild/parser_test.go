@@ -1,6 +1,10 @@
 package ebuild
 
 import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/leanovate/gopter"
@@ -259,6 +263,27 @@ func TestEbuild_String(t *testing.T) {
 	}
 }
 
+func TestScanPackageDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"hello-1.0.ebuild", "hello-2.0_rc1-r1.ebuild", "other-3.0.ebuild", "hello.ebuild", "metadata.xml"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	os.Mkdir(filepath.Join(dir, "files"), 0755)
+
+	versions, err := ScanPackageDir(dir, "app-misc", "hello")
+	if err != nil {
+		t.Fatalf("ScanPackageDir() error = %v", err)
+	}
+	sort.Strings(versions)
+	if got := strings.Join(versions, " "); got != "1.0 2.0_rc1-r1" {
+		t.Errorf("ScanPackageDir() = %q, want only the package's ebuild versions", got)
+	}
+
+	if _, err := ScanPackageDir(filepath.Join(dir, "missing"), "app-misc", "hello"); !os.IsNotExist(err) {
+		t.Errorf("ScanPackageDir() of a missing directory error = %v, want not exist", err)
+	}
+}
+
 func TestCompareVersions_EdgeCases(t *testing.T) {
 	tests := []struct {
 		name     string
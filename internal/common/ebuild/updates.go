@@ -0,0 +1,115 @@
+package ebuild
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UpdatesDir is the repository directory holding package move entries
+const UpdatesDir = "profiles/updates"
+
+// PackageMove records a package renamed or moved to another category
+type PackageMove struct {
+	From string `json:"from"` // e.g., "app-misc/old-name"
+	To   string `json:"to"`   // e.g., "app-misc/new-name"
+}
+
+// SlotMove records packages matching an atom moved from one slot to another
+type SlotMove struct {
+	Atom string `json:"atom"` // e.g., "dev-libs/foo" or ">=dev-libs/foo-2"
+	From string `json:"from"` // Old slot
+	To   string `json:"to"`   // New slot
+}
+
+// Updates holds the entries of a repository's profiles/updates files, in
+// the order they were recorded
+type Updates struct {
+	Moves     []PackageMove `json:"moves,omitempty"`
+	SlotMoves []SlotMove    `json:"slotmoves,omitempty"`
+}
+
+// ParseUpdates parses the content of one profiles/updates file. Each line is
+// "move <old> <new>" or "slotmove <atom> <old-slot> <new-slot>"; other and
+// malformed lines are skipped, as Portage does.
+func ParseUpdates(content []byte) *Updates {
+	u := &Updates{}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 3 && fields[0] == "move" && isPackageName(fields[1]) && isPackageName(fields[2]):
+			u.Moves = append(u.Moves, PackageMove{From: fields[1], To: fields[2]})
+		case len(fields) == 4 && fields[0] == "slotmove":
+			u.SlotMoves = append(u.SlotMoves, SlotMove{Atom: fields[1], From: fields[2], To: fields[3]})
+		}
+	}
+	return u
+}
+
+// Append adds the entries of a later updates file
+func (u *Updates) Append(other *Updates) {
+	u.Moves = append(u.Moves, other.Moves...)
+	u.SlotMoves = append(u.SlotMoves, other.SlotMoves...)
+}
+
+// Resolve returns the current name of a category/package, applying every
+// move in the order it was recorded, as Portage does, so a later move
+// overrides an earlier one. ok is false when the package ends up under its
+// own name, including when it was moved away and back again.
+func (u *Updates) Resolve(pkg string) (name string, ok bool) {
+	name = pkg
+	for _, m := range u.Moves {
+		if m.From == name {
+			name = m.To
+		}
+	}
+	return name, name != pkg
+}
+
+// ResolveSlot returns the slot a package's slot was moved to, following
+// every slotmove recorded since. Only slotmoves whose atom is the plain
+// category/package apply; versioned atoms are not matched.
+func (u *Updates) ResolveSlot(pkg, slot string) string {
+	for _, m := range u.SlotMoves {
+		if m.Atom == pkg && m.From == slot {
+			slot = m.To
+		}
+	}
+	return slot
+}
+
+// isPackageName reports whether s has the category/package form
+func isPackageName(s string) bool {
+	category, pkg, ok := strings.Cut(s, "/")
+	return ok && category != "" && pkg != "" && !strings.Contains(pkg, "/")
+}
+
+// updatesFileRegex matches the quarterly update file names, e.g. "2Q-2024"
+var updatesFileRegex = regexp.MustCompile(`^([1-4])Q-(\d{4})$`)
+
+// SortUpdatesFiles orders profiles/updates file names chronologically, as
+// Portage applies them. Names that are not quarters sort last by name.
+func SortUpdatesFiles(names []string) {
+	key := func(name string) (int, bool) {
+		m := updatesFileRegex.FindStringSubmatch(name)
+		if m == nil {
+			return 0, false
+		}
+		quarter, _ := strconv.Atoi(m[1])
+		year, _ := strconv.Atoi(m[2])
+		return year*10 + quarter, true
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		ki, oki := key(names[i])
+		kj, okj := key(names[j])
+		if oki != okj {
+			return oki
+		}
+		if ki != kj {
+			return ki < kj
+		}
+		return names[i] < names[j]
+	})
+}
@@ -0,0 +1,81 @@
+package ebuild
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseUpdates(t *testing.T) {
+	content := []byte(`move app-misc/old app-misc/new
+slotmove >=dev-libs/foo-2 0 2
+move dev-util/gone
+# comment
+unknown dev-util/a dev-util/b
+move sys-apps/a net-misc/a
+`)
+
+	u := ParseUpdates(content)
+	wantMoves := []PackageMove{
+		{From: "app-misc/old", To: "app-misc/new"},
+		{From: "sys-apps/a", To: "net-misc/a"},
+	}
+	if !reflect.DeepEqual(u.Moves, wantMoves) {
+		t.Errorf("Moves = %+v, want %+v", u.Moves, wantMoves)
+	}
+	wantSlotMoves := []SlotMove{{Atom: ">=dev-libs/foo-2", From: "0", To: "2"}}
+	if !reflect.DeepEqual(u.SlotMoves, wantSlotMoves) {
+		t.Errorf("SlotMoves = %+v, want %+v", u.SlotMoves, wantSlotMoves)
+	}
+}
+
+func TestUpdatesResolve(t *testing.T) {
+	u := ParseUpdates([]byte("move app-misc/a app-misc/b\nmove dev-util/x dev-util/y\n"))
+	u.Append(ParseUpdates([]byte("move app-misc/b sys-apps/c\nmove dev-util/y dev-util/x\n")))
+
+	tests := []struct {
+		pkg    string
+		name   string
+		moved  bool
+		reason string
+	}{
+		{"app-misc/a", "sys-apps/c", true, "moves are chained"},
+		{"app-misc/b", "sys-apps/c", true, "a later name is moved too"},
+		{"sys-apps/c", "sys-apps/c", false, "the current name is not moved"},
+		{"dev-util/x", "dev-util/x", false, "a move back restores the name"},
+		{"dev-util/y", "dev-util/x", true, "the name moved back to is current"},
+	}
+	for _, tc := range tests {
+		name, moved := u.Resolve(tc.pkg)
+		if name != tc.name || moved != tc.moved {
+			t.Errorf("Resolve(%s) = %s, %v, want %s, %v (%s)", tc.pkg, name, moved, tc.name, tc.moved, tc.reason)
+		}
+	}
+}
+
+func TestUpdatesResolveSlot(t *testing.T) {
+	u := ParseUpdates([]byte("slotmove dev-libs/foo 0 1\nslotmove >=dev-libs/bar-2 0 2\n"))
+	u.Append(ParseUpdates([]byte("slotmove dev-libs/foo 1 1.2\n")))
+
+	tests := []struct {
+		pkg, slot, want string
+	}{
+		{"dev-libs/foo", "0", "1.2"},
+		{"dev-libs/foo", "3", "3"},
+		{"dev-libs/bar", "0", "0"}, // versioned atoms are not matched
+	}
+	for _, tc := range tests {
+		if got := u.ResolveSlot(tc.pkg, tc.slot); got != tc.want {
+			t.Errorf("ResolveSlot(%s, %s) = %s, want %s", tc.pkg, tc.slot, got, tc.want)
+		}
+	}
+}
+
+func TestSortUpdatesFiles(t *testing.T) {
+	names := []string{"README", "1Q-2024", "4Q-2023", "2Q-2024", "1Q-2005"}
+	SortUpdatesFiles(names)
+
+	want := []string{"1Q-2005", "4Q-2023", "1Q-2024", "2Q-2024", "README"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("SortUpdatesFiles() = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,68 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Backends selectable with git.backend in the config
+const (
+	BackendCLI   = "cli"    // Runs the git binary (default)
+	BackendGoGit = "go-git" // Pure-Go implementation, no git binary required
+)
+
+// ErrUnknownBackend indicates an unrecognized git backend name
+var ErrUnknownBackend = errors.New("unknown git backend")
+
+// Feature names a GitExecutor operation that not every backend implements
+type Feature string
+
+// Features missing from the go-git backend
+const (
+	FeatureCommitPaths Feature = "commit of selected paths" // CommitPaths, used by commit --split
+	FeatureMerge       Feature = "merge"                    // Merge, MergeWithOptions, and Abort/Continue of a merge
+	FeatureRebase      Feature = "rebase"                   // Rebase, and Abort/Continue of a rebase
+	FeatureDiff        Feature = "diff"                     // Diff
+	FeatureStash       Feature = "stash"                    // StashPush, StashPop
+	FeaturePushDryRun  Feature = "push --dry-run"           // PushDryRun
+)
+
+// featureChecker is implemented by backends that lack some features
+type featureChecker interface {
+	supports(f Feature) bool
+}
+
+// RequireFeatures returns an error wrapping ErrUnsupported when the executor's
+// backend lacks any of the features, so that an operation can be refused
+// before it changes anything instead of failing midway
+func RequireFeatures(executor GitExecutor, features ...Feature) error {
+	checker, ok := executor.(featureChecker)
+	if !ok {
+		return nil
+	}
+
+	var missing []string
+	for _, f := range features {
+		if !checker.supports(f) {
+			missing = append(missing, string(f))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s (set git.backend to %q)", ErrUnsupported, strings.Join(missing, ", "), BackendCLI)
+	}
+	return nil
+}
+
+// NewExecutor returns the GitExecutor for the named backend. An empty name
+// selects the git binary.
+func NewExecutor(backend, workDir string) (GitExecutor, error) {
+	switch backend {
+	case "", BackendCLI:
+		return NewGitRunner(workDir), nil
+	case BackendGoGit:
+		return NewGoGitRunner(workDir), nil
+	default:
+		return nil, fmt.Errorf("%w: %q (want %q or %q)", ErrUnknownBackend, backend, BackendCLI, BackendGoGit)
+	}
+}
@@ -0,0 +1,466 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// The contract tests below run every GitExecutor backend against the same
+// temporary repositories. Repositories are created with go-git so the setup
+// itself does not depend on either backend.
+
+// forEachBackend runs a test once per backend
+func forEachBackend(t *testing.T, test func(t *testing.T, open func(dir string) GitExecutor)) {
+	for _, backend := range []string{BackendCLI, BackendGoGit} {
+		t.Run(backend, func(t *testing.T) {
+			test(t, func(dir string) GitExecutor {
+				executor, err := NewExecutor(backend, dir)
+				if err != nil {
+					t.Fatalf("NewExecutor(%q) error = %v", backend, err)
+				}
+				return executor
+			})
+		})
+	}
+}
+
+// initContractRepo creates a repository on main with a configured identity
+func initContractRepo(t *testing.T, bare bool) string {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := gogit.PlainInitWithOptions(dir, &gogit.PlainInitOptions{
+		InitOptions: gogit.InitOptions{DefaultBranch: plumbing.Main},
+		Bare:        bare,
+	})
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	cfg, _ := repo.Config()
+	cfg.User.Name = "Test User"
+	cfg.User.Email = "test@example.com"
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	return dir
+}
+
+// writeFiles writes files relative to dir, creating parent directories
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// commitFiles writes, stages and commits files
+func commitFiles(t *testing.T, executor GitExecutor, message string, files map[string]string) {
+	t.Helper()
+	writeFiles(t, executor.WorkDir(), files)
+	if err := executor.Add(); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := executor.Commit(message, "Dev", "dev@example.com"); err != nil {
+		t.Fatalf("Commit(%q) error = %v", message, err)
+	}
+}
+
+// statusCodes renders status entries as "XY path" lines
+func statusCodes(entries []StatusEntry) string {
+	var lines []string
+	for _, e := range entries {
+		line := string([]byte{e.Index, e.Worktree}) + " " + e.FilePath
+		if e.OrigPath != "" {
+			line += " <- " + e.OrigPath
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestBackendStatusAddCommit(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func(string) GitExecutor) {
+		executor := open(initContractRepo(t, false))
+		commitFiles(t, executor, "add(app-misc/hello-1.0)", map[string]string{
+			"app-misc/hello/hello-1.0.ebuild": "EAPI=8\n",
+			"app-misc/hello/Manifest":         "DIST hello-1.0.tar.gz 1 BLAKE2B a\n",
+		})
+
+		entries, err := executor.Status()
+		if err != nil || len(entries) != 0 {
+			t.Fatalf("Status() after commit = %v, %v; want clean", entries, err)
+		}
+
+		writeFiles(t, executor.WorkDir(), map[string]string{
+			"app-misc/hello/hello-1.0.ebuild": "EAPI=8\nKEYWORDS=\"~amd64\"\n",
+			"app-misc/world/world-2.0.ebuild": "EAPI=8\n",
+			"dev-lang/go/go-1.22.ebuild":      "EAPI=8\n",
+		})
+		if err := executor.Add("dev-lang"); err != nil {
+			t.Fatalf("Add(dir) error = %v", err)
+		}
+
+		entries, err = executor.Status()
+		if err != nil {
+			t.Fatalf("Status() error = %v", err)
+		}
+		want := strings.Join([]string{
+			".M app-misc/hello/hello-1.0.ebuild",
+			"A. dev-lang/go/go-1.22.ebuild",
+			"?? app-misc/world/world-2.0.ebuild",
+		}, "\n")
+		if got := statusCodes(entries); got != want {
+			t.Errorf("Status() =\n%s\nwant:\n%s", got, want)
+		}
+		for _, e := range entries {
+			if e.FilePath == "dev-lang/go/go-1.22.ebuild" && (e.Status != "A" || !e.IsStaged()) {
+				t.Errorf("staged entry = %+v, want Status A", e)
+			}
+			if e.FilePath == "app-misc/world/world-2.0.ebuild" && !e.IsUntracked() {
+				t.Errorf("untracked entry = %+v", e)
+			}
+		}
+
+		if err := executor.Add("../outside"); !errors.Is(err, ErrPathOutsideOverlay) {
+			t.Errorf("Add(outside) error = %v, want ErrPathOutsideOverlay", err)
+		}
+		if err := executor.Add("missing.ebuild"); !errors.Is(err, ErrFileNotFound) {
+			t.Errorf("Add(missing) error = %v, want ErrFileNotFound", err)
+		}
+
+		if err := executor.Commit("add(dev-lang/go-1.22)", "", ""); err != nil {
+			t.Fatalf("Commit() with config identity error = %v", err)
+		}
+		commits, err := executor.Log("", 1)
+		if err != nil || len(commits) != 1 {
+			t.Fatalf("Log() = %v, %v", commits, err)
+		}
+		if commits[0].Subject != "add(dev-lang/go-1.22)" || commits[0].Author != "Test User" || commits[0].Email != "test@example.com" {
+			t.Errorf("Log()[0] = %+v, want the config identity", commits[0])
+		}
+	})
+}
+
+func TestBackendStagedRename(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func(string) GitExecutor) {
+		executor := open(initContractRepo(t, false))
+		commitFiles(t, executor, "base", map[string]string{
+			"dev-lang/go/go-1.22.ebuild": "EAPI=8\nDESCRIPTION=\"Go\"\nSLOT=0\n",
+		})
+
+		dir := executor.WorkDir()
+		if err := os.Rename(filepath.Join(dir, "dev-lang/go/go-1.22.ebuild"), filepath.Join(dir, "dev-lang/go/go-1.23.ebuild")); err != nil {
+			t.Fatal(err)
+		}
+		if err := executor.Add(); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+
+		entries, err := executor.Status()
+		if err != nil {
+			t.Fatalf("Status() error = %v", err)
+		}
+		want := "R. dev-lang/go/go-1.23.ebuild <- dev-lang/go/go-1.22.ebuild"
+		if got := statusCodes(entries); got != want {
+			t.Errorf("Status() =\n%s\nwant:\n%s", got, want)
+		}
+	})
+}
+
+func TestBackendLogAndRevisions(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func(string) GitExecutor) {
+		executor := open(initContractRepo(t, false))
+		for i, subject := range []string{"first", "second", "third"} {
+			commitFiles(t, executor, subject, map[string]string{"file.txt": strings.Repeat("x", i+1)})
+		}
+
+		commits, err := executor.Log("", 0)
+		if err != nil {
+			t.Fatalf("Log() error = %v", err)
+		}
+		var subjects []string
+		for _, c := range commits {
+			subjects = append(subjects, c.Subject)
+		}
+		if strings.Join(subjects, ",") != "third,second,first" {
+			t.Errorf("Log() subjects = %v, want newest first", subjects)
+		}
+		if commits[0].Author != "Dev" || commits[0].Email != "dev@example.com" || commits[0].Date.IsZero() {
+			t.Errorf("Log()[0] = %+v, want the commit author", commits[0])
+		}
+
+		first := commits[2].Hash
+		head, err := executor.RevParse("HEAD")
+		if err != nil || head != commits[0].Hash {
+			t.Errorf("RevParse(HEAD) = %q, %v; want %q", head, err, commits[0].Hash)
+		}
+		if parent, _ := executor.RevParse("HEAD^"); parent != commits[1].Hash {
+			t.Errorf("RevParse(HEAD^) = %q, want %q", parent, commits[1].Hash)
+		}
+		if _, err := executor.RevParse("missing"); !errors.Is(err, ErrGitCommand) {
+			t.Errorf("RevParse(missing) error = %v, want ErrGitCommand", err)
+		}
+
+		ranged, err := executor.Log(first+"..HEAD", 0)
+		if err != nil || len(ranged) != 2 || ranged[0].Subject != "third" {
+			t.Errorf("Log(range) = %+v, %v; want third and second", ranged, err)
+		}
+
+		hashes, err := executor.RevList(first + "..HEAD")
+		if err != nil || len(hashes) != 2 || hashes[0] != commits[1].Hash || hashes[1] != commits[0].Hash {
+			t.Errorf("RevList() = %v, %v; want oldest first", hashes, err)
+		}
+
+		base, err := executor.MergeBase("HEAD", first)
+		if err != nil || base != first {
+			t.Errorf("MergeBase() = %q, %v; want %q", base, err, first)
+		}
+
+		content, err := executor.Show(first, "file.txt")
+		if err != nil || content != "x" {
+			t.Errorf("Show() = %q, %v; want the first version", content, err)
+		}
+
+		// The index holds the staged version, not the working tree
+		writeFiles(t, executor.WorkDir(), map[string]string{"file.txt": "staged"})
+		if err := executor.Add("file.txt"); err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, executor.WorkDir(), map[string]string{"file.txt": "unstaged"})
+		content, err = executor.Show("", "file.txt")
+		if err != nil || content != "staged" {
+			t.Errorf("Show(index) = %q, %v; want the staged version", content, err)
+		}
+		if _, err := executor.Show("", "missing.txt"); !errors.Is(err, ErrGitCommand) {
+			t.Errorf("Show(index, missing) error = %v, want ErrGitCommand", err)
+		}
+	})
+}
+
+func TestBackendDiffNameStatus(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func(string) GitExecutor) {
+		executor := open(initContractRepo(t, false))
+		commitFiles(t, executor, "base", map[string]string{
+			"app-misc/hello/hello-1.0.ebuild": "EAPI=8\nDESCRIPTION=\"Hello world\"\nSLOT=0\n",
+			"app-misc/hello/metadata.xml":     "<pkgmetadata/>\n",
+			"app-misc/old/old-1.0.ebuild":     "EAPI=7\nDESCRIPTION=\"Old\"\n",
+		})
+		base, _ := executor.RevParse("HEAD")
+
+		dir := executor.WorkDir()
+		if err := os.Rename(filepath.Join(dir, "app-misc/hello/hello-1.0.ebuild"), filepath.Join(dir, "app-misc/hello/hello-1.1.ebuild")); err != nil {
+			t.Fatal(err)
+		}
+		if err := executor.Remove("app-misc/old"); err != nil {
+			t.Fatalf("Remove() error = %v", err)
+		}
+		commitFiles(t, executor, "bump", map[string]string{
+			"app-misc/hello/metadata.xml": "<pkgmetadata></pkgmetadata>\n",
+			"app-misc/new/new-1.0.ebuild": "EAPI=8\nDESCRIPTION=\"Brand new package\"\nSLOT=0\n",
+		})
+
+		entries, err := executor.DiffNameStatus(base, "HEAD")
+		if err != nil {
+			t.Fatalf("DiffNameStatus() error = %v", err)
+		}
+		want := strings.Join([]string{
+			"R. app-misc/hello/hello-1.1.ebuild <- app-misc/hello/hello-1.0.ebuild",
+			"M. app-misc/hello/metadata.xml",
+			"A. app-misc/new/new-1.0.ebuild",
+			"D. app-misc/old/old-1.0.ebuild",
+		}, "\n")
+		if got := statusCodes(entries); got != want {
+			t.Errorf("DiffNameStatus() =\n%s\nwant:\n%s", got, want)
+		}
+
+		root, err := executor.DiffNameStatus(emptyTreeHash, base)
+		if err != nil || len(root) != 3 || root[0].Index != StatusAdded {
+			t.Errorf("DiffNameStatus(empty tree) = %+v, %v; want 3 additions", root, err)
+		}
+	})
+}
+
+func TestBackendResetAndRemove(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func(string) GitExecutor) {
+		executor := open(initContractRepo(t, false))
+		commitFiles(t, executor, "base", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+
+		writeFiles(t, executor.WorkDir(), map[string]string{"a.txt": "changed\n"})
+		if err := executor.Add("a.txt"); err != nil {
+			t.Fatal(err)
+		}
+		if err := executor.ResetPaths("a.txt"); err != nil {
+			t.Fatalf("ResetPaths() error = %v", err)
+		}
+		entries, _ := executor.Status()
+		if got := statusCodes(entries); got != ".M a.txt" {
+			t.Errorf("Status() after ResetPaths = %q, want an unstaged change", got)
+		}
+
+		if err := executor.Remove("b.txt"); err != nil {
+			t.Fatalf("Remove() error = %v", err)
+		}
+		entries, _ = executor.Status()
+		if got := statusCodes(entries); got != ".M a.txt\nD. b.txt" {
+			t.Errorf("Status() after Remove = %q, want a staged deletion", got)
+		}
+
+		if err := executor.ResetHard("HEAD"); err != nil {
+			t.Fatalf("ResetHard() error = %v", err)
+		}
+		if entries, _ := executor.Status(); len(entries) != 0 {
+			t.Errorf("Status() after ResetHard = %v, want clean", entries)
+		}
+	})
+}
+
+func TestBackendCloneFetchAndBranches(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func(string) GitExecutor) {
+		origin := open(initContractRepo(t, false))
+		commitFiles(t, origin, "first", map[string]string{"file.txt": "1\n"})
+		commitFiles(t, origin, "second", map[string]string{"file.txt": "2\n"})
+
+		clone := open(filepath.Join(t.TempDir(), "cache", "clone"))
+		if err := clone.Clone(origin.WorkDir(), "main", 1); err != nil {
+			t.Fatalf("Clone() error = %v", err)
+		}
+		commits, err := clone.Log("", 0)
+		if err != nil || len(commits) == 0 || commits[0].Subject != "second" {
+			t.Errorf("Log() of clone = %+v, %v; want the origin tip first", commits, err)
+		}
+
+		branch, err := clone.CurrentBranch()
+		if err != nil || branch != "main" {
+			t.Errorf("CurrentBranch() = %q, %v; want main", branch, err)
+		}
+		upstream, err := clone.UpstreamRef()
+		if err != nil || upstream != "origin/main" {
+			t.Errorf("UpstreamRef() = %q, %v; want origin/main", upstream, err)
+		}
+
+		commitFiles(t, origin, "third", map[string]string{"file.txt": "3\n"})
+		if err := clone.Fetch("origin"); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		ahead, behind, err := clone.AheadBehind("origin/main")
+		if err != nil || ahead != 0 || behind != 1 {
+			t.Errorf("AheadBehind() = %d, %d, %v; want 0, 1", ahead, behind, err)
+		}
+		if err := clone.ResetHard("origin/main"); err != nil {
+			t.Fatalf("ResetHard(origin/main) error = %v", err)
+		}
+		if content, _ := os.ReadFile(filepath.Join(clone.WorkDir(), "file.txt")); string(content) != "3\n" {
+			t.Errorf("file.txt = %q after reset, want the fetched version", content)
+		}
+		if err := clone.Fetch("origin"); err != nil {
+			t.Errorf("Fetch() when up to date error = %v", err)
+		}
+	})
+}
+
+func TestBackendPushBranch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, open func(string) GitExecutor) {
+		remote := initContractRepo(t, true)
+		executor := open(initContractRepo(t, false))
+		commitFiles(t, executor, "base", map[string]string{"file.txt": "1\n"})
+
+		repo, _ := gogit.PlainOpen(executor.WorkDir())
+		if _, err := repo.CreateRemote(&gitconfig.RemoteConfig{Name: "team/fork", URLs: []string{remote}}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := executor.CreateBranch("topic"); err != nil {
+			t.Fatalf("CreateBranch() error = %v", err)
+		}
+		if err := executor.CreateBranch("topic"); !errors.Is(err, ErrGitCommand) {
+			t.Errorf("CreateBranch() of an existing branch error = %v, want ErrGitCommand", err)
+		}
+		commitFiles(t, executor, "topic work", map[string]string{"file.txt": "2\n"})
+
+		if err := executor.PushBranch("team/fork", "topic"); err != nil {
+			t.Fatalf("PushBranch() error = %v", err)
+		}
+		upstream, err := executor.UpstreamRef()
+		if err != nil || upstream != "team/fork/topic" {
+			t.Errorf("UpstreamRef() = %q, %v; want team/fork/topic", upstream, err)
+		}
+		remoteName, err := executor.UpstreamRemote()
+		if err != nil || remoteName != "team/fork" {
+			t.Errorf("UpstreamRemote() = %q, %v; want team/fork", remoteName, err)
+		}
+
+		head, _ := executor.RevParse("HEAD")
+		pushed, err := NewGoGitRunner(remote).RevParse("topic")
+		if err != nil || pushed != head {
+			t.Errorf("remote topic = %q, %v; want %q", pushed, err, head)
+		}
+
+		commitFiles(t, executor, "more work", map[string]string{"file.txt": "3\n"})
+		if err := executor.Push(); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+		head, _ = executor.RevParse("HEAD")
+		if pushed, _ := NewGoGitRunner(remote).RevParse("topic"); pushed != head {
+			t.Errorf("remote topic after Push() = %q, want %q", pushed, head)
+		}
+	})
+}
+
+func TestGoGitUnsupported(t *testing.T) {
+	executor := NewGoGitRunner(initContractRepo(t, false))
+	for name, err := range map[string]error{
+		"rebase": executor.Rebase("origin/main", true),
+		"merge":  executor.Merge("origin/main"),
+		"stash":  executor.StashPush("wip"),
+	} {
+		if !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%s error = %v, want ErrUnsupported", name, err)
+		}
+	}
+}
+
+func TestRequireFeatures(t *testing.T) {
+	all := []Feature{FeatureCommitPaths, FeatureMerge, FeatureRebase, FeatureDiff, FeatureStash, FeaturePushDryRun}
+	if err := RequireFeatures(NewGitRunner("/overlay"), all...); err != nil {
+		t.Errorf("RequireFeatures(cli) error = %v, want nil", err)
+	}
+	if err := RequireFeatures(&MockGitRunner{}, all...); err != nil {
+		t.Errorf("RequireFeatures(mock) error = %v, want nil", err)
+	}
+
+	executor := NewGoGitRunner("/overlay")
+	if err := RequireFeatures(executor); err != nil {
+		t.Errorf("RequireFeatures(go-git) with no features error = %v, want nil", err)
+	}
+	err := RequireFeatures(executor, FeatureMerge, FeatureDiff)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("RequireFeatures(go-git) error = %v, want ErrUnsupported", err)
+	}
+	if !strings.Contains(err.Error(), "merge, diff") {
+		t.Errorf("RequireFeatures(go-git) error = %q, want it to name the missing features", err)
+	}
+}
+
+func TestNewExecutor(t *testing.T) {
+	if e, err := NewExecutor("", "/overlay"); err != nil || e.(*GitRunner) == nil {
+		t.Errorf("NewExecutor(\"\") = %T, %v; want the git binary backend", e, err)
+	}
+	if e, err := NewExecutor(BackendGoGit, "/overlay"); err != nil || e.WorkDir() != "/overlay" {
+		t.Errorf("NewExecutor(go-git) = %T, %v", e, err)
+	}
+	if _, err := NewExecutor("libgit2", "/overlay"); !errors.Is(err, ErrUnknownBackend) {
+		t.Errorf("NewExecutor(libgit2) error = %v, want ErrUnknownBackend", err)
+	}
+}
@@ -0,0 +1,817 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// ErrUnsupported indicates an operation the go-git backend does not implement
+var ErrUnsupported = errors.New("operation is not supported by the go-git backend")
+
+// emptyTreeHash is git's well-known empty tree, which never needs to be stored
+const emptyTreeHash = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+func init() {
+	// go-git serves local (file) remotes by running git-upload-pack; serve
+	// them in-process instead so the backend never needs the git binary
+	client.InstallProtocol("file", server.NewServer(localLoader{}))
+}
+
+// localLoader opens local repositories for the in-process file transport.
+// Unlike go-git's default loader, it also accepts non-bare repositories.
+type localLoader struct{}
+
+// Load opens the repository at the endpoint path, or its .git directory
+func (localLoader) Load(ep *transport.Endpoint) (storer.Storer, error) {
+	fs := osfs.New(ep.Path)
+	if _, err := fs.Stat(".git"); err == nil {
+		if fs, err = fs.Chroot(".git"); err != nil {
+			return nil, err
+		}
+	} else if _, err := fs.Stat("config"); err != nil {
+		return nil, transport.ErrRepositoryNotFound
+	}
+	return filesystem.NewStorage(fs, cache.NewObjectLRUDefault()), nil
+}
+
+// GoGitRunner implements GitExecutor with the pure-Go go-git library, for
+// systems without a git binary. The operations named by the Feature constants
+// (merges, rebases, stashes, textual diffs, push dry runs and commits of
+// selected paths) are not supported and return ErrUnsupported; RequireFeatures checks
+// for them up front.
+type GoGitRunner struct {
+	workDir string
+	repo    *gogit.Repository
+}
+
+// NewGoGitRunner creates a new GoGitRunner for the specified working directory.
+// The repository is opened on first use.
+func NewGoGitRunner(workDir string) *GoGitRunner {
+	return &GoGitRunner{
+		workDir: workDir,
+	}
+}
+
+// WorkDir returns the working directory of the GoGitRunner
+func (g *GoGitRunner) WorkDir() string {
+	return g.workDir
+}
+
+// gitError wraps a go-git error the way command failures are reported
+func gitError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return errors.Join(ErrGitCommand, err)
+}
+
+// unsupported returns ErrUnsupported for the named operation
+func unsupported(f Feature) error {
+	return fmt.Errorf("%w: %s", ErrUnsupported, f)
+}
+
+// supports reports whether go-git implements a feature; it implements none of
+// the optional ones
+func (g *GoGitRunner) supports(f Feature) bool {
+	return false
+}
+
+// open returns the repository, opening it on first use
+func (g *GoGitRunner) open() (*gogit.Repository, error) {
+	if g.repo == nil {
+		repo, err := gogit.PlainOpen(g.workDir)
+		if err != nil {
+			return nil, gitError(err)
+		}
+		g.repo = repo
+	}
+	return g.repo, nil
+}
+
+// worktree returns the repository's worktree
+func (g *GoGitRunner) worktree() (*gogit.Worktree, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+	w, err := repo.Worktree()
+	return w, gitError(err)
+}
+
+// resolveCommit resolves a revision to a commit, peeling annotated tags
+func (g *GoGitRunner) resolveCommit(rev string) (*object.Commit, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+
+	unknown := errors.Join(ErrGitCommand, errors.New("unknown revision: "+rev))
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, unknown
+	}
+	if tag, err := repo.TagObject(*hash); err == nil {
+		commit, err := tag.Commit()
+		if err != nil {
+			return nil, unknown
+		}
+		return commit, nil
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, unknown
+	}
+	return commit, nil
+}
+
+// statusCode converts a go-git status code to a porcelain v2 code
+func statusCode(code gogit.StatusCode) byte {
+	if code == gogit.Unmodified {
+		return StatusUnmodified
+	}
+	return byte(code)
+}
+
+// Status returns the current git status as a list of StatusEntry, sorted by path
+func (g *GoGitRunner) Status() ([]StatusEntry, error) {
+	w, err := g.worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return nil, gitError(err)
+	}
+
+	var entries []StatusEntry
+	for path, fs := range status {
+		if fs.Staging == gogit.Unmodified && fs.Worktree == gogit.Unmodified {
+			continue
+		}
+
+		entry := StatusEntry{
+			FilePath: path,
+			Index:    statusCode(fs.Staging),
+			Worktree: statusCode(fs.Worktree),
+		}
+		entry.Status = combinedStatus(entry.Index, entry.Worktree)
+		entries = append(entries, entry)
+	}
+
+	if entries, err = g.detectStagedRenames(entries); err != nil {
+		return nil, err
+	}
+
+	// Like git, list tracked entries first, then untracked ones, each by path
+	sort.Slice(entries, func(i, j int) bool {
+		if ui, uj := entries[i].IsUntracked(), entries[j].IsUntracked(); ui != uj {
+			return uj
+		}
+		return entries[i].FilePath < entries[j].FilePath
+	})
+	return entries, nil
+}
+
+// combinedStatus returns the combined XY code of StatusEntry.Status
+func combinedStatus(index, worktree byte) string {
+	if index == StatusUntracked {
+		return "??"
+	}
+	return strings.Trim(string([]byte{index, worktree}), string(StatusUnmodified))
+}
+
+// detectStagedRenames merges staged deletions and additions with identical
+// content into rename entries. go-git reports renames as a delete and an add;
+// git status pairs them, so exact renames are paired here to match.
+func (g *GoGitRunner) detectStagedRenames(entries []StatusEntry) ([]StatusEntry, error) {
+	head, err := g.resolveCommit("HEAD")
+	if err != nil {
+		// No commits yet, so nothing can have been renamed
+		return entries, nil
+	}
+	tree, err := head.Tree()
+	if err != nil {
+		return nil, gitError(err)
+	}
+	idx, err := g.repo.Storer.Index()
+	if err != nil {
+		return nil, gitError(err)
+	}
+
+	deleted := make(map[plumbing.Hash][]int)
+	for i, e := range entries {
+		if e.Index != StatusDeleted {
+			continue
+		}
+		if f, err := tree.File(e.FilePath); err == nil {
+			deleted[f.Hash] = append(deleted[f.Hash], i)
+		}
+	}
+	if len(deleted) == 0 {
+		return entries, nil
+	}
+
+	renamed := make(map[int]bool)
+	for i := range entries {
+		e := &entries[i]
+		if e.Index != StatusAdded {
+			continue
+		}
+		ie, err := idx.Entry(e.FilePath)
+		if err != nil || len(deleted[ie.Hash]) == 0 {
+			continue
+		}
+
+		from := deleted[ie.Hash][0]
+		deleted[ie.Hash] = deleted[ie.Hash][1:]
+		renamed[from] = true
+
+		e.Index = StatusRenamed
+		e.OrigPath = entries[from].FilePath
+		e.Score = 100
+		e.Status = combinedStatus(e.Index, e.Worktree)
+	}
+
+	var result []StatusEntry
+	for i, e := range entries {
+		if !renamed[i] {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// Add stages files for commit with path validation
+func (g *GoGitRunner) Add(paths ...string) error {
+	w, err := g.worktree()
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		// Default to adding all changes
+		return gitError(w.AddWithOptions(&gogit.AddOptions{All: true}))
+	}
+
+	for _, path := range paths {
+		rel, err := resolveWorkPath(g.workDir, path)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Add(filepath.ToSlash(rel)); err != nil {
+			return gitError(err)
+		}
+	}
+	return nil
+}
+
+// Commit creates a commit with the specified message and author. Without an
+// author, user.name and user.email from the git config are used.
+func (g *GoGitRunner) Commit(message, user, email string) error {
+	w, err := g.worktree()
+	if err != nil {
+		return err
+	}
+
+	opts := &gogit.CommitOptions{}
+	if user != "" && email != "" {
+		opts.Author = &object.Signature{Name: user, Email: email, When: time.Now()}
+	}
+	_, err = w.Commit(message, opts)
+	return gitError(err)
+}
+
+// CommitPaths is not supported by the go-git backend
+func (g *GoGitRunner) CommitPaths(message, user, email string, paths []string) error {
+	return unsupported(FeatureCommitPaths)
+}
+
+// RevParse resolves a revision to its full object name
+func (g *GoGitRunner) RevParse(rev string) (string, error) {
+	commit, err := g.resolveCommit(rev)
+	if err != nil {
+		return "", err
+	}
+	return commit.Hash.String(), nil
+}
+
+// reset moves HEAD to a commit with the given reset mode
+func (g *GoGitRunner) reset(commit string, mode gogit.ResetMode) error {
+	c, err := g.resolveCommit(commit)
+	if err != nil {
+		return err
+	}
+	w, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	return gitError(w.Reset(&gogit.ResetOptions{Commit: c.Hash, Mode: mode}))
+}
+
+// ResetSoft moves HEAD to the given commit, keeping the index and working tree
+func (g *GoGitRunner) ResetSoft(commit string) error {
+	return g.reset(commit, gogit.SoftReset)
+}
+
+// ResetHard moves HEAD to the given commit and discards all local changes
+func (g *GoGitRunner) ResetHard(commit string) error {
+	return g.reset(commit, gogit.HardReset)
+}
+
+// push pushes refspecs to a remote, treating an up-to-date remote as success
+func (g *GoGitRunner) push(remote string, specs ...gitconfig.RefSpec) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	err = repo.Push(&gogit.PushOptions{RemoteName: remote, RefSpecs: specs})
+	if errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	return gitError(err)
+}
+
+// upstreamBranch returns the remote and remote branch the current branch tracks
+func (g *GoGitRunner) upstreamBranch() (remote string, merge plumbing.ReferenceName, err error) {
+	branch, err := g.CurrentBranch()
+	if err != nil {
+		return "", "", err
+	}
+	repo, err := g.open()
+	if err != nil {
+		return "", "", err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", "", gitError(err)
+	}
+
+	b, ok := cfg.Branches[branch]
+	if branch == "" || !ok || b.Remote == "" || b.Merge == "" {
+		return "", "", errors.Join(ErrGitCommand, errors.New("no upstream configured for the current branch"))
+	}
+	return b.Remote, b.Merge, nil
+}
+
+// Push pushes the current branch to its upstream
+func (g *GoGitRunner) Push() error {
+	remote, merge, err := g.upstreamBranch()
+	if err != nil {
+		return err
+	}
+	branch, _ := g.CurrentBranch()
+	spec := gitconfig.RefSpec(plumbing.NewBranchReferenceName(branch).String() + ":" + merge.String())
+	return g.push(remote, spec)
+}
+
+// PushDryRun is not supported by the go-git backend
+func (g *GoGitRunner) PushDryRun() (string, error) {
+	return "", unsupported(FeaturePushDryRun)
+}
+
+// CreateBranch creates a branch at HEAD and switches to it, keeping local changes
+func (g *GoGitRunner) CreateBranch(name string) error {
+	w, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	return gitError(w.Checkout(&gogit.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(name),
+		Create: true,
+		Keep:   true,
+	}))
+}
+
+// PushBranch pushes a branch to a remote and sets it as the branch's upstream
+func (g *GoGitRunner) PushBranch(remote, branch string) error {
+	ref := plumbing.NewBranchReferenceName(branch)
+	if err := g.push(remote, gitconfig.RefSpec(ref.String()+":"+ref.String())); err != nil {
+		return err
+	}
+
+	repo, _ := g.open()
+	cfg, err := repo.Config()
+	if err != nil {
+		return gitError(err)
+	}
+	cfg.Branches[branch] = &gitconfig.Branch{Name: branch, Remote: remote, Merge: ref}
+	return gitError(repo.SetConfig(cfg))
+}
+
+// UpstreamRef returns the remote-tracking branch the current branch pushes
+// to and pulls from (e.g., "origin/main")
+func (g *GoGitRunner) UpstreamRef() (string, error) {
+	remote, merge, err := g.upstreamBranch()
+	if err != nil {
+		return "", err
+	}
+	return remote + "/" + merge.Short(), nil
+}
+
+// UpstreamRemote returns the remote the current branch tracks
+func (g *GoGitRunner) UpstreamRemote() (string, error) {
+	remote, _, err := g.upstreamBranch()
+	return remote, err
+}
+
+// Clone clones url into the runner's working directory. A non-empty branch
+// clones only that branch, and a positive depth makes a shallow clone.
+func (g *GoGitRunner) Clone(url, branch string, depth int) error {
+	opts := &gogit.CloneOptions{URL: url, Depth: depth}
+	if ep, err := transport.NewEndpoint(url); err == nil && ep.Protocol == "file" {
+		// The in-process file server cannot negotiate shallow clones; like
+		// git with a plain local path, clone the full history instead
+		opts.Depth = 0
+	}
+	if branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(branch)
+		opts.SingleBranch = true
+	}
+
+	repo, err := gogit.PlainClone(g.workDir, false, opts)
+	if err != nil {
+		return gitError(err)
+	}
+	g.repo = repo
+	return nil
+}
+
+// Fetch fetches changes from a remote repository
+func (g *GoGitRunner) Fetch(remote string) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	err = repo.Fetch(&gogit.FetchOptions{RemoteName: remote})
+	if errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	return gitError(err)
+}
+
+// Merge is not supported by the go-git backend
+func (g *GoGitRunner) Merge(branch string) error {
+	return unsupported(FeatureMerge)
+}
+
+// MergeWithOptions is not supported by the go-git backend
+func (g *GoGitRunner) MergeWithOptions(branch string, opts MergeOptions) error {
+	return unsupported(FeatureMerge)
+}
+
+// Rebase is not supported by the go-git backend
+func (g *GoGitRunner) Rebase(upstream string, autostash bool) error {
+	return unsupported(FeatureRebase)
+}
+
+// InProgress returns the merge or rebase currently stopped on conflicts, if any
+func (g *GoGitRunner) InProgress() (Operation, error) {
+	checks := []struct {
+		gitPath string
+		op      Operation
+	}{
+		{"rebase-merge", OperationRebase},
+		{"rebase-apply", OperationRebase},
+		{"MERGE_HEAD", OperationMerge},
+	}
+
+	for _, check := range checks {
+		if _, err := os.Stat(filepath.Join(g.workDir, ".git", check.gitPath)); err == nil {
+			return check.op, nil
+		}
+	}
+	return OperationNone, nil
+}
+
+// Abort is not supported by the go-git backend
+func (g *GoGitRunner) Abort(op Operation) error {
+	return unsupported(Feature(op) + " --abort")
+}
+
+// Continue is not supported by the go-git backend
+func (g *GoGitRunner) Continue(op Operation) error {
+	return unsupported(Feature(op) + " --continue")
+}
+
+// commits returns the commits of a revision ("rev" or "from..to", with an
+// empty side meaning HEAD), newest first
+func (g *GoGitRunner) commits(rev string) ([]*object.Commit, error) {
+	repo, err := g.open()
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, isRange := strings.Cut(rev, "..")
+	if !isRange {
+		from, to = "", rev
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+
+	tip, err := g.resolveCommit(to)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := make(map[plumbing.Hash]bool)
+	if isRange {
+		if from == "" {
+			from = "HEAD"
+		}
+		base, err := g.resolveCommit(from)
+		if err != nil {
+			return nil, err
+		}
+		if err := walkCommits(repo, base.Hash, func(c *object.Commit) error {
+			exclude[c.Hash] = true
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	var commits []*object.Commit
+	err = walkCommits(repo, tip.Hash, func(c *object.Commit) error {
+		if !exclude[c.Hash] {
+			commits = append(commits, c)
+		}
+		return nil
+	})
+	return commits, err
+}
+
+// walkCommits visits the commits reachable from a commit, newest first.
+// History ends at the boundary of a shallow clone.
+func walkCommits(repo *gogit.Repository, from plumbing.Hash, fn func(*object.Commit) error) error {
+	iter, err := repo.Log(&gogit.LogOptions{From: from, Order: gogit.LogOrderCommitterTime})
+	if err != nil {
+		return gitError(err)
+	}
+	err = iter.ForEach(fn)
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) && !errors.Is(err, storer.ErrStop) {
+		return gitError(err)
+	}
+	return nil
+}
+
+// RevList returns the commits in a revision range (e.g., "a..b"), oldest first
+func (g *GoGitRunner) RevList(revRange string) ([]string, error) {
+	commits, err := g.commits(revRange)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(commits))
+	for i, c := range commits {
+		hashes[len(commits)-1-i] = c.Hash.String()
+	}
+	return hashes, nil
+}
+
+// MergeBase returns the best common ancestor of two commits
+func (g *GoGitRunner) MergeBase(a, b string) (string, error) {
+	ca, err := g.resolveCommit(a)
+	if err != nil {
+		return "", err
+	}
+	cb, err := g.resolveCommit(b)
+	if err != nil {
+		return "", err
+	}
+
+	bases, err := ca.MergeBase(cb)
+	if err != nil {
+		return "", gitError(err)
+	}
+	if len(bases) == 0 {
+		return "", errors.Join(ErrGitCommand, fmt.Errorf("no merge base between %s and %s", a, b))
+	}
+	return bases[0].Hash.String(), nil
+}
+
+// treeAt returns the tree of a revision; the empty tree is returned as nil
+func (g *GoGitRunner) treeAt(rev string) (*object.Tree, error) {
+	if rev == emptyTreeHash {
+		return nil, nil
+	}
+	commit, err := g.resolveCommit(rev)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	return tree, gitError(err)
+}
+
+// DiffNameStatus returns the files changed between two commits, sorted by path.
+// Renames are detected and returned as a single entry with OrigPath set.
+func (g *GoGitRunner) DiffNameStatus(from, to string) ([]StatusEntry, error) {
+	fromTree, err := g.treeAt(from)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := g.treeAt(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTreeWithOptions(context.Background(), fromTree, toTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, gitError(err)
+	}
+
+	var entries []StatusEntry
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			return nil, gitError(err)
+		}
+
+		entry := StatusEntry{Worktree: StatusUnmodified}
+		switch {
+		case action == merkletrie.Insert:
+			entry.Index, entry.FilePath = StatusAdded, change.To.Name
+		case action == merkletrie.Delete:
+			entry.Index, entry.FilePath = StatusDeleted, change.From.Name
+		case change.From.Name != change.To.Name:
+			entry.Index, entry.FilePath, entry.OrigPath = StatusRenamed, change.To.Name, change.From.Name
+		default:
+			entry.Index, entry.FilePath = StatusModified, change.To.Name
+		}
+		entry.Status = string(entry.Index)
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].FilePath < entries[j].FilePath
+	})
+	return entries, nil
+}
+
+// Log returns the commits reachable from rev (HEAD if empty), newest first.
+// A maxCount of 0 returns the full history.
+func (g *GoGitRunner) Log(rev string, maxCount int) ([]CommitInfo, error) {
+	commits, err := g.commits(rev)
+	if err != nil {
+		return nil, err
+	}
+	if maxCount > 0 && len(commits) > maxCount {
+		commits = commits[:maxCount]
+	}
+
+	infos := make([]CommitInfo, len(commits))
+	for i, c := range commits {
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		infos[i] = CommitInfo{
+			Hash:    c.Hash.String(),
+			Author:  c.Author.Name,
+			Email:   c.Author.Email,
+			Date:    c.Author.When,
+			Subject: subject,
+		}
+	}
+	return infos, nil
+}
+
+// CurrentBranch returns the name of the checked-out branch, or an empty
+// string when HEAD is detached
+func (g *GoGitRunner) CurrentBranch() (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", gitError(err)
+	}
+	if !head.Name().IsBranch() {
+		return "", nil
+	}
+	return head.Name().Short(), nil
+}
+
+// AheadBehind returns the number of commits HEAD has that upstream lacks,
+// and the number upstream has that HEAD lacks
+func (g *GoGitRunner) AheadBehind(upstream string) (ahead, behind int, err error) {
+	aheadCommits, err := g.commits(upstream + "..HEAD")
+	if err != nil {
+		return 0, 0, err
+	}
+	behindCommits, err := g.commits("HEAD.." + upstream)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(aheadCommits), len(behindCommits), nil
+}
+
+// Diff is not supported by the go-git backend
+func (g *GoGitRunner) Diff(opts DiffOptions) (string, error) {
+	return "", unsupported(FeatureDiff)
+}
+
+// StashPush is not supported by the go-git backend
+func (g *GoGitRunner) StashPush(message string) error {
+	return unsupported(FeatureStash)
+}
+
+// StashPop is not supported by the go-git backend
+func (g *GoGitRunner) StashPop() error {
+	return unsupported(FeatureStash)
+}
+
+// ResetPaths unstages the given paths, leaving the worktree untouched
+func (g *GoGitRunner) ResetPaths(paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	w, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	return gitError(w.Restore(&gogit.RestoreOptions{Staged: true, Files: paths}))
+}
+
+// Show returns the contents of a file as of a revision; an empty revision
+// reads the staged contents from the index
+func (g *GoGitRunner) Show(rev, path string) (string, error) {
+	if rev == "" {
+		return g.showStaged(path)
+	}
+
+	commit, err := g.resolveCommit(rev)
+	if err != nil {
+		return "", err
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return "", gitError(fmt.Errorf("%s:%s: %w", rev, path, err))
+	}
+	contents, err := file.Contents()
+	return contents, gitError(err)
+}
+
+// showStaged returns the contents of a file as staged in the index
+func (g *GoGitRunner) showStaged(path string) (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return "", gitError(err)
+	}
+	entry, err := idx.Entry(filepath.ToSlash(path))
+	if err != nil {
+		return "", gitError(fmt.Errorf(":%s: %w", path, err))
+	}
+	blob, err := repo.BlobObject(entry.Hash)
+	if err != nil {
+		return "", gitError(err)
+	}
+
+	r, err := blob.Reader()
+	if err != nil {
+		return "", gitError(err)
+	}
+	defer r.Close()
+	contents, err := io.ReadAll(r)
+	return string(contents), gitError(err)
+}
+
+// Remove deletes tracked files from the worktree and stages the removal.
+// Directories are removed recursively.
+func (g *GoGitRunner) Remove(paths ...string) error {
+	w, err := g.worktree()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := w.Remove(filepath.ToSlash(path)); err != nil {
+			return gitError(fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return nil
+}
+
+// Ensure GoGitRunner implements GitExecutor interface
+var _ GitExecutor = (*GoGitRunner)(nil)
@@ -12,18 +12,99 @@ type GitExecutor interface {
 	// Commit creates a git commit with the specified message and author
 	Commit(message, user, email string) error
 
+	// CommitPaths commits only the staged state of the given paths,
+	// leaving other staged changes in the index
+	CommitPaths(message, user, email string, paths []string) error
+
+	// RevParse resolves a revision (e.g., "HEAD") to its full object name
+	RevParse(rev string) (string, error)
+
+	// ResetSoft moves HEAD to the given commit, keeping the index and working tree
+	ResetSoft(commit string) error
+
+	// ResetHard moves HEAD to the given commit and discards all local changes
+	ResetHard(commit string) error
+
 	// Push pushes commits to the remote repository
 	Push() error
 
 	// PushDryRun shows what would be pushed without actually pushing
 	PushDryRun() (string, error)
 
+	// CreateBranch creates a branch at HEAD and switches to it
+	CreateBranch(name string) error
+
+	// PushBranch pushes a branch to a remote and sets it as the branch's upstream
+	PushBranch(remote, branch string) error
+
+	// UpstreamRef returns the remote-tracking branch of the current branch (e.g., "origin/main")
+	UpstreamRef() (string, error)
+
+	// UpstreamRemote returns the remote the current branch tracks, whose name may contain slashes
+	UpstreamRemote() (string, error)
+
+	// Clone clones a repository into the working directory, optionally a single
+	// branch and shallow to the given depth
+	Clone(url, branch string, depth int) error
+
 	// Fetch fetches changes from a remote repository
 	Fetch(remote string) error
 
 	// Merge merges a branch into the current branch
 	Merge(branch string) error
 
+	// MergeWithOptions merges a branch with fast-forward and autostash options
+	MergeWithOptions(branch string, opts MergeOptions) error
+
+	// Rebase rebases the current branch onto upstream, optionally autostashing local changes
+	Rebase(upstream string, autostash bool) error
+
+	// InProgress returns the merge or rebase currently stopped on conflicts, if any
+	InProgress() (Operation, error)
+
+	// Abort aborts an in-progress merge or rebase
+	Abort(op Operation) error
+
+	// Continue resumes an in-progress merge or rebase after conflicts were resolved
+	Continue(op Operation) error
+
+	// RevList returns the commits in a revision range, oldest first
+	RevList(revRange string) ([]string, error)
+
+	// MergeBase returns the best common ancestor of two commits
+	MergeBase(a, b string) (string, error)
+
+	// DiffNameStatus returns the files changed between two commits
+	DiffNameStatus(from, to string) ([]StatusEntry, error)
+
+	// Log returns the commits reachable from rev (HEAD if empty), newest first
+	Log(rev string, maxCount int) ([]CommitInfo, error)
+
+	// CurrentBranch returns the checked-out branch, or "" when HEAD is detached
+	CurrentBranch() (string, error)
+
+	// AheadBehind counts the commits HEAD and upstream each have that the other lacks
+	AheadBehind(upstream string) (ahead, behind int, err error)
+
+	// Diff returns the textual diff of local changes
+	Diff(opts DiffOptions) (string, error)
+
+	// StashPush saves local changes, including untracked files, to a new stash entry
+	StashPush(message string) error
+
+	// StashPop reapplies the latest stash entry and drops it
+	StashPop() error
+
+	// ResetPaths unstages the given paths, leaving the worktree untouched
+	ResetPaths(paths ...string) error
+
+	// Show returns the contents of a file as of a revision; an empty
+	// revision reads the staged contents from the index
+	Show(rev, path string) (string, error)
+
+	// Remove deletes tracked files and stages the removal
+	Remove(paths ...string) error
+
 	// WorkDir returns the working directory of the git repository
 	WorkDir() string
 }
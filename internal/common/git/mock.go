@@ -3,14 +3,40 @@ package git
 // MockGitRunner implements GitExecutor for testing.
 // Each method can be configured with a custom function to control behavior.
 type MockGitRunner struct {
-	StatusFunc     func() ([]StatusEntry, error)
-	AddFunc        func(paths ...string) error
-	CommitFunc     func(message, user, email string) error
-	PushFunc       func() error
-	PushDryRunFunc func() (string, error)
-	FetchFunc      func(remote string) error
-	MergeFunc      func(branch string) error
-	workDir        string
+	StatusFunc           func() ([]StatusEntry, error)
+	AddFunc              func(paths ...string) error
+	CommitFunc           func(message, user, email string) error
+	CommitPathsFunc      func(message, user, email string, paths []string) error
+	RevParseFunc         func(rev string) (string, error)
+	ResetSoftFunc        func(commit string) error
+	ResetHardFunc        func(commit string) error
+	CloneFunc            func(url, branch string, depth int) error
+	PushFunc             func() error
+	PushDryRunFunc       func() (string, error)
+	UpstreamRefFunc      func() (string, error)
+	UpstreamRemoteFunc   func() (string, error)
+	CreateBranchFunc     func(name string) error
+	PushBranchFunc       func(remote, branch string) error
+	FetchFunc            func(remote string) error
+	MergeFunc            func(branch string) error
+	MergeWithOptionsFunc func(branch string, opts MergeOptions) error
+	RebaseFunc           func(upstream string, autostash bool) error
+	InProgressFunc       func() (Operation, error)
+	AbortFunc            func(op Operation) error
+	ContinueFunc         func(op Operation) error
+	RevListFunc          func(revRange string) ([]string, error)
+	MergeBaseFunc        func(a, b string) (string, error)
+	DiffNameStatusFunc   func(from, to string) ([]StatusEntry, error)
+	LogFunc              func(rev string, maxCount int) ([]CommitInfo, error)
+	CurrentBranchFunc    func() (string, error)
+	AheadBehindFunc      func(upstream string) (ahead, behind int, err error)
+	DiffFunc             func(opts DiffOptions) (string, error)
+	StashPushFunc        func(message string) error
+	StashPopFunc         func() error
+	ResetPathsFunc       func(paths ...string) error
+	ShowFunc             func(rev, path string) (string, error)
+	RemoveFunc           func(paths ...string) error
+	workDir              string
 }
 
 // NewMockGitRunner creates a new MockGitRunner with the specified working directory
@@ -20,6 +46,14 @@ func NewMockGitRunner(workDir string) *MockGitRunner {
 	}
 }
 
+// SetStatusOutput configures Status to return the entries parsed from raw
+// git status --porcelain=v2 -z output, so tests can describe status the way git prints it
+func (m *MockGitRunner) SetStatusOutput(raw string) {
+	m.StatusFunc = func() ([]StatusEntry, error) {
+		return ParseStatusV2(raw)
+	}
+}
+
 // Status returns the current git status as a list of StatusEntry
 func (m *MockGitRunner) Status() ([]StatusEntry, error) {
 	if m.StatusFunc != nil {
@@ -44,6 +78,30 @@ func (m *MockGitRunner) Commit(message, user, email string) error {
 	return nil
 }
 
+// CommitPaths commits only the staged state of the given paths
+func (m *MockGitRunner) CommitPaths(message, user, email string, paths []string) error {
+	if m.CommitPathsFunc != nil {
+		return m.CommitPathsFunc(message, user, email, paths)
+	}
+	return nil
+}
+
+// RevParse resolves a revision to its full object name
+func (m *MockGitRunner) RevParse(rev string) (string, error) {
+	if m.RevParseFunc != nil {
+		return m.RevParseFunc(rev)
+	}
+	return "", nil
+}
+
+// ResetSoft moves HEAD to the given commit, keeping the index and working tree
+func (m *MockGitRunner) ResetSoft(commit string) error {
+	if m.ResetSoftFunc != nil {
+		return m.ResetSoftFunc(commit)
+	}
+	return nil
+}
+
 // Push pushes commits to the remote repository
 func (m *MockGitRunner) Push() error {
 	if m.PushFunc != nil {
@@ -60,6 +118,38 @@ func (m *MockGitRunner) PushDryRun() (string, error) {
 	return "", nil
 }
 
+// CreateBranch creates a branch at HEAD and switches to it
+func (m *MockGitRunner) CreateBranch(name string) error {
+	if m.CreateBranchFunc != nil {
+		return m.CreateBranchFunc(name)
+	}
+	return nil
+}
+
+// PushBranch pushes a branch to a remote
+func (m *MockGitRunner) PushBranch(remote, branch string) error {
+	if m.PushBranchFunc != nil {
+		return m.PushBranchFunc(remote, branch)
+	}
+	return nil
+}
+
+// UpstreamRef returns the remote-tracking branch of the current branch
+func (m *MockGitRunner) UpstreamRef() (string, error) {
+	if m.UpstreamRefFunc != nil {
+		return m.UpstreamRefFunc()
+	}
+	return "", nil
+}
+
+// UpstreamRemote returns the remote the current branch tracks
+func (m *MockGitRunner) UpstreamRemote() (string, error) {
+	if m.UpstreamRemoteFunc != nil {
+		return m.UpstreamRemoteFunc()
+	}
+	return "", nil
+}
+
 // Fetch fetches changes from a remote repository
 func (m *MockGitRunner) Fetch(remote string) error {
 	if m.FetchFunc != nil {
@@ -76,6 +166,158 @@ func (m *MockGitRunner) Merge(branch string) error {
 	return nil
 }
 
+// MergeWithOptions merges a branch with fast-forward and autostash options
+func (m *MockGitRunner) MergeWithOptions(branch string, opts MergeOptions) error {
+	if m.MergeWithOptionsFunc != nil {
+		return m.MergeWithOptionsFunc(branch, opts)
+	}
+	return nil
+}
+
+// Rebase rebases the current branch onto upstream
+func (m *MockGitRunner) Rebase(upstream string, autostash bool) error {
+	if m.RebaseFunc != nil {
+		return m.RebaseFunc(upstream, autostash)
+	}
+	return nil
+}
+
+// InProgress returns the merge or rebase currently stopped on conflicts
+func (m *MockGitRunner) InProgress() (Operation, error) {
+	if m.InProgressFunc != nil {
+		return m.InProgressFunc()
+	}
+	return OperationNone, nil
+}
+
+// Abort aborts an in-progress merge or rebase
+func (m *MockGitRunner) Abort(op Operation) error {
+	if m.AbortFunc != nil {
+		return m.AbortFunc(op)
+	}
+	return nil
+}
+
+// Continue resumes an in-progress merge or rebase
+func (m *MockGitRunner) Continue(op Operation) error {
+	if m.ContinueFunc != nil {
+		return m.ContinueFunc(op)
+	}
+	return nil
+}
+
+// RevList returns the commits in a revision range, oldest first
+func (m *MockGitRunner) RevList(revRange string) ([]string, error) {
+	if m.RevListFunc != nil {
+		return m.RevListFunc(revRange)
+	}
+	return nil, nil
+}
+
+// MergeBase returns the best common ancestor of two commits
+func (m *MockGitRunner) MergeBase(a, b string) (string, error) {
+	if m.MergeBaseFunc != nil {
+		return m.MergeBaseFunc(a, b)
+	}
+	return "", nil
+}
+
+// DiffNameStatus returns the files changed between two commits
+func (m *MockGitRunner) DiffNameStatus(from, to string) ([]StatusEntry, error) {
+	if m.DiffNameStatusFunc != nil {
+		return m.DiffNameStatusFunc(from, to)
+	}
+	return nil, nil
+}
+
+// Log returns the commits reachable from rev, newest first
+func (m *MockGitRunner) Log(rev string, maxCount int) ([]CommitInfo, error) {
+	if m.LogFunc != nil {
+		return m.LogFunc(rev, maxCount)
+	}
+	return nil, nil
+}
+
+// CurrentBranch returns the checked-out branch
+func (m *MockGitRunner) CurrentBranch() (string, error) {
+	if m.CurrentBranchFunc != nil {
+		return m.CurrentBranchFunc()
+	}
+	return "", nil
+}
+
+// AheadBehind counts the commits HEAD and upstream each have that the other lacks
+func (m *MockGitRunner) AheadBehind(upstream string) (ahead, behind int, err error) {
+	if m.AheadBehindFunc != nil {
+		return m.AheadBehindFunc(upstream)
+	}
+	return 0, 0, nil
+}
+
+// Diff returns the textual diff of local changes
+func (m *MockGitRunner) Diff(opts DiffOptions) (string, error) {
+	if m.DiffFunc != nil {
+		return m.DiffFunc(opts)
+	}
+	return "", nil
+}
+
+// StashPush saves local changes to a new stash entry
+func (m *MockGitRunner) StashPush(message string) error {
+	if m.StashPushFunc != nil {
+		return m.StashPushFunc(message)
+	}
+	return nil
+}
+
+// StashPop reapplies the latest stash entry and drops it
+func (m *MockGitRunner) StashPop() error {
+	if m.StashPopFunc != nil {
+		return m.StashPopFunc()
+	}
+	return nil
+}
+
+// ResetPaths unstages the given paths
+func (m *MockGitRunner) ResetPaths(paths ...string) error {
+	if m.ResetPathsFunc != nil {
+		return m.ResetPathsFunc(paths...)
+	}
+	return nil
+}
+
+// Show returns the contents of a file as of a revision, or the index if rev is empty
+func (m *MockGitRunner) Show(rev, path string) (string, error) {
+	if m.ShowFunc != nil {
+		return m.ShowFunc(rev, path)
+	}
+	return "", nil
+}
+
+// Remove deletes tracked files and stages the removal
+func (m *MockGitRunner) Remove(paths ...string) error {
+	if m.RemoveFunc != nil {
+		return m.RemoveFunc(paths...)
+	}
+	return nil
+}
+
+// ResetHard moves HEAD to the given commit and discards all local changes
+func (m *MockGitRunner) ResetHard(commit string) error {
+	if m.ResetHardFunc != nil {
+		return m.ResetHardFunc(commit)
+	}
+	return nil
+}
+
+// Clone clones a repository into the working directory
+func (m *MockGitRunner) Clone(url, branch string, depth int) error {
+	if m.CloneFunc != nil {
+		return m.CloneFunc(url, branch, depth)
+	}
+	return nil
+}
+
 // WorkDir returns the working directory of the git repository
 func (m *MockGitRunner) WorkDir() string {
 	return m.workDir
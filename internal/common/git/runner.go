@@ -3,10 +3,13 @@ package git
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -35,8 +38,19 @@ func (g *GitRunner) WorkDir() string {
 
 // runCommand executes a git command and returns stdout, stderr, and any error
 func (g *GitRunner) runCommand(args ...string) (stdout, stderr string, err error) {
+	return g.runCommandWith(nil, "", args...)
+}
+
+// runCommandWith executes a git command with extra environment variables and stdin
+func (g *GitRunner) runCommandWith(env []string, stdin string, args ...string) (stdout, stderr string, err error) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = g.workDir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
 
 	var stdoutBuf, stderrBuf bytes.Buffer
 	cmd.Stdout = &stdoutBuf
@@ -56,23 +70,209 @@ func (g *GitRunner) runCommand(args ...string) (stdout, stderr string, err error
 	return stdout, stderr, err
 }
 
-// StatusEntry represents a single entry from git status --porcelain
+// Status codes used in StatusEntry.Index and StatusEntry.Worktree.
+// They follow the XY codes of git status --porcelain=v2.
+const (
+	StatusUnmodified  byte = '.'
+	StatusModified    byte = 'M'
+	StatusTypeChanged byte = 'T'
+	StatusAdded       byte = 'A'
+	StatusDeleted     byte = 'D'
+	StatusRenamed     byte = 'R'
+	StatusCopied      byte = 'C'
+	StatusUnmerged    byte = 'U'
+	StatusUntracked   byte = '?'
+	StatusIgnored     byte = '!'
+)
+
+// StatusEntry represents a single entry from git status
 type StatusEntry struct {
-	Status   string // A, M, D, R, ??
+	Status   string // Combined XY code without unmodified sides: A, M, MM, D, R, ??
 	FilePath string
+	Index    byte           // Index (staged) state, StatusUnmodified if unchanged
+	Worktree byte           // Worktree (unstaged) state, StatusUnmodified if unchanged
+	OrigPath string         // Source path for renames and copies
+	Score    int            // Similarity score (0-100) for renames and copies
+	Sub      SubmoduleState // Submodule flags, zero for regular files
+}
+
+// SubmoduleState describes the state of a submodule entry
+type SubmoduleState struct {
+	IsSubmodule   bool // Entry is a submodule
+	CommitChanged bool // Submodule HEAD differs from the recorded commit
+	Modified      bool // Submodule has tracked changes
+	Untracked     bool // Submodule has untracked files
+}
+
+// codes returns the index and worktree codes of the entry. Entries built
+// without explicit codes fall back to the combined Status, whose first
+// character is taken as the index state.
+func (e StatusEntry) codes() (index, worktree byte) {
+	if e.Index != 0 || e.Worktree != 0 {
+		return e.Index, e.Worktree
+	}
+
+	status := strings.TrimSpace(e.Status)
+	switch {
+	case status == "??":
+		return StatusUntracked, StatusUntracked
+	case status == "!!":
+		return StatusIgnored, StatusIgnored
+	case len(status) == 1:
+		return status[0], StatusUnmodified
+	case len(status) >= 2:
+		return status[0], status[1]
+	}
+	return StatusUnmodified, StatusUnmodified
+}
+
+// IsStaged reports whether the entry has changes in the index
+func (e StatusEntry) IsStaged() bool {
+	index, _ := e.codes()
+	switch index {
+	case StatusUnmodified, StatusUntracked, StatusIgnored, ' ':
+		return false
+	}
+	return true
+}
+
+// HasUnstagedChanges reports whether the entry has worktree changes not in the index
+func (e StatusEntry) HasUnstagedChanges() bool {
+	_, worktree := e.codes()
+	switch worktree {
+	case StatusUnmodified, StatusIgnored, ' ':
+		return false
+	}
+	return true
+}
+
+// IsUntracked reports whether the entry is an untracked file
+func (e StatusEntry) IsUntracked() bool {
+	index, _ := e.codes()
+	return index == StatusUntracked
+}
+
+// IsUnmerged reports whether the entry has unresolved merge conflicts
+func (e StatusEntry) IsUnmerged() bool {
+	index, worktree := e.codes()
+	if index == StatusUnmerged || worktree == StatusUnmerged {
+		return true
+	}
+	// Both sides added or both deleted
+	return (index == StatusAdded && worktree == StatusAdded) || (index == StatusDeleted && worktree == StatusDeleted)
+}
+
+// IsRename reports whether the entry is a staged rename with a known source path
+func (e StatusEntry) IsRename() bool {
+	index, _ := e.codes()
+	return index == StatusRenamed && e.OrigPath != ""
 }
 
 // Status returns the current git status as a list of StatusEntry
 func (g *GitRunner) Status() ([]StatusEntry, error) {
-	stdout, _, err := g.runCommand("status", "--porcelain")
+	stdout, _, err := g.runCommand("status", "--porcelain=v2", "-z", "--untracked-files=all")
 	if err != nil {
 		return nil, err
 	}
 
-	return ParseStatusOutput(stdout), nil
+	return ParseStatusV2(stdout)
 }
 
-// ParseStatusOutput parses git status --porcelain output into StatusEntry slice
+// ParseStatusV2 parses git status --porcelain=v2 -z output into a StatusEntry slice.
+// Paths are taken verbatim, so spaces, quotes and non-ASCII names are preserved.
+// Renames and copies are returned as a single entry with OrigPath set.
+func ParseStatusV2(output string) ([]StatusEntry, error) {
+	var entries []StatusEntry
+
+	records := strings.Split(output, "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if record == "" {
+			continue
+		}
+
+		switch record[0] {
+		case '#':
+			// Header lines (--branch); not status entries
+			continue
+
+		case '?', '!':
+			if len(record) < 3 {
+				return nil, malformedStatus(record)
+			}
+			code := record[0]
+			entries = append(entries, StatusEntry{
+				Status:   string([]byte{code, code}),
+				FilePath: record[2:],
+				Index:    code,
+				Worktree: code,
+			})
+
+		case '1', '2', 'u':
+			// Field count before the path:
+			//   1 XY sub mH mI mW hH hI <path>
+			//   2 XY sub mH mI mW hH hI Xscore <path>\x00<origPath>
+			//   u XY sub m1 m2 m3 mW h1 h2 h3 <path>
+			fieldCount := map[byte]int{'1': 8, '2': 9, 'u': 10}[record[0]]
+			fields := strings.SplitN(record, " ", fieldCount+1)
+			if len(fields) != fieldCount+1 || len(fields[1]) != 2 {
+				return nil, malformedStatus(record)
+			}
+
+			entry := StatusEntry{
+				FilePath: fields[fieldCount],
+				Index:    fields[1][0],
+				Worktree: fields[1][1],
+				Sub:      parseSubmoduleState(fields[2]),
+			}
+			entry.Status = strings.Trim(fields[1], string(StatusUnmodified))
+
+			if record[0] == '2' {
+				score, err := strconv.Atoi(fields[8][1:])
+				if err != nil {
+					return nil, malformedStatus(record)
+				}
+				entry.Score = score
+
+				// The original path is the next NUL-separated record
+				i++
+				if i >= len(records) || records[i] == "" {
+					return nil, malformedStatus(record)
+				}
+				entry.OrigPath = records[i]
+			}
+
+			entries = append(entries, entry)
+
+		default:
+			return nil, malformedStatus(record)
+		}
+	}
+
+	return entries, nil
+}
+
+// parseSubmoduleState parses the <sub> field of a porcelain v2 entry
+func parseSubmoduleState(field string) SubmoduleState {
+	if len(field) != 4 || field[0] != 'S' {
+		return SubmoduleState{}
+	}
+	return SubmoduleState{
+		IsSubmodule:   true,
+		CommitChanged: field[1] == 'C',
+		Modified:      field[2] == 'M',
+		Untracked:     field[3] == 'U',
+	}
+}
+
+// malformedStatus returns an error for an unparseable status record
+func malformedStatus(record string) error {
+	return errors.Join(ErrGitCommand, fmt.Errorf("malformed status entry: %q", record))
+}
+
+// ParseStatusOutput parses git status --porcelain (v1) output into StatusEntry slice.
+// Renames are split into delete and add entries. Prefer ParseStatusV2, which
+// keeps index and worktree states apart and handles unusual paths.
 func ParseStatusOutput(output string) []StatusEntry {
 	var entries []StatusEntry
 
@@ -137,37 +337,47 @@ func (g *GitRunner) Add(paths ...string) error {
 
 // validateAndAddPath validates a single path and adds it to staging
 func (g *GitRunner) validateAndAddPath(path string) error {
+	if _, err := resolveWorkPath(g.workDir, path); err != nil {
+		return err
+	}
+
+	// Add the file to staging
+	_, _, err := g.runCommand("add", path)
+	return err
+}
+
+// resolveWorkPath checks that path, absolute or relative to workDir, is an
+// existing file or directory inside workDir and returns it relative to workDir
+func resolveWorkPath(workDir, path string) (string, error) {
 	// Resolve the path relative to workDir
 	var absPath string
 	if filepath.IsAbs(path) {
 		absPath = path
 	} else {
-		absPath = filepath.Join(g.workDir, path)
+		absPath = filepath.Join(workDir, path)
 	}
 
 	// Clean the path to resolve any .. or . components
 	absPath = filepath.Clean(absPath)
-	workDirAbs := filepath.Clean(g.workDir)
+	workDirAbs := filepath.Clean(workDir)
 
 	// Check if path is inside the overlay directory
 	relPath, err := filepath.Rel(workDirAbs, absPath)
 	if err != nil {
-		return errors.Join(ErrInvalidPath, err)
+		return "", errors.Join(ErrInvalidPath, err)
 	}
 
 	// If the relative path starts with "..", it's outside the overlay
 	if strings.HasPrefix(relPath, "..") {
-		return ErrPathOutsideOverlay
+		return "", ErrPathOutsideOverlay
 	}
 
 	// Check if the file/directory exists
 	if !fileExists(absPath) {
-		return ErrFileNotFound
+		return "", ErrFileNotFound
 	}
 
-	// Add the file to staging
-	_, _, err = g.runCommand("add", path)
-	return err
+	return relPath, nil
 }
 
 // fileExists checks if a file or directory exists using os.Stat
@@ -190,6 +400,97 @@ func (g *GitRunner) Commit(message, user, email string) error {
 	return err
 }
 
+// CommitPaths commits only the staged state of the given paths.
+// Other staged changes stay in the index for later commits. The commit is
+// staged in a temporary index, so the real index is never modified, and
+// made with git commit, so signing and hooks apply as for Commit.
+func (g *GitRunner) CommitPaths(message, user, email string, paths []string) error {
+	if len(paths) == 0 {
+		return errors.Join(ErrGitCommand, errors.New("no paths to commit"))
+	}
+
+	// Snapshot of everything currently staged
+	stagedTree, _, err := g.runCommand("write-tree")
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "bentoo-index-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmpDir, "index")}
+
+	// Start from HEAD, or from an empty index on an unborn branch, and take
+	// the selected paths from the staged tree
+	if head, err := g.RevParse("HEAD"); err == nil {
+		if _, _, err := g.runCommandWith(env, "", "read-tree", head); err != nil {
+			return err
+		}
+	}
+
+	lsArgs := append([]string{"ls-tree", "-r", "-z", strings.TrimSpace(stagedTree), "--"}, paths...)
+	listing, _, err := g.runCommand(lsArgs...)
+	if err != nil {
+		return err
+	}
+
+	present := make(map[string]bool)
+	var indexInfo strings.Builder
+	for _, record := range strings.Split(listing, "\x00") {
+		// Format: <mode> SP <type> SP <object> TAB <path>
+		meta, path, ok := strings.Cut(record, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 {
+			continue
+		}
+		present[path] = true
+		indexInfo.WriteString(fields[0] + " " + fields[2] + "\t" + path + "\x00")
+	}
+	for _, path := range paths {
+		if !present[path] {
+			// Deleted in the staged tree: mode 0 removes the entry
+			indexInfo.WriteString("0 " + strings.Repeat("0", 40) + "\t" + path + "\x00")
+		}
+	}
+
+	if _, _, err := g.runCommandWith(env, indexInfo.String(), "update-index", "-z", "--index-info"); err != nil {
+		return err
+	}
+
+	args := []string{"commit", "-m", message}
+	if user != "" && email != "" {
+		args = append(args, "--author", user+" <"+email+">")
+	}
+	_, _, err = g.runCommandWith(env, "", args...)
+	return err
+}
+
+// RevParse resolves a revision to its full object name
+func (g *GitRunner) RevParse(rev string) (string, error) {
+	stdout, _, err := g.runCommand("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", errors.Join(ErrGitCommand, errors.New("unknown revision: "+rev))
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+// ResetSoft moves HEAD to the given commit, keeping the index and working tree
+func (g *GitRunner) ResetSoft(commit string) error {
+	_, _, err := g.runCommand("reset", "--soft", commit)
+	return err
+}
+
+// ResetHard moves HEAD to the given commit and discards all local changes
+func (g *GitRunner) ResetHard(commit string) error {
+	_, _, err := g.runCommand("reset", "--hard", commit)
+	return err
+}
+
 // Push pushes commits to the remote repository
 func (g *GitRunner) Push() error {
 	_, _, err := g.runCommand("push")
@@ -208,6 +509,70 @@ func (g *GitRunner) PushDryRun() (string, error) {
 	return strings.TrimSpace(stdout), nil
 }
 
+// CreateBranch creates a branch at HEAD and switches to it, keeping local changes
+func (g *GitRunner) CreateBranch(name string) error {
+	_, _, err := g.runCommand("switch", "-c", name)
+	return err
+}
+
+// PushBranch pushes a branch to a remote and sets it as the branch's upstream
+func (g *GitRunner) PushBranch(remote, branch string) error {
+	_, _, err := g.runCommand("push", "--set-upstream", remote, branch)
+	return err
+}
+
+// UpstreamRef returns the remote-tracking branch the current branch pushes
+// to and pulls from (e.g., "origin/main")
+func (g *GitRunner) UpstreamRef() (string, error) {
+	stdout, _, err := g.runCommand("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+// UpstreamRemote returns the remote the current branch tracks
+// (branch.<name>.remote). Unlike splitting UpstreamRef, this keeps remote
+// names containing slashes intact.
+func (g *GitRunner) UpstreamRemote() (string, error) {
+	branch, err := g.CurrentBranch()
+	if err != nil {
+		return "", err
+	}
+	if branch == "" {
+		return "", errors.Join(ErrGitCommand, errors.New("HEAD is detached"))
+	}
+	stdout, _, err := g.runCommand("config", "--get", "branch."+branch+".remote")
+	if err != nil {
+		// git config exits silently when the key is unset
+		return "", errors.Join(ErrGitCommand, errors.New("no upstream configured for the current branch"))
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+// Clone clones url into the runner's working directory, which must not exist
+// or be empty. A non-empty branch clones only that branch, and a positive depth
+// makes a shallow clone.
+func (g *GitRunner) Clone(url, branch string, depth int) error {
+	parent := filepath.Dir(g.workDir)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return err
+	}
+
+	args := []string{"clone"}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	if branch != "" {
+		args = append(args, "--single-branch", "--branch", branch)
+	}
+	args = append(args, "--", url, g.workDir)
+
+	// The working directory does not exist yet, so run from its parent
+	_, _, err := NewGitRunner(parent).runCommand(args...)
+	return err
+}
+
 // Fetch fetches changes from a remote repository
 func (g *GitRunner) Fetch(remote string) error {
 	_, _, err := g.runCommand("fetch", remote)
@@ -217,10 +582,102 @@ func (g *GitRunner) Fetch(remote string) error {
 // Merge merges a branch into the current branch.
 // If there are conflicts, the error message includes the conflict details from stdout.
 func (g *GitRunner) Merge(branch string) error {
-	stdout, stderr, err := g.runCommand("merge", branch)
+	// Git outputs conflict information to stdout, so include it in the error
+	// for proper conflict detection
+	return g.runWithOutputInError(nil, "merge", branch)
+}
+
+// MergeOptions configures MergeWithOptions
+type MergeOptions struct {
+	FFOnly    bool // Refuse to merge unless the merge can be resolved as a fast-forward
+	Autostash bool // Stash local changes before the merge and reapply them afterwards
+}
+
+// MergeWithOptions merges a branch into the current branch without opening an editor.
+// If there are conflicts, the error message includes the conflict details from stdout.
+func (g *GitRunner) MergeWithOptions(branch string, opts MergeOptions) error {
+	args := []string{"merge", "--no-edit"}
+	if opts.FFOnly {
+		args = append(args, "--ff-only")
+	}
+	if opts.Autostash {
+		args = append(args, "--autostash")
+	}
+	args = append(args, branch)
+	return g.runWithOutputInError(nil, args...)
+}
+
+// Rebase rebases the current branch onto upstream.
+// If there are conflicts, the error message includes the conflict details from stdout.
+func (g *GitRunner) Rebase(upstream string, autostash bool) error {
+	args := []string{"rebase"}
+	if autostash {
+		args = append(args, "--autostash")
+	}
+	args = append(args, upstream)
+	return g.runWithOutputInError(nil, args...)
+}
+
+// Operation identifies a multi-step git operation that can be continued or aborted
+type Operation string
+
+const (
+	OperationNone   Operation = ""
+	OperationMerge  Operation = "merge"
+	OperationRebase Operation = "rebase"
+)
+
+// InProgress returns the merge or rebase currently stopped on conflicts, if any
+func (g *GitRunner) InProgress() (Operation, error) {
+	checks := []struct {
+		gitPath string
+		op      Operation
+	}{
+		{"rebase-merge", OperationRebase},
+		{"rebase-apply", OperationRebase},
+		{"MERGE_HEAD", OperationMerge},
+	}
+
+	for _, check := range checks {
+		stdout, _, err := g.runCommand("rev-parse", "--git-path", check.gitPath)
+		if err != nil {
+			return OperationNone, err
+		}
+		path := strings.TrimSpace(stdout)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(g.workDir, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return check.op, nil
+		}
+	}
+
+	return OperationNone, nil
+}
+
+// Abort aborts an in-progress merge or rebase, restoring any autostash
+func (g *GitRunner) Abort(op Operation) error {
+	if op == OperationNone {
+		return errors.Join(ErrGitCommand, errors.New("no merge or rebase in progress"))
+	}
+	_, _, err := g.runCommand(string(op), "--abort")
+	return err
+}
+
+// Continue resumes an in-progress merge or rebase after conflicts were resolved.
+// Commit messages are accepted as prepared, without opening an editor.
+func (g *GitRunner) Continue(op Operation) error {
+	if op == OperationNone {
+		return errors.Join(ErrGitCommand, errors.New("no merge or rebase in progress"))
+	}
+	return g.runWithOutputInError([]string{"GIT_EDITOR=true"}, string(op), "--continue")
+}
+
+// runWithOutputInError runs a command whose conflict details are written to
+// stdout and includes both streams in the returned error
+func (g *GitRunner) runWithOutputInError(env []string, args ...string) error {
+	stdout, stderr, err := g.runCommandWith(env, "", args...)
 	if err != nil {
-		// Git outputs conflict information to stdout, so include it in the error
-		// for proper conflict detection
 		combinedOutput := strings.TrimSpace(stdout + "\n" + stderr)
 		if combinedOutput != "" {
 			return errors.Join(ErrGitCommand, errors.New(combinedOutput))
@@ -229,5 +686,246 @@ func (g *GitRunner) Merge(branch string) error {
 	return err
 }
 
+// RevList returns the commits in a revision range (e.g., "a..b"), oldest first
+func (g *GitRunner) RevList(revRange string) ([]string, error) {
+	stdout, _, err := g.runCommand("rev-list", "--reverse", revRange)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(stdout), nil
+}
+
+// MergeBase returns the best common ancestor of two commits
+func (g *GitRunner) MergeBase(a, b string) (string, error) {
+	stdout, _, err := g.runCommand("merge-base", a, b)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+// DiffNameStatus returns the files changed between two commits.
+// Renames are detected and returned as a single entry with OrigPath set.
+func (g *GitRunner) DiffNameStatus(from, to string) ([]StatusEntry, error) {
+	stdout, _, err := g.runCommand("diff", "--name-status", "-z", "-M", from, to)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNameStatus(stdout)
+}
+
+// ParseNameStatus parses git diff --name-status -z output into a StatusEntry slice.
+// The change is reported as the index state, as if the diff were staged.
+func ParseNameStatus(output string) ([]StatusEntry, error) {
+	var entries []StatusEntry
+
+	fields := strings.Split(output, "\x00")
+	for i := 0; i < len(fields); i++ {
+		code := fields[i]
+		if code == "" {
+			continue
+		}
+
+		entry := StatusEntry{
+			Status:   code[:1],
+			Index:    code[0],
+			Worktree: StatusUnmodified,
+		}
+
+		if code[0] == StatusRenamed || code[0] == StatusCopied {
+			// Format: R<score>\x00<old>\x00<new>
+			if score, err := strconv.Atoi(code[1:]); err == nil {
+				entry.Score = score
+			}
+			if i+2 >= len(fields) {
+				return nil, malformedStatus(code)
+			}
+			entry.OrigPath = fields[i+1]
+			entry.FilePath = fields[i+2]
+			i += 2
+		} else {
+			if i+1 >= len(fields) {
+				return nil, malformedStatus(code)
+			}
+			entry.FilePath = fields[i+1]
+			i++
+		}
+
+		if entry.FilePath == "" {
+			return nil, malformedStatus(code)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// CommitInfo describes a single commit
+type CommitInfo struct {
+	Hash    string
+	Author  string
+	Email   string
+	Date    time.Time // Author date
+	Subject string
+}
+
+// logFormat separates fields with NUL and records with RS, so subjects may hold any text
+const logFormat = "%H%x00%an%x00%ae%x00%aI%x00%s%x1e"
+
+// Log returns the commits reachable from rev (HEAD if empty), newest first.
+// A maxCount of 0 returns the full history.
+func (g *GitRunner) Log(rev string, maxCount int) ([]CommitInfo, error) {
+	args := []string{"log", "--format=" + logFormat}
+	if maxCount > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", maxCount))
+	}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	args = append(args, "--")
+
+	stdout, _, err := g.runCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLogOutput(stdout)
+}
+
+// ParseLogOutput parses git log output produced with logFormat
+func ParseLogOutput(output string) ([]CommitInfo, error) {
+	var commits []CommitInfo
+
+	for _, record := range strings.Split(output, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+
+		fields := strings.Split(record, "\x00")
+		if len(fields) != 5 {
+			return nil, errors.Join(ErrGitCommand, fmt.Errorf("malformed log record: %q", record))
+		}
+
+		date, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			return nil, errors.Join(ErrGitCommand, fmt.Errorf("malformed log date %q: %w", fields[3], err))
+		}
+
+		commits = append(commits, CommitInfo{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Date:    date,
+			Subject: fields[4],
+		})
+	}
+
+	return commits, nil
+}
+
+// CurrentBranch returns the name of the checked-out branch, or an empty
+// string when HEAD is detached
+func (g *GitRunner) CurrentBranch() (string, error) {
+	stdout, _, err := g.runCommand("branch", "--show-current")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+// AheadBehind returns the number of commits HEAD has that upstream lacks,
+// and the number upstream has that HEAD lacks
+func (g *GitRunner) AheadBehind(upstream string) (ahead, behind int, err error) {
+	stdout, _, err := g.runCommand("rev-list", "--left-right", "--count", "HEAD..."+upstream)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fields := strings.Fields(stdout)
+	if len(fields) != 2 {
+		return 0, 0, errors.Join(ErrGitCommand, fmt.Errorf("malformed rev-list count: %q", stdout))
+	}
+	if ahead, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, errors.Join(ErrGitCommand, err)
+	}
+	if behind, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, errors.Join(ErrGitCommand, err)
+	}
+	return ahead, behind, nil
+}
+
+// DiffOptions configures Diff
+type DiffOptions struct {
+	Staged bool     // Compare the index with HEAD instead of the worktree with the index
+	Color  bool     // Include ANSI colors in the output
+	Paths  []string // Limit the diff to these paths
+}
+
+// Diff returns the textual diff of local changes
+func (g *GitRunner) Diff(opts DiffOptions) (string, error) {
+	args := []string{"diff"}
+	if opts.Color {
+		args = append(args, "--color=always")
+	}
+	if opts.Staged {
+		args = append(args, "--staged")
+	}
+	args = append(args, "--")
+	args = append(args, opts.Paths...)
+
+	stdout, _, err := g.runCommand(args...)
+	if err != nil {
+		return "", err
+	}
+	return stdout, nil
+}
+
+// StashPush saves local changes, including untracked files, to a new stash entry
+func (g *GitRunner) StashPush(message string) error {
+	args := []string{"stash", "push", "--include-untracked"}
+	if message != "" {
+		args = append(args, "-m", message)
+	}
+	_, _, err := g.runCommand(args...)
+	return err
+}
+
+// StashPop reapplies the latest stash entry and drops it.
+// If it conflicts, the entry is kept and the error includes the conflict details.
+func (g *GitRunner) StashPop() error {
+	return g.runWithOutputInError(nil, "stash", "pop")
+}
+
+// ResetPaths unstages the given paths, leaving the worktree untouched
+func (g *GitRunner) ResetPaths(paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	args := append([]string{"reset", "--quiet", "--"}, paths...)
+	_, _, err := g.runCommand(args...)
+	return err
+}
+
+// Show returns the contents of a file as of a revision; an empty revision
+// reads the staged contents from the index (git show :path)
+func (g *GitRunner) Show(rev, path string) (string, error) {
+	stdout, _, err := g.runCommand("show", rev+":"+path)
+	if err != nil {
+		return "", err
+	}
+	return stdout, nil
+}
+
+// Remove deletes tracked files from the worktree and stages the removal.
+// Directories are removed recursively.
+func (g *GitRunner) Remove(paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	args := append([]string{"rm", "-r", "--quiet", "--"}, paths...)
+	_, _, err := g.runCommand(args...)
+	return err
+}
+
 // Ensure GitRunner implements GitExecutor interface
 var _ GitExecutor = (*GitRunner)(nil)
@@ -1,9 +1,14 @@
 package git
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseStatusOutput(t *testing.T) {
@@ -260,3 +265,846 @@ func TestGitRunnerStatus(t *testing.T) {
 		}
 	})
 }
+
+func TestGitRunnerCommitPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	runner := NewGitRunner(tmpDir)
+
+	if _, _, err := runner.runCommand("init"); err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	_, _, _ = runner.runCommand("config", "user.email", "test@example.com")
+	_, _, _ = runner.runCommand("config", "user.name", "Test User")
+
+	write := func(name, content string) {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	write("a/one.txt", "one")
+	write("b/old.txt", "old")
+	if err := runner.Add("."); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	if err := runner.Commit("initial", "", ""); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	// Stage a modification in a/, then change it again unstaged,
+	// and stage a deletion plus an addition in b/
+	write("a/one.txt", "staged")
+	if err := runner.Add("a/one.txt"); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	write("a/one.txt", "unstaged")
+	_, _, _ = runner.runCommand("rm", "-q", "b/old.txt")
+	write("b/new.txt", "new")
+	if err := runner.Add("b/new.txt"); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+
+	head, err := runner.RevParse("HEAD")
+	if err != nil {
+		t.Fatalf("RevParse failed: %v", err)
+	}
+
+	t.Run("commits only the given paths with staged content", func(t *testing.T) {
+		if err := runner.CommitPaths("update a", "Custom User", "custom@example.com", []string{"a/one.txt"}); err != nil {
+			t.Fatalf("CommitPaths failed: %v", err)
+		}
+
+		content, _, _ := runner.runCommand("show", "HEAD:a/one.txt")
+		if content != "staged" {
+			t.Errorf("expected committed content 'staged', got %q", content)
+		}
+		author, _, _ := runner.runCommand("log", "-1", "--format=%an <%ae>")
+		if strings.TrimSpace(author) != "Custom User <custom@example.com>" {
+			t.Errorf("unexpected author %q", author)
+		}
+
+		// b/ changes are still staged
+		staged, _, _ := runner.runCommand("diff", "--cached", "--name-only")
+		if strings.Contains(staged, "a/one.txt") || !strings.Contains(staged, "b/new.txt") || !strings.Contains(staged, "b/old.txt") {
+			t.Errorf("expected b/ changes to remain staged, got %q", staged)
+		}
+	})
+
+	t.Run("commits deletions", func(t *testing.T) {
+		if err := runner.CommitPaths("update b", "", "", []string{"b/new.txt", "b/old.txt"}); err != nil {
+			t.Fatalf("CommitPaths failed: %v", err)
+		}
+
+		files, _, _ := runner.runCommand("ls-tree", "-r", "--name-only", "HEAD")
+		if strings.Contains(files, "b/old.txt") || !strings.Contains(files, "b/new.txt") {
+			t.Errorf("unexpected tree contents %q", files)
+		}
+		staged, _, _ := runner.runCommand("diff", "--cached", "--name-only")
+		if strings.TrimSpace(staged) != "" {
+			t.Errorf("expected nothing staged, got %q", staged)
+		}
+	})
+
+	t.Run("reset soft restores HEAD", func(t *testing.T) {
+		if err := runner.ResetSoft(head); err != nil {
+			t.Fatalf("ResetSoft failed: %v", err)
+		}
+		current, _ := runner.RevParse("HEAD")
+		if current != head {
+			t.Errorf("expected HEAD %s, got %s", head, current)
+		}
+		staged, _, _ := runner.runCommand("diff", "--cached", "--name-only")
+		if len(strings.Fields(staged)) != 3 {
+			t.Errorf("expected all changes staged again, got %q", staged)
+		}
+	})
+
+	t.Run("no paths is an error", func(t *testing.T) {
+		if err := runner.CommitPaths("empty", "", "", nil); err == nil {
+			t.Error("expected error for empty path list")
+		}
+	})
+}
+
+func TestGitRunnerCommitPathsSignsAndRunsHooks(t *testing.T) {
+	runner := newTestRepo(t)
+	dir := runner.WorkDir()
+
+	// A stand-in for gpg that emits a fixed signature, and a commit-msg
+	// hook that appends a trailer
+	gpg := filepath.Join(t.TempDir(), "fake-gpg")
+	script := "#!/bin/sh\ncat >/dev/null\nprintf '\\n[GNUPG:] SIG_CREATED ' >&2\n" +
+		"printf -- '-----BEGIN PGP SIGNATURE-----\\nfake\\n-----END PGP SIGNATURE-----\\n'\n"
+	if err := os.WriteFile(gpg, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	hook := "#!/bin/sh\nprintf '\\nHooked-By: commit-msg\\n' >> \"$1\"\n"
+	if err := os.WriteFile(filepath.Join(dir, ".git", "hooks", "commit-msg"), []byte(hook), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"config", "commit.gpgsign", "true"},
+		{"config", "gpg.program", gpg},
+	} {
+		if _, _, err := runner.runCommand(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("signed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Add("hello.txt"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := runner.CommitPaths("signed change", "", "", []string{"hello.txt"}); err != nil {
+		t.Fatalf("CommitPaths() error = %v", err)
+	}
+
+	commit, _, _ := runner.runCommand("cat-file", "commit", "HEAD")
+	if !strings.Contains(commit, "gpgsig -----BEGIN PGP SIGNATURE-----") {
+		t.Errorf("commit is not signed:\n%s", commit)
+	}
+	if !strings.Contains(commit, "Hooked-By: commit-msg") {
+		t.Errorf("commit-msg hook did not run:\n%s", commit)
+	}
+
+	// A failing signer refuses the commit, as git commit does
+	if err := os.WriteFile(gpg, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("unsigned\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Add("hello.txt"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := runner.CommitPaths("unsigned change", "", "", []string{"hello.txt"}); err == nil {
+		t.Error("CommitPaths() with a failing signer succeeded")
+	}
+}
+
+func TestGitRunnerCommitPathsUnbornHead(t *testing.T) {
+	tmpDir := t.TempDir()
+	runner := NewGitRunner(tmpDir)
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test User"},
+	} {
+		if _, _, err := runner.runCommand(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	for _, name := range []string{"one.txt", "two.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := runner.Add("one.txt", "two.txt"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if err := runner.CommitPaths("first", "", "", []string{"one.txt"}); err != nil {
+		t.Fatalf("CommitPaths() on an unborn branch error = %v", err)
+	}
+	files, _, _ := runner.runCommand("ls-tree", "-r", "--name-only", "HEAD")
+	if strings.TrimSpace(files) != "one.txt" {
+		t.Errorf("first commit tree = %q, want only one.txt", files)
+	}
+	staged, _, _ := runner.runCommand("diff", "--cached", "--name-only")
+	if strings.TrimSpace(staged) != "two.txt" {
+		t.Errorf("staged after first commit = %q, want two.txt", staged)
+	}
+}
+
+func TestParseStatusV2(t *testing.T) {
+	const hashes = "100644 100644 100644 0123456789abcdef0123456789abcdef01234567 0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []StatusEntry
+	}{
+		{
+			name:     "empty output",
+			input:    "",
+			expected: nil,
+		},
+		{
+			name:  "staged addition",
+			input: "1 A. N... 000000 100644 100644 0000000000000000000000000000000000000000 0123456789abcdef0123456789abcdef01234567 app-misc/hello/hello-1.0.ebuild\x00",
+			expected: []StatusEntry{
+				{Status: "A", FilePath: "app-misc/hello/hello-1.0.ebuild", Index: StatusAdded, Worktree: StatusUnmodified},
+			},
+		},
+		{
+			name:  "partially staged and unstaged only",
+			input: "1 MM N... " + hashes + " app-misc/hello/hello-1.0.ebuild\x001 .M N... " + hashes + " app-misc/hello/Manifest\x00",
+			expected: []StatusEntry{
+				{Status: "MM", FilePath: "app-misc/hello/hello-1.0.ebuild", Index: StatusModified, Worktree: StatusModified},
+				{Status: "M", FilePath: "app-misc/hello/Manifest", Index: StatusUnmodified, Worktree: StatusModified},
+			},
+		},
+		{
+			name:  "rename with score and original path",
+			input: "2 R. N... " + hashes + " R95 app-misc/hello/hello-2.0.ebuild\x00app-misc/hello/hello-1.0.ebuild\x00",
+			expected: []StatusEntry{
+				{Status: "R", FilePath: "app-misc/hello/hello-2.0.ebuild", Index: StatusRenamed, Worktree: StatusUnmodified,
+					OrigPath: "app-misc/hello/hello-1.0.ebuild", Score: 95},
+			},
+		},
+		{
+			name:  "copy",
+			input: "2 C. N... " + hashes + " C100 b.txt\x00a.txt\x00",
+			expected: []StatusEntry{
+				{Status: "C", FilePath: "b.txt", Index: StatusCopied, Worktree: StatusUnmodified, OrigPath: "a.txt", Score: 100},
+			},
+		},
+		{
+			name:  "paths with spaces, quotes and non-ASCII",
+			input: "1 A. N... " + hashes + " files/my patch \"v2\".diff\x00? résumé.txt\x00",
+			expected: []StatusEntry{
+				{Status: "A", FilePath: "files/my patch \"v2\".diff", Index: StatusAdded, Worktree: StatusUnmodified},
+				{Status: "??", FilePath: "résumé.txt", Index: StatusUntracked, Worktree: StatusUntracked},
+			},
+		},
+		{
+			name:  "submodule with new commits and untracked files",
+			input: "1 .M SC.U 160000 160000 160000 " + hashes[21:] + " vendor/lib\x00",
+			expected: []StatusEntry{
+				{Status: "M", FilePath: "vendor/lib", Index: StatusUnmodified, Worktree: StatusModified,
+					Sub: SubmoduleState{IsSubmodule: true, CommitChanged: true, Untracked: true}},
+			},
+		},
+		{
+			name:  "unmerged and ignored entries, headers skipped",
+			input: "# branch.oid 0123456789abcdef0123456789abcdef01234567\x00u UU N... 100644 100644 100644 100644 h1 h2 h3 app-misc/hello/Manifest\x00! build.log\x00",
+			expected: []StatusEntry{
+				{Status: "UU", FilePath: "app-misc/hello/Manifest", Index: StatusUnmerged, Worktree: StatusUnmerged},
+				{Status: "!!", FilePath: "build.log", Index: StatusIgnored, Worktree: StatusIgnored},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseStatusV2(tt.input)
+			if err != nil {
+				t.Fatalf("ParseStatusV2() error = %v", err)
+			}
+			if len(result) != len(tt.expected) {
+				t.Fatalf("expected %d entries, got %d: %+v", len(tt.expected), len(result), result)
+			}
+			for i, entry := range result {
+				if entry != tt.expected[i] {
+					t.Errorf("entry %d:\n got  %+v\n want %+v", i, entry, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseStatusV2Malformed(t *testing.T) {
+	inputs := []string{
+		"1 M N... truncated\x00",
+		"2 R. N... 100644 100644 100644 h1 h2 R95 new.txt\x00",
+		"x something\x00",
+		"? \x00",
+	}
+	for _, input := range inputs {
+		if _, err := ParseStatusV2(input); err == nil {
+			t.Errorf("ParseStatusV2(%q) expected error", input)
+		}
+	}
+}
+
+func TestStatusEntryStates(t *testing.T) {
+	tests := []struct {
+		name      string
+		entry     StatusEntry
+		staged    bool
+		unstaged  bool
+		untracked bool
+		rename    bool
+	}{
+		{"staged only", StatusEntry{Index: StatusModified, Worktree: StatusUnmodified}, true, false, false, false},
+		{"unstaged only", StatusEntry{Index: StatusUnmodified, Worktree: StatusModified}, false, true, false, false},
+		{"partially staged", StatusEntry{Index: StatusAdded, Worktree: StatusModified}, true, true, false, false},
+		{"untracked", StatusEntry{Index: StatusUntracked, Worktree: StatusUntracked}, false, true, true, false},
+		{"rename", StatusEntry{Index: StatusRenamed, Worktree: StatusUnmodified, OrigPath: "old"}, true, false, false, true},
+		{"legacy staged code", StatusEntry{Status: "A"}, true, false, false, false},
+		{"legacy untracked code", StatusEntry{Status: "??"}, false, true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.IsStaged(); got != tt.staged {
+				t.Errorf("IsStaged() = %v, want %v", got, tt.staged)
+			}
+			if got := tt.entry.HasUnstagedChanges(); got != tt.unstaged {
+				t.Errorf("HasUnstagedChanges() = %v, want %v", got, tt.unstaged)
+			}
+			if got := tt.entry.IsUntracked(); got != tt.untracked {
+				t.Errorf("IsUntracked() = %v, want %v", got, tt.untracked)
+			}
+			if got := tt.entry.IsRename(); got != tt.rename {
+				t.Errorf("IsRename() = %v, want %v", got, tt.rename)
+			}
+		})
+	}
+}
+
+func TestGitRunnerStatusV2(t *testing.T) {
+	tmpDir := t.TempDir()
+	runner := NewGitRunner(tmpDir)
+
+	if _, _, err := runner.runCommand("init"); err != nil {
+		t.Fatalf("failed to init git repo: %v", err)
+	}
+	_, _, _ = runner.runCommand("config", "user.email", "test@example.com")
+	_, _, _ = runner.runCommand("config", "user.name", "Test User")
+
+	content := strings.Repeat("line of ebuild content\n", 20)
+	if err := os.MkdirAll(filepath.Join(tmpDir, "app-misc", "hello"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "app-misc/hello/hello-1.0.ebuild"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := runner.Add("."); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	if err := runner.Commit("initial", "", ""); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	// Staged rename, partially staged file, and an untracked path with a space
+	_, _, _ = runner.runCommand("mv", "app-misc/hello/hello-1.0.ebuild", "app-misc/hello/hello-2.0.ebuild")
+	_ = os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("b"), 0644)
+	_ = runner.Add("notes.txt")
+	_ = os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("c"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "my notes.txt"), []byte("d"), 0644)
+
+	entries, err := runner.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	byPath := make(map[string]StatusEntry)
+	for _, e := range entries {
+		byPath[e.FilePath] = e
+	}
+
+	if e := byPath["app-misc/hello/hello-2.0.ebuild"]; !e.IsRename() || e.OrigPath != "app-misc/hello/hello-1.0.ebuild" {
+		t.Errorf("expected rename from hello-1.0.ebuild, got %+v", e)
+	}
+	if e := byPath["notes.txt"]; !e.IsStaged() || !e.HasUnstagedChanges() {
+		t.Errorf("expected partially staged notes.txt, got %+v", e)
+	}
+	if e := byPath["my notes.txt"]; !e.IsUntracked() {
+		t.Errorf("expected untracked 'my notes.txt', got %+v", e)
+	}
+}
+
+func TestParseNameStatus(t *testing.T) {
+	input := "A\x00app-misc/hello/hello-2.0.ebuild\x00" +
+		"R087\x00net-misc/foo/foo-1.0.ebuild\x00net-misc/foo/foo-1.1.ebuild\x00" +
+		"D\x00dev-libs/old/old-1.0.ebuild\x00"
+
+	entries, err := ParseNameStatus(input)
+	if err != nil {
+		t.Fatalf("ParseNameStatus() error = %v", err)
+	}
+
+	expected := []StatusEntry{
+		{Status: "A", FilePath: "app-misc/hello/hello-2.0.ebuild", Index: StatusAdded, Worktree: StatusUnmodified},
+		{Status: "R", FilePath: "net-misc/foo/foo-1.1.ebuild", Index: StatusRenamed, Worktree: StatusUnmodified,
+			OrigPath: "net-misc/foo/foo-1.0.ebuild", Score: 87},
+		{Status: "D", FilePath: "dev-libs/old/old-1.0.ebuild", Index: StatusDeleted, Worktree: StatusUnmodified},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(expected), len(entries), entries)
+	}
+	for i := range expected {
+		if entries[i] != expected[i] {
+			t.Errorf("entry %d:\n got  %+v\n want %+v", i, entries[i], expected[i])
+		}
+	}
+
+	if _, err := ParseNameStatus("R100\x00only-old\x00"); err == nil {
+		t.Error("expected error for truncated rename")
+	}
+}
+
+func TestGitRunnerSyncOperations(t *testing.T) {
+	tmpDir := t.TempDir()
+	runner := NewGitRunner(tmpDir)
+
+	mustRun := func(args ...string) string {
+		t.Helper()
+		stdout, _, err := runner.runCommand(args...)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return strings.TrimSpace(stdout)
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	mustRun("init", "-b", "main")
+	mustRun("config", "user.email", "test@example.com")
+	mustRun("config", "user.name", "Test User")
+	write("Manifest", "base\n")
+	write("notes.txt", "notes\n")
+	mustRun("add", ".")
+	mustRun("commit", "-m", "base")
+	base := mustRun("rev-parse", "HEAD")
+
+	// Diverging changes to Manifest on two branches
+	mustRun("checkout", "-b", "upstream")
+	write("Manifest", "upstream\n")
+	write("new.txt", "new\n")
+	mustRun("add", ".")
+	mustRun("commit", "-m", "upstream change")
+	mustRun("checkout", "main")
+	write("Manifest", "local\n")
+	mustRun("commit", "-am", "local change")
+
+	t.Run("history queries", func(t *testing.T) {
+		commits, err := runner.RevList("main..upstream")
+		if err != nil || len(commits) != 1 {
+			t.Fatalf("RevList() = %v, %v; want 1 commit", commits, err)
+		}
+		mergeBase, err := runner.MergeBase("main", "upstream")
+		if err != nil || mergeBase != base {
+			t.Fatalf("MergeBase() = %q, %v; want %q", mergeBase, err, base)
+		}
+		entries, err := runner.DiffNameStatus(mergeBase, "upstream")
+		if err != nil || len(entries) != 2 {
+			t.Fatalf("DiffNameStatus() = %+v, %v; want 2 entries", entries, err)
+		}
+	})
+
+	t.Run("rebase conflict can be aborted with autostash restored", func(t *testing.T) {
+		write("notes.txt", "uncommitted\n")
+
+		err := runner.Rebase("upstream", true)
+		if err == nil || !strings.Contains(err.Error(), "CONFLICT") {
+			t.Fatalf("expected rebase conflict, got %v", err)
+		}
+		op, err := runner.InProgress()
+		if err != nil || op != OperationRebase {
+			t.Fatalf("InProgress() = %q, %v; want rebase", op, err)
+		}
+
+		if err := runner.Abort(op); err != nil {
+			t.Fatalf("Abort() error = %v", err)
+		}
+		if op, _ := runner.InProgress(); op != OperationNone {
+			t.Errorf("InProgress() after abort = %q, want none", op)
+		}
+		data, _ := os.ReadFile(filepath.Join(tmpDir, "notes.txt"))
+		if string(data) != "uncommitted\n" {
+			t.Errorf("autostashed change not restored, got %q", data)
+		}
+		mustRun("checkout", "notes.txt")
+	})
+
+	t.Run("merge conflict can be continued", func(t *testing.T) {
+		err := runner.MergeWithOptions("upstream", MergeOptions{})
+		if err == nil || !strings.Contains(err.Error(), "CONFLICT") {
+			t.Fatalf("expected merge conflict, got %v", err)
+		}
+		op, _ := runner.InProgress()
+		if op != OperationMerge {
+			t.Fatalf("InProgress() = %q, want merge", op)
+		}
+
+		entries, _ := runner.Status()
+		unmerged := false
+		for _, e := range entries {
+			if e.FilePath == "Manifest" {
+				unmerged = e.IsUnmerged()
+			}
+		}
+		if !unmerged {
+			t.Errorf("expected unmerged Manifest, got %+v", entries)
+		}
+
+		write("Manifest", "resolved\n")
+		mustRun("add", "Manifest")
+		if err := runner.Continue(op); err != nil {
+			t.Fatalf("Continue() error = %v", err)
+		}
+		if op, _ := runner.InProgress(); op != OperationNone {
+			t.Errorf("InProgress() after continue = %q, want none", op)
+		}
+	})
+
+	t.Run("ff-only refuses diverged history", func(t *testing.T) {
+		mustRun("checkout", "-b", "diverged", base)
+		write("other.txt", "x\n")
+		mustRun("add", "other.txt")
+		mustRun("commit", "-m", "diverge")
+
+		if err := runner.MergeWithOptions("main", MergeOptions{FFOnly: true}); err == nil {
+			t.Error("expected ff-only merge to fail on diverged history")
+		}
+	})
+}
+
+func TestParseLogOutput(t *testing.T) {
+	raw := "abc123\x00Alice\x00alice@example.com\x002025-03-01T10:00:00+01:00\x00up(www-client/firefox-1.0 -> 2.0)\x1e\n" +
+		"def456\x00Bob\x00bob@example.com\x002025-02-01T09:30:00Z\x00fix typo\x1e\n"
+
+	commits, err := ParseLogOutput(raw)
+	if err != nil {
+		t.Fatalf("ParseLogOutput() error = %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("got %d commits, want 2", len(commits))
+	}
+
+	first := commits[0]
+	if first.Hash != "abc123" || first.Author != "Alice" || first.Email != "alice@example.com" {
+		t.Errorf("first commit = %+v", first)
+	}
+	if first.Subject != "up(www-client/firefox-1.0 -> 2.0)" {
+		t.Errorf("Subject = %q", first.Subject)
+	}
+	if want := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC); !first.Date.Equal(want) {
+		t.Errorf("Date = %v, want %v", first.Date, want)
+	}
+
+	if commits, err := ParseLogOutput(""); err != nil || len(commits) != 0 {
+		t.Errorf("ParseLogOutput(\"\") = %v, %v; want empty", commits, err)
+	}
+
+	for _, bad := range []string{
+		"abc\x00Alice\x00a@example.com\x1e",
+		"abc\x00Alice\x00a@example.com\x00yesterday\x00subject\x1e",
+	} {
+		if _, err := ParseLogOutput(bad); !errors.Is(err, ErrGitCommand) {
+			t.Errorf("ParseLogOutput(%q) error = %v, want ErrGitCommand", bad, err)
+		}
+	}
+}
+
+func TestGitRunnerLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	runner := NewGitRunner(tmpDir)
+
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test User"},
+	} {
+		if _, _, err := runner.runCommand(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	for i, subject := range []string{"first", "add(app-misc/foo-1.0)", "second\n\nwith a body"} {
+		name := filepath.Join(tmpDir, fmt.Sprintf("file%d", i))
+		if err := os.WriteFile(name, []byte(subject), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		if _, _, err := runner.runCommand("add", "."); err != nil {
+			t.Fatalf("git add: %v", err)
+		}
+		if err := runner.Commit(subject, "Test User", "test@example.com"); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+	}
+
+	commits, err := runner.Log("", 0)
+	if err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	var subjects []string
+	for _, c := range commits {
+		subjects = append(subjects, c.Subject)
+	}
+	if want := []string{"second", "add(app-misc/foo-1.0)", "first"}; !reflect.DeepEqual(subjects, want) {
+		t.Errorf("subjects = %q, want %q", subjects, want)
+	}
+	if commits[0].Author != "Test User" || commits[0].Email != "test@example.com" || len(commits[0].Hash) != 40 {
+		t.Errorf("commit = %+v", commits[0])
+	}
+
+	commits, err = runner.Log("HEAD~1", 1)
+	if err != nil || len(commits) != 1 || commits[0].Subject != "add(app-misc/foo-1.0)" {
+		t.Errorf("Log(HEAD~1, 1) = %+v, %v", commits, err)
+	}
+}
+
+func TestGitRunnerUpstreamRef(t *testing.T) {
+	remoteDir := t.TempDir()
+	tmpDir := t.TempDir()
+	runner := NewGitRunner(tmpDir)
+
+	if _, _, err := NewGitRunner(remoteDir).runCommand("init", "--bare"); err != nil {
+		t.Fatalf("git init --bare: %v", err)
+	}
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test User"},
+		{"commit", "--allow-empty", "-m", "base"},
+		{"remote", "add", "origin", remoteDir},
+	} {
+		if _, _, err := runner.runCommand(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+
+	if _, err := runner.UpstreamRef(); !errors.Is(err, ErrGitCommand) {
+		t.Errorf("UpstreamRef() without upstream error = %v, want ErrGitCommand", err)
+	}
+	if _, err := runner.UpstreamRemote(); !errors.Is(err, ErrGitCommand) {
+		t.Errorf("UpstreamRemote() without upstream error = %v, want ErrGitCommand", err)
+	}
+
+	if _, _, err := runner.runCommand("push", "-u", "origin", "main"); err != nil {
+		t.Fatalf("git push: %v", err)
+	}
+	upstream, err := runner.UpstreamRef()
+	if err != nil || upstream != "origin/main" {
+		t.Errorf("UpstreamRef() = %q, %v; want origin/main", upstream, err)
+	}
+	remote, err := runner.UpstreamRemote()
+	if err != nil || remote != "origin" {
+		t.Errorf("UpstreamRemote() = %q, %v; want origin", remote, err)
+	}
+}
+
+func TestGitRunnerCreateAndPushBranch(t *testing.T) {
+	remoteDir := t.TempDir()
+	tmpDir := t.TempDir()
+	runner := NewGitRunner(tmpDir)
+
+	if _, _, err := NewGitRunner(remoteDir).runCommand("init", "--bare"); err != nil {
+		t.Fatalf("git init --bare: %v", err)
+	}
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test User"},
+		{"commit", "--allow-empty", "-m", "base"},
+		{"remote", "add", "fork", remoteDir},
+	} {
+		if _, _, err := runner.runCommand(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+
+	if err := runner.CreateBranch("topic"); err != nil {
+		t.Fatalf("CreateBranch() error = %v", err)
+	}
+	if err := runner.CreateBranch("topic"); !errors.Is(err, ErrGitCommand) {
+		t.Errorf("CreateBranch() of an existing branch error = %v, want ErrGitCommand", err)
+	}
+
+	if err := runner.PushBranch("fork", "topic"); err != nil {
+		t.Fatalf("PushBranch() error = %v", err)
+	}
+	upstream, err := runner.UpstreamRef()
+	if err != nil || upstream != "fork/topic" {
+		t.Errorf("UpstreamRef() = %q, %v; want fork/topic", upstream, err)
+	}
+}
+
+// newTestRepo initializes a repository on main with one commit of hello.txt
+func newTestRepo(t *testing.T) *GitRunner {
+	t.Helper()
+	tmpDir := t.TempDir()
+	runner := NewGitRunner(tmpDir)
+	if err := os.WriteFile(filepath.Join(tmpDir, "hello.txt"), []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test User"},
+		{"add", "hello.txt"},
+		{"commit", "-m", "base"},
+	} {
+		if _, _, err := runner.runCommand(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	return runner
+}
+
+func TestGitRunnerCurrentBranchAndAheadBehind(t *testing.T) {
+	runner := newTestRepo(t)
+
+	branch, err := runner.CurrentBranch()
+	if err != nil || branch != "main" {
+		t.Errorf("CurrentBranch() = %q, %v; want main", branch, err)
+	}
+
+	for _, args := range [][]string{
+		{"branch", "other"},
+		{"commit", "--allow-empty", "-m", "local 1"},
+		{"commit", "--allow-empty", "-m", "local 2"},
+		{"switch", "other"},
+		{"commit", "--allow-empty", "-m", "other 1"},
+		{"switch", "main"},
+	} {
+		if _, _, err := runner.runCommand(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+
+	ahead, behind, err := runner.AheadBehind("other")
+	if err != nil || ahead != 2 || behind != 1 {
+		t.Errorf("AheadBehind() = %d, %d, %v; want 2, 1", ahead, behind, err)
+	}
+	if _, _, err := runner.AheadBehind("missing"); !errors.Is(err, ErrGitCommand) {
+		t.Errorf("AheadBehind(missing) error = %v, want ErrGitCommand", err)
+	}
+
+	if _, _, err := runner.runCommand("switch", "--detach", "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	if branch, err := runner.CurrentBranch(); err != nil || branch != "" {
+		t.Errorf("CurrentBranch() detached = %q, %v; want empty", branch, err)
+	}
+}
+
+func TestGitRunnerDiffShowAndResetPaths(t *testing.T) {
+	runner := newTestRepo(t)
+	path := filepath.Join(runner.WorkDir(), "hello.txt")
+	if err := os.WriteFile(path, []byte("hello\nworld\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := runner.Diff(DiffOptions{})
+	if err != nil || !strings.Contains(diff, "+world") {
+		t.Errorf("Diff() = %q, %v; want the worktree change", diff, err)
+	}
+	if staged, _ := runner.Diff(DiffOptions{Staged: true}); staged != "" {
+		t.Errorf("Diff(Staged) before add = %q, want empty", staged)
+	}
+
+	if err := runner.Add("hello.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if staged, _ := runner.Diff(DiffOptions{Staged: true, Paths: []string{"hello.txt"}}); !strings.Contains(staged, "+world") {
+		t.Errorf("Diff(Staged) = %q, want the staged change", staged)
+	}
+
+	if err := runner.ResetPaths("hello.txt"); err != nil {
+		t.Fatalf("ResetPaths() error = %v", err)
+	}
+	entries, _ := runner.Status()
+	if len(entries) != 1 || entries[0].IsStaged() || !entries[0].HasUnstagedChanges() {
+		t.Errorf("status after ResetPaths = %+v, want an unstaged change only", entries)
+	}
+
+	content, err := runner.Show("HEAD", "hello.txt")
+	if err != nil || content != "hello\n" {
+		t.Errorf("Show() = %q, %v; want the committed content", content, err)
+	}
+	if _, err := runner.Show("HEAD", "missing.txt"); !errors.Is(err, ErrGitCommand) {
+		t.Errorf("Show(missing) error = %v, want ErrGitCommand", err)
+	}
+}
+
+func TestGitRunnerStash(t *testing.T) {
+	runner := newTestRepo(t)
+	dir := runner.WorkDir()
+	if err := os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runner.StashPush("wip"); err != nil {
+		t.Fatalf("StashPush() error = %v", err)
+	}
+	if entries, _ := runner.Status(); len(entries) != 0 {
+		t.Errorf("status after StashPush = %+v, want clean", entries)
+	}
+
+	if err := runner.StashPop(); err != nil {
+		t.Fatalf("StashPop() error = %v", err)
+	}
+	if entries, _ := runner.Status(); len(entries) != 2 {
+		t.Errorf("status after StashPop = %+v, want both changes back", entries)
+	}
+	if err := runner.StashPop(); !errors.Is(err, ErrGitCommand) {
+		t.Errorf("StashPop() with no stash error = %v, want ErrGitCommand", err)
+	}
+}
+
+func TestGitRunnerRemove(t *testing.T) {
+	runner := newTestRepo(t)
+
+	if err := runner.Remove("hello.txt"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(runner.WorkDir(), "hello.txt")); !os.IsNotExist(err) {
+		t.Errorf("hello.txt still exists after Remove()")
+	}
+	entries, _ := runner.Status()
+	if len(entries) != 1 || entries[0].Index != StatusDeleted {
+		t.Errorf("status after Remove = %+v, want a staged deletion", entries)
+	}
+	if err := runner.Remove("missing.txt"); !errors.Is(err, ErrGitCommand) {
+		t.Errorf("Remove(missing) error = %v, want ErrGitCommand", err)
+	}
+}
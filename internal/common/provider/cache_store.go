@@ -0,0 +1,286 @@
+package provider
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
+)
+
+// Directories of the provider caches under bentoo's cache root
+const (
+	compareCacheDir = "compare"
+	reposCacheDir   = "repos"
+)
+
+// apiCacheFiles are the files of a GitHub or GitLab provider CacheDir. Any
+// other JSON file there was written by the retired per-package cache.
+var apiCacheFiles = []string{treeCacheFile, blobCacheFile, updatesCacheFile}
+
+// CacheStores returns the provider caches under bentoo's cache root (see
+// config.CacheDir): the API caches of each GitHub and GitLab repository, the
+// files left by the retired per-package cache, and the git clones.
+func CacheStores(root string) ([]cache.Store, error) {
+	var stores []cache.Store
+
+	for _, host := range []string{"github", "gitlab"} {
+		dirs, err := subdirs(filepath.Join(root, compareCacheDir, host))
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			name := host + "/" + filepath.Base(dir)
+			stores = append(stores, &apiCacheStore{name: name, dir: dir})
+
+			legacy, err := legacyCacheFiles(dir)
+			if err != nil {
+				return nil, err
+			}
+			if len(legacy) > 0 {
+				stores = append(stores, &legacyCacheStore{name: name, dir: dir})
+			}
+		}
+	}
+
+	clones, err := subdirs(filepath.Join(root, reposCacheDir))
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range clones {
+		stores = append(stores, &cloneStore{dir: dir})
+	}
+	return stores, nil
+}
+
+// subdirs returns the sorted subdirectories of dir. A missing dir has none.
+func subdirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// legacyCacheFiles returns the per-package cache files in an API cache dir
+func legacyCacheFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") || isAPICacheFile(name) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files, nil
+}
+
+// isAPICacheFile reports whether name is one of apiCacheFiles
+func isAPICacheFile(name string) bool {
+	for _, f := range apiCacheFiles {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
+
+// apiCacheStore is the tree index, ebuild blob and profiles/updates cache of
+// one GitHub or GitLab repository. Entries are indexed packages, ebuild blobs
+// and updates files. They are revalidated against the API rather than
+// expiring, except for a tree index of an older format, which is rebuilt.
+type apiCacheStore struct {
+	name string
+	dir  string
+}
+
+// Kind returns cache.KindCompare
+func (s *apiCacheStore) Kind() string { return cache.KindCompare }
+
+// Name returns the host and repository of the cache
+func (s *apiCacheStore) Name() string { return s.name }
+
+// Path returns the cache directory
+func (s *apiCacheStore) Path() string { return s.dir }
+
+// Stats counts the entries of the cache files
+func (s *apiCacheStore) Stats() (cache.Stats, error) {
+	var stats cache.Stats
+	for _, name := range apiCacheFiles {
+		fileStats, err := cache.PathStats(filepath.Join(s.dir, name))
+		if err != nil {
+			return stats, err
+		}
+		stats.Add(fileStats)
+	}
+
+	var index treeIndex
+	if s.readJSON(treeCacheFile, &index) {
+		packages := 0
+		for _, c := range index.Categories {
+			packages += len(c.Packages)
+		}
+		stats.Entries += packages
+		if index.Format != treeIndexFormat {
+			stats.Expired += packages
+		}
+	}
+	var blobs map[string]map[string]string
+	if s.readJSON(blobCacheFile, &blobs) {
+		stats.Entries += len(blobs)
+	}
+	var updates updatesCache
+	if s.readJSON(updatesCacheFile, &updates) {
+		stats.Entries += len(updates.Files)
+	}
+	return stats, nil
+}
+
+// readJSON decodes a cache file into v. It reports false when the file is
+// missing or unreadable.
+func (s *apiCacheStore) readJSON(name string, v any) bool {
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	return err == nil && json.Unmarshal(data, v) == nil
+}
+
+// Prune removes a tree index of an older format
+func (s *apiCacheStore) Prune() error {
+	var index treeIndex
+	if !s.readJSON(treeCacheFile, &index) || index.Format == treeIndexFormat {
+		return nil
+	}
+	return removeIfExists(filepath.Join(s.dir, treeCacheFile))
+}
+
+// Clear removes the cache files
+func (s *apiCacheStore) Clear() error {
+	for _, name := range apiCacheFiles {
+		if err := removeIfExists(filepath.Join(s.dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// legacyCacheStore is the per-package files the GitHub client and provider
+// cached versions in before the tree index replaced them. Nothing reads them
+// anymore, so every entry is expired.
+type legacyCacheStore struct {
+	name string
+	dir  string
+}
+
+// Kind returns cache.KindLegacy
+func (s *legacyCacheStore) Kind() string { return cache.KindLegacy }
+
+// Name returns the host and repository of the cache
+func (s *legacyCacheStore) Name() string { return s.name }
+
+// Path returns the directory holding the files
+func (s *legacyCacheStore) Path() string { return s.dir }
+
+// Stats counts the per-package files
+func (s *legacyCacheStore) Stats() (cache.Stats, error) {
+	files, err := legacyCacheFiles(s.dir)
+	if err != nil {
+		return cache.Stats{}, err
+	}
+
+	var stats cache.Stats
+	for _, f := range files {
+		fileStats, err := cache.PathStats(f)
+		if err != nil {
+			return stats, err
+		}
+		fileStats.Entries, fileStats.Expired = 1, 1
+		stats.Add(fileStats)
+	}
+	return stats, nil
+}
+
+// Prune removes the per-package files, all of them being expired
+func (s *legacyCacheStore) Prune() error {
+	return s.Clear()
+}
+
+// Clear removes the per-package files
+func (s *legacyCacheStore) Clear() error {
+	files, err := legacyCacheFiles(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := removeIfExists(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cloneStore is a repository clone of the git clone provider. A clone is a
+// single entry; it is refreshed when used rather than expiring.
+type cloneStore struct {
+	dir string
+}
+
+// Kind returns cache.KindRepos
+func (s *cloneStore) Kind() string { return cache.KindRepos }
+
+// Name returns the repository name of the clone
+func (s *cloneStore) Name() string { return filepath.Base(s.dir) }
+
+// Path returns the clone directory
+func (s *cloneStore) Path() string { return s.dir }
+
+// Stats returns the size of the clone and the time it was last fetched
+func (s *cloneStore) Stats() (cache.Stats, error) {
+	stats, err := cache.PathStats(s.dir)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Entries = 1
+	for _, name := range []string{"FETCH_HEAD", "HEAD"} {
+		if info, err := os.Stat(filepath.Join(s.dir, ".git", name)); err == nil {
+			stats.Oldest, stats.Newest = info.ModTime(), info.ModTime()
+			break
+		}
+	}
+	return stats, nil
+}
+
+// Prune does nothing, as clones do not expire
+func (s *cloneStore) Prune() error {
+	return nil
+}
+
+// Clear removes the clone
+func (s *cloneStore) Clear() error {
+	return os.RemoveAll(s.dir)
+}
+
+// removeIfExists removes a file, ignoring a missing one
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
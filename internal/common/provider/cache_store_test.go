@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheStores(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"compare/github/gentoo/tree.json":       `{"format":1,"categories":{"app-misc":{"packages":{"a":["1"],"b":["2"]}}}}`,
+		"compare/github/gentoo/ebuilds.json":    `{"blob1":{"EAPI":"8"}}`,
+		"compare/github/gentoo/updates.json":    `{"etag":"x","files":{"1Q-2024":{}}}`,
+		"compare/github/gentoo/app-misc_a.json": `{}`,
+		"compare/gitlab/guru/tree.json":         `{"format":2,"categories":{}}`,
+		"repos/gentoo/.git/HEAD":                "ref: refs/heads/master\n",
+	})
+
+	stores, err := CacheStores(root)
+	if err != nil {
+		t.Fatalf("CacheStores() error = %v", err)
+	}
+	var got []string
+	for _, s := range stores {
+		got = append(got, s.Kind()+":"+s.Name())
+	}
+	want := []string{"compare:github/gentoo", "legacy:github/gentoo", "compare:gitlab/guru", "repos:gentoo"}
+	if len(got) != len(want) {
+		t.Fatalf("CacheStores() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("CacheStores() = %v, want %v", got, want)
+		}
+	}
+
+	// Packages of an old tree index format are expired
+	api := stores[0]
+	if stats, err := api.Stats(); err != nil || stats.Entries != 4 || stats.Expired != 2 {
+		t.Errorf("compare Stats() = %+v, %v", stats, err)
+	}
+	if err := api.Prune(); err != nil {
+		t.Fatalf("compare Prune() error = %v", err)
+	}
+	if stats, _ := api.Stats(); stats.Entries != 2 || stats.Expired != 0 {
+		t.Errorf("compare after Prune: %+v", stats)
+	}
+
+	legacy := stores[1]
+	if stats, err := legacy.Stats(); err != nil || stats.Entries != 1 || stats.Expired != 1 {
+		t.Errorf("legacy Stats() = %+v, %v", stats, err)
+	}
+	if err := legacy.Prune(); err != nil {
+		t.Fatalf("legacy Prune() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "compare/github/gentoo/app-misc_a.json")); !os.IsNotExist(err) {
+		t.Errorf("legacy file kept: %v", err)
+	}
+
+	// A current index is kept by Prune, Clear removes every file
+	guru := stores[2]
+	if err := guru.Prune(); err != nil {
+		t.Fatalf("gitlab Prune() error = %v", err)
+	}
+	if stats, _ := guru.Stats(); stats.Entries != 0 || stats.Size == 0 {
+		t.Errorf("gitlab after Prune: %+v", stats)
+	}
+	if err := api.Clear(); err != nil {
+		t.Fatalf("compare Clear() error = %v", err)
+	}
+	if stats, _ := api.Stats(); stats.Entries != 0 || stats.Size != 0 {
+		t.Errorf("compare after Clear: %+v", stats)
+	}
+
+	clone := stores[3]
+	if stats, err := clone.Stats(); err != nil || stats.Entries != 1 || stats.Newest.IsZero() {
+		t.Errorf("repos Stats() = %+v, %v", stats, err)
+	}
+	if err := clone.Clear(); err != nil {
+		t.Fatalf("repos Clear() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "repos/gentoo")); !os.IsNotExist(err) {
+		t.Errorf("clone kept: %v", err)
+	}
+
+	// An empty cache root has no stores
+	if stores, err := CacheStores(t.TempDir()); err != nil || len(stores) != 0 {
+		t.Errorf("CacheStores(empty) = %v, %v", stores, err)
+	}
+}
@@ -35,6 +35,11 @@ func NewProvider(repoInfo *RepositoryInfo, forceClone bool) (Provider, error) {
 		return nil, ErrRepositoryNotFound
 	}
 
+	// A tree on disk is read in place; there is nothing to clone
+	if repoInfo.Provider == "local" {
+		return NewLocalProvider(repoInfo)
+	}
+
 	// If --clone flag is used, always use git clone
 	if forceClone {
 		return NewGitCloneProvider(repoInfo)
@@ -55,7 +60,8 @@ func NewProvider(repoInfo *RepositoryInfo, forceClone bool) (Provider, error) {
 }
 
 // ResolveRepository resolves a repository name to its full info
-// It first checks built-in repositories, then config-defined repositories
+// It first checks built-in repositories, then config-defined repositories,
+// then the local repositories registered in Portage's repos.conf
 func ResolveRepository(name string, configRepos map[string]*RepositoryInfo) (*RepositoryInfo, error) {
 	// Check built-in repositories first
 	if repo, ok := BuiltinRepositories[name]; ok {
@@ -69,12 +75,18 @@ func ResolveRepository(name string, configRepos map[string]*RepositoryInfo) (*Re
 		}
 	}
 
+	// Check repositories Portage knows about
+	if repo, ok := systemRepositories()[name]; ok {
+		return repo, nil
+	}
+
 	return nil, fmt.Errorf("%w: %s", ErrRepositoryNotFound, name)
 }
 
 // ListAvailableRepositories returns a list of all available repository names
 func ListAvailableRepositories(configRepos map[string]*RepositoryInfo) []string {
-	repos := make([]string, 0, len(BuiltinRepositories)+len(configRepos))
+	systemRepos := systemRepositories()
+	repos := make([]string, 0, len(BuiltinRepositories)+len(configRepos)+len(systemRepos))
 
 	// Add built-in repos
 	for name := range BuiltinRepositories {
@@ -89,5 +101,14 @@ func ListAvailableRepositories(configRepos map[string]*RepositoryInfo) []string
 		}
 	}
 
+	// Add repos.conf repos not shadowed by either of the above
+	for name := range systemRepos {
+		_, builtin := BuiltinRepositories[name]
+		_, configured := configRepos[name]
+		if !builtin && !configured {
+			repos = append(repos, name)
+		}
+	}
+
 	return repos
 }
@@ -5,6 +5,7 @@ import (
 )
 
 func TestResolveRepository(t *testing.T) {
+	withReposConf(t, "")
 	tests := []struct {
 		name        string
 		repoName    string
@@ -155,6 +156,7 @@ func TestNewProvider(t *testing.T) {
 }
 
 func TestListAvailableRepositories(t *testing.T) {
+	withReposConf(t, "")
 	configRepos := map[string]*RepositoryInfo{
 		"my-overlay": {Name: "my-overlay"},
 		"custom":     {Name: "custom"},
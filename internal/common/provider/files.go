@@ -0,0 +1,194 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+)
+
+// FileReader is implemented by providers that can read any file of the
+// repository, not just list package versions
+type FileReader interface {
+	// ReadFile returns the content of a file, by path from the repository root
+	ReadFile(path string) ([]byte, error)
+
+	// ListDir returns the sorted names of the entries of a directory.
+	// Subdirectory names end with a slash.
+	ListDir(path string) ([]string, error)
+}
+
+// UpdatesReader is implemented by providers that read profiles/updates more
+// cheaply than file by file
+type UpdatesReader interface {
+	// ReadUpdates returns the package moves of the repository
+	ReadUpdates() (*ebuild.Updates, error)
+}
+
+// updatesCacheFile is the name of the profiles/updates cache in a provider's CacheDir
+const updatesCacheFile = "updates.json"
+
+// updatesCache holds the parsed profiles/updates files of a repository,
+// with the ETag of the directory listing they were read from
+type updatesCache struct {
+	ETag  string                 `json:"etag"`
+	Files map[string]updatesFile `json:"files"`
+}
+
+// updatesFile is a parsed profiles/updates file and the blob it was read from
+type updatesFile struct {
+	SHA     string          `json:"sha"`
+	Updates *ebuild.Updates `json:"updates"`
+}
+
+// loadUpdatesCache reads the updates cache from dir. A missing or unreadable
+// cache is empty.
+func loadUpdatesCache(dir string) *updatesCache {
+	c := &updatesCache{}
+	if dir != "" {
+		if data, err := os.ReadFile(filepath.Join(dir, updatesCacheFile)); err == nil {
+			_ = json.Unmarshal(data, c)
+		}
+	}
+	if c.Files == nil {
+		c.ETag = ""
+		c.Files = make(map[string]updatesFile)
+	}
+	return c
+}
+
+// save writes the cache to dir. Failures are ignored, as the cache only
+// saves requests.
+func (c *updatesCache) save(dir string) {
+	if dir == "" {
+		return
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	_ = os.MkdirAll(dir, 0755)
+	_ = os.WriteFile(filepath.Join(dir, updatesCacheFile), data, 0644)
+}
+
+// updates returns the moves of every cached file, in the order Portage
+// applies them
+func (c *updatesCache) updates() *ebuild.Updates {
+	names := make([]string, 0, len(c.Files))
+	for name := range c.Files {
+		names = append(names, name)
+	}
+	ebuild.SortUpdatesFiles(names)
+
+	updates := &ebuild.Updates{}
+	for _, name := range names {
+		if f := c.Files[name]; f.Updates != nil {
+			updates.Append(f.Updates)
+		}
+	}
+	return updates
+}
+
+// ReadUpdates reads the package moves of a repository from every
+// profiles/updates file, in the order Portage applies them. A repository
+// without profiles/updates has no moves.
+func ReadUpdates(r FileReader) (*ebuild.Updates, error) {
+	if u, ok := r.(UpdatesReader); ok {
+		return u.ReadUpdates()
+	}
+
+	updates := &ebuild.Updates{}
+
+	names, err := r.ListDir(ebuild.UpdatesDir)
+	if errors.Is(err, ErrNotFound) {
+		return updates, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ebuild.SortUpdatesFiles(names)
+	for _, name := range names {
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+		content, err := r.ReadFile(path.Join(ebuild.UpdatesDir, name))
+		if err != nil {
+			return nil, err
+		}
+		updates.Append(ebuild.ParseUpdates(content))
+	}
+	return updates, nil
+}
+
+// readRepoFile reads a file of a repository checked out at root
+func readRepoFile(root, name string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return content, err
+}
+
+// listRepoDir lists a directory of a repository checked out at root
+func listRepoDir(root, name string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(name)))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name()+"/")
+		} else {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ReadFile returns the content of a file of the repository
+func (p *LocalProvider) ReadFile(name string) ([]byte, error) {
+	return readRepoFile(p.Path, name)
+}
+
+// ListDir returns the sorted names of the entries of a repository directory
+func (p *LocalProvider) ListDir(name string) ([]string, error) {
+	return listRepoDir(p.Path, name)
+}
+
+// ReadFile returns the content of a file of the cloned repository
+func (p *GitCloneProvider) ReadFile(name string) ([]byte, error) {
+	if err := p.ensureRepo(); err != nil {
+		return nil, err
+	}
+	return readRepoFile(p.LocalPath, name)
+}
+
+// ListDir returns the sorted names of the entries of a directory of the
+// cloned repository
+func (p *GitCloneProvider) ListDir(name string) ([]string, error) {
+	if err := p.ensureRepo(); err != nil {
+		return nil, err
+	}
+	return listRepoDir(p.LocalPath, name)
+}
+
+// Ensure every provider can read repository files
+var (
+	_ FileReader    = (*GitHubProvider)(nil)
+	_ FileReader    = (*GitLabProvider)(nil)
+	_ FileReader    = (*GitCloneProvider)(nil)
+	_ FileReader    = (*LocalProvider)(nil)
+	_ UpdatesReader = (*GitHubProvider)(nil)
+)
@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadUpdates(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"profiles/updates/2Q-2024":     "move app-misc/b app-misc/c\n",
+		"profiles/updates/4Q-2023":     "move app-misc/a app-misc/b\nslotmove dev-libs/foo 0 1\n",
+		"profiles/updates/old/1Q-2005": "move app-misc/z app-misc/y\n",
+	})
+
+	updates, err := ReadUpdates(&LocalProvider{Path: root})
+	if err != nil {
+		t.Fatalf("ReadUpdates() error = %v", err)
+	}
+	if len(updates.Moves) != 2 || updates.Moves[0].From != "app-misc/a" || len(updates.SlotMoves) != 1 {
+		t.Errorf("ReadUpdates() = %+v, want the 4Q-2023 entries first", updates)
+	}
+	if name, ok := updates.Resolve("app-misc/a"); !ok || name != "app-misc/c" {
+		t.Errorf("Resolve(app-misc/a) = %s, %v", name, ok)
+	}
+
+	// Subdirectories are listed with a trailing slash and not read
+	names, err := (&LocalProvider{Path: root}).ListDir("profiles/updates")
+	if err != nil || strings.Join(names, " ") != "2Q-2024 4Q-2023 old/" {
+		t.Errorf("ListDir() = %v, %v", names, err)
+	}
+
+	// A repository without profiles/updates has no moves
+	updates, err = ReadUpdates(&LocalProvider{Path: t.TempDir()})
+	if err != nil || len(updates.Moves) != 0 {
+		t.Errorf("ReadUpdates(empty) = %+v, %v", updates, err)
+	}
+}
+
+func TestGitHubProvider_Files(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ref") != "master" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/repos/test/repo/contents/profiles/updates":
+			json.NewEncoder(w).Encode([]map[string]string{{"name": "2Q-2024"}, {"name": "1Q-2024"}, {"name": "old", "type": "dir"}})
+		case "/repos/test/repo/contents/profiles/updates/1Q-2024":
+			if r.Header.Get("Accept") != "application/vnd.github.raw+json" {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+			w.Write([]byte("move app-misc/a app-misc/b\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	prov, err := NewGitHubProvider(&RepositoryInfo{Name: "test", Provider: "github", URL: "test/repo", Branch: "master"})
+	if err != nil {
+		t.Fatalf("NewGitHubProvider failed: %v", err)
+	}
+	prov.BaseURL = server.URL
+	prov.CacheDir = ""
+
+	names, err := prov.ListDir("profiles/updates")
+	if err != nil || strings.Join(names, " ") != "1Q-2024 2Q-2024 old/" {
+		t.Errorf("ListDir() = %v, %v", names, err)
+	}
+	content, err := prov.ReadFile("profiles/updates/1Q-2024")
+	if err != nil || string(content) != "move app-misc/a app-misc/b\n" {
+		t.Errorf("ReadFile() = %q, %v", content, err)
+	}
+	if _, err := prov.ReadFile("profiles/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ReadFile(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGitHubProvider_ReadUpdates(t *testing.T) {
+	files := map[string]string{
+		"s1": "move app-misc/a app-misc/b\n",
+		"s2": "move app-misc/b app-misc/c\n",
+	}
+	listing := []githubContent{{Name: "4Q-2023", Type: "file", SHA: "s1"}}
+	etag := `"v1"`
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		switch {
+		case r.URL.Path == "/repos/test/repo/contents/profiles/updates":
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			json.NewEncoder(w).Encode(listing)
+		case strings.HasPrefix(r.URL.Path, "/repos/test/repo/git/blobs/"):
+			w.Write([]byte(files[strings.TrimPrefix(r.URL.Path, "/repos/test/repo/git/blobs/")]))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	readUpdates := func() string {
+		t.Helper()
+		prov, err := NewGitHubProvider(&RepositoryInfo{Name: "test", Provider: "github", URL: "test/repo"})
+		if err != nil {
+			t.Fatalf("NewGitHubProvider failed: %v", err)
+		}
+		prov.BaseURL = server.URL
+		prov.CacheDir = cacheDir
+
+		updates, err := ReadUpdates(prov)
+		if err != nil {
+			t.Fatalf("ReadUpdates() error = %v", err)
+		}
+		name, _ := updates.Resolve("app-misc/a")
+		return name
+	}
+
+	if name := readUpdates(); name != "app-misc/b" || len(requests) != 2 {
+		t.Errorf("first run: Resolve = %s, requests = %v", name, requests)
+	}
+
+	// An unchanged directory is answered from the cache
+	requests = nil
+	if name := readUpdates(); name != "app-misc/b" || len(requests) != 1 {
+		t.Errorf("unchanged: Resolve = %s, requests = %v", name, requests)
+	}
+
+	// A new quarter only fetches the new file
+	requests = nil
+	etag = `"v2"`
+	listing = append(listing, githubContent{Name: "1Q-2024", Type: "file", SHA: "s2"})
+	if name := readUpdates(); name != "app-misc/c" || len(requests) != 2 {
+		t.Errorf("new quarter: Resolve = %s, requests = %v", name, requests)
+	}
+}
+
+func TestGitLabProvider_Files(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v4/projects/test/repo/repository/tree" && r.URL.Query().Get("path") == "profiles/updates":
+			json.NewEncoder(w).Encode([]GitLabTreeEntry{
+				{Name: "1Q-2024", Type: "blob", Path: "profiles/updates/1Q-2024"},
+				{Name: "old", Type: "tree", Path: "profiles/updates/old"},
+			})
+		case r.URL.EscapedPath() == "/api/v4/projects/test%2Frepo/repository/files/profiles%2Fupdates%2F1Q-2024/raw":
+			w.Write([]byte("move app-misc/a app-misc/b\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	prov, err := NewGitLabProvider(&RepositoryInfo{Name: "test", Provider: "gitlab", URL: "test/repo"})
+	if err != nil {
+		t.Fatalf("NewGitLabProvider failed: %v", err)
+	}
+	prov.BaseURL = server.URL
+	prov.CacheDir = ""
+
+	updates, err := ReadUpdates(prov)
+	if err != nil {
+		t.Fatalf("ReadUpdates() error = %v", err)
+	}
+	if name, ok := updates.Resolve("app-misc/a"); !ok || name != "app-misc/b" {
+		t.Errorf("Resolve(app-misc/a) = %s, %v", name, ok)
+	}
+	if _, err := prov.ListDir("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ListDir(missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -3,10 +3,14 @@ package provider
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/git"
 )
 
 // GitCloneProvider fetches package versions by cloning a git repository
@@ -16,8 +20,16 @@ type GitCloneProvider struct {
 	Branch    string
 	RepoName  string
 
+	// Backend selects the git implementation (see git.NewExecutor)
+	Backend string
+
 	// UpdateInterval is how often to pull updates (default: 24h)
 	UpdateInterval time.Duration
+
+	// Offline uses an existing clone as is, never fetching or cloning
+	Offline bool
+
+	fetched bool // The clone was cloned or updated during the run
 }
 
 // NewGitCloneProvider creates a new git clone provider
@@ -30,13 +42,13 @@ func NewGitCloneProvider(repoInfo *RepositoryInfo) (*GitCloneProvider, error) {
 	}
 
 	// Setup cache directory
-	home, err := os.UserHomeDir()
+	root, err := config.CacheDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return nil, fmt.Errorf("failed to get cache directory: %w", err)
 	}
 
 	safeName := strings.ReplaceAll(repoInfo.Name, "/", "_")
-	localPath := filepath.Join(home, ".cache", "bentoo", "repos", safeName)
+	localPath := filepath.Join(root, reposCacheDir, safeName)
 
 	branch := repoInfo.Branch
 	if branch == "" {
@@ -79,8 +91,41 @@ func (p *GitCloneProvider) GetPackageVersions(category, pkg string) ([]string, e
 	return p.scanLocalPackage(pkgPath, pkg)
 }
 
-// ensureRepo ensures the repository is cloned and up-to-date
+// GetEbuildMetadata returns the slot and keywords of a package version
+func (p *GitCloneProvider) GetEbuildMetadata(category, pkg, version string) (*ebuild.Metadata, error) {
+	if err := p.ensureRepo(); err != nil {
+		return nil, err
+	}
+	return readEbuildMetadata(p.LocalPath, category, pkg, version)
+}
+
+// Freshness reports a clone updated during the run as live. A clone within
+// UpdateInterval is cached, and one used offline is stale.
+func (p *GitCloneProvider) Freshness() (cache.Freshness, time.Time) {
+	if p.fetched {
+		return cache.Live, time.Time{}
+	}
+
+	var fetchedAt time.Time
+	if info, err := os.Stat(filepath.Join(p.LocalPath, ".git", "FETCH_HEAD")); err == nil {
+		fetchedAt = info.ModTime()
+	}
+	if p.Offline {
+		return cache.Stale, fetchedAt
+	}
+	return cache.Cached, fetchedAt
+}
+
+// ensureRepo ensures the repository is cloned and up-to-date. Offline, an
+// existing clone is used as is.
 func (p *GitCloneProvider) ensureRepo() error {
+	if p.Offline {
+		if !p.repoExists() {
+			return fmt.Errorf("%w: no clone of %s in %s", ErrOffline, p.RepoName, p.LocalPath)
+		}
+		return nil
+	}
+
 	if p.repoExists() {
 		// Check if we need to update
 		if p.needsUpdate() {
@@ -113,91 +158,75 @@ func (p *GitCloneProvider) needsUpdate() bool {
 	return time.Since(info.ModTime()) > p.UpdateInterval
 }
 
+// executor returns the GitExecutor for the local clone
+func (p *GitCloneProvider) executor() (git.GitExecutor, error) {
+	return git.NewExecutor(p.Backend, p.LocalPath)
+}
+
 // cloneRepo clones the repository
 func (p *GitCloneProvider) cloneRepo() error {
-	// Ensure parent directory exists
-	parentDir := filepath.Dir(p.LocalPath)
-	if err := os.MkdirAll(parentDir, 0755); err != nil {
-		return fmt.Errorf("failed to create cache directory: %w", err)
+	executor, err := p.executor()
+	if err != nil {
+		return err
 	}
 
 	// Clone with depth 1 for faster clone (we only need latest files)
-	cmd := exec.Command("git", "clone",
-		"--depth", "1",
-		"--single-branch",
-		"--branch", p.Branch,
-		p.RepoURL,
-		p.LocalPath,
-	)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("%w: %s: %s", ErrCloneFailed, err.Error(), string(output))
+	if err := executor.Clone(p.RepoURL, p.Branch, 1); err != nil {
+		return fmt.Errorf("%w: %v", ErrCloneFailed, err)
 	}
 
-	return nil
+	return p.markFetched()
 }
 
-// updateRepo updates the repository
+// updateRepo updates the repository. The clone is a read-only cache, so it
+// is reset to the fetched branch rather than merged.
 func (p *GitCloneProvider) updateRepo() error {
-	cmd := exec.Command("git", "-C", p.LocalPath, "pull", "--ff-only")
-	output, err := cmd.CombinedOutput()
+	executor, err := p.executor()
 	if err != nil {
-		// If pull fails, try a fetch + reset
-		fetchCmd := exec.Command("git", "-C", p.LocalPath, "fetch", "origin", p.Branch)
-		if fetchErr := fetchCmd.Run(); fetchErr != nil {
-			return fmt.Errorf("failed to update repository: %s: %s", err.Error(), string(output))
-		}
+		return err
+	}
 
-		resetCmd := exec.Command("git", "-C", p.LocalPath, "reset", "--hard", "origin/"+p.Branch)
-		if resetErr := resetCmd.Run(); resetErr != nil {
-			return fmt.Errorf("failed to reset repository: %v", resetErr)
-		}
+	if err := executor.Fetch("origin"); err != nil {
+		return fmt.Errorf("failed to update repository: %w", err)
+	}
+	if err := executor.ResetHard("origin/" + p.Branch); err != nil {
+		return fmt.Errorf("failed to reset repository: %w", err)
 	}
 
-	return nil
+	return p.markFetched()
+}
+
+// markFetched records the time of the last update in .git/FETCH_HEAD.
+// Not every backend writes FETCH_HEAD, so needsUpdate would otherwise
+// refresh the clone on every run.
+func (p *GitCloneProvider) markFetched() error {
+	p.fetched = true
+	fetchHead := filepath.Join(p.LocalPath, ".git", "FETCH_HEAD")
+	now := time.Now()
+	if err := os.Chtimes(fetchHead, now, now); err == nil {
+		return nil
+	}
+	return os.WriteFile(fetchHead, nil, 0644)
 }
 
 // scanLocalPackage scans a local package directory for ebuild versions
 func (p *GitCloneProvider) scanLocalPackage(pkgPath, pkgName string) ([]string, error) {
-	entries, err := os.ReadDir(pkgPath)
+	category := filepath.Base(filepath.Dir(pkgPath))
+	versions, err := ebuild.ScanPackageDir(pkgPath, category, pkgName)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, ErrNotFound
 		}
 		return nil, err
 	}
-
-	var versions []string
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		filename := entry.Name()
-		if !strings.HasSuffix(filename, ".ebuild") {
-			continue
-		}
-
-		matches := ebuildVersionRegex.FindStringSubmatch(filename)
-		if matches == nil {
-			continue
-		}
-
-		name := matches[1]
-		version := matches[2]
-
-		if name == pkgName {
-			versions = append(versions, version)
-		}
-	}
-
 	return versions, nil
 }
 
 // ForceUpdate forces an update of the repository regardless of age
 func (p *GitCloneProvider) ForceUpdate() error {
+	if p.Offline {
+		return fmt.Errorf("%w: cannot update %s", ErrOffline, p.RepoName)
+	}
 	if !p.repoExists() {
 		return p.cloneRepo()
 	}
@@ -209,5 +238,8 @@ func (p *GitCloneProvider) RemoveCache() error {
 	return os.RemoveAll(p.LocalPath)
 }
 
-// Ensure GitCloneProvider implements Provider interface
-var _ Provider = (*GitCloneProvider)(nil)
+// Ensure GitCloneProvider implements Provider and FreshnessReporter interfaces
+var (
+	_ Provider          = (*GitCloneProvider)(nil)
+	_ FreshnessReporter = (*GitCloneProvider)(nil)
+)
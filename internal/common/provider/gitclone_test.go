@@ -1,9 +1,16 @@
 package provider
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/obentoo/bentoolkit/internal/common/cache"
+	"github.com/obentoo/bentoolkit/internal/common/git"
 )
 
 func TestGitCloneProvider_ScanLocalPackage(t *testing.T) {
@@ -65,6 +72,47 @@ func TestGitCloneProvider_NotFound(t *testing.T) {
 	}
 }
 
+func TestGitCloneProvider_Offline(t *testing.T) {
+	tmpDir := t.TempDir()
+	prov := &GitCloneProvider{
+		RepoURL:   "https://example.invalid/repo.git",
+		LocalPath: filepath.Join(tmpDir, "repos", "test"),
+		Branch:    "master",
+		RepoName:  "test",
+		Offline:   true,
+	}
+
+	if _, err := prov.GetPackageVersions("app-misc", "hello"); !errors.Is(err, ErrOffline) {
+		t.Errorf("without a clone: error = %v, want ErrOffline", err)
+	}
+
+	// An existing clone is used however old it is
+	pkgDir := filepath.Join(prov.LocalPath, "app-misc", "hello")
+	os.MkdirAll(pkgDir, 0755)
+	os.MkdirAll(filepath.Join(prov.LocalPath, ".git"), 0755)
+	os.WriteFile(filepath.Join(pkgDir, "hello-1.0.ebuild"), []byte("# mock ebuild"), 0644)
+	fetchHead := filepath.Join(prov.LocalPath, ".git", "FETCH_HEAD")
+	os.WriteFile(fetchHead, nil, 0644)
+	fetched := time.Now().Add(-30 * 24 * time.Hour)
+	os.Chtimes(fetchHead, fetched, fetched)
+
+	versions, err := prov.GetPackageVersions("app-misc", "hello")
+	if err != nil || len(versions) != 1 {
+		t.Errorf("GetPackageVersions() = %v, %v", versions, err)
+	}
+	if f, at := prov.Freshness(); f != cache.Stale || !at.Equal(fetched) {
+		t.Errorf("Freshness() = %s, %v; want stale as of %v", f, at, fetched)
+	}
+	if err := prov.ForceUpdate(); !errors.Is(err, ErrOffline) {
+		t.Errorf("ForceUpdate() error = %v, want ErrOffline", err)
+	}
+
+	prov.Offline = false
+	if f, _ := prov.Freshness(); f != cache.Cached {
+		t.Errorf("online Freshness() before an update = %s, want cached", f)
+	}
+}
+
 func TestGitCloneProvider_GetName(t *testing.T) {
 	repoInfo := &RepositoryInfo{
 		Name: "gentoo",
@@ -131,3 +179,65 @@ func TestGitCloneProvider_URLFormats(t *testing.T) {
 		})
 	}
 }
+
+// commitEbuild writes an ebuild into the origin repository and commits it
+func commitEbuild(t *testing.T, origin git.GitExecutor, name string) {
+	t.Helper()
+	path := filepath.Join(origin.WorkDir(), "app-misc", "hello", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("EAPI=8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := origin.Add(); err != nil {
+		t.Fatal(err)
+	}
+	if err := origin.Commit("add "+name, "Dev", "dev@example.com"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitCloneProvider_CloneAndUpdate(t *testing.T) {
+	for _, backend := range []string{git.BackendCLI, git.BackendGoGit} {
+		t.Run(backend, func(t *testing.T) {
+			originDir := t.TempDir()
+			if _, err := gogit.PlainInitWithOptions(originDir, &gogit.PlainInitOptions{
+				InitOptions: gogit.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			origin := git.NewGoGitRunner(originDir)
+			commitEbuild(t, origin, "hello-1.0.ebuild")
+
+			prov := &GitCloneProvider{
+				RepoURL:        originDir,
+				LocalPath:      filepath.Join(t.TempDir(), "repos", "origin"),
+				Branch:         "main",
+				RepoName:       "origin",
+				Backend:        backend,
+				UpdateInterval: time.Hour,
+			}
+
+			versions, err := prov.GetPackageVersions("app-misc", "hello")
+			if err != nil || len(versions) != 1 || versions[0] != "1.0" {
+				t.Fatalf("GetPackageVersions() after clone = %v, %v; want [1.0]", versions, err)
+			}
+			if prov.needsUpdate() {
+				t.Error("needsUpdate() = true right after cloning")
+			}
+			if f, _ := prov.Freshness(); f != cache.Live {
+				t.Errorf("Freshness() = %s right after cloning, want live", f)
+			}
+
+			commitEbuild(t, origin, "hello-1.1.ebuild")
+			if err := prov.updateRepo(); err != nil {
+				t.Fatalf("updateRepo() error = %v", err)
+			}
+			versions, err = prov.GetPackageVersions("app-misc", "hello")
+			if err != nil || len(versions) != 2 {
+				t.Errorf("GetPackageVersions() after update = %v, %v; want both versions", versions, err)
+			}
+		})
+	}
+}
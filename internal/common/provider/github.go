@@ -2,39 +2,40 @@ package provider
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
 )
 
 // GitHubProvider fetches package versions from GitHub API
 type GitHubProvider struct {
 	BaseURL    string
 	Repository string // e.g., "gentoo/gentoo"
+	Branch     string // Branch to list (default: the repository's default branch)
 	UserAgent  string
 	Token      string
 	HTTPClient *http.Client
 	CacheDir   string
-	CacheTTL   time.Duration
-}
 
-// ContentEntry represents a file/directory entry from GitHub Contents API
-type ContentEntry struct {
-	Name        string `json:"name"`
-	Path        string `json:"path"`
-	Type        string `json:"type"` // "file" or "dir"
-	DownloadURL string `json:"download_url,omitempty"`
-}
+	// Offline answers from CacheDir only: no request reaches the API, and
+	// the cached tree index is used without revalidation
+	Offline bool
 
-// CacheEntry represents a cached API response
-type CacheEntry struct {
-	Versions  []string  `json:"versions"`
-	Timestamp time.Time `json:"timestamp"`
+	index    *treeIndex
+	indexErr error
+	blobs    *blobCache
+	updates  *ebuild.Updates
 }
 
 // NewGitHubProvider creates a new GitHub API provider
@@ -42,18 +43,18 @@ func NewGitHubProvider(repoInfo *RepositoryInfo) (*GitHubProvider, error) {
 	p := &GitHubProvider{
 		BaseURL:    "https://api.github.com",
 		Repository: repoInfo.URL,
+		Branch:     repoInfo.Branch,
 		UserAgent:  "bentoolkit/1.0",
 		Token:      repoInfo.Token,
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		CacheTTL: 24 * time.Hour,
 	}
 
 	// Setup default cache directory
-	home, err := os.UserHomeDir()
+	root, err := config.CacheDir()
 	if err == nil {
-		p.CacheDir = filepath.Join(home, ".cache", "bentoo", "compare", "github", strings.ReplaceAll(repoInfo.URL, "/", "_"))
+		p.CacheDir = filepath.Join(root, compareCacheDir, "github", strings.ReplaceAll(repoInfo.URL, "/", "_"))
 		os.MkdirAll(p.CacheDir, 0755)
 	}
 
@@ -70,11 +71,29 @@ func (p *GitHubProvider) SupportsAPI() bool {
 	return true
 }
 
-// Close cleans up resources
+// Close saves the ebuild metadata read during the run to the cache
 func (p *GitHubProvider) Close() error {
+	p.blobs.save()
 	return nil
 }
 
+// client returns the HTTP client, which refuses every request offline
+func (p *GitHubProvider) client() *http.Client {
+	if p.Offline {
+		return offlineClient
+	}
+	return p.HTTPClient
+}
+
+// Freshness reports answers from the cached tree index as stale offline,
+// as it could not be revalidated
+func (p *GitHubProvider) Freshness() (cache.Freshness, time.Time) {
+	if !p.Offline || p.index == nil {
+		return cache.Live, time.Time{}
+	}
+	return cache.Stale, p.index.Timestamp
+}
+
 // SetCacheDir sets the cache directory for API responses
 func (p *GitHubProvider) SetCacheDir(dir string) error {
 	if dir == "" {
@@ -91,36 +110,159 @@ func (p *GitHubProvider) SetCacheDir(dir string) error {
 	return os.MkdirAll(dir, 0755)
 }
 
-// GetPackageVersions fetches all ebuild versions for a package from GitHub
+// GetPackageVersions returns all ebuild versions for a package. The whole
+// repository tree is listed on first use; later calls are answered from it.
 func (p *GitHubProvider) GetPackageVersions(category, pkg string) ([]string, error) {
-	// Check cache first
-	if p.CacheDir != "" {
-		if versions, ok := p.loadFromCache(category, pkg); ok {
-			return versions, nil
+	if err := p.ensureIndex(); err != nil {
+		return nil, err
+	}
+	return p.index.versions(category, pkg)
+}
+
+// GetEbuildMetadata returns the slot and keywords of a package version. The
+// ebuild is fetched by its blob from the tree listing; blobs are cached.
+func (p *GitHubProvider) GetEbuildMetadata(category, pkg, version string) (*ebuild.Metadata, error) {
+	if err := p.ensureIndex(); err != nil {
+		return nil, err
+	}
+	sha, err := p.index.blob(category, pkg, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.blobs == nil {
+		p.blobs = loadBlobCache(p.CacheDir)
+	}
+	return p.blobs.metadata(sha, version, p.getBlob)
+}
+
+// ensureIndex lists the repository tree on first use. A failed listing is
+// not retried.
+func (p *GitHubProvider) ensureIndex() error {
+	if p.index == nil && p.indexErr == nil {
+		p.index, p.indexErr = p.loadIndex()
+	}
+	return p.indexErr
+}
+
+// githubTree is a response of the GitHub Git Trees API
+type githubTree struct {
+	SHA  string `json:"sha"`
+	Tree []struct {
+		Path string `json:"path"`
+		Type string `json:"type"` // "blob", "tree" or "commit"
+		SHA  string `json:"sha"`
+	} `json:"tree"`
+	Truncated bool `json:"truncated"`
+}
+
+// ref returns the branch to list, or HEAD for the default branch
+func (p *GitHubProvider) ref() string {
+	if p.Branch == "" {
+		return "HEAD"
+	}
+	return p.Branch
+}
+
+// loadIndex lists the top level of the repository, conditional on the cached
+// ETag, so an unchanged repository is answered from the cache after a single
+// 304 response. Otherwise the categories that changed since the cached index
+// are listed one at a time. Without any cache the whole tree is first tried
+// in one recursive request, which suffices for all but large repositories
+// such as gentoo. Offline, the cached index is used as is.
+func (p *GitHubProvider) loadIndex() (*treeIndex, error) {
+	cached := loadTreeIndex(p.CacheDir)
+	if p.Offline {
+		if cached == nil {
+			return nil, fmt.Errorf("%w: no cached tree of %s", ErrOffline, p.Repository)
+		}
+		if cached.Partial {
+			return nil, fmt.Errorf("%w: cached tree of %s is incomplete", ErrOffline, p.Repository)
 		}
+		return cached, nil
+	}
+	etag := ""
+	if cached != nil && !cached.Partial {
+		etag = cached.ETag
 	}
 
-	// Fetch from API
-	versions, err := p.fetchPackageVersions(category, pkg)
+	var root githubTree
+	newETag, err := p.getTree(p.ref(), etag, &root)
+	if errors.Is(err, errNotModified) {
+		return cached, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Save to cache
-	if p.CacheDir != "" {
-		p.saveToCache(category, pkg, versions)
+	index := newTreeIndex(root.SHA)
+	if cached == nil {
+		var tree githubTree
+		if _, err := p.getTree(root.SHA+"?recursive=1", "", &tree); err != nil {
+			return nil, err
+		}
+		if !tree.Truncated {
+			for _, e := range tree.Tree {
+				index.add(e.Path, e.Type == "tree", e.SHA)
+			}
+			index.ETag = newETag
+			index.save(p.CacheDir)
+			return index, nil
+		}
+	}
+
+	if err := p.indexCategories(index, cached, root); err != nil {
+		return nil, err
 	}
+	index.ETag = newETag
+	index.Partial = false
+	index.save(p.CacheDir)
+	return index, nil
+}
+
+// indexCategories fills index one category of the root listing at a time.
+// Categories whose tree is unchanged since the cached index are reused without
+// a request. The index is saved as partial after each listed category, so a
+// run stopped by the rate limit resumes where it failed.
+func (p *GitHubProvider) indexCategories(index, cached *treeIndex, root githubTree) error {
+	index.Partial = true
+	for _, e := range root.Tree {
+		if e.Type != "tree" || !isCategoryDir(e.Path) {
+			continue
+		}
+		if cached != nil {
+			if c, ok := cached.Categories[e.Path]; ok && c.SHA == e.SHA {
+				index.Categories[e.Path] = c
+				continue
+			}
+		}
 
-	return versions, nil
+		var sub githubTree
+		if _, err := p.getTree(e.SHA+"?recursive=1", "", &sub); err != nil {
+			return err
+		}
+		if sub.Truncated {
+			return fmt.Errorf("%w: listing of %s is truncated", ErrAPIError, e.Path)
+		}
+
+		index.add(e.Path, true, e.SHA)
+		for _, entry := range sub.Tree {
+			index.add(e.Path+"/"+entry.Path, entry.Type == "tree", entry.SHA)
+		}
+		index.save(p.CacheDir)
+	}
+	return nil
 }
 
-// fetchPackageVersions fetches versions from GitHub API
-func (p *GitHubProvider) fetchPackageVersions(category, pkg string) ([]string, error) {
-	url := fmt.Sprintf("%s/repos/%s/contents/%s/%s", p.BaseURL, p.Repository, category, pkg)
+// getTree fetches a tree from the Git Trees API into v and returns the
+// response ETag. With a non-empty etag the request is conditional and
+// errNotModified is returned when the tree has not changed.
+func (p *GitHubProvider) getTree(path, etag string, v *githubTree) (string, error) {
+	url := fmt.Sprintf("%s/repos/%s/git/trees/%s", p.BaseURL, p.Repository, path)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	req.Header.Set("User-Agent", p.UserAgent)
@@ -129,125 +271,214 @@ func (p *GitHubProvider) fetchPackageVersions(category, pkg string) ([]string, e
 	if p.Token != "" {
 		req.Header.Set("Authorization", "Bearer "+p.Token)
 	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
 
-	resp, err := p.HTTPClient.Do(req)
+	resp, err := p.client().Do(req)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	defer resp.Body.Close()
 
-	// Handle rate limiting
-	if resp.StatusCode == 403 {
-		resetHeader := resp.Header.Get("X-RateLimit-Reset")
-		return nil, fmt.Errorf("%w: rate limit resets at %s", ErrRateLimit, resetHeader)
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return etag, errNotModified
+	default:
+		return "", p.statusError(resp)
 	}
 
-	// Handle not found
-	if resp.StatusCode == 404 {
-		return nil, ErrNotFound
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", fmt.Errorf("failed to parse GitHub response: %w", err)
 	}
+	return resp.Header.Get("ETag"), nil
+}
 
-	// Handle other errors
-	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("%w: status %d: %s", ErrAPIError, resp.StatusCode, string(body))
-	}
+// getBlob fetches the raw content of a blob with the Git Blobs API
+func (p *GitHubProvider) getBlob(sha string) ([]byte, error) {
+	url := fmt.Sprintf("%s/repos/%s/git/blobs/%s", p.BaseURL, p.Repository, sha)
 
-	// Parse response
-	body, err := io.ReadAll(resp.Body)
+	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var entries []ContentEntry
-	if err := json.Unmarshal(body, &entries); err != nil {
-		return nil, fmt.Errorf("failed to parse GitHub response: %w", err)
+	req.Header.Set("User-Agent", p.UserAgent)
+	req.Header.Set("Accept", "application/vnd.github.raw+json")
+
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
 	}
 
-	// Extract versions from ebuild filenames
-	versions := extractVersionsFromEntries(entries, pkg)
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-	return versions, nil
+	if resp.StatusCode != http.StatusOK {
+		return nil, p.statusError(resp)
+	}
+	return io.ReadAll(resp.Body)
 }
 
-// ebuildVersionRegex matches package-version.ebuild format
-var ebuildVersionRegex = regexp.MustCompile(`^(.+)-(\d+[\d.]*[\w._-]*)\.ebuild$`)
-
-// extractVersionsFromEntries extracts version strings from ContentEntry list
-func extractVersionsFromEntries(entries []ContentEntry, pkg string) []string {
-	var versions []string
-
-	for _, entry := range entries {
-		if entry.Type != "file" {
-			continue
-		}
-		if !strings.HasSuffix(entry.Name, ".ebuild") {
-			continue
-		}
+// ReadFile returns the content of a file of the repository with the
+// Repository Contents API
+func (p *GitHubProvider) ReadFile(name string) ([]byte, error) {
+	content, _, err := p.getContents(name, "application/vnd.github.raw+json", "")
+	return content, err
+}
 
-		matches := ebuildVersionRegex.FindStringSubmatch(entry.Name)
-		if matches == nil {
-			continue
-		}
+// githubContent is an entry of a directory listing of the Contents API
+type githubContent struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // "file", "dir", "symlink" or "submodule"
+	SHA  string `json:"sha"`
+}
 
-		name := matches[1]
-		version := matches[2]
+// ListDir returns the sorted names of the entries of a repository directory
+// with the Repository Contents API
+func (p *GitHubProvider) ListDir(name string) ([]string, error) {
+	entries, _, err := p.listContents(name, "")
+	if err != nil {
+		return nil, err
+	}
 
-		if name == pkg {
-			versions = append(versions, version)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.Type == "dir" {
+			names = append(names, e.Name+"/")
+		} else {
+			names = append(names, e.Name)
 		}
 	}
-
-	return versions
+	sort.Strings(names)
+	return names, nil
 }
 
-// cacheFilePath returns the cache file path for a package
-func (p *GitHubProvider) cacheFilePath(category, pkg string) string {
-	return filepath.Join(p.CacheDir, fmt.Sprintf("%s_%s.json", category, pkg))
+// listContents lists a directory with the Contents API. With a non-empty
+// etag the request is conditional and errNotModified is returned when the
+// directory has not changed.
+func (p *GitHubProvider) listContents(name, etag string) ([]githubContent, string, error) {
+	body, newETag, err := p.getContents(name, "application/vnd.github.v3+json", etag)
+	if err != nil {
+		return nil, newETag, err
+	}
+
+	var entries []githubContent
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, "", fmt.Errorf("%w: %s is not a directory: %v", ErrAPIError, name, err)
+	}
+	return entries, newETag, nil
 }
 
-// loadFromCache attempts to load versions from cache
-func (p *GitHubProvider) loadFromCache(category, pkg string) ([]string, bool) {
-	if p.CacheDir == "" {
-		return nil, false
+// getContents fetches a path from the Repository Contents API on the listed
+// branch and returns the response ETag. A missing path is ErrNotFound; with
+// a non-empty etag, an unchanged path is errNotModified.
+func (p *GitHubProvider) getContents(name, accept, etag string) ([]byte, string, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/contents/%s", p.BaseURL, p.Repository, name)
+	if p.Branch != "" {
+		apiURL += "?ref=" + url.QueryEscape(p.Branch)
 	}
 
-	cacheFile := p.cacheFilePath(category, pkg)
-	data, err := os.ReadFile(cacheFile)
+	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
-		return nil, false
+		return nil, "", err
 	}
 
-	var entry CacheEntry
-	if err := json.Unmarshal(data, &entry); err != nil {
-		return nil, false
+	req.Header.Set("User-Agent", p.UserAgent)
+	req.Header.Set("Accept", accept)
+
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
 	}
 
-	if time.Since(entry.Timestamp) > p.CacheTTL {
-		return nil, false
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return nil, "", err
 	}
+	defer resp.Body.Close()
 
-	return entry.Versions, true
+	switch resp.StatusCode {
+	case http.StatusOK:
+		body, err := io.ReadAll(resp.Body)
+		return body, resp.Header.Get("ETag"), err
+	case http.StatusNotModified:
+		return nil, etag, errNotModified
+	case http.StatusNotFound:
+		return nil, "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	default:
+		return nil, "", p.statusError(resp)
+	}
 }
 
-// saveToCache saves versions to cache
-func (p *GitHubProvider) saveToCache(category, pkg string, versions []string) {
-	if p.CacheDir == "" {
-		return
+// ReadUpdates reads the package moves of profiles/updates. The directory
+// listing is conditional on the cached ETag and files are cached by blob, so
+// an unchanged directory costs one request and a new quarter one more.
+// Offline, the cached moves are used as is.
+func (p *GitHubProvider) ReadUpdates() (*ebuild.Updates, error) {
+	if p.updates != nil {
+		return p.updates, nil
 	}
 
-	entry := CacheEntry{
-		Versions:  versions,
-		Timestamp: time.Now(),
+	cached := loadUpdatesCache(p.CacheDir)
+	if p.Offline {
+		if cached.ETag == "" && len(cached.Files) == 0 {
+			return nil, fmt.Errorf("%w: no cached %s of %s", ErrOffline, ebuild.UpdatesDir, p.Repository)
+		}
+		p.updates = cached.updates()
+		return p.updates, nil
+	}
+	entries, etag, err := p.listContents(ebuild.UpdatesDir, cached.ETag)
+	switch {
+	case errors.Is(err, errNotModified):
+		p.updates = cached.updates()
+		return p.updates, nil
+	case errors.Is(err, ErrNotFound):
+		p.updates = &ebuild.Updates{}
+		return p.updates, nil
+	case err != nil:
+		return nil, err
 	}
 
-	data, err := json.Marshal(entry)
-	if err != nil {
-		return
+	fresh := &updatesCache{ETag: etag, Files: make(map[string]updatesFile)}
+	for _, e := range entries {
+		if e.Type != "file" {
+			continue
+		}
+		if f, ok := cached.Files[e.Name]; ok && f.SHA == e.SHA {
+			fresh.Files[e.Name] = f
+			continue
+		}
+
+		content, err := p.getBlob(e.SHA)
+		if err != nil {
+			return nil, err
+		}
+		fresh.Files[e.Name] = updatesFile{SHA: e.SHA, Updates: ebuild.ParseUpdates(content)}
 	}
 
-	cacheFile := p.cacheFilePath(category, pkg)
-	_ = os.WriteFile(cacheFile, data, 0644)
+	fresh.save(p.CacheDir)
+	p.updates = fresh.updates()
+	return p.updates, nil
+}
+
+// statusError maps an unexpected API response status to an error
+func (p *GitHubProvider) statusError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		resetHeader := resp.Header.Get("X-RateLimit-Reset")
+		return fmt.Errorf("%w: rate limit resets at %s", ErrRateLimit, resetHeader)
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s@%s", ErrRepositoryNotFound, p.Repository, p.ref())
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%w: status %d: %s", ErrAPIError, resp.StatusCode, string(body))
+	}
 }
 
 // GetRateLimitInfo returns current rate limit status
@@ -265,7 +496,7 @@ func (p *GitHubProvider) GetRateLimitInfo() (remaining int, resetTime time.Time,
 		req.Header.Set("Authorization", "Bearer "+p.Token)
 	}
 
-	resp, err := p.HTTPClient.Do(req)
+	resp, err := p.client().Do(req)
 	if err != nil {
 		return 0, time.Time{}, err
 	}
@@ -293,5 +524,8 @@ func (p *GitHubProvider) GetRateLimitInfo() (remaining int, resetTime time.Time,
 	return result.Resources.Core.Remaining, resetTime, nil
 }
 
-// Ensure GitHubProvider implements Provider interface
-var _ Provider = (*GitHubProvider)(nil)
+// Ensure GitHubProvider implements Provider and FreshnessReporter interfaces
+var (
+	_ Provider          = (*GitHubProvider)(nil)
+	_ FreshnessReporter = (*GitHubProvider)(nil)
+)
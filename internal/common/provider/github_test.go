@@ -2,37 +2,58 @@ package provider
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
-	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
 )
 
+// githubTreeJSON encodes a Git Trees API response listing the given paths.
+// Paths ending in "/" are trees.
+func githubTreeJSON(sha string, truncated bool, paths ...string) map[string]any {
+	var tree []map[string]string
+	for _, path := range paths {
+		entry := map[string]string{"path": path, "type": "blob", "sha": "b-" + path}
+		if strings.HasSuffix(path, "/") {
+			entry["path"] = strings.TrimSuffix(path, "/")
+			entry["type"] = "tree"
+			entry["sha"] = "t-" + sha + "-" + entry["path"]
+		}
+		tree = append(tree, entry)
+	}
+	return map[string]any{"sha": sha, "tree": tree, "truncated": truncated}
+}
+
 func TestGitHubProvider_GetPackageVersions(t *testing.T) {
-	// Create mock server
+	requests := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Check path
-		if r.URL.Path == "/repos/test/repo/contents/app-misc/hello" {
-			entries := []ContentEntry{
-				{Name: "hello-1.0.ebuild", Type: "file"},
-				{Name: "hello-1.1.ebuild", Type: "file"},
-				{Name: "hello-2.0.ebuild", Type: "file"},
-				{Name: "metadata.xml", Type: "file"},
-				{Name: "files", Type: "dir"},
-			}
-			json.NewEncoder(w).Encode(entries)
+		requests++
+		switch {
+		case r.URL.Path == "/repos/test/repo/git/trees/master" && r.URL.Query().Get("recursive") == "":
+			json.NewEncoder(w).Encode(githubTreeJSON("root", false, "app-misc/", "profiles/"))
 			return
-		}
-
-		// Package not found
-		if r.URL.Path == "/repos/test/repo/contents/app-misc/notfound" {
+		case r.URL.Path != "/repos/test/repo/git/trees/root" || r.URL.Query().Get("recursive") != "1":
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
-
-		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(githubTreeJSON("root", false,
+			"app-misc/",
+			"app-misc/hello/",
+			"app-misc/hello/hello-1.0.ebuild",
+			"app-misc/hello/hello-1.1.ebuild",
+			"app-misc/hello/hello-2.0.ebuild",
+			"app-misc/hello/metadata.xml",
+			"app-misc/hello/files/",
+			"app-misc/hello/files/hello-1.0.patch",
+			"app-misc/nothing/metadata.xml",
+			"profiles/",
+			"profiles/repo_name",
+		))
 	}))
 	defer server.Close()
 
@@ -41,6 +62,7 @@ func TestGitHubProvider_GetPackageVersions(t *testing.T) {
 		Name:     "test",
 		Provider: "github",
 		URL:      "test/repo",
+		Branch:   "master",
 	}
 
 	prov, err := NewGitHubProvider(repoInfo)
@@ -71,154 +93,297 @@ func TestGitHubProvider_GetPackageVersions(t *testing.T) {
 	})
 
 	t.Run("package not found", func(t *testing.T) {
-		_, err := prov.GetPackageVersions("app-misc", "notfound")
-		if err != ErrNotFound {
-			t.Errorf("Expected ErrNotFound, got: %v", err)
+		for _, pkg := range []string{"notfound", "nothing"} {
+			if _, err := prov.GetPackageVersions("app-misc", pkg); err != ErrNotFound {
+				t.Errorf("%s: expected ErrNotFound, got: %v", pkg, err)
+			}
+		}
+		if _, err := prov.GetPackageVersions("dev-lang", "go"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound for an unknown category, got: %v", err)
 		}
 	})
+
+	if requests != 2 {
+		t.Errorf("Expected a root and a recursive tree request, got %d", requests)
+	}
 }
 
 func TestGitHubProvider_Cache(t *testing.T) {
-	callCount := 0
+	var requests []string
 
-	// Create mock server that counts calls
+	// Serve the tree with an ETag and honour conditional requests
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		callCount++
-		entries := []ContentEntry{
-			{Name: "hello-1.0.ebuild", Type: "file"},
+		requests = append(requests, r.URL.Path+" "+r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
 		}
-		json.NewEncoder(w).Encode(entries)
+		w.Header().Set("ETag", `"v1"`)
+		json.NewEncoder(w).Encode(githubTreeJSON("root", false, "app-misc/", "app-misc/hello/hello-1.0.ebuild"))
 	}))
 	defer server.Close()
 
 	// Create temp cache directory
 	cacheDir := t.TempDir()
 
-	// Create provider with cache
-	repoInfo := &RepositoryInfo{
-		Name: "test",
-		URL:  "test/repo",
+	newProvider := func() *GitHubProvider {
+		prov, _ := NewGitHubProvider(&RepositoryInfo{Name: "test", URL: "test/repo"})
+		prov.BaseURL = server.URL
+		prov.CacheDir = cacheDir
+		return prov
 	}
 
-	prov, _ := NewGitHubProvider(repoInfo)
-	prov.BaseURL = server.URL
-	prov.CacheDir = cacheDir
-	prov.CacheTTL = 1 * time.Hour
+	// First run lists the tree and saves it
+	if versions, err := newProvider().GetPackageVersions("app-misc", "hello"); err != nil || len(versions) != 1 {
+		t.Fatalf("First run = %v, %v", versions, err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "tree.json")); os.IsNotExist(err) {
+		t.Error("Cache file was not created")
+	}
 
-	// First call should hit server
-	_, err := prov.GetPackageVersions("app-misc", "hello")
-	if err != nil {
-		t.Fatalf("First call failed: %v", err)
+	// Second run revalidates with one conditional request
+	prov := newProvider()
+	for i := 0; i < 2; i++ {
+		versions, err := prov.GetPackageVersions("app-misc", "hello")
+		if err != nil || len(versions) != 1 || versions[0] != "1.0" {
+			t.Fatalf("Second run = %v, %v; want the cached versions", versions, err)
+		}
 	}
-	if callCount != 1 {
-		t.Errorf("Expected 1 API call, got %d", callCount)
+
+	want := []string{"/repos/test/repo/git/trees/HEAD ", "/repos/test/repo/git/trees/root ", `/repos/test/repo/git/trees/HEAD "v1"`}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests = %q, want %q", requests, want)
 	}
+}
 
-	// Second call should use cache
-	_, err = prov.GetPackageVersions("app-misc", "hello")
-	if err != nil {
-		t.Fatalf("Second call failed: %v", err)
+func TestGitHubProvider_Offline(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(githubTreeJSON("root", false, "app-misc/", "app-misc/hello/hello-1.0.ebuild"))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	newProvider := func(offline bool) *GitHubProvider {
+		prov, _ := NewGitHubProvider(&RepositoryInfo{Name: "test", URL: "test/repo"})
+		prov.BaseURL = server.URL
+		prov.CacheDir = cacheDir
+		prov.Offline = offline
+		return prov
 	}
-	if callCount != 1 {
-		t.Errorf("Expected 1 API call (cached), got %d", callCount)
+
+	// Nothing is cached yet
+	if _, err := newProvider(true).GetPackageVersions("app-misc", "hello"); !errors.Is(err, ErrOffline) {
+		t.Errorf("uncached offline error = %v, want ErrOffline", err)
 	}
 
-	// Verify cache file exists
-	cacheFile := filepath.Join(cacheDir, "app-misc_hello.json")
-	if _, err := os.Stat(cacheFile); os.IsNotExist(err) {
-		t.Error("Cache file was not created")
+	online := newProvider(false)
+	if _, err := online.GetPackageVersions("app-misc", "hello"); err != nil {
+		t.Fatalf("online GetPackageVersions() error = %v", err)
+	}
+	if f, _ := online.Freshness(); f != cache.Live {
+		t.Errorf("online Freshness() = %s, want live", f)
+	}
+
+	// The cached index answers without a request and is reported stale
+	requests = 0
+	prov := newProvider(true)
+	versions, err := prov.GetPackageVersions("app-misc", "hello")
+	if err != nil || len(versions) != 1 || versions[0] != "1.0" {
+		t.Errorf("offline GetPackageVersions() = %v, %v", versions, err)
+	}
+	if f, at := prov.Freshness(); f != cache.Stale || at.IsZero() {
+		t.Errorf("offline Freshness() = %s, %v; want stale with a fetch time", f, at)
+	}
+
+	// Uncached ebuilds, updates and files cannot be fetched
+	if _, err := prov.GetEbuildMetadata("app-misc", "hello", "1.0"); !errors.Is(err, ErrOffline) {
+		t.Errorf("GetEbuildMetadata() error = %v, want ErrOffline", err)
+	}
+	if _, err := prov.ReadUpdates(); !errors.Is(err, ErrOffline) {
+		t.Errorf("ReadUpdates() error = %v, want ErrOffline", err)
+	}
+	if _, err := prov.ReadFile("metadata/layout.conf"); !errors.Is(err, ErrOffline) {
+		t.Errorf("ReadFile() error = %v, want ErrOffline", err)
+	}
+	if requests != 0 {
+		t.Errorf("offline provider made %d requests", requests)
 	}
 }
 
-func TestGitHubProvider_TokenAuth(t *testing.T) {
-	var authHeader string
+func TestGitHubProvider_TruncatedTree(t *testing.T) {
+	var requests []string
+	rootSHA := "root1"
 
-	// Create mock server that captures auth header
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader = r.Header.Get("Authorization")
-		entries := []ContentEntry{}
-		json.NewEncoder(w).Encode(entries)
+		path := strings.TrimPrefix(r.URL.Path, "/repos/test/repo/git/trees/")
+		requests = append(requests, path)
+		switch path {
+		case rootSHA:
+			json.NewEncoder(w).Encode(githubTreeJSON(rootSHA, true, "app-misc/"))
+		case "HEAD":
+			w.Header().Set("ETag", `"`+rootSHA+`"`)
+			json.NewEncoder(w).Encode(map[string]any{"sha": rootSHA, "tree": []map[string]string{
+				{"path": "app-misc", "type": "tree", "sha": "misc1"},
+				{"path": "dev-lang", "type": "tree", "sha": "lang-" + rootSHA},
+				{"path": "profiles", "type": "tree", "sha": "prof"},
+				{"path": "header.txt", "type": "blob", "sha": "hdr"},
+			}})
+		case "misc1":
+			json.NewEncoder(w).Encode(githubTreeJSON("misc1", false, "hello/", "hello/hello-1.0.ebuild"))
+		case "lang-" + rootSHA:
+			json.NewEncoder(w).Encode(githubTreeJSON(path, false, "go/go-1.22.ebuild"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
 	}))
 	defer server.Close()
 
-	repoInfo := &RepositoryInfo{
-		Name:  "test",
-		URL:   "test/repo",
-		Token: "test-token-123",
+	cacheDir := t.TempDir()
+	newProvider := func() *GitHubProvider {
+		prov, _ := NewGitHubProvider(&RepositoryInfo{Name: "test", URL: "test/repo"})
+		prov.BaseURL = server.URL
+		prov.CacheDir = cacheDir
+		return prov
 	}
 
-	prov, _ := NewGitHubProvider(repoInfo)
-	prov.BaseURL = server.URL
-	prov.CacheDir = ""
+	prov := newProvider()
+	if versions, err := prov.GetPackageVersions("dev-lang", "go"); err != nil || len(versions) != 1 || versions[0] != "1.22" {
+		t.Fatalf("GetPackageVersions(go) = %v, %v", versions, err)
+	}
+	if versions, err := prov.GetPackageVersions("app-misc", "hello"); err != nil || len(versions) != 1 {
+		t.Fatalf("GetPackageVersions(hello) = %v, %v", versions, err)
+	}
+	if got := strings.Join(requests, " "); got != "HEAD root1 misc1 lang-root1" {
+		t.Errorf("requests = %q, want one listing per category", got)
+	}
 
-	_, _ = prov.GetPackageVersions("app-misc", "hello")
+	// Only the changed category is listed again
+	requests = nil
+	rootSHA = "root2"
+	if _, err := newProvider().GetPackageVersions("app-misc", "hello"); err != nil {
+		t.Fatalf("GetPackageVersions after change failed: %v", err)
+	}
+	if got := strings.Join(requests, " "); got != "HEAD lang-root2" {
+		t.Errorf("requests = %q, want the unchanged category reused", got)
+	}
+}
 
-	if authHeader != "Bearer test-token-123" {
-		t.Errorf("Expected 'Bearer test-token-123', got '%s'", authHeader)
+func TestGitHubProvider_ResumeCategories(t *testing.T) {
+	var requests []string
+	limited := true
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/repos/test/repo/git/trees/")
+		requests = append(requests, path)
+		switch {
+		case path == "HEAD":
+			w.Header().Set("ETag", `"root"`)
+			json.NewEncoder(w).Encode(githubTreeJSON("root", false, "app-misc/", "dev-lang/"))
+		case path == "root":
+			json.NewEncoder(w).Encode(githubTreeJSON("root", true))
+		case path == "t-root-app-misc":
+			json.NewEncoder(w).Encode(githubTreeJSON(path, false, "hello/hello-1.0.ebuild"))
+		case path == "t-root-dev-lang" && !limited:
+			json.NewEncoder(w).Encode(githubTreeJSON(path, false, "go/go-1.22.ebuild"))
+		default:
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	newProvider := func(offline bool) *GitHubProvider {
+		prov, _ := NewGitHubProvider(&RepositoryInfo{Name: "test", URL: "test/repo"})
+		prov.BaseURL = server.URL
+		prov.CacheDir = cacheDir
+		prov.Offline = offline
+		return prov
+	}
+
+	// The rate limit stops the listing after the first category
+	if _, err := newProvider(false).GetPackageVersions("dev-lang", "go"); !errors.Is(err, ErrRateLimit) {
+		t.Fatalf("rate limited error = %v, want ErrRateLimit", err)
+	}
+	if _, err := newProvider(true).GetPackageVersions("app-misc", "hello"); !errors.Is(err, ErrOffline) {
+		t.Errorf("offline with a partial cache error = %v, want ErrOffline", err)
+	}
+
+	// The next run lists only the category that was not finished
+	requests = nil
+	limited = false
+	if versions, err := newProvider(false).GetPackageVersions("dev-lang", "go"); err != nil || len(versions) != 1 {
+		t.Fatalf("resumed GetPackageVersions() = %v, %v", versions, err)
+	}
+	if got := strings.Join(requests, " "); got != "HEAD t-root-dev-lang" {
+		t.Errorf("requests = %q, want the finished category reused", got)
+	}
+	if _, err := newProvider(true).GetPackageVersions("app-misc", "hello"); err != nil {
+		t.Errorf("offline after a complete listing error = %v", err)
 	}
 }
 
-func TestExtractVersionsFromEntries(t *testing.T) {
+func TestGitHubProvider_TreeErrors(t *testing.T) {
 	tests := []struct {
-		name     string
-		entries  []ContentEntry
-		pkg      string
-		expected []string
+		name    string
+		status  int
+		wantErr error
 	}{
-		{
-			name: "normal versions",
-			entries: []ContentEntry{
-				{Name: "hello-1.0.ebuild", Type: "file"},
-				{Name: "hello-2.0.ebuild", Type: "file"},
-				{Name: "hello-2.0_rc1.ebuild", Type: "file"},
-			},
-			pkg:      "hello",
-			expected: []string{"1.0", "2.0", "2.0_rc1"},
-		},
-		{
-			name: "with directories",
-			entries: []ContentEntry{
-				{Name: "hello-1.0.ebuild", Type: "file"},
-				{Name: "files", Type: "dir"},
-				{Name: "metadata.xml", Type: "file"},
-			},
-			pkg:      "hello",
-			expected: []string{"1.0"},
-		},
-		{
-			name: "complex version",
-			entries: []ContentEntry{
-				{Name: "vscode-1.107.1.ebuild", Type: "file"},
-				{Name: "vscode-1.107.1-r1.ebuild", Type: "file"},
-			},
-			pkg:      "vscode",
-			expected: []string{"1.107.1", "1.107.1-r1"},
-		},
-		{
-			name: "different package names",
-			entries: []ContentEntry{
-				{Name: "vim-9.0.ebuild", Type: "file"},
-				{Name: "vim-core-9.0.ebuild", Type: "file"},
-			},
-			pkg:      "vim",
-			expected: []string{"9.0"},
-		},
+		{"rate limited", http.StatusForbidden, ErrRateLimit},
+		{"unknown branch", http.StatusNotFound, ErrRepositoryNotFound},
+		{"server error", http.StatusInternalServerError, ErrAPIError},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			versions := extractVersionsFromEntries(tc.entries, tc.pkg)
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
 
-			if len(versions) != len(tc.expected) {
-				t.Errorf("Expected %d versions, got %d: %v", len(tc.expected), len(versions), versions)
-				return
-			}
+			prov, _ := NewGitHubProvider(&RepositoryInfo{Name: "test", URL: "test/repo"})
+			prov.BaseURL = server.URL
+			prov.CacheDir = ""
 
-			for i, v := range versions {
-				if v != tc.expected[i] {
-					t.Errorf("Version %d: expected %s, got %s", i, tc.expected[i], v)
+			for i := 0; i < 2; i++ {
+				if _, err := prov.GetPackageVersions("app-misc", "hello"); !errors.Is(err, tc.wantErr) {
+					t.Errorf("error = %v, want %v", err, tc.wantErr)
 				}
 			}
+			if requests != 1 {
+				t.Errorf("Expected the failed listing to be requested once, got %d", requests)
+			}
 		})
 	}
 }
+
+func TestGitHubProvider_TokenAuth(t *testing.T) {
+	var authHeader string
+
+	// Create mock server that captures auth header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode(githubTreeJSON("root", false))
+	}))
+	defer server.Close()
+
+	repoInfo := &RepositoryInfo{
+		Name:  "test",
+		URL:   "test/repo",
+		Token: "test-token-123",
+	}
+
+	prov, _ := NewGitHubProvider(repoInfo)
+	prov.BaseURL = server.URL
+	prov.CacheDir = ""
+
+	_, _ = prov.GetPackageVersions("app-misc", "hello")
+
+	if authHeader != "Bearer test-token-123" {
+		t.Errorf("Expected 'Bearer test-token-123', got '%s'", authHeader)
+	}
+}
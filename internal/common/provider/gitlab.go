@@ -2,25 +2,39 @@ package provider
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
 )
 
 // GitLabProvider fetches package versions from GitLab API
 type GitLabProvider struct {
 	BaseURL    string // e.g., "https://gitlab.com" or "https://gitlab.gentoo.org"
 	ProjectID  string // URL-encoded project path or numeric ID
+	Branch     string // Branch to list (default: the project's default branch)
 	Token      string
 	UserAgent  string
 	HTTPClient *http.Client
 	CacheDir   string
-	CacheTTL   time.Duration
+
+	// Offline answers from CacheDir only: no request reaches the API, and
+	// the cached tree index is used without revalidation
+	Offline bool
+
+	index    *treeIndex
+	indexErr error
+	blobs    *blobCache
 }
 
 // GitLabTreeEntry represents a file/directory entry from GitLab Repository Tree API
@@ -43,25 +57,42 @@ func NewGitLabProvider(repoInfo *RepositoryInfo) (*GitLabProvider, error) {
 	p := &GitLabProvider{
 		BaseURL:   baseURL,
 		ProjectID: url.PathEscape(projectID),
+		Branch:    repoInfo.Branch,
 		Token:     repoInfo.Token,
 		UserAgent: "bentoolkit/1.0",
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		CacheTTL: 24 * time.Hour,
 	}
 
 	// Setup default cache directory
-	home, err := os.UserHomeDir()
+	root, err := config.CacheDir()
 	if err == nil {
 		safeName := strings.ReplaceAll(projectID, "/", "_")
-		p.CacheDir = filepath.Join(home, ".cache", "bentoo", "compare", "gitlab", safeName)
+		p.CacheDir = filepath.Join(root, compareCacheDir, "gitlab", safeName)
 		os.MkdirAll(p.CacheDir, 0755)
 	}
 
 	return p, nil
 }
 
+// client returns the HTTP client, which refuses every request offline
+func (p *GitLabProvider) client() *http.Client {
+	if p.Offline {
+		return offlineClient
+	}
+	return p.HTTPClient
+}
+
+// Freshness reports answers from the cached tree index as stale offline,
+// as it could not be checked against the branch head
+func (p *GitLabProvider) Freshness() (cache.Freshness, time.Time) {
+	if !p.Offline || p.index == nil {
+		return cache.Live, time.Time{}
+	}
+	return cache.Stale, p.index.Timestamp
+}
+
 // parseGitLabURL parses a GitLab URL into base URL and project path
 // Supports formats:
 // - https://gitlab.com/group/project
@@ -95,169 +126,243 @@ func (p *GitLabProvider) SupportsAPI() bool {
 	return true
 }
 
-// Close cleans up resources
+// Close saves the ebuild metadata read during the run to the cache
 func (p *GitLabProvider) Close() error {
+	p.blobs.save()
 	return nil
 }
 
-// GetPackageVersions fetches all ebuild versions for a package from GitLab
+// GetPackageVersions returns all ebuild versions for a package. The whole
+// repository tree is listed on first use; later calls are answered from it.
 func (p *GitLabProvider) GetPackageVersions(category, pkg string) ([]string, error) {
-	// Check cache first
-	if p.CacheDir != "" {
-		if versions, ok := p.loadFromCache(category, pkg); ok {
-			return versions, nil
-		}
+	if err := p.ensureIndex(); err != nil {
+		return nil, err
 	}
+	return p.index.versions(category, pkg)
+}
 
-	// Fetch from API
-	versions, err := p.fetchPackageVersions(category, pkg)
+// GetEbuildMetadata returns the slot and keywords of a package version. The
+// ebuild is fetched by its blob from the tree listing; blobs are cached.
+func (p *GitLabProvider) GetEbuildMetadata(category, pkg, version string) (*ebuild.Metadata, error) {
+	if err := p.ensureIndex(); err != nil {
+		return nil, err
+	}
+	sha, err := p.index.blob(category, pkg, version)
 	if err != nil {
 		return nil, err
 	}
 
-	// Save to cache
-	if p.CacheDir != "" {
-		p.saveToCache(category, pkg, versions)
+	if p.blobs == nil {
+		p.blobs = loadBlobCache(p.CacheDir)
 	}
-
-	return versions, nil
+	return p.blobs.metadata(sha, version, p.getBlob)
 }
 
-// fetchPackageVersions fetches versions from GitLab API
-func (p *GitLabProvider) fetchPackageVersions(category, pkg string) ([]string, error) {
-	// GitLab Repository Tree API
-	// GET /api/v4/projects/:id/repository/tree?path=category/package
-	path := url.QueryEscape(fmt.Sprintf("%s/%s", category, pkg))
-	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/repository/tree?path=%s&per_page=100",
-		p.BaseURL, p.ProjectID, path)
-
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return nil, err
+// ensureIndex lists the repository tree on first use. A failed listing is
+// not retried.
+func (p *GitLabProvider) ensureIndex() error {
+	if p.index == nil && p.indexErr == nil {
+		p.index, p.indexErr = p.loadIndex()
 	}
+	return p.indexErr
+}
 
-	req.Header.Set("User-Agent", p.UserAgent)
+// ref returns the branch to list, or HEAD for the default branch
+func (p *GitLabProvider) ref() string {
+	if p.Branch == "" {
+		return "HEAD"
+	}
+	return p.Branch
+}
 
-	// GitLab uses PRIVATE-TOKEN header for authentication
-	if p.Token != "" {
-		req.Header.Set("PRIVATE-TOKEN", p.Token)
+// loadIndex lists the repository with the paginated recursive Repository
+// Tree API. The index is keyed by the branch's head commit, so an unchanged
+// repository costs the single request that resolves the commit. Offline,
+// the cached index is used as is.
+func (p *GitLabProvider) loadIndex() (*treeIndex, error) {
+	if p.Offline {
+		cached := loadTreeIndex(p.CacheDir)
+		if cached == nil {
+			return nil, fmt.Errorf("%w: no cached tree of %s", ErrOffline, p.ProjectID)
+		}
+		return cached, nil
 	}
 
-	resp, err := p.HTTPClient.Do(req)
+	sha, err := p.headCommit()
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	if cached := loadTreeIndex(p.CacheDir); cached != nil && cached.SHA == sha {
+		return cached, nil
+	}
 
-	// Handle rate limiting
-	if resp.StatusCode == 429 {
-		return nil, ErrRateLimit
+	index := newTreeIndex(sha)
+	next := fmt.Sprintf("%s/api/v4/projects/%s/repository/tree?recursive=true&per_page=100&pagination=keyset&ref=%s",
+		p.BaseURL, p.ProjectID, sha)
+	for next != "" {
+		var entries []GitLabTreeEntry
+		if next, err = p.getTreePage(next, &entries); err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			index.add(e.Path, e.Type == "tree", e.ID)
+		}
 	}
 
-	// Handle not found
-	if resp.StatusCode == 404 {
-		return nil, ErrNotFound
+	index.save(p.CacheDir)
+	return index, nil
+}
+
+// headCommit resolves the listed branch to its head commit
+func (p *GitLabProvider) headCommit() (string, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/repository/commits/%s",
+		p.BaseURL, p.ProjectID, url.PathEscape(p.ref()))
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return "", err
 	}
+	p.setHeaders(req)
 
-	// Handle other errors
-	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("%w: status %d: %s", ErrAPIError, resp.StatusCode, string(body))
+	var commit struct {
+		ID string `json:"id"`
 	}
+	if err := doJSON(p.client(), req, http.StatusOK, &commit); err != nil {
+		return "", err
+	}
+	return commit.ID, nil
+}
 
-	// Parse response
-	body, err := io.ReadAll(resp.Body)
+// getTreePage fetches one page of a tree listing into entries and returns
+// the URL of the next page from the Link header, or "" on the last page
+func (p *GitLabProvider) getTreePage(pageURL string, entries *[]GitLabTreeEntry) (string, error) {
+	req, err := http.NewRequest("GET", pageURL, nil)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
+	p.setHeaders(req)
 
-	var entries []GitLabTreeEntry
-	if err := json.Unmarshal(body, &entries); err != nil {
-		return nil, fmt.Errorf("failed to parse GitLab response: %w", err)
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return "", err
 	}
+	defer resp.Body.Close()
 
-	// Extract versions from ebuild filenames
-	versions := p.extractVersionsFromEntries(entries, pkg)
+	if resp.StatusCode != http.StatusOK {
+		return "", p.statusError(resp)
+	}
 
-	return versions, nil
+	if err := json.NewDecoder(resp.Body).Decode(entries); err != nil {
+		return "", fmt.Errorf("failed to parse GitLab response: %w", err)
+	}
+	return nextLink(resp.Header.Get("Link")), nil
 }
 
-// extractVersionsFromEntries extracts version strings from GitLabTreeEntry list
-func (p *GitLabProvider) extractVersionsFromEntries(entries []GitLabTreeEntry, pkg string) []string {
-	var versions []string
-
-	for _, entry := range entries {
-		if entry.Type != "blob" {
-			continue
-		}
-		if !strings.HasSuffix(entry.Name, ".ebuild") {
-			continue
-		}
-
-		matches := ebuildVersionRegex.FindStringSubmatch(entry.Name)
-		if matches == nil {
-			continue
-		}
-
-		name := matches[1]
-		version := matches[2]
+// getBlob fetches the raw content of a blob with the Repository Blobs API
+func (p *GitLabProvider) getBlob(sha string) ([]byte, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/repository/blobs/%s/raw", p.BaseURL, p.ProjectID, sha)
 
-		if name == pkg {
-			versions = append(versions, version)
-		}
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, err
 	}
+	p.setHeaders(req)
 
-	return versions
-}
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-// cacheFilePath returns the cache file path for a package
-func (p *GitLabProvider) cacheFilePath(category, pkg string) string {
-	return filepath.Join(p.CacheDir, fmt.Sprintf("%s_%s.json", category, pkg))
+	if resp.StatusCode != http.StatusOK {
+		return nil, p.statusError(resp)
+	}
+	return io.ReadAll(resp.Body)
 }
 
-// loadFromCache attempts to load versions from cache
-func (p *GitLabProvider) loadFromCache(category, pkg string) ([]string, bool) {
-	if p.CacheDir == "" {
-		return nil, false
-	}
+// ReadFile returns the content of a file of the repository with the
+// Repository Files API
+func (p *GitLabProvider) ReadFile(name string) ([]byte, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/repository/files/%s/raw?ref=%s",
+		p.BaseURL, p.ProjectID, url.PathEscape(name), url.QueryEscape(p.ref()))
 
-	cacheFile := p.cacheFilePath(category, pkg)
-	data, err := os.ReadFile(cacheFile)
+	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
-		return nil, false
+		return nil, err
 	}
+	p.setHeaders(req)
 
-	var entry CacheEntry
-	if err := json.Unmarshal(data, &entry); err != nil {
-		return nil, false
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	if time.Since(entry.Timestamp) > p.CacheTTL {
-		return nil, false
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	default:
+		return nil, p.statusError(resp)
 	}
-
-	return entry.Versions, true
 }
 
-// saveToCache saves versions to cache
-func (p *GitLabProvider) saveToCache(category, pkg string, versions []string) {
-	if p.CacheDir == "" {
-		return
+// ListDir returns the sorted names of the entries of a repository directory
+// with the Repository Tree API
+func (p *GitLabProvider) ListDir(name string) ([]string, error) {
+	next := fmt.Sprintf("%s/api/v4/projects/%s/repository/tree?path=%s&ref=%s&per_page=100",
+		p.BaseURL, p.ProjectID, url.QueryEscape(name), url.QueryEscape(p.ref()))
+
+	var names []string
+	for next != "" {
+		var entries []GitLabTreeEntry
+		var err error
+		if next, err = p.getTreePage(next, &entries); err != nil {
+			if errors.Is(err, ErrRepositoryNotFound) {
+				// GitLab answers 404 for a missing path
+				return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+			}
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.Type == "tree" {
+				names = append(names, e.Name+"/")
+			} else {
+				names = append(names, e.Name)
+			}
+		}
 	}
+	sort.Strings(names)
+	return names, nil
+}
 
-	entry := CacheEntry{
-		Versions:  versions,
-		Timestamp: time.Now(),
+// statusError maps an unexpected API response status to an error
+func (p *GitLabProvider) statusError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return ErrRateLimit
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrRepositoryNotFound, p.ProjectID)
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%w: status %d: %s", ErrAPIError, resp.StatusCode, string(body))
 	}
+}
 
-	data, err := json.Marshal(entry)
-	if err != nil {
-		return
+// nextLink returns the rel="next" target of an RFC 8288 Link header
+func nextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(target), "<>")
 	}
-
-	cacheFile := p.cacheFilePath(category, pkg)
-	_ = os.WriteFile(cacheFile, data, 0644)
+	return ""
 }
 
-// Ensure GitLabProvider implements Provider interface
-var _ Provider = (*GitLabProvider)(nil)
+// Ensure GitLabProvider implements Provider and FreshnessReporter interfaces
+var (
+	_ Provider          = (*GitLabProvider)(nil)
+	_ FreshnessReporter = (*GitLabProvider)(nil)
+)
@@ -2,28 +2,45 @@ package provider
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
 )
 
 func TestGitLabProvider_GetPackageVersions(t *testing.T) {
-	// Create mock server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Match the actual URL pattern the provider uses
-		// The path query param will be URL-encoded
-		if r.URL.Query().Get("path") == "app-misc/hello" {
-			entries := []GitLabTreeEntry{
+	var requests []string
+	headSHA := "c1"
+
+	// Serve the branch head and a tree listing split over two pages
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.EscapedPath()+"?"+r.URL.Query().Get("page_token"))
+		switch {
+		case r.URL.EscapedPath() == "/api/v4/projects/test%2Frepo/repository/commits/master":
+			json.NewEncoder(w).Encode(map[string]string{"id": headSHA})
+		case r.URL.Path == "/api/v4/projects/test/repo/repository/tree" && r.URL.Query().Get("page_token") == "":
+			if r.URL.Query().Get("recursive") != "true" || r.URL.Query().Get("ref") != headSHA {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Link", `<`+server.URL+`/api/v4/projects/test%2Frepo/repository/tree?page_token=next>; rel="next"`)
+			json.NewEncoder(w).Encode([]GitLabTreeEntry{
+				{Name: "app-misc", Type: "tree", Path: "app-misc", ID: "t1"},
 				{Name: "hello-1.0.ebuild", Type: "blob", Path: "app-misc/hello/hello-1.0.ebuild"},
+				{Name: "files", Type: "tree", Path: "app-misc/hello/files"},
+			})
+		case r.URL.Path == "/api/v4/projects/test/repo/repository/tree":
+			json.NewEncoder(w).Encode([]GitLabTreeEntry{
 				{Name: "hello-1.1.ebuild", Type: "blob", Path: "app-misc/hello/hello-1.1.ebuild"},
 				{Name: "metadata.xml", Type: "blob", Path: "app-misc/hello/metadata.xml"},
-				{Name: "files", Type: "tree", Path: "app-misc/hello/files"},
-			}
-			json.NewEncoder(w).Encode(entries)
-			return
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
 		}
-
-		w.WriteHeader(http.StatusNotFound)
 	}))
 	defer server.Close()
 
@@ -31,17 +48,22 @@ func TestGitLabProvider_GetPackageVersions(t *testing.T) {
 		Name:     "test",
 		Provider: "gitlab",
 		URL:      "test/repo",
+		Branch:   "master",
 	}
 
-	prov, err := NewGitLabProvider(repoInfo)
-	if err != nil {
-		t.Fatalf("NewGitLabProvider failed: %v", err)
+	cacheDir := t.TempDir()
+	newProvider := func() *GitLabProvider {
+		prov, err := NewGitLabProvider(repoInfo)
+		if err != nil {
+			t.Fatalf("NewGitLabProvider failed: %v", err)
+		}
+		// Override base URL to mock server
+		prov.BaseURL = server.URL
+		prov.CacheDir = cacheDir
+		return prov
 	}
 
-	// Override base URL to mock server
-	prov.BaseURL = server.URL
-	prov.CacheDir = "" // Disable cache
-
+	prov := newProvider()
 	t.Run("existing package", func(t *testing.T) {
 		versions, err := prov.GetPackageVersions("app-misc", "hello")
 		if err != nil {
@@ -53,6 +75,111 @@ func TestGitLabProvider_GetPackageVersions(t *testing.T) {
 			t.Errorf("Expected 2 versions, got %d: %v", len(versions), versions)
 		}
 	})
+
+	t.Run("package not found", func(t *testing.T) {
+		if _, err := prov.GetPackageVersions("app-misc", "notfound"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got: %v", err)
+		}
+	})
+
+	if len(requests) != 3 {
+		t.Errorf("requests = %v, want the head commit and two pages", requests)
+	}
+
+	t.Run("unchanged head uses the cache", func(t *testing.T) {
+		requests = nil
+		if versions, err := newProvider().GetPackageVersions("app-misc", "hello"); err != nil || len(versions) != 2 {
+			t.Errorf("GetPackageVersions = %v, %v", versions, err)
+		}
+		if len(requests) != 1 {
+			t.Errorf("requests = %v, want only the head commit", requests)
+		}
+	})
+
+	t.Run("new head lists the tree again", func(t *testing.T) {
+		requests = nil
+		headSHA = "c2"
+		if _, err := newProvider().GetPackageVersions("app-misc", "hello"); err != nil {
+			t.Errorf("GetPackageVersions failed: %v", err)
+		}
+		if len(requests) != 3 {
+			t.Errorf("requests = %v, want a new listing", requests)
+		}
+	})
+
+	t.Run("offline uses the cache without requests", func(t *testing.T) {
+		requests = nil
+		offline := newProvider()
+		offline.Offline = true
+		if versions, err := offline.GetPackageVersions("app-misc", "hello"); err != nil || len(versions) != 2 {
+			t.Errorf("GetPackageVersions = %v, %v", versions, err)
+		}
+		if f, _ := offline.Freshness(); f != cache.Stale {
+			t.Errorf("Freshness() = %s, want stale", f)
+		}
+		if _, err := ReadUpdates(offline); !errors.Is(err, ErrOffline) {
+			t.Errorf("ReadUpdates() error = %v, want ErrOffline", err)
+		}
+		if len(requests) != 0 {
+			t.Errorf("requests = %v, want none", requests)
+		}
+
+		offline.CacheDir = t.TempDir()
+		offline.index = nil
+		if _, err := offline.GetPackageVersions("app-misc", "hello"); !errors.Is(err, ErrOffline) {
+			t.Errorf("uncached error = %v, want ErrOffline", err)
+		}
+	})
+}
+
+func TestGitLabProvider_TreeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr error
+	}{
+		{"rate limited", http.StatusTooManyRequests, ErrRateLimit},
+		{"unknown project", http.StatusNotFound, ErrRepositoryNotFound},
+		{"server error", http.StatusInternalServerError, ErrAPIError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.Contains(r.URL.Path, "/repository/commits/") {
+					json.NewEncoder(w).Encode(map[string]string{"id": "c1"})
+					return
+				}
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			prov, _ := NewGitLabProvider(&RepositoryInfo{Name: "test", URL: "test/repo"})
+			prov.BaseURL = server.URL
+			prov.CacheDir = ""
+
+			if _, err := prov.GetPackageVersions("app-misc", "hello"); !errors.Is(err, tc.wantErr) {
+				t.Errorf("error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{`<https://gitlab.com/api/v4/x?page_token=a>; rel="next", <https://gitlab.com/api/v4/x>; rel="first"`, "https://gitlab.com/api/v4/x?page_token=a"},
+		{`<https://gitlab.com/api/v4/x>; rel="first"`, ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := nextLink(tt.header); got != tt.want {
+			t.Errorf("nextLink(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
 }
 
 func TestGitLabProvider_TokenAuth(t *testing.T) {
@@ -61,8 +188,7 @@ func TestGitLabProvider_TokenAuth(t *testing.T) {
 	// Create mock server that captures auth header
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		privateToken = r.Header.Get("PRIVATE-TOKEN")
-		entries := []GitLabTreeEntry{}
-		json.NewEncoder(w).Encode(entries)
+		json.NewEncoder(w).Encode(map[string]string{"id": "c1"})
 	}))
 	defer server.Close()
 
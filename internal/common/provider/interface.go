@@ -1,6 +1,11 @@
 package provider
 
-import "errors"
+import (
+	"errors"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/cache"
+)
 
 var (
 	// ErrNotFound indicates the requested resource was not found
@@ -11,6 +16,8 @@ var (
 	ErrAPIError = errors.New("API error")
 	// ErrCloneFailed indicates git clone operation failed
 	ErrCloneFailed = errors.New("git clone failed")
+	// ErrOffline indicates the data is not available without network access
+	ErrOffline = errors.New("not available offline")
 )
 
 // Provider is the interface for fetching package versions from a repository
@@ -28,11 +35,20 @@ type Provider interface {
 	Close() error
 }
 
+// FreshnessReporter is implemented by providers that may answer from a cache
+// without checking upstream, such as in offline mode. Providers that do not
+// implement it always answer live.
+type FreshnessReporter interface {
+	// Freshness returns how current the answers given so far are, and when
+	// the data behind them was fetched (zero when live)
+	Freshness() (cache.Freshness, time.Time)
+}
+
 // RepositoryInfo contains information about a repository to compare against
 type RepositoryInfo struct {
 	Name     string // e.g., "gentoo", "guru", "my-overlay"
-	Provider string // "github", "gitlab", "git"
-	URL      string // Full URL or org/repo for GitHub/GitLab
+	Provider string // "github", "gitlab", "git", "local"
+	URL      string // Full URL, org/repo for GitHub/GitLab, or a path for local
 	Token    string // Optional auth token
 	Branch   string // Branch to use (default: master/main)
 }
@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+)
+
+// ErrNotARepository indicates a local path that is not an ebuild repository
+var ErrNotARepository = errors.New("not an ebuild repository")
+
+// ReposConfPath is the Portage repository configuration scanned for local
+// repositories. It may be a file or a directory of files; empty disables it.
+var ReposConfPath = "/etc/portage/repos.conf"
+
+// LocalProvider reads package versions from an ebuild repository on disk,
+// such as a Portage-synced tree under /var/db/repos
+type LocalProvider struct {
+	Path     string
+	RepoName string
+}
+
+// NewLocalProvider creates a provider for the repository at repoInfo.URL.
+// The repository name is read from profiles/repo_name when present.
+func NewLocalProvider(repoInfo *RepositoryInfo) (*LocalProvider, error) {
+	path := strings.TrimPrefix(repoInfo.URL, "file://")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrNotARepository, path, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s is not a directory", ErrNotARepository, path)
+	}
+
+	name := repoInfo.Name
+	if repoName, err := ReadRepoName(path); err == nil {
+		name = repoName
+	}
+
+	return &LocalProvider{
+		Path:     path,
+		RepoName: name,
+	}, nil
+}
+
+// ReadRepoName returns the name a repository declares in profiles/repo_name
+func ReadRepoName(path string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(path, "profiles", "repo_name"))
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.TrimSpace(string(content))
+	if name == "" {
+		return "", fmt.Errorf("%w: %s has an empty profiles/repo_name", ErrNotARepository, path)
+	}
+	return name, nil
+}
+
+// GetName returns the provider name
+func (p *LocalProvider) GetName() string {
+	return fmt.Sprintf("Local (%s)", p.RepoName)
+}
+
+// SupportsAPI returns false - this provider reads the tree in place
+func (p *LocalProvider) SupportsAPI() bool {
+	return false
+}
+
+// Close cleans up resources (nothing to clean for a local tree)
+func (p *LocalProvider) Close() error {
+	return nil
+}
+
+// GetPackageVersions returns all ebuild versions for a package
+func (p *LocalProvider) GetPackageVersions(category, pkg string) ([]string, error) {
+	versions, err := ebuild.ScanPackageDir(filepath.Join(p.Path, category, pkg), category, pkg)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	return versions, nil
+}
+
+// GetEbuildMetadata returns the slot and keywords of a package version
+func (p *LocalProvider) GetEbuildMetadata(category, pkg, version string) (*ebuild.Metadata, error) {
+	return readEbuildMetadata(p.Path, category, pkg, version)
+}
+
+// ReadReposConf returns the repositories configured in a Portage repos.conf
+// file, or in every file of a repos.conf directory, as local repositories.
+// Sections without a location, and the DEFAULT section, are skipped.
+func ReadReposConf(path string) (map[string]*RepositoryInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		files = files[:0]
+		for _, entry := range entries {
+			name := entry.Name()
+			// Portage ignores hidden files and editor backups
+			if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+				continue
+			}
+			files = append(files, filepath.Join(path, name))
+		}
+		sort.Strings(files)
+	}
+
+	repos := make(map[string]*RepositoryInfo)
+	for _, file := range files {
+		if err := parseReposConf(file, repos); err != nil {
+			return nil, err
+		}
+	}
+	return repos, nil
+}
+
+// parseReposConf adds the repositories of one repos.conf file to repos.
+// Later files override the location of repositories defined earlier.
+func parseReposConf(file string, repos map[string]*RepositoryInfo) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || section == "" || section == "DEFAULT" {
+			continue
+		}
+		if strings.TrimSpace(key) == "location" {
+			repos[section] = &RepositoryInfo{
+				Name:     section,
+				Provider: "local",
+				URL:      strings.TrimSpace(value),
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// systemRepositories returns the repositories registered in ReposConfPath.
+// A missing or unreadable repos.conf yields no repositories.
+func systemRepositories() map[string]*RepositoryInfo {
+	if ReposConfPath == "" {
+		return nil
+	}
+	repos, err := ReadReposConf(ReposConfPath)
+	if err != nil {
+		return nil
+	}
+	return repos
+}
+
+// Ensure LocalProvider implements Provider interface
+var _ Provider = (*LocalProvider)(nil)
@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// withReposConf points ReposConfPath at path for the duration of the test
+func withReposConf(t *testing.T, path string) {
+	t.Helper()
+	saved := ReposConfPath
+	ReposConfPath = path
+	t.Cleanup(func() { ReposConfPath = saved })
+}
+
+// writeTree creates the given files, relative to root
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLocalProvider(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"profiles/repo_name":                "gentoo\n",
+		"app-misc/hello/hello-1.0.ebuild":   "",
+		"app-misc/hello/hello-1.1.ebuild":   "",
+		"app-misc/hello/metadata.xml":       "",
+		"app-misc/empty/metadata.xml":       "",
+		"dev-lang/go/go-1.23.0-r1.ebuild":   "",
+		"dev-lang/go/files/go-1.23.0.patch": "",
+	})
+
+	prov, err := NewProvider(&RepositoryInfo{Name: "gentoo-local", Provider: "local", URL: root}, true)
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	local, ok := prov.(*LocalProvider)
+	if !ok {
+		t.Fatalf("NewProvider() = %T, want *LocalProvider even with forceClone", prov)
+	}
+	if local.GetName() != "Local (gentoo)" || local.SupportsAPI() {
+		t.Errorf("GetName() = %q, want the name from profiles/repo_name", local.GetName())
+	}
+
+	versions, err := local.GetPackageVersions("app-misc", "hello")
+	sort.Strings(versions)
+	if err != nil || strings.Join(versions, " ") != "1.0 1.1" {
+		t.Errorf("GetPackageVersions(hello) = %v, %v", versions, err)
+	}
+	if versions, err := local.GetPackageVersions("dev-lang", "go"); err != nil || len(versions) != 1 || versions[0] != "1.23.0-r1" {
+		t.Errorf("GetPackageVersions(go) = %v, %v", versions, err)
+	}
+
+	for _, pkg := range []string{"empty", "missing"} {
+		if _, err := local.GetPackageVersions("app-misc", pkg); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetPackageVersions(%s) error = %v, want ErrNotFound", pkg, err)
+		}
+	}
+}
+
+func TestNewLocalProviderErrors(t *testing.T) {
+	root := t.TempDir()
+
+	local, err := NewLocalProvider(&RepositoryInfo{Name: "unnamed", URL: "file://" + root})
+	if err != nil || local.RepoName != "unnamed" || local.Path != root {
+		t.Errorf("NewLocalProvider() without repo_name = %+v, %v; want the configured name", local, err)
+	}
+
+	if _, err := NewLocalProvider(&RepositoryInfo{URL: filepath.Join(root, "missing")}); !errors.Is(err, ErrNotARepository) {
+		t.Errorf("missing path error = %v, want ErrNotARepository", err)
+	}
+
+	writeTree(t, root, map[string]string{"file": ""})
+	if _, err := NewLocalProvider(&RepositoryInfo{URL: filepath.Join(root, "file")}); !errors.Is(err, ErrNotARepository) {
+		t.Errorf("file path error = %v, want ErrNotARepository", err)
+	}
+}
+
+func TestReadReposConf(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"gentoo.conf": `[DEFAULT]
+main-repo = gentoo
+
+[gentoo]
+location = /var/db/repos/gentoo
+sync-type = rsync
+`,
+		"eselect-repo.conf": `# created by eselect-repo
+[guru]
+location = /var/db/repos/guru
+sync-type = git
+
+[no-location]
+sync-type = git
+`,
+		"zz-override.conf":      "[guru]\nlocation=/srv/guru\n",
+		"bentoo.conf~":          "[stale]\nlocation = /tmp/stale\n",
+		".hidden.conf":          "[hidden]\nlocation = /tmp/hidden\n",
+		"single/repos.conf":     "[bentoo]\n  location = /var/db/repos/bentoo  \n",
+		"single/ignored/x.conf": "",
+	})
+
+	repos, err := ReadReposConf(dir)
+	if err != nil {
+		t.Fatalf("ReadReposConf() error = %v", err)
+	}
+
+	var names []string
+	for name := range repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, " ") != "gentoo guru" {
+		t.Errorf("repositories = %v, want gentoo and guru", names)
+	}
+	if repos["guru"].URL != "/srv/guru" || repos["guru"].Provider != "local" {
+		t.Errorf("guru = %+v, want the location of the later file", repos["guru"])
+	}
+
+	repos, err = ReadReposConf(filepath.Join(dir, "single", "repos.conf"))
+	if err != nil || repos["bentoo"] == nil || repos["bentoo"].URL != "/var/db/repos/bentoo" {
+		t.Errorf("ReadReposConf(file) = %v, %v", repos, err)
+	}
+
+	if _, err := ReadReposConf(filepath.Join(dir, "missing")); err == nil {
+		t.Error("ReadReposConf() of a missing path succeeded")
+	}
+}
+
+func TestResolveRepositoryFromReposConf(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"repos.conf": "[gentoo]\nlocation = /var/db/repos/gentoo\n\n[steam-overlay]\nlocation = /var/db/repos/steam-overlay\n\n[mine]\nlocation = /srv/mine\n",
+	})
+	withReposConf(t, filepath.Join(dir, "repos.conf"))
+
+	configRepos := map[string]*RepositoryInfo{
+		"mine": {Name: "mine", Provider: "github", URL: "me/mine"},
+	}
+
+	repo, err := ResolveRepository("steam-overlay", configRepos)
+	if err != nil || repo.Provider != "local" || repo.URL != "/var/db/repos/steam-overlay" {
+		t.Errorf("ResolveRepository(steam-overlay) = %+v, %v; want the repos.conf entry", repo, err)
+	}
+	if repo, _ := ResolveRepository("gentoo", configRepos); repo.Provider != "github" {
+		t.Errorf("ResolveRepository(gentoo) = %+v, want the built-in repository", repo)
+	}
+	if repo, _ := ResolveRepository("mine", configRepos); repo.Provider != "github" {
+		t.Errorf("ResolveRepository(mine) = %+v, want the configured repository", repo)
+	}
+
+	repos := ListAvailableRepositories(configRepos)
+	sort.Strings(repos)
+	if strings.Join(repos, " ") != "gentoo guru mine steam-overlay" {
+		t.Errorf("ListAvailableRepositories() = %v", repos)
+	}
+}
@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+)
+
+// MetadataReader is implemented by providers that can read the slot and
+// keywords of an upstream ebuild
+type MetadataReader interface {
+	// GetEbuildMetadata returns the metadata of a package version's ebuild
+	GetEbuildMetadata(category, pkg, version string) (*ebuild.Metadata, error)
+}
+
+// blobCacheFile is the name of the ebuild variable cache in a provider's CacheDir
+const blobCacheFile = "ebuilds.json"
+
+// blobCache holds the metadata variables of ebuild blobs. A blob never
+// changes, so entries are kept for as long as the cache exists. Variables are
+// cached rather than metadata because identical ebuilds of different versions
+// may expand to different slots.
+type blobCache struct {
+	dir   string
+	vars  map[string]map[string]string
+	dirty bool
+}
+
+// loadBlobCache reads the blob cache from dir. A missing or unreadable cache
+// starts empty.
+func loadBlobCache(dir string) *blobCache {
+	c := &blobCache{dir: dir, vars: make(map[string]map[string]string)}
+	if dir == "" {
+		return c
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, blobCacheFile))
+	if err == nil {
+		_ = json.Unmarshal(data, &c.vars)
+	}
+	if c.vars == nil {
+		c.vars = make(map[string]map[string]string)
+	}
+	return c
+}
+
+// metadata returns the metadata of the ebuild of version stored in blob sha,
+// fetching the blob content on a cache miss
+func (c *blobCache) metadata(sha, version string, fetch func(sha string) ([]byte, error)) (*ebuild.Metadata, error) {
+	vars, ok := c.vars[sha]
+	if !ok {
+		content, err := fetch(sha)
+		if err != nil {
+			return nil, err
+		}
+		vars = ebuild.ReadVariables(content, ebuild.MetadataVariables...)
+		c.vars[sha] = vars
+		c.dirty = true
+	}
+	return ebuild.NewMetadata(vars, version), nil
+}
+
+// save writes the cache to its directory if it changed. Failures are
+// ignored, as the cache only saves requests.
+func (c *blobCache) save() {
+	if c == nil || !c.dirty || c.dir == "" {
+		return
+	}
+
+	data, err := json.Marshal(c.vars)
+	if err != nil {
+		return
+	}
+	_ = os.MkdirAll(c.dir, 0755)
+	if os.WriteFile(filepath.Join(c.dir, blobCacheFile), data, 0644) == nil {
+		c.dirty = false
+	}
+}
+
+// readEbuildMetadata reads the metadata of a package version's ebuild in a
+// repository checked out at root
+func readEbuildMetadata(root, category, pkg, version string) (*ebuild.Metadata, error) {
+	path := filepath.Join(root, category, pkg, pkg+"-"+version+".ebuild")
+	m, err := ebuild.ReadMetadata(path, version)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return m, err
+}
+
+// Ensure every provider can read ebuild metadata
+var (
+	_ MetadataReader = (*GitHubProvider)(nil)
+	_ MetadataReader = (*GitLabProvider)(nil)
+	_ MetadataReader = (*GitCloneProvider)(nil)
+	_ MetadataReader = (*LocalProvider)(nil)
+)
@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+)
+
+func TestGitHubProvider_GetEbuildMetadata(t *testing.T) {
+	blobs := map[string]string{
+		"b-app-misc/hello/hello-1.0.ebuild": "EAPI=8\nSLOT=\"0\"\nKEYWORDS=\"amd64\"\n",
+		"b-app-misc/hello/hello-2.0.ebuild": "EAPI=8\nSLOT=\"$(ver_cut 1)\"\nKEYWORDS=\"~amd64\"\n",
+	}
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		if r.URL.Path == "/repos/test/repo/git/trees/HEAD" || r.URL.Path == "/repos/test/repo/git/trees/root" {
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			json.NewEncoder(w).Encode(githubTreeJSON("root", false,
+				"app-misc/",
+				"app-misc/hello/hello-1.0.ebuild",
+				"app-misc/hello/hello-2.0.ebuild",
+			))
+			return
+		}
+
+		sha, ok := strings.CutPrefix(r.URL.Path, "/repos/test/repo/git/blobs/")
+		content, found := blobs[sha]
+		if !ok || !found || r.Header.Get("Accept") != "application/vnd.github.raw+json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	newProvider := func() *GitHubProvider {
+		prov, err := NewGitHubProvider(&RepositoryInfo{Name: "test", Provider: "github", URL: "test/repo"})
+		if err != nil {
+			t.Fatalf("NewGitHubProvider failed: %v", err)
+		}
+		prov.BaseURL = server.URL
+		prov.CacheDir = cacheDir
+		return prov
+	}
+
+	prov := newProvider()
+	m, err := prov.GetEbuildMetadata("app-misc", "hello", "2.0")
+	if err != nil {
+		t.Fatalf("GetEbuildMetadata() error = %v", err)
+	}
+	if m.Slot != "2" || m.KeywordLevel("amd64") != ebuild.Testing {
+		t.Errorf("GetEbuildMetadata(2.0) = %+v", m)
+	}
+	if m, err := prov.GetEbuildMetadata("app-misc", "hello", "1.0"); err != nil || m.KeywordLevel("amd64") != ebuild.Stable {
+		t.Errorf("GetEbuildMetadata(1.0) = %+v, %v", m, err)
+	}
+	if _, err := prov.GetEbuildMetadata("app-misc", "hello", "3.0"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetEbuildMetadata(3.0) error = %v, want ErrNotFound", err)
+	}
+	if len(requests) != 4 {
+		t.Errorf("requests = %v, want the root, the tree and two blobs", requests)
+	}
+	prov.Close()
+
+	// A new run reuses the cached tree and blobs
+	requests = nil
+	if m, err := newProvider().GetEbuildMetadata("app-misc", "hello", "2.0"); err != nil || m.Slot != "2" {
+		t.Errorf("cached GetEbuildMetadata() = %+v, %v", m, err)
+	}
+	if len(requests) != 1 {
+		t.Errorf("requests = %v, want only the conditional tree request", requests)
+	}
+}
+
+func TestGitLabProvider_GetEbuildMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/projects/test/repo/repository/commits/HEAD":
+			json.NewEncoder(w).Encode(map[string]string{"id": "c1"})
+		case "/api/v4/projects/test/repo/repository/tree":
+			json.NewEncoder(w).Encode([]GitLabTreeEntry{
+				{Type: "blob", Path: "app-misc/hello/hello-1.0.ebuild", ID: "blob1"},
+			})
+		case "/api/v4/projects/test/repo/repository/blobs/blob1/raw":
+			w.Write([]byte("SLOT=\"1/1.0\"\nKEYWORDS=\"amd64 ~arm64\"\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	prov, err := NewGitLabProvider(&RepositoryInfo{Name: "test", Provider: "gitlab", URL: "test/repo"})
+	if err != nil {
+		t.Fatalf("NewGitLabProvider failed: %v", err)
+	}
+	prov.BaseURL = server.URL
+	prov.CacheDir = ""
+
+	m, err := prov.GetEbuildMetadata("app-misc", "hello", "1.0")
+	if err != nil {
+		t.Fatalf("GetEbuildMetadata() error = %v", err)
+	}
+	if m.Slot != "1" || m.KeywordLevel("arm64") != ebuild.Testing {
+		t.Errorf("GetEbuildMetadata() = %+v", m)
+	}
+}
+
+func TestLocalProvider_GetEbuildMetadata(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"dev-lang/python/python-3.12.4.ebuild": "SLOT=\"$(ver_cut 1-2)\"\nKEYWORDS=\"amd64\"\n",
+	})
+
+	prov := &LocalProvider{Path: root, RepoName: "gentoo"}
+	m, err := prov.GetEbuildMetadata("dev-lang", "python", "3.12.4")
+	if err != nil || m.Slot != "3.12" || m.KeywordLevel("amd64") != ebuild.Stable {
+		t.Errorf("GetEbuildMetadata() = %+v, %v", m, err)
+	}
+	if _, err := prov.GetEbuildMetadata("dev-lang", "python", "3.13.0"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetEbuildMetadata(missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,15 @@
+package provider
+
+import "net/http"
+
+// offlineClient is the HTTP client of providers in offline mode. Every
+// request fails with ErrOffline, whichever code path makes it.
+var offlineClient = &http.Client{Transport: offlineTransport{}}
+
+// offlineTransport refuses every request. The client reports the URL.
+type offlineTransport struct{}
+
+// RoundTrip fails with ErrOffline
+func (offlineTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, ErrOffline
+}
@@ -0,0 +1,207 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PullRequest describes a pull request (merge request on GitLab) to open
+type PullRequest struct {
+	Title    string
+	Body     string
+	Head     string // Source branch
+	HeadRepo string // Fork holding the source branch (owner/repo); empty for the same repository
+	Base     string // Target branch
+	Draft    bool
+}
+
+// PullRequestResult identifies an opened pull request
+type PullRequestResult struct {
+	Number int    // Pull request number (merge request IID on GitLab)
+	URL    string // Web URL of the pull request
+}
+
+// PullRequestCreator is implemented by providers that can open pull requests
+type PullRequestCreator interface {
+	// CreatePullRequest opens a pull request against the provider's repository
+	CreatePullRequest(pr PullRequest) (*PullRequestResult, error)
+}
+
+// NewPullRequestCreator returns the API client for opening pull requests
+// against a GitHub or GitLab repository
+func NewPullRequestCreator(repoInfo *RepositoryInfo) (PullRequestCreator, error) {
+	if repoInfo == nil {
+		return nil, ErrRepositoryNotFound
+	}
+
+	switch repoInfo.Provider {
+	case "github", "":
+		return NewGitHubProvider(repoInfo)
+	case "gitlab":
+		p, err := NewGitLabProvider(repoInfo)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	default:
+		return nil, fmt.Errorf("%w: %s does not support pull requests", ErrInvalidProvider, repoInfo.Provider)
+	}
+}
+
+// CreatePullRequest opens a pull request with the GitHub Pulls API
+func (p *GitHubProvider) CreatePullRequest(pr PullRequest) (*PullRequestResult, error) {
+	head := pr.Head
+	if pr.HeadRepo != "" {
+		// Cross-repository pull requests name the head as owner:branch
+		owner, _, _ := strings.Cut(pr.HeadRepo, "/")
+		head = owner + ":" + pr.Head
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"title": pr.Title,
+		"body":  pr.Body,
+		"head":  head,
+		"base":  pr.Base,
+		"draft": pr.Draft,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := fmt.Sprintf("%s/repos/%s/pulls", p.BaseURL, p.Repository)
+	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", p.UserAgent)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Content-Type", "application/json")
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+
+	var result struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := doJSON(p.client(), req, http.StatusCreated, &result); err != nil {
+		return nil, err
+	}
+
+	return &PullRequestResult{Number: result.Number, URL: result.HTMLURL}, nil
+}
+
+// CreatePullRequest opens a merge request with the GitLab Merge Requests API.
+// For a fork, the request is created on the fork project and targets this
+// provider's project. Drafts are marked with the "Draft:" title prefix.
+func (p *GitLabProvider) CreatePullRequest(pr PullRequest) (*PullRequestResult, error) {
+	title := pr.Title
+	if pr.Draft {
+		title = "Draft: " + title
+	}
+
+	fields := map[string]any{
+		"title":         title,
+		"description":   pr.Body,
+		"source_branch": pr.Head,
+		"target_branch": pr.Base,
+	}
+
+	sourceProject := p.ProjectID
+	if pr.HeadRepo != "" {
+		targetID, err := p.projectID()
+		if err != nil {
+			return nil, err
+		}
+		fields["target_project_id"] = targetID
+		sourceProject = url.PathEscape(pr.HeadRepo)
+	}
+
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests", p.BaseURL, sourceProject)
+	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	p.setHeaders(req)
+
+	var result struct {
+		IID    int    `json:"iid"`
+		WebURL string `json:"web_url"`
+	}
+	if err := doJSON(p.client(), req, http.StatusCreated, &result); err != nil {
+		return nil, err
+	}
+
+	return &PullRequestResult{Number: result.IID, URL: result.WebURL}, nil
+}
+
+// projectID resolves the numeric ID of the provider's project
+func (p *GitLabProvider) projectID() (int, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/v4/projects/%s", p.BaseURL, p.ProjectID), nil)
+	if err != nil {
+		return 0, err
+	}
+	p.setHeaders(req)
+
+	var project struct {
+		ID int `json:"id"`
+	}
+	if err := doJSON(p.client(), req, http.StatusOK, &project); err != nil {
+		return 0, err
+	}
+	return project.ID, nil
+}
+
+// setHeaders sets the user agent and, if configured, the access token
+func (p *GitLabProvider) setHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", p.UserAgent)
+	if p.Token != "" {
+		req.Header.Set("PRIVATE-TOKEN", p.Token)
+	}
+}
+
+// doJSON performs a request and decodes a JSON response with the expected status
+func doJSON(client *http.Client, req *http.Request, expected int, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimit
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrRepositoryNotFound, req.URL.Path)
+	case resp.StatusCode != expected:
+		return fmt.Errorf("%w: status %d: %s", ErrAPIError, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: invalid response: %v", ErrAPIError, err)
+	}
+	return nil
+}
+
+// Ensure the API providers can open pull requests
+var (
+	_ PullRequestCreator = (*GitHubProvider)(nil)
+	_ PullRequestCreator = (*GitLabProvider)(nil)
+)
@@ -0,0 +1,181 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitHubProvider_CreatePullRequest(t *testing.T) {
+	var path, auth string
+	var payload map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&payload)
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{
+			"number":   42,
+			"html_url": "https://github.com/obentoo/bentoo/pull/42",
+		})
+	}))
+	defer server.Close()
+
+	prov, _ := NewGitHubProvider(&RepositoryInfo{Name: "test", URL: "obentoo/bentoo", Token: "ghp_test"})
+	prov.BaseURL = server.URL
+	prov.CacheDir = ""
+
+	result, err := prov.CreatePullRequest(PullRequest{
+		Title:    "add(app-misc/hello-1.0)",
+		Body:     "## Changes",
+		Head:     "bentoo/add-app-misc-hello-1.0",
+		HeadRepo: "contributor/bentoo",
+		Base:     "master",
+		Draft:    true,
+	})
+	if err != nil {
+		t.Fatalf("CreatePullRequest failed: %v", err)
+	}
+
+	if result.Number != 42 || result.URL != "https://github.com/obentoo/bentoo/pull/42" {
+		t.Errorf("result = %+v", result)
+	}
+	if path != "/repos/obentoo/bentoo/pulls" {
+		t.Errorf("path = %q, want the upstream pulls endpoint", path)
+	}
+	if auth != "Bearer ghp_test" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if payload["head"] != "contributor:bentoo/add-app-misc-hello-1.0" {
+		t.Errorf("head = %v, want fork owner prefix", payload["head"])
+	}
+	if payload["base"] != "master" || payload["draft"] != true || payload["title"] != "add(app-misc/hello-1.0)" {
+		t.Errorf("payload = %v", payload)
+	}
+}
+
+func TestGitHubProvider_CreatePullRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr error
+	}{
+		{"validation failed", http.StatusUnprocessableEntity, ErrAPIError},
+		{"rate limited", http.StatusTooManyRequests, ErrRateLimit},
+		{"unknown repository", http.StatusNotFound, ErrRepositoryNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"message":"failed"}`))
+			}))
+			defer server.Close()
+
+			prov, _ := NewGitHubProvider(&RepositoryInfo{Name: "test", URL: "obentoo/bentoo"})
+			prov.BaseURL = server.URL
+			prov.CacheDir = ""
+
+			_, err := prov.CreatePullRequest(PullRequest{Title: "t", Head: "topic", Base: "master"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGitLabProvider_CreatePullRequest(t *testing.T) {
+	var mrPath string
+	var payload map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.EscapedPath() == "/api/v4/projects/obentoo%2Fbentoo":
+			json.NewEncoder(w).Encode(map[string]any{"id": 1234})
+		case r.Method == "POST":
+			mrPath = r.URL.EscapedPath()
+			json.NewDecoder(r.Body).Decode(&payload)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]any{
+				"iid":     7,
+				"web_url": "https://gitlab.com/obentoo/bentoo/-/merge_requests/7",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	newProvider := func() *GitLabProvider {
+		prov, err := NewGitLabProvider(&RepositoryInfo{Name: "test", Provider: "gitlab", URL: "obentoo/bentoo"})
+		if err != nil {
+			t.Fatalf("NewGitLabProvider failed: %v", err)
+		}
+		prov.BaseURL = server.URL
+		prov.CacheDir = ""
+		return prov
+	}
+
+	t.Run("from fork", func(t *testing.T) {
+		result, err := newProvider().CreatePullRequest(PullRequest{
+			Title:    "add(app-misc/hello-1.0)",
+			Head:     "topic",
+			HeadRepo: "contributor/bentoo",
+			Base:     "master",
+			Draft:    true,
+		})
+		if err != nil {
+			t.Fatalf("CreatePullRequest failed: %v", err)
+		}
+
+		if result.Number != 7 || result.URL != "https://gitlab.com/obentoo/bentoo/-/merge_requests/7" {
+			t.Errorf("result = %+v", result)
+		}
+		if mrPath != "/api/v4/projects/contributor%2Fbentoo/merge_requests" {
+			t.Errorf("merge request created on %q, want the fork project", mrPath)
+		}
+		if payload["target_project_id"] != float64(1234) {
+			t.Errorf("target_project_id = %v, want 1234", payload["target_project_id"])
+		}
+		if payload["title"] != "Draft: add(app-misc/hello-1.0)" {
+			t.Errorf("title = %v, want draft prefix", payload["title"])
+		}
+		if payload["source_branch"] != "topic" || payload["target_branch"] != "master" {
+			t.Errorf("payload = %v", payload)
+		}
+	})
+
+	t.Run("same project", func(t *testing.T) {
+		payload = nil
+		if _, err := newProvider().CreatePullRequest(PullRequest{Title: "t", Head: "topic", Base: "master"}); err != nil {
+			t.Fatalf("CreatePullRequest failed: %v", err)
+		}
+		if mrPath != "/api/v4/projects/obentoo%2Fbentoo/merge_requests" {
+			t.Errorf("merge request created on %q, want the upstream project", mrPath)
+		}
+		if _, ok := payload["target_project_id"]; ok {
+			t.Errorf("target_project_id set for a same-project merge request")
+		}
+	})
+}
+
+func TestNewPullRequestCreator(t *testing.T) {
+	if _, err := NewPullRequestCreator(&RepositoryInfo{Provider: "git", URL: "https://example.com/repo.git"}); !errors.Is(err, ErrInvalidProvider) {
+		t.Errorf("git provider error = %v, want ErrInvalidProvider", err)
+	}
+	if _, err := NewPullRequestCreator(nil); !errors.Is(err, ErrRepositoryNotFound) {
+		t.Errorf("nil repository error = %v, want ErrRepositoryNotFound", err)
+	}
+	if c, err := NewPullRequestCreator(&RepositoryInfo{Provider: "gitlab", URL: "obentoo/bentoo"}); err != nil || c == nil {
+		t.Errorf("gitlab creator = %v, %v", c, err)
+	}
+}
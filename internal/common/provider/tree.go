@@ -0,0 +1,167 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+)
+
+// treeCacheFile is the name of the tree index cache in a provider's CacheDir
+const treeCacheFile = "tree.json"
+
+// treeIndexFormat is the version of the cached index layout. Caches of
+// another format are rebuilt.
+const treeIndexFormat = 2
+
+// errNotModified reports a conditional request answered with 304 Not Modified
+var errNotModified = errors.New("not modified")
+
+// nonCategoryDirs are top-level repository directories that hold no packages
+var nonCategoryDirs = map[string]bool{
+	"eclass":    true,
+	"licenses":  true,
+	"metadata":  true,
+	"profiles":  true,
+	"scripts":   true,
+	"distfiles": true,
+	"packages":  true,
+}
+
+// isCategoryDir reports whether a top-level directory may be a category
+func isCategoryDir(name string) bool {
+	return !strings.HasPrefix(name, ".") && !nonCategoryDirs[name]
+}
+
+// treeIndex holds the ebuild versions of every package in a repository,
+// built from listings of its tree
+type treeIndex struct {
+	Format     int                       `json:"format"`
+	ETag       string                    `json:"etag,omitempty"`    // ETag of the listing response
+	SHA        string                    `json:"sha"`               // Tree or commit the index was built from
+	Partial    bool                      `json:"partial,omitempty"` // Listing stopped before all categories were indexed
+	Categories map[string]*categoryIndex `json:"categories"`
+	Timestamp  time.Time                 `json:"timestamp"`
+}
+
+// categoryIndex holds the packages of one category. SHA is the category's
+// tree object, so an unchanged category can be reused without listing it.
+// Blobs maps package/version to the blob of its ebuild.
+type categoryIndex struct {
+	SHA      string              `json:"sha,omitempty"`
+	Packages map[string][]string `json:"packages"`
+	Blobs    map[string]string   `json:"blobs,omitempty"`
+}
+
+// newTreeIndex creates an empty index for the given tree or commit
+func newTreeIndex(sha string) *treeIndex {
+	return &treeIndex{
+		Format:     treeIndexFormat,
+		SHA:        sha,
+		Categories: make(map[string]*categoryIndex),
+		Timestamp:  time.Now(),
+	}
+}
+
+// category returns the index of a category, creating it if needed
+func (t *treeIndex) category(name string) *categoryIndex {
+	c, ok := t.Categories[name]
+	if !ok {
+		c = &categoryIndex{
+			Packages: make(map[string][]string),
+			Blobs:    make(map[string]string),
+		}
+		t.Categories[name] = c
+	}
+	return c
+}
+
+// add records one entry of a recursive tree listing. isTree reports whether
+// the entry is a directory and sha is its object name. Top-level directories
+// record their category tree; category/package/package-version.ebuild files
+// record a version and its blob. All other entries are ignored.
+func (t *treeIndex) add(path string, isTree bool, sha string) {
+	if isTree {
+		if !strings.Contains(path, "/") && isCategoryDir(path) {
+			t.category(path).SHA = sha
+		}
+		return
+	}
+
+	if strings.Count(path, "/") != 2 || !strings.HasSuffix(path, ".ebuild") {
+		return
+	}
+	eb, err := ebuild.ParsePath(path)
+	if err != nil {
+		return
+	}
+	c := t.category(eb.Category)
+	c.Packages[eb.Package] = append(c.Packages[eb.Package], eb.Version)
+	if sha != "" {
+		c.Blobs[eb.Package+"/"+eb.Version] = sha
+	}
+}
+
+// versions returns the ebuild versions of a package, or ErrNotFound
+func (t *treeIndex) versions(category, pkg string) ([]string, error) {
+	c, ok := t.Categories[category]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	versions, ok := c.Packages[pkg]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return versions, nil
+}
+
+// blob returns the blob of a package version's ebuild, or ErrNotFound
+func (t *treeIndex) blob(category, pkg, version string) (string, error) {
+	c, ok := t.Categories[category]
+	if !ok {
+		return "", ErrNotFound
+	}
+	sha, ok := c.Blobs[pkg+"/"+version]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return sha, nil
+}
+
+// loadTreeIndex reads the cached index from dir. It returns nil when there is
+// no usable cache.
+func loadTreeIndex(dir string) *treeIndex {
+	if dir == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, treeCacheFile))
+	if err != nil {
+		return nil
+	}
+
+	var index treeIndex
+	if err := json.Unmarshal(data, &index); err != nil || index.Format != treeIndexFormat || index.Categories == nil {
+		return nil
+	}
+	return &index
+}
+
+// save writes the index to dir. Failures are ignored, as the cache only
+// saves requests.
+func (t *treeIndex) save(dir string) {
+	if dir == "" {
+		return
+	}
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return
+	}
+	_ = os.MkdirAll(dir, 0755)
+	_ = os.WriteFile(filepath.Join(dir, treeCacheFile), data, 0644)
+}
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestTreeIndexVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		paths    []string
+		pkg      string
+		expected []string
+	}{
+		{
+			name:     "normal versions",
+			paths:    []string{"app-misc/hello/hello-1.0.ebuild", "app-misc/hello/hello-2.0.ebuild", "app-misc/hello/hello-2.0_rc1.ebuild"},
+			pkg:      "hello",
+			expected: []string{"1.0", "2.0", "2.0_rc1"},
+		},
+		{
+			name:     "with other files",
+			paths:    []string{"app-misc/hello/hello-1.0.ebuild", "app-misc/hello/files/hello-9.0.ebuild", "app-misc/hello/metadata.xml"},
+			pkg:      "hello",
+			expected: []string{"1.0"},
+		},
+		{
+			name:     "complex version",
+			paths:    []string{"app-misc/vscode/vscode-1.107.1.ebuild", "app-misc/vscode/vscode-1.107.1-r1.ebuild"},
+			pkg:      "vscode",
+			expected: []string{"1.107.1", "1.107.1-r1"},
+		},
+		{
+			name:     "different package names",
+			paths:    []string{"app-misc/vim/vim-9.0.ebuild", "app-misc/vim/vim-core-9.0.ebuild"},
+			pkg:      "vim",
+			expected: []string{"9.0"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			index := newTreeIndex("sha")
+			for _, path := range tc.paths {
+				index.add(path, false, "")
+			}
+
+			versions, err := index.versions("app-misc", tc.pkg)
+			if err != nil {
+				t.Fatalf("versions() error = %v", err)
+			}
+			if len(versions) != len(tc.expected) {
+				t.Errorf("Expected %d versions, got %d: %v", len(tc.expected), len(versions), versions)
+				return
+			}
+
+			for i, v := range versions {
+				if v != tc.expected[i] {
+					t.Errorf("Version %d: expected %s, got %s", i, tc.expected[i], v)
+				}
+			}
+		})
+	}
+}
+
+func TestTreeIndexCache(t *testing.T) {
+	dir := t.TempDir()
+	if loadTreeIndex(dir) != nil {
+		t.Fatal("loadTreeIndex() of an empty directory returned an index")
+	}
+
+	index := newTreeIndex("abc")
+	index.ETag = `"etag"`
+	index.add("app-misc", true, "tree1")
+	index.add("profiles", true, "tree2")
+	index.add("app-misc/hello/hello-1.0.ebuild", false, "blob")
+	index.save(dir)
+
+	loaded := loadTreeIndex(dir)
+	if loaded == nil || loaded.SHA != "abc" || loaded.ETag != `"etag"` {
+		t.Fatalf("loadTreeIndex() = %+v", loaded)
+	}
+	if c := loaded.Categories["app-misc"]; c == nil || c.SHA != "tree1" {
+		t.Errorf("app-misc = %+v, want its tree SHA", c)
+	}
+	if _, ok := loaded.Categories["profiles"]; ok {
+		t.Error("profiles was indexed as a category")
+	}
+	if versions, err := loaded.versions("app-misc", "hello"); err != nil || len(versions) != 1 {
+		t.Errorf("versions() = %v, %v", versions, err)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/obentoo/bentoolkit/internal/common/config"
-	"github.com/obentoo/bentoolkit/internal/common/git"
 )
 
 // AddResult contains the result of an Add operation
@@ -18,12 +17,11 @@ type AddResult struct {
 // When paths are provided, only those specific paths are staged.
 // Returns a structured result with added files and any errors encountered.
 func AddFiles(cfg *config.Config, paths ...string) (*AddResult, error) {
-	overlayPath, err := cfg.GetOverlayPath()
+	runner, err := NewExecutor(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	runner := git.NewGitRunner(overlayPath)
 	result := &AddResult{
 		Added:  []string{},
 		Errors: []error{},
@@ -0,0 +1,390 @@
+package overlay
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+var (
+	// ErrEmptySince indicates that no starting point was given for a changelog
+	ErrEmptySince = errors.New("changelog requires a starting ref or date")
+)
+
+// emptyTree is git's well-known empty tree, diffed against for root commits
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// changeTypeOrder is the order change types are listed in
+var changeTypeOrder = []ChangeType{Add, Del, Mod, Up, Down}
+
+// changeTypeTitles are the section titles used for each change type
+var changeTypeTitles = map[ChangeType]string{
+	Add:  "Added",
+	Del:  "Removed",
+	Mod:  "Modified",
+	Up:   "Updated",
+	Down: "Downgraded",
+}
+
+// ChangelogItem is a package change, deduplicated across the changelog range
+type ChangelogItem struct {
+	Change
+	Date    time.Time // Date of the latest commit contributing to the change
+	Commits []string  // Contributing commits, oldest first
+}
+
+// Changelog holds the package changes made since a ref or date
+type Changelog struct {
+	Since   string          // Ref or YYYY-MM-DD date the changelog starts from
+	Commits int             // Number of commits in the range
+	Items   []ChangelogItem // Changes in order of first appearance
+}
+
+// ChangelogGroup holds the changes of one type within a category
+type ChangelogGroup struct {
+	Type  ChangeType
+	Title string
+	Items []ChangelogItem
+}
+
+// ChangelogCategory holds the changes to one category on a given day
+type ChangelogCategory struct {
+	Name   string
+	Groups []ChangelogGroup
+}
+
+// ChangelogDay holds the changes dated on a given day
+type ChangelogDay struct {
+	Date       string    // YYYY-MM-DD
+	Updated    time.Time // Latest commit date of the day
+	Categories []ChangelogCategory
+}
+
+// Days groups the changelog by date (newest first), category and change type
+func (c *Changelog) Days() []ChangelogDay {
+	byDay := make(map[string][]ChangelogItem)
+	for _, item := range c.Items {
+		day := item.Date.Format(time.DateOnly)
+		byDay[day] = append(byDay[day], item)
+	}
+
+	dates := make([]string, 0, len(byDay))
+	for d := range byDay {
+		dates = append(dates, d)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+
+	var days []ChangelogDay
+	for _, d := range dates {
+		items := byDay[d]
+		day := ChangelogDay{Date: d}
+
+		byCategory := make(map[string][]ChangelogItem)
+		for _, item := range items {
+			byCategory[item.Category] = append(byCategory[item.Category], item)
+			if item.Date.After(day.Updated) {
+				day.Updated = item.Date
+			}
+		}
+
+		categories := make([]string, 0, len(byCategory))
+		for cat := range byCategory {
+			categories = append(categories, cat)
+		}
+		sort.Strings(categories)
+
+		for _, cat := range categories {
+			category := ChangelogCategory{Name: cat}
+			for _, ct := range changeTypeOrder {
+				group := ChangelogGroup{Type: ct, Title: changeTypeTitles[ct]}
+				for _, item := range byCategory[cat] {
+					if item.Type == ct {
+						group.Items = append(group.Items, item)
+					}
+				}
+				if len(group.Items) > 0 {
+					sort.SliceStable(group.Items, func(i, j int) bool {
+						return group.Items[i].Package < group.Items[j].Package
+					})
+					category.Groups = append(category.Groups, group)
+				}
+			}
+			day.Categories = append(day.Categories, category)
+		}
+
+		days = append(days, day)
+	}
+
+	return days
+}
+
+// BuildChangelog collects the package changes made since a ref or date
+func BuildChangelog(cfg *config.Config, since string) (*Changelog, error) {
+	runner, err := NewExecutor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildChangelogWithExecutor(runner, since)
+}
+
+// BuildChangelogWithExecutor collects changes using the provided GitExecutor.
+// since is either a YYYY-MM-DD date or a ref; for a ref, the range is ref..HEAD.
+// Commits are classified by their subject when it uses the bentoo grammar and
+// by the files they change otherwise.
+func BuildChangelogWithExecutor(executor git.GitExecutor, since string) (*Changelog, error) {
+	if since == "" {
+		return nil, ErrEmptySince
+	}
+
+	var commits []git.CommitInfo
+	if date, err := time.ParseInLocation(time.DateOnly, since, time.Local); err == nil {
+		all, err := executor.Log("", 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range all {
+			if !c.Date.Before(date) {
+				commits = append(commits, c)
+			}
+		}
+	} else {
+		if _, err := executor.RevParse(since); err != nil {
+			return nil, err
+		}
+		if commits, err = executor.Log(since+"..HEAD", 0); err != nil {
+			return nil, err
+		}
+	}
+
+	cl := &Changelog{Since: since, Commits: len(commits)}
+
+	// Walk oldest first so bumps chain in order
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		changes, err := classifyCommit(executor, c)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range changes {
+			cl.add(change, c)
+		}
+	}
+
+	return cl, nil
+}
+
+// classifyCommit returns the changes made by a commit, parsed from its subject
+// or, for hand-written subjects, derived from the files it changes
+func classifyCommit(executor git.GitExecutor, c git.CommitInfo) ([]Change, error) {
+	if changes, err := ParseMessage(c.Subject); err == nil && changes != nil {
+		return changes, nil
+	}
+
+	entries, err := commitDiff(executor, c.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return AnalyzeChanges(entries), nil
+}
+
+// commitDiff returns the files a commit changes relative to its first parent,
+// or to the empty tree for a root commit
+func commitDiff(executor git.GitExecutor, hash string) ([]git.StatusEntry, error) {
+	parent := hash + "^"
+	if _, err := executor.RevParse(parent); err != nil {
+		parent = emptyTree
+	}
+	return executor.DiffNameStatus(parent, hash)
+}
+
+// add records a change, merging chained bumps of a package into a single
+// old -> newest entry and dropping exact duplicates
+func (cl *Changelog) add(change Change, commit git.CommitInfo) {
+	for i := range cl.Items {
+		item := &cl.Items[i]
+		if item.Category != change.Category || item.Package != change.Package {
+			continue
+		}
+
+		merged := false
+		switch {
+		case item.Change == change:
+			merged = true
+		case (change.Type == Up || change.Type == Down) && item.Version == change.OldVersion:
+			switch item.Type {
+			case Add:
+				// A package added and bumped within the range is simply new
+				item.Version = change.Version
+				merged = true
+			case Up, Down:
+				item.Version = change.Version
+				if ebuild.CompareVersions(item.Version, item.OldVersion) < 0 {
+					item.Type = Down
+				} else {
+					item.Type = Up
+				}
+				merged = true
+			}
+		}
+
+		if merged {
+			item.Commits = append(item.Commits, commit.Hash)
+			if commit.Date.After(item.Date) {
+				item.Date = commit.Date
+			}
+			if (item.Type == Up || item.Type == Down) && item.Version == item.OldVersion {
+				// Bumped and reverted within the range
+				cl.Items = append(cl.Items[:i], cl.Items[i+1:]...)
+			}
+			return
+		}
+	}
+
+	cl.Items = append(cl.Items, ChangelogItem{Change: change, Date: commit.Date, Commits: []string{commit.Hash}})
+}
+
+// formatChangelogItem formats an item without its category
+func formatChangelogItem(item ChangelogItem) string {
+	if item.Type == Up || item.Type == Down {
+		return fmt.Sprintf("%s %s -> %s", item.Package, item.OldVersion, item.Version)
+	}
+	return fmt.Sprintf("%s %s", item.Package, item.Version)
+}
+
+// FormatChangelogMarkdown renders a changelog as Markdown
+func FormatChangelogMarkdown(cl *Changelog, title string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# %s\n\n", title))
+	sb.WriteString(fmt.Sprintf("Changes since %s (%d commit(s)).\n", cl.Since, cl.Commits))
+
+	days := cl.Days()
+	if len(days) == 0 {
+		sb.WriteString("\nNo package changes.\n")
+		return sb.String()
+	}
+
+	for _, day := range days {
+		sb.WriteString(fmt.Sprintf("\n## %s\n", day.Date))
+		for _, cat := range day.Categories {
+			sb.WriteString(fmt.Sprintf("\n### %s\n\n", cat.Name))
+			for _, group := range cat.Groups {
+				sb.WriteString(fmt.Sprintf("**%s**\n\n", group.Title))
+				for _, item := range group.Items {
+					sb.WriteString(fmt.Sprintf("- %s\n", formatChangelogItem(item)))
+				}
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// changelogTemplates renders HTML changelogs; "day" is shared with Atom entries
+var changelogTemplates = template.Must(template.New("changelog").Funcs(template.FuncMap{
+	"item": formatChangelogItem,
+}).Parse(`{{define "day"}}{{range .Categories}}<h3>{{.Name}}</h3>
+{{range .Groups}}<h4>{{.Title}}</h4>
+<ul>
+{{range .Items}}<li>{{item .}}</li>
+{{end}}</ul>
+{{end}}{{end}}{{end}}{{define "page"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Changes since {{.Changelog.Since}} ({{.Changelog.Commits}} commit(s)).</p>
+{{range .Days}}<h2>{{.Date}}</h2>
+{{template "day" .}}{{else}}<p>No package changes.</p>
+{{end}}</body>
+</html>
+{{end}}`))
+
+// FormatChangelogHTML renders a changelog as a standalone HTML page
+func FormatChangelogHTML(cl *Changelog, title string) (string, error) {
+	var buf bytes.Buffer
+	err := changelogTemplates.ExecuteTemplate(&buf, "page", struct {
+		Title     string
+		Changelog *Changelog
+		Days      []ChangelogDay
+	}{title, cl, cl.Days()})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// atomFeed is the Atom (RFC 4287) document written by FormatChangelogAtom
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// FormatChangelogAtom renders a changelog as an Atom feed with one entry per day
+func FormatChangelogAtom(cl *Changelog, title string) (string, error) {
+	feed := atomFeed{
+		Title:  title,
+		ID:     "urn:bentoo:changelog",
+		Author: atomAuthor{Name: title},
+	}
+
+	var updated time.Time
+	for _, day := range cl.Days() {
+		var body bytes.Buffer
+		if err := changelogTemplates.ExecuteTemplate(&body, "day", day); err != nil {
+			return "", err
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:   "Changes on " + day.Date,
+			ID:      "urn:bentoo:changelog:" + day.Date,
+			Updated: day.Updated.Format(time.RFC3339),
+			Content: atomContent{Type: "html", Body: body.String()},
+		})
+		if day.Updated.After(updated) {
+			updated = day.Updated
+		}
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	feed.Updated = updated.Format(time.RFC3339)
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data) + "\n", nil
+}
@@ -0,0 +1,232 @@
+package overlay
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+// changelogMock returns a mock whose history holds the given commits, newest
+// first, with c1 as the root commit
+func changelogMock(commits []git.CommitInfo, diffs map[string][]git.StatusEntry) *git.MockGitRunner {
+	mock := git.NewMockGitRunner("/test/overlay")
+	mock.LogFunc = func(rev string, maxCount int) ([]git.CommitInfo, error) {
+		if rev == "" {
+			return commits, nil
+		}
+		// ref..HEAD: everything newer than ref
+		from := strings.TrimSuffix(rev, "..HEAD")
+		for i, c := range commits {
+			if c.Hash == from {
+				return commits[:i], nil
+			}
+		}
+		return commits, nil
+	}
+	mock.RevParseFunc = func(rev string) (string, error) {
+		if rev == "c1^" || rev == "missing" {
+			return "", git.ErrGitCommand
+		}
+		return rev, nil
+	}
+	mock.DiffNameStatusFunc = func(from, to string) ([]git.StatusEntry, error) {
+		return diffs[to], nil
+	}
+	return mock
+}
+
+func changelogHistory() ([]git.CommitInfo, map[string][]git.StatusEntry) {
+	at := func(s string) time.Time {
+		d, _ := time.Parse(time.RFC3339, s)
+		return d
+	}
+	commits := []git.CommitInfo{
+		{Hash: "c6", Date: at("2025-06-05T18:00:00Z"), Subject: "up(www-client/firefox{,-bin}-131.0 -> 132.0)"},
+		{Hash: "c5", Date: at("2025-06-05T09:00:00Z"), Subject: "Bump nss"},
+		{Hash: "c4", Date: at("2025-06-04T12:00:00Z"), Subject: "up(dev-python/new-1.0 -> 1.1, www-client/firefox-130.0 -> 131.0)"},
+		{Hash: "c3", Date: at("2025-06-03T12:00:00Z"), Subject: "add(dev-python/new-1.0), up(dev-python/flip-1.0 -> 2.0)"},
+		{Hash: "c2", Date: at("2025-06-02T12:00:00Z"), Subject: "down(dev-python/flip-2.0 -> 1.0), add(www-client/firefox-bin-131.0)"},
+		{Hash: "c1", Date: at("2025-06-01T12:00:00Z"), Subject: "Initial import"},
+	}
+	diffs := map[string][]git.StatusEntry{
+		"c5": {
+			{Status: "R", Index: git.StatusRenamed, FilePath: "dev-libs/nss/nss-3.2.ebuild", OrigPath: "dev-libs/nss/nss-3.1.ebuild"},
+		},
+		"c1": {
+			{Status: "A", Index: git.StatusAdded, FilePath: "www-client/firefox/firefox-130.0.ebuild"},
+		},
+	}
+	return commits, diffs
+}
+
+func TestBuildChangelogDeduplicatesBumps(t *testing.T) {
+	commits, diffs := changelogHistory()
+	cl, err := BuildChangelogWithExecutor(changelogMock(commits, diffs), "c1")
+	if err != nil {
+		t.Fatalf("BuildChangelogWithExecutor() error = %v", err)
+	}
+
+	if cl.Commits != 5 {
+		t.Errorf("Commits = %d, want 5", cl.Commits)
+	}
+
+	var got []string
+	for _, item := range cl.Items {
+		got = append(got, string(item.Type)+" "+item.Category+"/"+formatChangelogItem(item))
+	}
+	// flip was bumped and reverted; new was added then bumped; firefox-bin was
+	// added then bumped; firefox's bumps chain into one entry
+	want := []string{
+		"add www-client/firefox-bin 132.0",
+		"add dev-python/new 1.1",
+		"up www-client/firefox 130.0 -> 132.0",
+		"up dev-libs/nss 3.1 -> 3.2",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("items:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	for _, item := range cl.Items {
+		if item.Package == "firefox" {
+			if strings.Join(item.Commits, ",") != "c4,c6" {
+				t.Errorf("firefox commits = %v, want [c4 c6]", item.Commits)
+			}
+			if item.Date.Format(time.DateOnly) != "2025-06-05" {
+				t.Errorf("firefox date = %v, want the latest bump", item.Date)
+			}
+		}
+	}
+}
+
+func TestBuildChangelogSinceDate(t *testing.T) {
+	commits, diffs := changelogHistory()
+	cl, err := BuildChangelogWithExecutor(changelogMock(commits, diffs), "2025-06-04")
+	if err != nil {
+		t.Fatalf("BuildChangelogWithExecutor() error = %v", err)
+	}
+	if cl.Commits != 3 {
+		t.Errorf("Commits = %d, want 3", cl.Commits)
+	}
+}
+
+func TestBuildChangelogRootCommit(t *testing.T) {
+	commits, diffs := changelogHistory()
+	var from string
+	mock := changelogMock(commits, diffs)
+	mock.DiffNameStatusFunc = func(f, to string) ([]git.StatusEntry, error) {
+		if to == "c1" {
+			from = f
+		}
+		return diffs[to], nil
+	}
+
+	cl, err := BuildChangelogWithExecutor(mock, "2025-01-01")
+	if err != nil {
+		t.Fatalf("BuildChangelogWithExecutor() error = %v", err)
+	}
+	if from != emptyTree {
+		t.Errorf("root commit diffed against %q, want the empty tree", from)
+	}
+	if cl.Items[0].Type != Add || cl.Items[0].Package != "firefox" || cl.Items[0].Version != "132.0" {
+		t.Errorf("first item = %+v, want firefox added at its newest version", cl.Items[0])
+	}
+}
+
+func TestBuildChangelogErrors(t *testing.T) {
+	commits, diffs := changelogHistory()
+	mock := changelogMock(commits, diffs)
+
+	if _, err := BuildChangelogWithExecutor(mock, ""); !errors.Is(err, ErrEmptySince) {
+		t.Errorf("empty since error = %v, want ErrEmptySince", err)
+	}
+	if _, err := BuildChangelogWithExecutor(mock, "missing"); !errors.Is(err, git.ErrGitCommand) {
+		t.Errorf("unknown ref error = %v, want ErrGitCommand", err)
+	}
+}
+
+func TestChangelogDays(t *testing.T) {
+	commits, diffs := changelogHistory()
+	cl, _ := BuildChangelogWithExecutor(changelogMock(commits, diffs), "c1")
+
+	days := cl.Days()
+	if len(days) != 2 || days[0].Date != "2025-06-05" || days[1].Date != "2025-06-04" {
+		t.Fatalf("days = %+v, want 2025-06-05 then 2025-06-04", days)
+	}
+
+	var categories []string
+	for _, cat := range days[0].Categories {
+		categories = append(categories, cat.Name)
+	}
+	if strings.Join(categories, ",") != "dev-libs,www-client" {
+		t.Errorf("categories = %v, want sorted", categories)
+	}
+
+	www := days[0].Categories[1]
+	if len(www.Groups) != 2 || www.Groups[0].Type != Add || www.Groups[1].Type != Up {
+		t.Errorf("www-client groups = %+v, want add then up", www.Groups)
+	}
+}
+
+func TestFormatChangelogMarkdown(t *testing.T) {
+	commits, diffs := changelogHistory()
+	cl, _ := BuildChangelogWithExecutor(changelogMock(commits, diffs), "c1")
+
+	md := FormatChangelogMarkdown(cl, "Weekly")
+	for _, want := range []string{"# Weekly", "Changes since c1 (5 commit(s))", "## 2025-06-05", "### www-client", "**Updated**", "- firefox 130.0 -> 132.0"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	empty := FormatChangelogMarkdown(&Changelog{Since: "HEAD"}, "Weekly")
+	if !strings.Contains(empty, "No package changes.") {
+		t.Errorf("empty changelog = %q", empty)
+	}
+}
+
+func TestFormatChangelogHTML(t *testing.T) {
+	commits, diffs := changelogHistory()
+	cl, _ := BuildChangelogWithExecutor(changelogMock(commits, diffs), "c1")
+
+	html, err := FormatChangelogHTML(cl, "<Weekly>")
+	if err != nil {
+		t.Fatalf("FormatChangelogHTML() error = %v", err)
+	}
+	for _, want := range []string{"<title>&lt;Weekly&gt;</title>", "<h2>2025-06-05</h2>", "<h3>www-client</h3>", "<li>firefox 130.0 -&gt; 132.0</li>"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML missing %q:\n%s", want, html)
+		}
+	}
+}
+
+func TestFormatChangelogAtom(t *testing.T) {
+	commits, diffs := changelogHistory()
+	cl, _ := BuildChangelogWithExecutor(changelogMock(commits, diffs), "c1")
+
+	out, err := FormatChangelogAtom(cl, "Weekly")
+	if err != nil {
+		t.Fatalf("FormatChangelogAtom() error = %v", err)
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal([]byte(out), &feed); err != nil {
+		t.Fatalf("feed is not valid XML: %v\n%s", err, out)
+	}
+	if feed.Title != "Weekly" || feed.Updated != "2025-06-05T18:00:00Z" {
+		t.Errorf("feed = %q updated %q", feed.Title, feed.Updated)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(feed.Entries))
+	}
+	entry := feed.Entries[0]
+	if entry.ID != "urn:bentoo:changelog:2025-06-05" || entry.Content.Type != "html" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if !strings.Contains(entry.Content.Body, "<li>firefox 130.0 -&gt; 132.0</li>") {
+		t.Errorf("entry content = %q", entry.Content.Body)
+	}
+}
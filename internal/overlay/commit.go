@@ -22,11 +22,11 @@ const (
 
 // Change represents a single package change
 type Change struct {
-	Type       ChangeType
-	Category   string
-	Package    string
-	Version    string
-	OldVersion string // for up/down
+	Type       ChangeType `json:"type"`
+	Category   string     `json:"category"`
+	Package    string     `json:"package"`
+	Version    string     `json:"version"`
+	OldVersion string     `json:"old_version,omitempty"` // for up/down
 }
 
 // AnalyzeChanges analyzes git status entries and returns a list of changes
@@ -46,7 +46,7 @@ func AnalyzeChanges(entries []git.StatusEntry) []Change {
 	modifiedEbuilds := make(map[string]*ebuild.Ebuild)
 
 	// First pass: collect all ebuild changes
-	for _, entry := range entries {
+	for _, entry := range expandRenames(entries) {
 		eb, err := ebuild.ParsePath(entry.FilePath)
 		if err != nil {
 			// Not an ebuild file, skip for now
@@ -181,6 +181,24 @@ func AnalyzeChanges(entries []git.StatusEntry) []Change {
 	return changes
 }
 
+// expandRenames adds a delete entry for the source of each rename, so a
+// renamed ebuild is seen as a removed old version plus an added new one
+func expandRenames(entries []git.StatusEntry) []git.StatusEntry {
+	expanded := make([]git.StatusEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.IsRename() {
+			expanded = append(expanded, git.StatusEntry{
+				Status:   "D",
+				FilePath: e.OrigPath,
+				Index:    git.StatusDeleted,
+				Worktree: git.StatusUnmodified,
+			})
+		}
+		expanded = append(expanded, e)
+	}
+	return expanded
+}
+
 // normalizeStatus converts git status codes to single-character codes
 func normalizeStatus(status string) string {
 	status = strings.TrimSpace(status)
@@ -275,24 +293,22 @@ func formatCategoryChanges(category string, changes []Change, ct ChangeType) str
 		return ""
 	}
 
-	// Check for package variants (e.g., firefox and firefox-bin)
-	variants := detectVariants(changes)
-
-	if len(variants) > 0 {
-		return formatVariants(category, variants, ct)
+	// Group package variants (e.g., firefox and firefox-bin); packages
+	// without variants are formatted on their own, in their original order
+	var pkgParts []string
+	for _, g := range detectVariants(changes) {
+		if len(g.suffixes) > 1 {
+			pkgParts = append(pkgParts, formatVariant(g, ct))
+		} else {
+			pkgParts = append(pkgParts, formatPackageVersion(g.changes[0], ct))
+		}
 	}
 
-	// No variants, format normally
-	if len(changes) == 1 {
-		return formatSingleChange(category, changes[0], ct)
+	if len(pkgParts) == 1 {
+		return category + "/" + pkgParts[0]
 	}
 
 	// Multiple packages in same category - use braces
-	var pkgParts []string
-	for _, c := range changes {
-		pkgParts = append(pkgParts, formatPackageVersion(c, ct))
-	}
-
 	return category + "/{" + strings.Join(pkgParts, ", ") + "}"
 }
 
@@ -303,95 +319,65 @@ type variantGroup struct {
 	changes  []Change
 }
 
-// detectVariants detects package variants like firefox/firefox-bin
-func detectVariants(changes []Change) []variantGroup {
-	if len(changes) < 2 {
-		return nil
-	}
-
-	// Group by potential base name
-	// A variant is detected when we have packages like "pkg" and "pkg-bin"
-	pkgMap := make(map[string]Change)
-	for _, c := range changes {
-		pkgMap[c.Package] = c
-	}
+// variantSuffixes are the package name suffixes grouped as variants
+var variantSuffixes = []string{"-bin", "-qt5", "-qt6", "-gtk", "-gtk2", "-gtk3"}
 
-	var groups []variantGroup
-	used := make(map[string]bool)
+// detectVariants partitions changes into groups, in order of first appearance.
+// Packages like "pkg" and "pkg-bin" with the same versions share a group;
+// every other change forms a group of its own.
+func detectVariants(changes []Change) []variantGroup {
+	used := make([]bool, len(changes))
+	heads := make(map[int]variantGroup)
 
-	for _, c := range changes {
-		if used[c.Package] {
+	for i, c := range changes {
+		if used[i] {
 			continue
 		}
+		group := variantGroup{baseName: c.Package, suffixes: []string{""}, changes: []Change{c}}
 
-		// Check for common variant suffixes
-		suffixes := []string{"-bin", "-qt5", "-qt6", "-gtk", "-gtk2", "-gtk3"}
-		var foundVariants []Change
-		var foundSuffixes []string
-
-		for _, suffix := range suffixes {
-			variantName := c.Package + suffix
-			if variant, ok := pkgMap[variantName]; ok && !used[variantName] {
-				// Check if versions match for up/down changes
-				if c.Type == Up || c.Type == Down {
-					if variant.Version == c.Version && variant.OldVersion == c.OldVersion {
-						foundVariants = append(foundVariants, variant)
-						foundSuffixes = append(foundSuffixes, suffix)
-					}
-				} else if variant.Version == c.Version {
-					foundVariants = append(foundVariants, variant)
-					foundSuffixes = append(foundSuffixes, suffix)
+		for _, suffix := range variantSuffixes {
+			for j, v := range changes {
+				if used[j] || j == i || v.Package != c.Package+suffix {
+					continue
+				}
+				// Versions must match, including the old version for up/down
+				if v.Version == c.Version && v.OldVersion == c.OldVersion {
+					group.suffixes = append(group.suffixes, suffix)
+					group.changes = append(group.changes, v)
+					used[j] = true
+					break
 				}
 			}
 		}
 
-		if len(foundVariants) > 0 {
-			// Found variants
-			group := variantGroup{
-				baseName: c.Package,
-				suffixes: append([]string{""}, foundSuffixes...),
-				changes:  append([]Change{c}, foundVariants...),
-			}
-			groups = append(groups, group)
-			used[c.Package] = true
-			for _, v := range foundVariants {
-				used[v.Package] = true
-			}
+		if len(group.changes) > 1 {
+			used[i] = true
+			heads[i] = group
 		}
 	}
 
-	return groups
-}
-
-// formatVariants formats a group of package variants with nested braces
-func formatVariants(category string, groups []variantGroup, ct ChangeType) string {
-	var parts []string
-
-	for _, g := range groups {
-		// Format: pkg{,-bin}-version or pkg{,-bin}-oldver -> newver
-		suffixPart := "{" + strings.Join(g.suffixes, ",") + "}"
-		c := g.changes[0] // Use first change for version info
-
-		if ct == Up || ct == Down {
-			parts = append(parts, g.baseName+suffixPart+"-"+c.OldVersion+" -> "+c.Version)
-		} else {
-			parts = append(parts, g.baseName+suffixPart+"-"+c.Version)
+	var groups []variantGroup
+	for i, c := range changes {
+		if g, ok := heads[i]; ok {
+			groups = append(groups, g)
+		} else if !used[i] {
+			groups = append(groups, variantGroup{baseName: c.Package, suffixes: []string{""}, changes: []Change{c}})
 		}
 	}
 
-	if len(parts) == 1 {
-		return category + "/" + parts[0]
-	}
-
-	return category + "/{" + strings.Join(parts, ", ") + "}"
+	return groups
 }
 
-// formatSingleChange formats a single change
-func formatSingleChange(category string, c Change, ct ChangeType) string {
+// formatVariant formats a variant group with nested braces:
+// pkg{,-bin}-version or pkg{,-bin}-oldver -> newver
+func formatVariant(g variantGroup, ct ChangeType) string {
+	suffixPart := "{" + strings.Join(g.suffixes, ",") + "}"
+	c := g.changes[0] // All variants share the version info
+
 	if ct == Up || ct == Down {
-		return category + "/" + c.Package + "-" + c.OldVersion + " -> " + c.Version
+		return g.baseName + suffixPart + "-" + c.OldVersion + " -> " + c.Version
 	}
-	return category + "/" + c.Package + "-" + c.Version
+	return g.baseName + suffixPart + "-" + c.Version
 }
 
 // formatPackageVersion formats a package-version string for grouping
@@ -404,12 +390,11 @@ func formatPackageVersion(c Change, ct ChangeType) string {
 
 // Commit executes a git commit with the given message
 func Commit(cfg *config.Config, message string) error {
-	overlayPath, err := cfg.GetOverlayPath()
+	runner, err := NewExecutor(cfg)
 	if err != nil {
 		return err
 	}
 
-	runner := git.NewGitRunner(overlayPath)
 	return CommitWithExecutor(cfg, message, runner)
 }
 
@@ -425,27 +410,29 @@ func CommitWithExecutor(cfg *config.Config, message string, executor git.GitExec
 
 // GetStagedChanges returns the list of changes from staged files
 func GetStagedChanges(cfg *config.Config) ([]Change, error) {
-	overlayPath, err := cfg.GetOverlayPath()
+	runner, err := NewExecutor(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	runner := git.NewGitRunner(overlayPath)
 	entries, err := runner.Status()
 	if err != nil {
 		return nil, err
 	}
 
-	// Filter to only staged entries (those with index status)
-	var stagedEntries []git.StatusEntry
+	return AnalyzeChanges(StagedEntries(entries)), nil
+}
+
+// StagedEntries filters status entries to those with changes in the index.
+// Files modified only in the worktree and untracked files are excluded.
+func StagedEntries(entries []git.StatusEntry) []git.StatusEntry {
+	var staged []git.StatusEntry
 	for _, e := range entries {
-		status := strings.TrimSpace(e.Status)
-		if len(status) > 0 && status[0] != ' ' && status != "??" {
-			stagedEntries = append(stagedEntries, e)
+		if e.IsStaged() {
+			staged = append(staged, e)
 		}
 	}
-
-	return AnalyzeChanges(stagedEntries), nil
+	return staged
 }
 
 // HasEbuildChanges checks if there are any ebuild changes in the list
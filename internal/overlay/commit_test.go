@@ -389,6 +389,22 @@ func TestGenerateMessagePackageVariants(t *testing.T) {
 	}
 }
 
+// TestGenerateMessageVariantsWithOtherPackages tests that packages outside a
+// variant group in the same category are kept
+func TestGenerateMessageVariantsWithOtherPackages(t *testing.T) {
+	changes := []Change{
+		{Type: Up, Category: "www-client", Package: "chromium", Version: "130.0", OldVersion: "129.0"},
+		{Type: Up, Category: "www-client", Package: "firefox-bin", Version: "120.0", OldVersion: "119.0"},
+		{Type: Up, Category: "www-client", Package: "firefox", Version: "120.0", OldVersion: "119.0"},
+	}
+
+	message := GenerateMessage(changes)
+	expected := "up(www-client/{chromium-129.0 -> 130.0, firefox{,-bin}-119.0 -> 120.0})"
+	if message != expected {
+		t.Errorf("GenerateMessage() = %q, want %q", message, expected)
+	}
+}
+
 // TestGenerateMessageDefaultForNonEbuild tests default message for non-ebuild changes
 // _Requirements: 4.11_
 func TestGenerateMessageDefaultForNonEbuild(t *testing.T) {
@@ -632,3 +648,51 @@ func TestCommitMessageGeneration(t *testing.T) {
 		})
 	}
 }
+
+// TestStagedChangesFromPorcelainV2 verifies that staged analysis uses the index
+// state only: unstaged edits are ignored and renames are seen as version bumps
+func TestStagedChangesFromPorcelainV2(t *testing.T) {
+	const hashes = "100644 100644 100644 0123456789abcdef0123456789abcdef01234567 0123456789abcdef0123456789abcdef01234567"
+
+	mock := git.NewMockGitRunner("/test/overlay")
+	mock.SetStatusOutput(
+		"2 R. N... " + hashes + " R98 net-misc/foo/foo-2.0.ebuild\x00net-misc/foo/foo-1.0.ebuild\x00" +
+			"1 MM N... " + hashes + " net-misc/foo/Manifest\x00" +
+			"1 .M N... " + hashes + " app-misc/bar/bar-1.0.ebuild\x00" +
+			"1 A. N... " + hashes + " app-misc/baz/files/fix build.patch\x00" +
+			"? app-misc/new/new-1.0.ebuild\x00")
+
+	entries, err := mock.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	staged := StagedEntries(entries)
+	var paths []string
+	for _, e := range staged {
+		paths = append(paths, e.FilePath)
+	}
+	wantPaths := []string{"net-misc/foo/foo-2.0.ebuild", "net-misc/foo/Manifest", "app-misc/baz/files/fix build.patch"}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("StagedEntries() paths = %v, want %v", paths, wantPaths)
+	}
+
+	changes := AnalyzeChanges(staged)
+	want := []Change{{Type: Up, Category: "net-misc", Package: "foo", Version: "2.0", OldVersion: "1.0"}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("AnalyzeChanges() = %+v, want %+v", changes, want)
+	}
+
+	plans, err := PlanSplitCommits(staged, MessageOptions{})
+	if err != nil {
+		t.Fatalf("PlanSplitCommits() error = %v", err)
+	}
+	for _, plan := range plans {
+		if plan.Name() == "net-misc/foo" {
+			wantPlanPaths := []string{"net-misc/foo/foo-2.0.ebuild", "net-misc/foo/foo-1.0.ebuild", "net-misc/foo/Manifest"}
+			if !reflect.DeepEqual(plan.Paths, wantPlanPaths) {
+				t.Errorf("rename plan paths = %v, want %v", plan.Paths, wantPlanPaths)
+			}
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package overlay
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/obentoo/bentoolkit/internal/common/cache"
 	"github.com/obentoo/bentoolkit/internal/common/ebuild"
 	"github.com/obentoo/bentoolkit/internal/common/github"
 	"github.com/obentoo/bentoolkit/internal/common/output"
@@ -19,6 +23,31 @@ type CompareResult struct {
 	LocalVersion  string // Version in Bentoo overlay
 	RemoteVersion string // Version in Gentoo repository
 	Status        CompareStatus
+
+	// RemoteName is the category/package upstream carries the package
+	// under when upstream has moved it, and empty otherwise
+	RemoteName string
+
+	// RemoteRepo is the repository holding RemoteVersion and RemoteVersions
+	// the latest version in each repository ("-" when absent). Both are only
+	// set in multi-repository reports.
+	RemoteRepo     string
+	RemoteVersions map[string]string
+
+	// Slot is the slot compared in a slot-level report. RemoteKeywords is
+	// how far RemoteVersion is keyworded on the compared architecture and
+	// RemoteStable the highest stable upstream version in the slot ("" when
+	// there is none).
+	Slot           string
+	RemoteKeywords ebuild.KeywordLevel
+	RemoteStable   string
+
+	// Freshness tells whether the upstream data came from upstream during
+	// the run or from a cache, such as in offline mode, and FetchedAt when
+	// cached data was fetched. A multi-repository result carries the least
+	// current of its repositories.
+	Freshness cache.Freshness
+	FetchedAt time.Time
 }
 
 // CompareStatus indicates the comparison result
@@ -65,6 +94,12 @@ type CompareOptions struct {
 	IncludeSynced bool
 	// IncludeNotInRemote includes packages that don't exist in remote
 	IncludeNotInRemote bool
+	// Slots compares each slot separately, reading SLOT and KEYWORDS from
+	// the local and upstream ebuilds. The provider must implement
+	// provider.MetadataReader.
+	Slots bool
+	// Arch is the architecture keywords are checked on; empty means any
+	Arch string
 	// ProgressCallback is called for each package processed
 	ProgressCallback func(current, total int, pkg string)
 }
@@ -79,10 +114,88 @@ type CompareReport struct {
 	NotInRemoteCount int
 	ErrorCount       int
 	Results          []CompareResult
+
+	// Repositories lists the compared repositories of a multi-repository
+	// report, in column order
+	Repositories []string
+
+	// Slots is set for slot-level reports, which have a result per slot.
+	// Arch is the architecture their keywords were checked on.
+	Slots bool
+	Arch  string
+
+	// Freshness and FetchedAt are those of the least current result, so a
+	// report read from stale caches says so
+	Freshness cache.Freshness
+	FetchedAt time.Time
 }
 
-// Compare compares local packages against a remote GitHub repository
+// UpstreamRepo is a named repository taking part in a multi-repository compare
+type UpstreamRepo struct {
+	Name     string
+	Provider provider.Provider
+}
+
+// liveOnlyVersion is reported as the remote version when a repository only
+// has live ebuilds of a package
+const liveOnlyVersion = "9999 (live only)"
+
+// Compare compares local packages against a remote GitHub repository.
+//
+// Deprecated: github.Client is a provider.GitHubProvider; use CompareWithProvider.
 func Compare(localPackages []PackageInfo, client *github.Client, opts CompareOptions) (*CompareReport, error) {
+	return CompareWithProvider(localPackages, client, opts)
+}
+
+// CompareWithProvider compares local packages against an upstream repository
+// using any Provider. Packages upstream has moved are followed through its
+// profiles/updates.
+func CompareWithProvider(localPackages []PackageInfo, prov provider.Provider, opts CompareOptions) (*CompareReport, error) {
+	moves := newUpstreamMoves(prov)
+	if !opts.Slots {
+		return compareEach(localPackages, opts, func(pkg PackageInfo) []CompareResult {
+			return []CompareResult{comparePackageWithProvider(pkg, prov, moves)}
+		}), nil
+	}
+
+	reader, ok := prov.(provider.MetadataReader)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot read ebuild metadata for a slot-level compare", prov.GetName())
+	}
+	report := compareEach(localPackages, opts, func(pkg PackageInfo) []CompareResult {
+		return comparePackageSlots(pkg, prov, moves, reader, opts.Arch)
+	})
+	report.Slots = true
+	report.Arch = opts.Arch
+	return report, nil
+}
+
+// CompareMulti compares local packages against several upstream repositories
+// in one report. Each package is classified against the highest version found
+// in any of them; ties go to the repository listed first.
+func CompareMulti(localPackages []PackageInfo, repos []UpstreamRepo, opts CompareOptions) (*CompareReport, error) {
+	if opts.Slots {
+		return nil, errors.New("slot-level compare supports a single repository")
+	}
+
+	moves := make([]*upstreamMoves, len(repos))
+	for i, repo := range repos {
+		moves[i] = newUpstreamMoves(repo.Provider)
+	}
+
+	report := compareEach(localPackages, opts, func(pkg PackageInfo) []CompareResult {
+		return []CompareResult{comparePackageMulti(pkg, repos, moves)}
+	})
+	for _, repo := range repos {
+		report.Repositories = append(report.Repositories, repo.Name)
+	}
+	return report, nil
+}
+
+// compareEach builds a report by comparing every local package with compare,
+// counting each package's status and keeping the results selected by opts.
+// compare returns one result per package, or one per slot.
+func compareEach(localPackages []PackageInfo, opts CompareOptions, compare func(PackageInfo) []CompareResult) *CompareReport {
 	report := &CompareReport{
 		TotalPackages: len(localPackages),
 		Results:       []CompareResult{},
@@ -94,11 +207,14 @@ func Compare(localPackages []PackageInfo, client *github.Client, opts CompareOpt
 			opts.ProgressCallback(i+1, len(localPackages), pkg.FullName())
 		}
 
-		result := comparePackage(pkg, client)
+		results := compare(pkg)
 		report.ComparedPackages++
+		for _, result := range results {
+			report.Freshness, report.FetchedAt = staler(report.Freshness, report.FetchedAt, result.Freshness, result.FetchedAt)
+		}
 
 		// Update counters
-		switch result.Status {
+		switch packageStatus(results) {
 		case StatusOutdated:
 			report.OutdatedCount++
 		case StatusNewer:
@@ -111,102 +227,99 @@ func Compare(localPackages []PackageInfo, client *github.Client, opts CompareOpt
 			report.ErrorCount++
 		}
 
-		// Filter based on options using switch for clarity
-		include := false
-		switch result.Status {
-		case StatusOutdated:
-			include = true // Always include outdated (primary use case)
-		case StatusUpToDate:
-			include = opts.IncludeSynced
-		case StatusNewer:
-			include = !opts.OnlyOutdated // Include if not filtering to outdated only
-		case StatusNotInRemote:
-			include = opts.IncludeNotInRemote
-		case StatusError:
-			include = true // Always include errors for visibility
+		for _, result := range results {
+			// Filter based on options using switch for clarity
+			include := false
+			switch result.Status {
+			case StatusOutdated:
+				include = true // Always include outdated (primary use case)
+			case StatusUpToDate:
+				include = opts.IncludeSynced
+			case StatusNewer:
+				include = !opts.OnlyOutdated // Include if not filtering to outdated only
+			case StatusNotInRemote:
+				include = opts.IncludeNotInRemote
+			case StatusError:
+				include = true // Always include errors for visibility
+			}
+			if include {
+				report.Results = append(report.Results, result)
+			}
 		}
-		if !include {
-			continue
-		}
-
-		report.Results = append(report.Results, result)
 	}
 
-	// Sort results by category/package
-	sort.Slice(report.Results, func(i, j int) bool {
+	// Sort results by category/package, keeping the slot order of a package
+	sort.SliceStable(report.Results, func(i, j int) bool {
 		if report.Results[i].Category != report.Results[j].Category {
 			return report.Results[i].Category < report.Results[j].Category
 		}
 		return report.Results[i].Package < report.Results[j].Package
 	})
 
-	return report, nil
+	return report
 }
 
-// CompareWithProvider compares local packages against an upstream repository using any Provider
-func CompareWithProvider(localPackages []PackageInfo, prov provider.Provider, opts CompareOptions) (*CompareReport, error) {
-	report := &CompareReport{
-		TotalPackages: len(localPackages),
-		Results:       []CompareResult{},
-	}
-
-	for i, pkg := range localPackages {
-		// Progress callback
-		if opts.ProgressCallback != nil {
-			opts.ProgressCallback(i+1, len(localPackages), pkg.FullName())
+// packageStatus returns the status a package is counted under: an error or
+// an outdated slot outweighs the others
+func packageStatus(results []CompareResult) CompareStatus {
+	for _, priority := range []CompareStatus{StatusError, StatusOutdated, StatusNewer, StatusUpToDate} {
+		for _, r := range results {
+			if r.Status == priority {
+				return priority
+			}
 		}
+	}
+	return StatusNotInRemote
+}
 
-		result := comparePackageWithProvider(pkg, prov)
-		report.ComparedPackages++
+// comparePackageMulti compares a single package against every repository and
+// keeps the result of the one with the highest version. moves holds the
+// package moves of each repository.
+func comparePackageMulti(pkg PackageInfo, repos []UpstreamRepo, moves []*upstreamMoves) CompareResult {
+	result := CompareResult{
+		Category:       pkg.Category,
+		Package:        pkg.Package,
+		LocalVersion:   pkg.LatestVersion,
+		Status:         StatusNotInRemote,
+		RemoteVersions: make(map[string]string, len(repos)),
+	}
 
-		// Update counters
-		switch result.Status {
-		case StatusOutdated:
-			report.OutdatedCount++
-		case StatusNewer:
-			report.NewerCount++
-		case StatusUpToDate:
-			report.UpToDateCount++
-		case StatusNotInRemote:
-			report.NotInRemoteCount++
-		case StatusError:
-			report.ErrorCount++
-		}
+	var best *CompareResult
+	for i, repo := range repos {
+		r := comparePackageWithProvider(pkg, repo.Provider, moves[i])
+		result.Freshness, result.FetchedAt = staler(result.Freshness, result.FetchedAt, r.Freshness, r.FetchedAt)
 
-		// Filter based on options using switch for clarity
-		include := false
-		switch result.Status {
-		case StatusOutdated:
-			include = true // Always include outdated (primary use case)
-		case StatusUpToDate:
-			include = opts.IncludeSynced
-		case StatusNewer:
-			include = !opts.OnlyOutdated // Include if not filtering to outdated only
+		switch r.Status {
 		case StatusNotInRemote:
-			include = opts.IncludeNotInRemote
+			result.RemoteVersions[repo.Name] = "-"
+			continue
 		case StatusError:
-			include = true // Always include errors for visibility
-		}
-		if !include {
+			result.RemoteVersions[repo.Name] = "error"
+			if best == nil {
+				result.Status = StatusError
+			}
 			continue
 		}
+		result.RemoteVersions[repo.Name] = r.RemoteVersion
 
-		report.Results = append(report.Results, result)
-	}
-
-	// Sort results by category/package
-	sort.Slice(report.Results, func(i, j int) bool {
-		if report.Results[i].Category != report.Results[j].Category {
-			return report.Results[i].Category < report.Results[j].Category
+		if best == nil || betterRemote(r.RemoteVersion, best.RemoteVersion) {
+			r.RemoteRepo = repo.Name
+			best = &r
 		}
-		return report.Results[i].Package < report.Results[j].Package
-	})
+	}
 
-	return report, nil
+	if best != nil {
+		result.RemoteVersion = best.RemoteVersion
+		result.RemoteRepo = best.RemoteRepo
+		result.RemoteName = best.RemoteName
+		result.Status = best.Status
+	}
+	return result
 }
 
-// comparePackageWithProvider compares a single package using a Provider
-func comparePackageWithProvider(pkg PackageInfo, prov provider.Provider) CompareResult {
+// comparePackageWithProvider compares a single package using a Provider,
+// following upstream's package moves when it does not know the local name
+func comparePackageWithProvider(pkg PackageInfo, prov provider.Provider, moves *upstreamMoves) CompareResult {
 	result := CompareResult{
 		Category:     pkg.Category,
 		Package:      pkg.Package,
@@ -214,9 +327,13 @@ func comparePackageWithProvider(pkg PackageInfo, prov provider.Provider) Compare
 	}
 
 	// Fetch remote versions
-	remoteVersions, err := prov.GetPackageVersions(pkg.Category, pkg.Package)
+	remoteVersions, remoteName, err := findRemoteVersions(prov, moves, pkg)
+	result.Freshness, result.FetchedAt = providerFreshness(prov)
+	if remoteName != pkg.FullName() {
+		result.RemoteName = remoteName
+	}
 	if err != nil {
-		if err == provider.ErrNotFound {
+		if errors.Is(err, provider.ErrNotFound) {
 			result.Status = StatusNotInRemote
 			return result
 		}
@@ -236,7 +353,7 @@ func comparePackageWithProvider(pkg PackageInfo, prov provider.Provider) Compare
 	// If remote only has live versions, consider up-to-date
 	if remoteLatest == "" {
 		result.Status = StatusUpToDate
-		result.RemoteVersion = "9999 (live only)"
+		result.RemoteVersion = liveOnlyVersion
 		return result
 	}
 
@@ -254,54 +371,175 @@ func comparePackageWithProvider(pkg PackageInfo, prov provider.Provider) Compare
 	return result
 }
 
-// comparePackage compares a single package against the remote repository
-// Deprecated: Use comparePackageWithProvider instead
-func comparePackage(pkg PackageInfo, client *github.Client) CompareResult {
-	result := CompareResult{
+// comparePackageSlots compares a single package slot by slot. Each slot in
+// both repositories compares the latest versions in it; a slot only in the
+// overlay is not-in-remote, and a slot only upstream is outdated when its
+// version is newer than anything in the overlay. Package moves are followed
+// as in comparePackageWithProvider, and local slots follow upstream's
+// slotmoves.
+func comparePackageSlots(pkg PackageInfo, prov provider.Provider, moves *upstreamMoves, reader provider.MetadataReader, arch string) []CompareResult {
+	failed := []CompareResult{{
 		Category:     pkg.Category,
 		Package:      pkg.Package,
 		LocalVersion: pkg.LatestVersion,
+		Status:       StatusError,
+	}}
+
+	remoteVersions, remoteName, err := findRemoteVersions(prov, moves, pkg)
+	failed[0].Freshness, failed[0].FetchedAt = providerFreshness(prov)
+	if err != nil || len(remoteVersions) == 0 {
+		if err == nil || errors.Is(err, provider.ErrNotFound) {
+			failed[0].Status = StatusNotInRemote
+		}
+		return failed
+	}
+	movedName := ""
+	if remoteName != pkg.FullName() {
+		movedName = remoteName
 	}
 
-	// Fetch remote versions
-	remoteVersions, err := client.GetPackageVersions(pkg.Category, pkg.Package)
-	if err != nil {
-		if err == github.ErrNotFound {
-			result.Status = StatusNotInRemote
-			return result
+	localSlots := make(map[string][]string)
+	for _, v := range pkg.Versions {
+		m, err := ebuild.ReadMetadata(filepath.Join(pkg.Path, pkg.Package+"-"+v+".ebuild"), v)
+		if err != nil {
+			return failed
 		}
-		result.Status = StatusError
-		return result
+		slot := moves.resolveSlot(remoteName, m.Slot)
+		localSlots[slot] = append(localSlots[slot], v)
 	}
 
-	if len(remoteVersions) == 0 {
-		result.Status = StatusNotInRemote
-		return result
+	remoteCategory, remotePkg, _ := strings.Cut(remoteName, "/")
+	remoteSlots := make(map[string][]string)
+	remoteMeta := make(map[string]*ebuild.Metadata, len(remoteVersions))
+	for _, v := range remoteVersions {
+		m, err := reader.GetEbuildMetadata(remoteCategory, remotePkg, v)
+		if err != nil {
+			return failed
+		}
+		remoteMeta[v] = m
+		remoteSlots[m.Slot] = append(remoteSlots[m.Slot], v)
 	}
 
-	// Find latest remote version (ignoring live/9999 ebuilds)
-	remoteLatest := FindLatestVersionFiltered(remoteVersions, true)
-	result.RemoteVersion = remoteLatest
+	slots := make([]string, 0, len(localSlots)+len(remoteSlots))
+	for slot := range localSlots {
+		slots = append(slots, slot)
+	}
+	for slot := range remoteSlots {
+		if _, ok := localSlots[slot]; !ok {
+			slots = append(slots, slot)
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		if cmp := ebuild.CompareVersions(slots[i], slots[j]); cmp != 0 {
+			return cmp < 0
+		}
+		return slots[i] < slots[j]
+	})
 
-	// If remote only has live versions, consider up-to-date
-	if remoteLatest == "" {
-		result.Status = StatusUpToDate
-		result.RemoteVersion = "9999 (live only)"
-		return result
+	freshness, fetchedAt := providerFreshness(prov)
+	localLatest := FindLatestVersionFiltered(pkg.Versions, true)
+	var results []CompareResult
+	for _, slot := range slots {
+		result := CompareResult{
+			Category:   pkg.Category,
+			Package:    pkg.Package,
+			RemoteName: movedName,
+			Slot:       slot,
+			Freshness:  freshness,
+			FetchedAt:  fetchedAt,
+		}
+		if local, ok := localSlots[slot]; ok {
+			result.LocalVersion = FindLatestVersion(local)
+		}
+
+		remote, ok := remoteSlots[slot]
+		if !ok {
+			result.Status = StatusNotInRemote
+			results = append(results, result)
+			continue
+		}
+
+		remoteLatest := FindLatestVersionFiltered(remote, true)
+		if isLiveVersion(remoteLatest) {
+			if result.LocalVersion == "" {
+				continue // Upstream only has a live ebuild in a slot we lack
+			}
+			result.RemoteVersion = liveOnlyVersion
+			result.Status = StatusUpToDate
+			results = append(results, result)
+			continue
+		}
+
+		result.RemoteVersion = remoteLatest
+		result.RemoteKeywords = remoteMeta[remoteLatest].KeywordLevel(arch)
+		for _, v := range remote {
+			if !isLiveVersion(v) && remoteMeta[v].KeywordLevel(arch) == ebuild.Stable &&
+				(result.RemoteStable == "" || ebuild.CompareVersions(v, result.RemoteStable) > 0) {
+				result.RemoteStable = v
+			}
+		}
+
+		if result.LocalVersion == "" {
+			// A slot only upstream matters when it is ahead of the overlay
+			if ebuild.CompareVersions(remoteLatest, localLatest) <= 0 {
+				continue
+			}
+			result.Status = StatusOutdated
+			results = append(results, result)
+			continue
+		}
+
+		cmp := ebuild.CompareVersions(result.LocalVersion, remoteLatest)
+		switch {
+		case cmp < 0:
+			result.Status = StatusOutdated
+		case cmp > 0:
+			result.Status = StatusNewer
+		default:
+			result.Status = StatusUpToDate
+		}
+		results = append(results, result)
 	}
+	return results
+}
 
-	// Compare versions
-	cmp := ebuild.CompareVersions(pkg.LatestVersion, remoteLatest)
+// providerFreshness returns how current a provider's answers are. Providers
+// that cannot answer from a cache are live.
+func providerFreshness(prov provider.Provider) (cache.Freshness, time.Time) {
+	if r, ok := prov.(provider.FreshnessReporter); ok {
+		return r.Freshness()
+	}
+	return cache.Live, time.Time{}
+}
+
+// staler returns the less current of two freshness values, with the older
+// fetch time when they are the same
+func staler(f cache.Freshness, at time.Time, other cache.Freshness, otherAt time.Time) (cache.Freshness, time.Time) {
 	switch {
-	case cmp < 0:
-		result.Status = StatusOutdated
-	case cmp > 0:
-		result.Status = StatusNewer
+	case other > f:
+		return other, otherAt
+	case other == f && !otherAt.IsZero() && (at.IsZero() || otherAt.Before(at)):
+		return f, otherAt
 	default:
-		result.Status = StatusUpToDate
+		return f, at
 	}
+}
 
-	return result
+// HasStableUpdate reports whether upstream has a stable version newer than
+// the local one in a slot-level result
+func (r CompareResult) HasStableUpdate() bool {
+	return r.RemoteStable != "" &&
+		(r.LocalVersion == "" || ebuild.CompareVersions(r.LocalVersion, r.RemoteStable) < 0)
+}
+
+// betterRemote reports whether candidate outranks current as the upstream
+// version: a released version beats a live-only package, then the highest wins
+func betterRemote(candidate, current string) bool {
+	candidateLive, currentLive := isLiveVersion(candidate), isLiveVersion(current)
+	if candidateLive != currentLive {
+		return currentLive
+	}
+	return ebuild.CompareVersions(candidate, current) > 0
 }
 
 // FormatReport formats a comparison report for terminal output
@@ -309,6 +547,15 @@ func comparePackage(pkg PackageInfo, client *github.Client) CompareResult {
 func FormatReport(report *CompareReport) string {
 	var sb strings.Builder
 
+	// Data read from caches is flagged before anything else
+	switch report.Freshness {
+	case cache.Stale:
+		sb.WriteString(output.Sprintf(output.Warning, "\nUpstream data is %s: it was read from caches without checking upstream.\n",
+			cache.Describe(report.Freshness, report.FetchedAt, time.Now())))
+	case cache.Cached:
+		sb.WriteString(output.Sprintf(output.Dim, "\nUpstream data: %s\n", cache.Describe(report.Freshness, report.FetchedAt, time.Now())))
+	}
+
 	if len(report.Results) == 0 {
 		sb.WriteString(output.Sprintf(output.Success, "All packages are up-to-date!"))
 		return sb.String()
@@ -327,26 +574,64 @@ func FormatReport(report *CompareReport) string {
 		}
 	}
 
+	// Multi-repository reports get a version column per repository, and
+	// slot-level reports a row per slot
+	section := formatResultSection
+	outdatedTitle := "Outdated Packages (Bentoo < Gentoo)"
+	switch {
+	case len(report.Repositories) > 0:
+		section = func(results []CompareResult, title string, headerColor *color.Color) string {
+			return formatMultiSection(results, report.Repositories, title, headerColor)
+		}
+		outdatedTitle = "Outdated Packages (Bentoo < Upstream)"
+	case report.Slots:
+		section = func(results []CompareResult, title string, headerColor *color.Color) string {
+			return formatSlotSection(results, report.Arch, title, headerColor)
+		}
+	}
+
 	// Format outdated section if any
 	if len(outdated) > 0 {
-		sb.WriteString(formatResultSection(outdated, "Outdated Packages (Bentoo < Gentoo)", output.Warning))
+		sb.WriteString(section(outdated, outdatedTitle, output.Warning))
 	}
 
 	// Format synced section if any
 	if len(synced) > 0 {
-		sb.WriteString(formatResultSection(synced, "Up-to-Date Packages", output.Success))
+		sb.WriteString(section(synced, "Up-to-Date Packages", output.Success))
 	}
 
 	// Format other results (newer, not-in-remote, errors) if any
 	if len(other) > 0 {
-		sb.WriteString(formatResultSection(other, "Other Packages", output.Info))
+		sb.WriteString(section(other, "Other Packages", output.Info))
+	}
+
+	// Packages found upstream under another name
+	var moved []string
+	for _, r := range report.Results {
+		if r.RemoteName != "" && (len(moved) == 0 || !strings.HasPrefix(moved[len(moved)-1], "  "+r.Category+"/"+r.Package+" ")) {
+			moved = append(moved, fmt.Sprintf("  %s/%s -> %s", r.Category, r.Package, r.RemoteName))
+		}
+	}
+	if len(moved) > 0 {
+		sb.WriteString(output.Sprintf(output.Info, "\nMoved Upstream:\n"))
+		sb.WriteString(strings.Join(moved, "\n") + "\n")
 	}
 
 	// Summary
 	sb.WriteString("\n")
 	if len(outdated) > 0 {
-		sb.WriteString(fmt.Sprintf("Outdated: %s | ",
+		sb.WriteString(fmt.Sprintf("Outdated: %s ",
 			output.Sprint(output.Warning, fmt.Sprintf("%d", len(outdated)))))
+		if report.Slots {
+			stable := 0
+			for _, r := range outdated {
+				if r.HasStableUpdate() {
+					stable++
+				}
+			}
+			sb.WriteString(fmt.Sprintf("(%d with a stable update) ", stable))
+		}
+		sb.WriteString("| ")
 	}
 	if len(synced) > 0 {
 		sb.WriteString(fmt.Sprintf("Up-to-date: %s | ",
@@ -422,6 +707,144 @@ func formatResultSection(results []CompareResult, title string, headerColor *col
 	return sb.String()
 }
 
+// formatMultiSection formats a section of a multi-repository report. After
+// the Bentoo version it has a column per repository, then the highest
+// upstream version with the repository holding it.
+func formatMultiSection(results []CompareResult, repos []string, title string, headerColor *color.Color) string {
+	headers := append([]string{"Package", "Category", "Bentoo Version"}, repos...)
+	headers = append(headers, "Latest", "Status")
+
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		row := []string{r.Package, r.Category, r.LocalVersion}
+		for _, repo := range repos {
+			row = append(row, r.RemoteVersions[repo])
+		}
+		latest := "-"
+		if r.RemoteRepo != "" {
+			latest = r.RemoteVersion + " (" + r.RemoteRepo + ")"
+		}
+		rows = append(rows, append(row, latest, r.Status.String()))
+	}
+
+	return formatColumnsTable(headers, rows, statusColors(results), title, headerColor)
+}
+
+// formatSlotSection formats a section of a slot-level report. Each row is a
+// slot, with how far the upstream version is keyworded on arch.
+func formatSlotSection(results []CompareResult, arch string, title string, headerColor *color.Color) string {
+	headers := []string{"Package", "Category", "Slot", "Bentoo Version", "Upstream Version", "Keywords", "Status"}
+
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, []string{
+			r.Package, r.Category, orDash(r.Slot), orDash(r.LocalVersion), orDash(r.RemoteVersion),
+			keywordSummary(r, arch), r.Status.String(),
+		})
+	}
+
+	return formatColumnsTable(headers, rows, statusColors(results), title, headerColor)
+}
+
+// keywordSummary describes how far the upstream version of a slot-level
+// result is keyworded, naming a newer stable version when it is not stable
+func keywordSummary(r CompareResult, arch string) string {
+	if r.RemoteVersion == "" || r.RemoteVersion == liveOnlyVersion {
+		return "-"
+	}
+
+	var summary string
+	switch r.RemoteKeywords {
+	case ebuild.Stable:
+		return "stable"
+	case ebuild.Testing:
+		summary = "~arch only"
+		if arch != "" {
+			summary = "~" + arch + " only"
+		}
+	default:
+		summary = "unkeyworded"
+	}
+	if r.HasStableUpdate() {
+		summary += " (stable " + r.RemoteStable + ")"
+	}
+	return summary
+}
+
+// orDash returns s, or "-" when s is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// statusColors returns the color of each result's status
+func statusColors(results []CompareResult) []*color.Color {
+	colors := make([]*color.Color, len(results))
+	for i, r := range results {
+		colors[i] = getStatusColor(r.Status)
+	}
+	return colors
+}
+
+// formatColumnsTable renders a section table from its headers and rows,
+// coloring the last cell of each row with the row's color, if any
+func formatColumnsTable(headers []string, rows [][]string, colors []*color.Color, title string, headerColor *color.Color) string {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = len(h)
+		for _, row := range rows {
+			widths[i] = max(widths[i], len(row[i]))
+		}
+	}
+	// Cap widths for readability
+	widths[0] = min(widths[0], 30)
+	widths[1] = min(widths[1], 20)
+
+	var sb strings.Builder
+	sb.WriteString(output.Sprintf(headerColor, "\n%s:\n", title))
+	sb.WriteString(formatColumnsLine(widths, "┌", "┬", "┐"))
+	sb.WriteString(output.Sprint(output.Header, formatColumnsRow(widths, headers)))
+	sb.WriteString(formatColumnsLine(widths, "├", "┼", "┤"))
+
+	lastCol := len(headers) - 1
+	for i, row := range rows {
+		for j := range row {
+			row[j] = fmt.Sprintf("%-*s", widths[j], truncateString(row[j], widths[j]))
+		}
+		if colors[i] != nil {
+			row[lastCol] = output.Sprint(colors[i], row[lastCol])
+		}
+		sb.WriteString(formatColumnsRow(nil, row))
+	}
+
+	sb.WriteString(formatColumnsLine(widths, "└", "┴", "┘"))
+	return sb.String()
+}
+
+// formatColumnsLine creates a horizontal table line for columns of the given widths
+func formatColumnsLine(widths []int, left, mid, right string) string {
+	parts := make([]string, len(widths))
+	for i, w := range widths {
+		parts[i] = strings.Repeat("─", w+2)
+	}
+	return left + strings.Join(parts, mid) + right + "\n"
+}
+
+// formatColumnsRow creates a table row, padding each cell to its width.
+// With nil widths the cells are used as they are.
+func formatColumnsRow(widths []int, cells []string) string {
+	padded := make([]string, len(cells))
+	for i, c := range cells {
+		if widths != nil {
+			c = fmt.Sprintf("%-*s", widths[i], c)
+		}
+		padded[i] = c
+	}
+	return "│ " + strings.Join(padded, " │ ") + " │\n"
+}
+
 // getStatusColor returns the appropriate color for a CompareStatus
 func getStatusColor(status CompareStatus) *color.Color {
 	switch status {
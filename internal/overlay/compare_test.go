@@ -4,51 +4,53 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/obentoo/bentoolkit/internal/common/cache"
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
 	"github.com/obentoo/bentoolkit/internal/common/github"
+	"github.com/obentoo/bentoolkit/internal/common/provider"
 )
 
-func TestCompare(t *testing.T) {
-	// Create mock GitHub server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var entries []github.ContentEntry
+// fakeProvider serves package versions from a map keyed by category/package
+type fakeProvider struct {
+	versions map[string][]string
+	err      error
+}
 
-		switch {
-		case strings.Contains(r.URL.Path, "app-misc/hello"):
-			entries = []github.ContentEntry{
-				{Name: "hello-1.0.ebuild", Type: "file"},
-				{Name: "hello-2.0.ebuild", Type: "file"},
-			}
-		case strings.Contains(r.URL.Path, "app-editors/vscode"):
-			entries = []github.ContentEntry{
-				{Name: "vscode-1.107.1.ebuild", Type: "file"},
-				{Name: "vscode-1.108.0.ebuild", Type: "file"},
-			}
-		case strings.Contains(r.URL.Path, "www-client/firefox"):
-			entries = []github.ContentEntry{
-				{Name: "firefox-129.0.ebuild", Type: "file"},
-				{Name: "firefox-130.0.ebuild", Type: "file"},
-			}
-		default:
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
+func (f *fakeProvider) GetPackageVersions(category, pkg string) ([]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	versions, ok := f.versions[category+"/"+pkg]
+	if !ok {
+		return nil, provider.ErrNotFound
+	}
+	return versions, nil
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(entries)
-	}))
-	defer server.Close()
+func (f *fakeProvider) GetName() string   { return "fake" }
+func (f *fakeProvider) SupportsAPI() bool { return false }
+func (f *fakeProvider) Close() error      { return nil }
 
-	client := github.NewClient()
-	client.BaseURL = server.URL
+func TestCompare(t *testing.T) {
+	prov := &fakeProvider{versions: map[string][]string{
+		"app-misc/hello":      {"1.0", "2.0"},
+		"app-editors/vscode":  {"1.107.1", "1.108.0"},
+		"www-client/firefox":  {"129.0", "130.0"},
+		"dev-util/live-newer": {"1.0", "9999"},
+	}}
 
 	localPackages := []PackageInfo{
 		{Category: "app-misc", Package: "hello", LatestVersion: "2.0"},         // up-to-date
 		{Category: "app-editors", Package: "vscode", LatestVersion: "1.107.1"}, // outdated
 		{Category: "www-client", Package: "firefox", LatestVersion: "128.0"},   // outdated
 		{Category: "app-misc", Package: "bentoo-only", LatestVersion: "1.0"},   // not in remote
+		{Category: "dev-util", Package: "live-newer", LatestVersion: "1.0"},    // live ebuild ignored
 	}
 
 	opts := CompareOptions{
@@ -56,14 +58,14 @@ func TestCompare(t *testing.T) {
 		IncludeNotInRemote: false,
 	}
 
-	report, err := Compare(localPackages, client, opts)
+	report, err := CompareWithProvider(localPackages, prov, opts)
 	if err != nil {
-		t.Fatalf("Compare failed: %v", err)
+		t.Fatalf("CompareWithProvider failed: %v", err)
 	}
 
 	// Check results
-	if report.TotalPackages != 4 {
-		t.Errorf("Expected 4 total packages, got %d", report.TotalPackages)
+	if report.TotalPackages != 5 {
+		t.Errorf("Expected 5 total packages, got %d", report.TotalPackages)
 	}
 
 	if report.OutdatedCount != 2 {
@@ -74,6 +76,10 @@ func TestCompare(t *testing.T) {
 		t.Errorf("Expected 1 not-in-remote package, got %d", report.NotInRemoteCount)
 	}
 
+	if report.UpToDateCount != 2 {
+		t.Errorf("Expected 2 up-to-date packages, got %d", report.UpToDateCount)
+	}
+
 	// Only outdated should be in results
 	if len(report.Results) != 2 {
 		t.Errorf("Expected 2 results (outdated only), got %d", len(report.Results))
@@ -88,25 +94,9 @@ func TestCompare(t *testing.T) {
 }
 
 func TestCompareWithAllResults(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var entries []github.ContentEntry
-
-		if strings.Contains(r.URL.Path, "app-misc/hello") {
-			entries = []github.ContentEntry{
-				{Name: "hello-1.0.ebuild", Type: "file"},
-			}
-		} else {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(entries)
-	}))
-	defer server.Close()
-
-	client := github.NewClient()
-	client.BaseURL = server.URL
+	prov := &fakeProvider{versions: map[string][]string{
+		"app-misc/hello": {"1.0"},
+	}}
 
 	localPackages := []PackageInfo{
 		{Category: "app-misc", Package: "hello", LatestVersion: "1.0"}, // up-to-date
@@ -118,9 +108,9 @@ func TestCompareWithAllResults(t *testing.T) {
 		IncludeSynced: true,
 	}
 
-	report, err := Compare(localPackages, client, opts)
+	report, err := CompareWithProvider(localPackages, prov, opts)
 	if err != nil {
-		t.Fatalf("Compare failed: %v", err)
+		t.Fatalf("CompareWithProvider failed: %v", err)
 	}
 
 	if report.UpToDateCount != 1 {
@@ -134,17 +124,9 @@ func TestCompareWithAllResults(t *testing.T) {
 }
 
 func TestCompareNewerInLocal(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		entries := []github.ContentEntry{
-			{Name: "hello-1.0.ebuild", Type: "file"},
-		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(entries)
-	}))
-	defer server.Close()
-
-	client := github.NewClient()
-	client.BaseURL = server.URL
+	prov := &fakeProvider{versions: map[string][]string{
+		"app-misc/hello": {"1.0"},
+	}}
 
 	localPackages := []PackageInfo{
 		{Category: "app-misc", Package: "hello", LatestVersion: "2.0"}, // newer locally
@@ -154,9 +136,9 @@ func TestCompareNewerInLocal(t *testing.T) {
 		OnlyOutdated: false,
 	}
 
-	report, err := Compare(localPackages, client, opts)
+	report, err := CompareWithProvider(localPackages, prov, opts)
 	if err != nil {
-		t.Fatalf("Compare failed: %v", err)
+		t.Fatalf("CompareWithProvider failed: %v", err)
 	}
 
 	if report.NewerCount != 1 {
@@ -168,16 +150,24 @@ func TestCompareNewerInLocal(t *testing.T) {
 	}
 }
 
-func TestCompareProgressCallback(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		entries := []github.ContentEntry{{Name: "hello-1.0.ebuild", Type: "file"}}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(entries)
-	}))
-	defer server.Close()
+func TestCompareProviderErrors(t *testing.T) {
+	prov := &fakeProvider{err: provider.ErrRateLimit}
 
-	client := github.NewClient()
-	client.BaseURL = server.URL
+	report, err := CompareWithProvider([]PackageInfo{
+		{Category: "app-misc", Package: "hello", LatestVersion: "1.0"},
+	}, prov, CompareOptions{OnlyOutdated: true})
+	if err != nil {
+		t.Fatalf("CompareWithProvider failed: %v", err)
+	}
+	if report.ErrorCount != 1 || len(report.Results) != 1 || report.Results[0].Status != StatusError {
+		t.Errorf("report = %+v, want the error reported", report)
+	}
+}
+
+func TestCompareProgressCallback(t *testing.T) {
+	prov := &fakeProvider{versions: map[string][]string{
+		"app-misc/hello": {"1.0"},
+	}}
 
 	localPackages := []PackageInfo{
 		{Category: "app-misc", Package: "hello", LatestVersion: "1.0"},
@@ -191,9 +181,9 @@ func TestCompareProgressCallback(t *testing.T) {
 		},
 	}
 
-	_, err := Compare(localPackages, client, opts)
+	_, err := CompareWithProvider(localPackages, prov, opts)
 	if err != nil {
-		t.Fatalf("Compare failed: %v", err)
+		t.Fatalf("CompareWithProvider failed: %v", err)
 	}
 
 	if callbackCount != 2 {
@@ -201,6 +191,36 @@ func TestCompareProgressCallback(t *testing.T) {
 	}
 }
 
+func TestCompareGitHubClient(t *testing.T) {
+	// The legacy client is served by the provider's tree listing
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/repos/gentoo/gentoo/git/trees/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"sha": "root",
+			"tree": []map[string]string{
+				{"path": "app-misc/hello/hello-2.0.ebuild", "type": "blob"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := github.NewClient()
+	client.BaseURL = server.URL
+
+	report, err := Compare([]PackageInfo{
+		{Category: "app-misc", Package: "hello", LatestVersion: "1.0"},
+	}, client, CompareOptions{OnlyOutdated: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if report.OutdatedCount != 1 || report.Results[0].RemoteVersion != "2.0" {
+		t.Errorf("report = %+v, want hello outdated against 2.0", report)
+	}
+}
+
 func TestCompareStatus(t *testing.T) {
 	tests := []struct {
 		status   CompareStatus
@@ -254,6 +274,256 @@ func TestFormatReport(t *testing.T) {
 	}
 }
 
+func TestCompareMulti(t *testing.T) {
+	gentoo := &fakeProvider{versions: map[string][]string{
+		"app-misc/hello":   {"1.0", "2.0"},
+		"dev-util/tool":    {"3.0"},
+		"net-misc/live":    {"9999"},
+		"app-misc/same":    {"1.5"},
+		"app-misc/flakey":  {"1.0"},
+		"app-misc/newer":   {"0.9"},
+		"app-misc/tie":     {"4.0"},
+		"net-misc/partial": {"9999"},
+	}}
+	guru := &fakeProvider{versions: map[string][]string{
+		"app-misc/hello":   {"2.1"},
+		"dev-util/tool":    {"2.0"},
+		"app-misc/same":    {"1.5"},
+		"app-misc/tie":     {"4.0"},
+		"net-misc/partial": {"0.5"},
+	}}
+	broken := &fakeProvider{err: provider.ErrAPIError}
+
+	repos := []UpstreamRepo{{Name: "gentoo", Provider: gentoo}, {Name: "guru", Provider: guru}, {Name: "friend", Provider: broken}}
+	localPackages := []PackageInfo{
+		{Category: "app-misc", Package: "hello", LatestVersion: "1.0"},
+		{Category: "dev-util", Package: "tool", LatestVersion: "1.0"},
+		{Category: "net-misc", Package: "live", LatestVersion: "1.0"},
+		{Category: "app-misc", Package: "same", LatestVersion: "1.5"},
+		{Category: "app-misc", Package: "newer", LatestVersion: "1.0"},
+		{Category: "app-misc", Package: "tie", LatestVersion: "1.0"},
+		{Category: "net-misc", Package: "partial", LatestVersion: "0.1"},
+		{Category: "app-misc", Package: "bentoo-only", LatestVersion: "1.0"},
+	}
+
+	report, err := CompareMulti(localPackages, repos, CompareOptions{IncludeSynced: true, IncludeNotInRemote: true})
+	if err != nil {
+		t.Fatalf("CompareMulti failed: %v", err)
+	}
+
+	if strings.Join(report.Repositories, ",") != "gentoo,guru,friend" {
+		t.Errorf("Repositories = %v", report.Repositories)
+	}
+
+	byPkg := make(map[string]CompareResult)
+	for _, r := range report.Results {
+		byPkg[r.Package] = r
+	}
+
+	tests := []struct {
+		pkg      string
+		status   CompareStatus
+		version  string
+		repo     string
+		versions string
+	}{
+		{"hello", StatusOutdated, "2.1", "guru", "2.0 2.1 error"},
+		{"tool", StatusOutdated, "3.0", "gentoo", "3.0 2.0 error"},
+		{"live", StatusOutdated, "9999", "gentoo", "9999 - error"},
+		{"same", StatusUpToDate, "1.5", "gentoo", "1.5 1.5 error"},
+		{"newer", StatusNewer, "0.9", "gentoo", "0.9 - error"},
+		{"tie", StatusOutdated, "4.0", "gentoo", "4.0 4.0 error"},
+		{"partial", StatusOutdated, "0.5", "guru", "9999 0.5 error"},
+		{"bentoo-only", StatusError, "", "", "- - error"},
+	}
+
+	for _, tt := range tests {
+		r, ok := byPkg[tt.pkg]
+		if !ok {
+			t.Errorf("%s: missing from results", tt.pkg)
+			continue
+		}
+		versions := []string{r.RemoteVersions["gentoo"], r.RemoteVersions["guru"], r.RemoteVersions["friend"]}
+		if r.Status != tt.status || r.RemoteVersion != tt.version || r.RemoteRepo != tt.repo || strings.Join(versions, " ") != tt.versions {
+			t.Errorf("%s = %v %q from %q, versions %v; want %v %q from %q, versions %s",
+				tt.pkg, r.Status, r.RemoteVersion, r.RemoteRepo, versions, tt.status, tt.version, tt.repo, tt.versions)
+		}
+	}
+
+	if report.OutdatedCount != 5 || report.ErrorCount != 1 {
+		t.Errorf("OutdatedCount = %d, ErrorCount = %d; want 5 and 1", report.OutdatedCount, report.ErrorCount)
+	}
+}
+
+func TestFormatReportMulti(t *testing.T) {
+	report := &CompareReport{
+		Repositories: []string{"gentoo", "guru"},
+		Results: []CompareResult{
+			{Category: "app-misc", Package: "hello", LocalVersion: "1.0", RemoteVersion: "2.1", RemoteRepo: "guru", Status: StatusOutdated,
+				RemoteVersions: map[string]string{"gentoo": "2.0", "guru": "2.1"}},
+		},
+	}
+
+	output := FormatReport(report)
+	for _, want := range []string{"Bentoo < Upstream", "│ gentoo", "│ guru", "│ 2.0 ", "2.1 (guru)", "outdated"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+
+	// Every row of the table has the same width
+	var widths []int
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "│") || strings.HasPrefix(line, "┌") || strings.Contains(line, "│ hello") {
+			widths = append(widths, len([]rune(line)))
+		}
+	}
+	for _, w := range widths {
+		if w != widths[0] {
+			t.Errorf("table rows have different widths: %v", widths)
+			break
+		}
+	}
+}
+
+// writeEbuilds creates ebuilds with the given content under root, keyed by
+// category/package/package-version.ebuild
+func writeEbuilds(t *testing.T, root string, ebuilds map[string]string) {
+	t.Helper()
+	for name, content := range ebuilds {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// cachedProvider is a fakeProvider answering from a cache fetched at fetchedAt
+type cachedProvider struct {
+	fakeProvider
+	freshness cache.Freshness
+	fetchedAt time.Time
+}
+
+func (c *cachedProvider) Freshness() (cache.Freshness, time.Time) { return c.freshness, c.fetchedAt }
+
+func TestCompareFreshness(t *testing.T) {
+	fetchedAt := time.Now().Add(-72 * time.Hour)
+	versions := map[string][]string{"app-misc/hello": {"2.0"}}
+	stale := &cachedProvider{fakeProvider{versions: versions}, cache.Stale, fetchedAt}
+	localPackages := []PackageInfo{{Category: "app-misc", Package: "hello", LatestVersion: "1.0"}}
+
+	report, err := CompareWithProvider(localPackages, stale, CompareOptions{})
+	if err != nil {
+		t.Fatalf("CompareWithProvider failed: %v", err)
+	}
+	if r := report.Results[0]; r.Freshness != cache.Stale || !r.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("result freshness = %s as of %v", r.Freshness, r.FetchedAt)
+	}
+	if report.Freshness != cache.Stale || !strings.Contains(FormatReport(report), "Upstream data is stale, fetched 3d ago") {
+		t.Errorf("FormatReport() =\n%s", FormatReport(report))
+	}
+
+	// A multi-repository result is as current as its least current repository
+	live := &fakeProvider{versions: versions}
+	cached := &cachedProvider{fakeProvider{versions: versions}, cache.Cached, fetchedAt}
+	report, err = CompareMulti(localPackages, []UpstreamRepo{{Name: "gentoo", Provider: live}, {Name: "guru", Provider: cached}}, CompareOptions{})
+	if err != nil {
+		t.Fatalf("CompareMulti failed: %v", err)
+	}
+	if r := report.Results[0]; r.Freshness != cache.Cached || report.Freshness != cache.Cached {
+		t.Errorf("multi freshness = %s, report %s; want cached", r.Freshness, report.Freshness)
+	}
+
+	// Live reports carry no notice
+	report, _ = CompareWithProvider(localPackages, live, CompareOptions{})
+	if report.Freshness != cache.Live || strings.Contains(FormatReport(report), "Upstream data") {
+		t.Errorf("live FormatReport() =\n%s", FormatReport(report))
+	}
+}
+
+func TestCompareSlots(t *testing.T) {
+	upstream := t.TempDir()
+	writeEbuilds(t, upstream, map[string]string{
+		"app-misc/hello/hello-1.5.ebuild":         "SLOT=\"0\"\nKEYWORDS=\"amd64\"\n",
+		"app-misc/hello/hello-2.0.ebuild":         "SLOT=\"0\"\nKEYWORDS=\"~amd64\"\n",
+		"app-misc/synced/synced-1.0.ebuild":       "SLOT=\"0\"\nKEYWORDS=\"amd64\"\n",
+		"dev-lang/python/python-3.11.9.ebuild":    "SLOT=\"$(ver_cut 1-2)\"\nKEYWORDS=\"amd64\"\n",
+		"dev-lang/python/python-3.12.5.ebuild":    "SLOT=\"$(ver_cut 1-2)\"\nKEYWORDS=\"amd64\"\n",
+		"dev-lang/python/python-3.12.6.ebuild":    "SLOT=\"$(ver_cut 1-2)\"\nKEYWORDS=\"~amd64\"\n",
+		"dev-lang/python/python-3.13.1.ebuild":    "SLOT=\"$(ver_cut 1-2)\"\nKEYWORDS=\"~amd64 arm64\"\n",
+		"dev-lang/python/python-3.14.9999.ebuild": "SLOT=\"$(ver_cut 1-2)\"\n",
+	})
+
+	overlayPath := t.TempDir()
+	writeEbuilds(t, overlayPath, map[string]string{
+		"app-misc/hello/hello-1.0.ebuild":      "SLOT=\"0\"\nKEYWORDS=\"~amd64\"\n",
+		"app-misc/synced/synced-1.0.ebuild":    "SLOT=\"0\"\nKEYWORDS=\"~amd64\"\n",
+		"app-misc/only/only-1.0.ebuild":        "SLOT=\"0\"\n",
+		"dev-lang/python/python-3.12.4.ebuild": "SLOT=\"3.12\"\nKEYWORDS=\"~amd64\"\n",
+	})
+	scan, err := ScanOverlay(overlayPath)
+	if err != nil {
+		t.Fatalf("ScanOverlay() error = %v", err)
+	}
+
+	prov := &provider.LocalProvider{Path: upstream, RepoName: "gentoo"}
+	report, err := CompareWithProvider(scan.Packages, prov, CompareOptions{OnlyOutdated: true, Slots: true, Arch: "amd64"})
+	if err != nil {
+		t.Fatalf("CompareWithProvider() error = %v", err)
+	}
+
+	// Packages are counted once, whatever their number of slots
+	if report.OutdatedCount != 2 || report.UpToDateCount != 1 || report.NotInRemoteCount != 1 {
+		t.Errorf("counts: outdated %d, up-to-date %d, not-in-remote %d",
+			report.OutdatedCount, report.UpToDateCount, report.NotInRemoteCount)
+	}
+
+	expected := []struct {
+		pkg, slot, local, remote, stable string
+		keywords                         ebuild.KeywordLevel
+		stableUpdate                     bool
+	}{
+		{"hello", "0", "1.0", "2.0", "1.5", ebuild.Testing, true},
+		{"python", "3.12", "3.12.4", "3.12.6", "3.12.5", ebuild.Testing, true},
+		{"python", "3.13", "", "3.13.1", "", ebuild.Testing, false},
+	}
+	if len(report.Results) != len(expected) {
+		t.Fatalf("Results = %+v, want %d slots", report.Results, len(expected))
+	}
+	for i, want := range expected {
+		r := report.Results[i]
+		if r.Package != want.pkg || r.Slot != want.slot || r.LocalVersion != want.local || r.RemoteVersion != want.remote ||
+			r.RemoteStable != want.stable || r.RemoteKeywords != want.keywords || r.HasStableUpdate() != want.stableUpdate ||
+			r.Status != StatusOutdated {
+			t.Errorf("Results[%d] = %+v, want %+v", i, r, want)
+		}
+	}
+
+	output := FormatReport(report)
+	for _, want := range []string{"│ Slot", "│ 3.13 ", "~amd64 only (stable 1.5)", "(2 with a stable update)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestCompareSlotsUnsupported(t *testing.T) {
+	local := []PackageInfo{{Category: "app-misc", Package: "hello", LatestVersion: "1.0"}}
+	opts := CompareOptions{Slots: true}
+
+	if _, err := CompareWithProvider(local, &fakeProvider{}, opts); err == nil {
+		t.Error("CompareWithProvider() with a provider without metadata succeeded")
+	}
+	repos := []UpstreamRepo{{Name: "a", Provider: &fakeProvider{}}, {Name: "b", Provider: &fakeProvider{}}}
+	if _, err := CompareMulti(local, repos, opts); err == nil {
+		t.Error("CompareMulti() with Slots succeeded")
+	}
+}
+
 func TestFormatReportEmpty(t *testing.T) {
 	report := &CompareReport{
 		TotalPackages: 5,
@@ -0,0 +1,25 @@
+package overlay
+
+import (
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+// NewExecutor returns a GitExecutor for the configured overlay, using the
+// git backend selected by git.backend in the config. It fails with
+// git.ErrUnsupported when that backend lacks any of the given features, so
+// operations needing them are refused before they start.
+func NewExecutor(cfg *config.Config, features ...git.Feature) (git.GitExecutor, error) {
+	overlayPath, err := cfg.GetOverlayPath()
+	if err != nil {
+		return nil, err
+	}
+	executor, err := git.NewExecutor(cfg.Git.Backend, overlayPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := git.RequireFeatures(executor, features...); err != nil {
+		return nil, err
+	}
+	return executor, nil
+}
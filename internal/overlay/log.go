@@ -0,0 +1,245 @@
+package overlay
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+)
+
+// LogFilter selects commits from the overlay history
+type LogFilter struct {
+	Package  string       // category/package, or a bare package name matched in any category
+	Category string       // Category name
+	Types    []ChangeType // Change types to include; empty means all
+	Author   string       // Case-insensitive substring of the author name or email
+	Since    time.Time    // Include commits on or after this time; zero means no bound
+	Until    time.Time    // Include commits before this time; zero means no bound
+	Limit    int          // Maximum number of commits returned; 0 means no limit
+}
+
+// hasChangeFilter reports whether the filter selects commits by their changes
+func (f LogFilter) hasChangeFilter() bool {
+	return f.Package != "" || f.Category != "" || len(f.Types) > 0
+}
+
+// matchesChange reports whether a change satisfies the package, category and type filters
+func (f LogFilter) matchesChange(c Change) bool {
+	if f.Category != "" && c.Category != f.Category {
+		return false
+	}
+	if f.Package != "" {
+		if strings.Contains(f.Package, "/") {
+			if c.Category+"/"+c.Package != f.Package {
+				return false
+			}
+		} else if c.Package != f.Package {
+			return false
+		}
+	}
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if c.Type == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesCommit reports whether a commit satisfies the author and date filters
+func (f LogFilter) matchesCommit(c git.CommitInfo) bool {
+	if f.Author != "" {
+		author := strings.ToLower(f.Author)
+		if !strings.Contains(strings.ToLower(c.Author), author) && !strings.Contains(strings.ToLower(c.Email), author) {
+			return false
+		}
+	}
+	if !f.Since.IsZero() && c.Date.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !c.Date.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// LogEntry is a commit with the changes parsed from its subject
+type LogEntry struct {
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	Changes []Change  `json:"changes"` // Parsed changes; only matching ones when filtering by change
+}
+
+// ParseChangeTypes parses a comma-separated list of change types (e.g., "up,add")
+func ParseChangeTypes(s string) ([]ChangeType, error) {
+	var types []ChangeType
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		switch ct := ChangeType(part); ct {
+		case Add, Del, Mod, Up, Down:
+			types = append(types, ct)
+		default:
+			return nil, fmt.Errorf("unknown change type %q (expected add, del, mod, up or down)", part)
+		}
+	}
+	return types, nil
+}
+
+// Log returns the overlay history matching the filter, newest first
+func Log(cfg *config.Config, filter LogFilter) ([]LogEntry, error) {
+	runner, err := NewExecutor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return LogWithExecutor(runner, filter)
+}
+
+// LogWithExecutor returns the history matching the filter using the provided GitExecutor.
+// Commit subjects are parsed with ParseMessage; commits whose subjects do not use the
+// bentoo grammar are included with no changes unless the filter selects by change.
+func LogWithExecutor(executor git.GitExecutor, filter LogFilter) ([]LogEntry, error) {
+	// Without filters git can apply the limit itself
+	maxCount := 0
+	if !filter.hasChangeFilter() && filter.Author == "" && filter.Since.IsZero() && filter.Until.IsZero() {
+		maxCount = filter.Limit
+	}
+
+	commits, err := executor.Log("", maxCount)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []LogEntry
+	for _, c := range commits {
+		if filter.Limit > 0 && len(entries) >= filter.Limit {
+			break
+		}
+		if !filter.matchesCommit(c) {
+			continue
+		}
+
+		// Hand-written subjects simply carry no changes
+		changes, _ := ParseMessage(c.Subject)
+
+		if filter.hasChangeFilter() {
+			var matching []Change
+			for _, ch := range changes {
+				if filter.matchesChange(ch) {
+					matching = append(matching, ch)
+				}
+			}
+			if len(matching) == 0 {
+				continue
+			}
+			changes = matching
+		}
+
+		entries = append(entries, LogEntry{
+			Hash:    c.Hash,
+			Author:  c.Author,
+			Email:   c.Email,
+			Date:    c.Date,
+			Subject: c.Subject,
+			Changes: changes,
+		})
+	}
+
+	return entries, nil
+}
+
+// FormatLogTable formats log entries as a table with one row per change.
+// Commits without parsed changes get a single row showing their subject.
+func FormatLogTable(entries []LogEntry) string {
+	type row struct {
+		hash, date, author, kind, pkg, version string
+	}
+
+	var rows []row
+	for _, e := range entries {
+		base := row{hash: shortHash(e.Hash), date: e.Date.Format("2006-01-02"), author: e.Author}
+		if len(e.Changes) == 0 {
+			r := base
+			r.kind, r.pkg = "-", e.Subject
+			rows = append(rows, r)
+			continue
+		}
+		for _, c := range e.Changes {
+			r := base
+			r.kind = string(c.Type)
+			r.pkg = c.Category + "/" + c.Package
+			r.version = c.Version
+			if c.OldVersion != "" {
+				r.version = c.OldVersion + " -> " + c.Version
+			}
+			rows = append(rows, r)
+		}
+	}
+
+	if len(rows) == 0 {
+		return output.Sprint(output.Dim, "No matching commits.")
+	}
+
+	authorWidth, pkgWidth := len("Author"), len("Package")
+	for _, r := range rows {
+		authorWidth = max(authorWidth, len(r.author))
+		pkgWidth = max(pkgWidth, len(r.pkg))
+	}
+	authorWidth = min(authorWidth, 24)
+	pkgWidth = min(pkgWidth, 50)
+
+	var sb strings.Builder
+	header := fmt.Sprintf("%-7s  %-10s  %-*s  %-4s  %-*s  %s", "Commit", "Date", authorWidth, "Author", "Type", pkgWidth, "Package", "Version")
+	sb.WriteString(output.Sprint(output.Header, header) + "\n")
+	for _, r := range rows {
+		sb.WriteString(fmt.Sprintf("%s  %-10s  %-*s  %s  %-*s  %s\n",
+			output.Sprintf(output.Warning, "%-7s", r.hash),
+			r.date,
+			authorWidth, truncateString(r.author, authorWidth),
+			output.Sprintf(changeTypeColor(r.kind), "%-4s", r.kind),
+			pkgWidth, truncateString(r.pkg, pkgWidth),
+			r.version))
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// changeTypeColor returns the color used for a change type in tables
+func changeTypeColor(kind string) *color.Color {
+	switch ChangeType(kind) {
+	case Add:
+		return output.Added
+	case Del:
+		return output.Deleted
+	case Up, Down:
+		return output.Info
+	case Mod:
+		return output.Modified
+	default:
+		return output.Dim
+	}
+}
+
+// shortHash abbreviates a commit hash for display
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,162 @@
+package overlay
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+// logMock returns a mock whose history holds the given commits, newest first
+func logMock(commits []git.CommitInfo) *git.MockGitRunner {
+	mock := git.NewMockGitRunner("/test/overlay")
+	mock.LogFunc = func(rev string, maxCount int) ([]git.CommitInfo, error) {
+		if maxCount > 0 && maxCount < len(commits) {
+			return commits[:maxCount], nil
+		}
+		return commits, nil
+	}
+	return mock
+}
+
+func testHistory() []git.CommitInfo {
+	date := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+	return []git.CommitInfo{
+		{Hash: "c6", Author: "Alice", Email: "alice@example.com", Date: date("2026-01-10"), Subject: "up(www-client/firefox{,-bin}-134.0 -> 135.0)"},
+		{Hash: "c5", Author: "Bob", Email: "bob@example.org", Date: date("2025-11-02"), Subject: "Fix metadata.xml"},
+		{Hash: "c4", Author: "Bob", Email: "bob@example.org", Date: date("2025-09-15"), Subject: "up(www-client/firefox-130.0 -> 131.0, dev-libs/nss-3.1 -> 3.2)"},
+		{Hash: "c3", Author: "Alice", Email: "alice@example.com", Date: date("2025-03-01"), Subject: "add(dev-python/requests-2.0), up(www-client/firefox-120.0 -> 121.0)"},
+		{Hash: "c2", Author: "Alice", Email: "alice@example.com", Date: date("2024-12-20"), Subject: "up(www-client/firefox-119.0 -> 120.0)"},
+		{Hash: "c1", Author: "Carol", Email: "carol@example.net", Date: date("2024-06-01"), Subject: "add(www-client/firefox-119.0)"},
+	}
+}
+
+func logHashes(entries []LogEntry) []string {
+	var hashes []string
+	for _, e := range entries {
+		hashes = append(hashes, e.Hash)
+	}
+	return hashes
+}
+
+func TestLogWithExecutorFilters(t *testing.T) {
+	since, _ := time.Parse(time.DateOnly, "2025-01-01")
+	until, _ := time.Parse(time.DateOnly, "2026-01-01")
+
+	tests := []struct {
+		name     string
+		filter   LogFilter
+		expected []string
+	}{
+		{"no filter", LogFilter{}, []string{"c6", "c5", "c4", "c3", "c2", "c1"}},
+		{"limit", LogFilter{Limit: 2}, []string{"c6", "c5"}},
+		{"package", LogFilter{Package: "www-client/firefox"}, []string{"c6", "c4", "c3", "c2", "c1"}},
+		{"bare package name", LogFilter{Package: "firefox-bin"}, []string{"c6"}},
+		{"category", LogFilter{Category: "dev-python"}, []string{"c3"}},
+		{"type", LogFilter{Types: []ChangeType{Add}}, []string{"c3", "c1"}},
+		{"author by email", LogFilter{Author: "EXAMPLE.ORG"}, []string{"c5", "c4"}},
+		{"date range", LogFilter{Since: since, Until: until}, []string{"c5", "c4", "c3"}},
+		{
+			name:     "firefox bumps in 2025",
+			filter:   LogFilter{Package: "www-client/firefox", Types: []ChangeType{Up}, Since: since, Until: until},
+			expected: []string{"c4", "c3"},
+		},
+		{"limit after filtering", LogFilter{Types: []ChangeType{Up}, Limit: 3}, []string{"c6", "c4", "c3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := LogWithExecutor(logMock(testHistory()), tt.filter)
+			if err != nil {
+				t.Fatalf("LogWithExecutor() error = %v", err)
+			}
+			if got := logHashes(entries); strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("hashes = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLogWithExecutorKeepsMatchingChanges(t *testing.T) {
+	entries, err := LogWithExecutor(logMock(testHistory()), LogFilter{Package: "nss"})
+	if err != nil {
+		t.Fatalf("LogWithExecutor() error = %v", err)
+	}
+	if len(entries) != 1 || len(entries[0].Changes) != 1 {
+		t.Fatalf("entries = %+v, want one commit with one change", entries)
+	}
+	want := Change{Type: Up, Category: "dev-libs", Package: "nss", Version: "3.2", OldVersion: "3.1"}
+	if entries[0].Changes[0] != want {
+		t.Errorf("change = %+v, want %+v", entries[0].Changes[0], want)
+	}
+
+	// Without a change filter, every parsed change is kept
+	entries, _ = LogWithExecutor(logMock(testHistory()), LogFilter{Limit: 1})
+	if len(entries[0].Changes) != 2 {
+		t.Errorf("changes = %+v, want firefox and firefox-bin", entries[0].Changes)
+	}
+}
+
+func TestLogWithExecutorError(t *testing.T) {
+	mock := git.NewMockGitRunner("/test/overlay")
+	mock.LogFunc = func(rev string, maxCount int) ([]git.CommitInfo, error) {
+		return nil, git.ErrGitCommand
+	}
+
+	if _, err := LogWithExecutor(mock, LogFilter{}); !errors.Is(err, git.ErrGitCommand) {
+		t.Errorf("LogWithExecutor() error = %v, want ErrGitCommand", err)
+	}
+}
+
+func TestLogEntryJSON(t *testing.T) {
+	entries, _ := LogWithExecutor(logMock(testHistory()), LogFilter{Category: "dev-python"})
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	for _, want := range []string{`"hash":"c3"`, `"author":"Alice"`, `"type":"add"`, `"package":"requests"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON %s missing %s", data, want)
+		}
+	}
+}
+
+func TestParseChangeTypes(t *testing.T) {
+	types, err := ParseChangeTypes("up, down,add")
+	if err != nil {
+		t.Fatalf("ParseChangeTypes() error = %v", err)
+	}
+	if len(types) != 3 || types[0] != Up || types[1] != Down || types[2] != Add {
+		t.Errorf("ParseChangeTypes() = %v", types)
+	}
+
+	if _, err := ParseChangeTypes("up,bump"); err == nil {
+		t.Error("ParseChangeTypes() expected error for unknown type")
+	}
+}
+
+func TestFormatLogTable(t *testing.T) {
+	entries, _ := LogWithExecutor(logMock(testHistory()), LogFilter{Limit: 2})
+	table := FormatLogTable(entries)
+
+	for _, want := range []string{"Commit", "2026-01-10", "www-client/firefox-bin", "134.0 -> 135.0", "Fix metadata.xml"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("table missing %q:\n%s", want, table)
+		}
+	}
+	// One header row, two firefox rows and one free-form commit
+	if lines := strings.Split(table, "\n"); len(lines) != 4 {
+		t.Errorf("table has %d lines, want 4:\n%s", len(lines), table)
+	}
+
+	if got := FormatLogTable(nil); !strings.Contains(got, "No matching commits") {
+		t.Errorf("FormatLogTable(nil) = %q", got)
+	}
+}
@@ -0,0 +1,100 @@
+// Package overlay provides business logic for overlay management operations.
+package overlay
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// MappingError reports a malformed line in a rename mapping file.
+type MappingError struct {
+	Line   int    // 1-based line number
+	Text   string // Offending line content
+	Reason string // Human-readable explanation
+}
+
+// Error implements the error interface.
+func (e *MappingError) Error() string {
+	return fmt.Sprintf("mapping line %d: %s: %q", e.Line, e.Reason, e.Text)
+}
+
+// ParseRenameMapping parses a rename mapping from r.
+//
+// Each non-empty line has the form:
+//
+//	<category>/<package> <old-version> <new-version>
+//
+// The old version may be an exact version, a glob or a constraint (see
+// ParseVersionPattern). Lines starting with '#' are comments. Each package
+// may appear only once.
+func ParseRenameMapping(r io.Reader) ([]*RenameSpec, error) {
+	var specs []*RenameSpec
+	seen := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+
+		// Skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, &MappingError{Line: lineNum, Text: raw, Reason: "expected '<category>/<package> <old-version> <new-version>'"}
+		}
+
+		category, pkg, ok := strings.Cut(fields[0], "/")
+		if !ok || category == "" || pkg == "" || strings.Contains(pkg, "/") {
+			return nil, &MappingError{Line: lineNum, Text: raw, Reason: "package must be in '<category>/<package>' form"}
+		}
+		if strings.ContainsAny(category+pkg, "*?[") {
+			return nil, &MappingError{Line: lineNum, Text: raw, Reason: "wildcards are not allowed in mapping package names"}
+		}
+
+		if _, err := ParseVersionPattern(fields[1]); err != nil {
+			return nil, &MappingError{Line: lineNum, Text: raw, Reason: err.Error()}
+		}
+
+		if prev, dup := seen[fields[0]]; dup {
+			return nil, &MappingError{Line: lineNum, Text: raw, Reason: fmt.Sprintf("duplicate entry (first seen on line %d)", prev)}
+		}
+		seen[fields[0]] = lineNum
+
+		specs = append(specs, &RenameSpec{
+			Category:       category,
+			PackagePattern: pkg,
+			OldVersion:     fields[1],
+			NewVersion:     fields[2],
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return specs, nil
+}
+
+// LoadRenameMapping reads a rename mapping from a file.
+// A path of "-" reads from standard input.
+func LoadRenameMapping(path string) ([]*RenameSpec, error) {
+	if path == "-" {
+		return ParseRenameMapping(os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseRenameMapping(f)
+}
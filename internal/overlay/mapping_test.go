@@ -0,0 +1,124 @@
+package overlay
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+)
+
+// TestParseRenameMapping tests parsing of valid mapping input.
+func TestParseRenameMapping(t *testing.T) {
+	input := `# bulk gstreamer bump
+media-plugins/gst-plugins-base 1.24.11 1.26.10
+
+media-plugins/gst-plugins-good  1.24.*   1.26.10
+media-libs/gstreamer <1.26 1.26.10
+`
+	specs, err := ParseRenameMapping(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseRenameMapping() error = %v", err)
+	}
+	if len(specs) != 3 {
+		t.Fatalf("ParseRenameMapping() got %d specs, want 3", len(specs))
+	}
+
+	want := RenameSpec{Category: "media-plugins", PackagePattern: "gst-plugins-good", OldVersion: "1.24.*", NewVersion: "1.26.10"}
+	if *specs[1] != want {
+		t.Errorf("specs[1] = %+v, want %+v", *specs[1], want)
+	}
+}
+
+// TestParseRenameMappingErrors tests that malformed lines are rejected with line numbers.
+func TestParseRenameMappingErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantLine int
+	}{
+		{"missing field", "app-misc/hello 1.0\n", 1},
+		{"no category", "\nhello 1.0 2.0\n", 2},
+		{"wildcard package", "app-misc/hel* 1.0 2.0\n", 1},
+		{"bad version pattern", "app-misc/hello < 2.0\n", 1},
+		{"duplicate", "app-misc/hello 1.0 2.0\napp-misc/hello 1.1 2.0\n", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRenameMapping(strings.NewReader(tt.input))
+			var mErr *MappingError
+			if !errors.As(err, &mErr) {
+				t.Fatalf("expected MappingError, got %v", err)
+			}
+			if mErr.Line != tt.wantLine {
+				t.Errorf("error line = %d, want %d", mErr.Line, tt.wantLine)
+			}
+		})
+	}
+}
+
+// TestRenameBatchFromMapping tests that a mapping is executed as a single rename.
+func TestRenameBatchFromMapping(t *testing.T) {
+	overlayPath := setupRenameTestOverlay(t)
+	defer os.RemoveAll(overlayPath)
+
+	createRenameTestEbuild(t, overlayPath, "media-plugins", "gst-plugins-base", "1.24.10")
+	createRenameTestEbuild(t, overlayPath, "media-plugins", "gst-plugins-good", "1.24.11")
+
+	mapping := filepath.Join(overlayPath, "bumps.txt")
+	content := "media-plugins/gst-plugins-base 1.24.10 1.26.10\nmedia-plugins/gst-plugins-good 1.24.* 1.26.10\n"
+	if err := os.WriteFile(mapping, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write mapping: %v", err)
+	}
+
+	specs, err := LoadRenameMapping(mapping)
+	if err != nil {
+		t.Fatalf("LoadRenameMapping() error = %v", err)
+	}
+
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+
+	preview, err := RenamePreviewBatch(cfg, specs)
+	if err != nil {
+		t.Fatalf("RenamePreviewBatch() error = %v", err)
+	}
+	if len(preview.Matches) != 2 {
+		t.Fatalf("RenamePreviewBatch() got %d matches, want 2", len(preview.Matches))
+	}
+
+	result, err := RenameBatch(cfg, specs, &RenameOptions{SkipPrompt: true, NoManifest: true})
+	if err != nil {
+		t.Fatalf("RenameBatch() error = %v", err)
+	}
+	if len(result.Renamed) != 2 {
+		t.Errorf("RenameBatch() renamed %d, want 2", len(result.Renamed))
+	}
+
+	for _, pkg := range []string{"gst-plugins-base", "gst-plugins-good"} {
+		newPath := filepath.Join(overlayPath, "media-plugins", pkg, pkg+"-1.26.10.ebuild")
+		if _, err := os.Stat(newPath); err != nil {
+			t.Errorf("expected %s to exist", newPath)
+		}
+	}
+}
+
+// TestRenameBatchOverlappingSpecs tests that an ebuild matched twice is rejected.
+func TestRenameBatchOverlappingSpecs(t *testing.T) {
+	overlayPath := setupRenameTestOverlay(t)
+	defer os.RemoveAll(overlayPath)
+
+	createRenameTestEbuild(t, overlayPath, "app-misc", "hello", "1.0.0")
+
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+	specs := []*RenameSpec{
+		{Category: "app-misc", PackagePattern: "hello", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+		{Category: "*", PackagePattern: "hello", OldVersion: "1.*", NewVersion: "3.0.0"},
+	}
+
+	if _, err := RenamePreviewBatch(cfg, specs); err == nil {
+		t.Error("RenamePreviewBatch() expected error for overlapping specs")
+	}
+}
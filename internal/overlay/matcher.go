@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
 )
 
 // revisionRegex matches revision suffixes like -r1, -r2, etc.
@@ -25,10 +27,15 @@ func NewEbuildMatcher(overlayPath string) *EbuildMatcher {
 
 // Match finds all ebuilds matching the specification.
 // It searches within the specified category (or all categories if "*")
-// and matches both package name pattern and exact old version.
+// and matches both package name pattern and old version pattern.
 func (m *EbuildMatcher) Match(spec *RenameSpec) ([]RenameMatch, error) {
 	var matches []RenameMatch
 
+	pattern, err := ParseVersionPattern(spec.OldVersion)
+	if err != nil {
+		return nil, err
+	}
+
 	if spec.Category == "*" {
 		// Global search: scan all categories
 		entries, err := os.ReadDir(m.overlayPath)
@@ -47,7 +54,7 @@ func (m *EbuildMatcher) Match(spec *RenameSpec) ([]RenameMatch, error) {
 			}
 
 			categoryPath := filepath.Join(m.overlayPath, categoryName)
-			categoryMatches, err := m.matchCategory(categoryPath, categoryName, spec)
+			categoryMatches, err := m.matchCategory(categoryPath, categoryName, spec, pattern)
 			if err != nil {
 				// Continue scanning other categories on error
 				continue
@@ -61,7 +68,7 @@ func (m *EbuildMatcher) Match(spec *RenameSpec) ([]RenameMatch, error) {
 			return nil, &CategoryNotFoundError{Category: spec.Category}
 		}
 
-		categoryMatches, err := m.matchCategory(categoryPath, spec.Category, spec)
+		categoryMatches, err := m.matchCategory(categoryPath, spec.Category, spec, pattern)
 		if err != nil {
 			return nil, err
 		}
@@ -82,7 +89,7 @@ func (e *CategoryNotFoundError) Error() string {
 }
 
 // matchCategory searches within a single category for matching ebuilds.
-func (m *EbuildMatcher) matchCategory(categoryPath, categoryName string, spec *RenameSpec) ([]RenameMatch, error) {
+func (m *EbuildMatcher) matchCategory(categoryPath, categoryName string, spec *RenameSpec, pattern *VersionPattern) ([]RenameMatch, error) {
 	var matches []RenameMatch
 
 	entries, err := os.ReadDir(categoryPath)
@@ -108,7 +115,7 @@ func (m *EbuildMatcher) matchCategory(categoryPath, categoryName string, spec *R
 
 		// Scan package directory for matching ebuilds
 		pkgPath := filepath.Join(categoryPath, pkgName)
-		pkgMatches, err := m.matchPackageEbuilds(pkgPath, categoryName, pkgName, spec)
+		pkgMatches, err := m.matchPackageEbuilds(pkgPath, categoryName, pkgName, spec, pattern)
 		if err != nil {
 			// Continue scanning other packages on error
 			continue
@@ -136,7 +143,8 @@ func (m *EbuildMatcher) matchPackage(pkgName, pattern string) bool {
 }
 
 // matchPackageEbuilds scans a package directory for ebuilds matching the version.
-func (m *EbuildMatcher) matchPackageEbuilds(pkgPath, category, pkgName string, spec *RenameSpec) ([]RenameMatch, error) {
+// For non-exact version patterns only the highest matching ebuild is returned.
+func (m *EbuildMatcher) matchPackageEbuilds(pkgPath, category, pkgName string, spec *RenameSpec, pattern *VersionPattern) ([]RenameMatch, error) {
 	var matches []RenameMatch
 
 	entries, err := os.ReadDir(pkgPath)
@@ -144,6 +152,7 @@ func (m *EbuildMatcher) matchPackageEbuilds(pkgPath, category, pkgName string, s
 		return nil, err
 	}
 
+	var highest string
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
@@ -155,33 +164,44 @@ func (m *EbuildMatcher) matchPackageEbuilds(pkgPath, category, pkgName string, s
 		}
 
 		// Check if ebuild matches the old version
-		matched, hasRevision := m.matchEbuild(filename, pkgName, spec.OldVersion)
-		if !matched {
+		version, baseVersion, hasRevision, ok := m.parseEbuildVersion(filename, pkgName)
+		if !ok || !pattern.Match(baseVersion) {
 			continue
 		}
 
 		// Build the rename match
-		match := m.buildRenameMatch(category, pkgName, filename, spec.OldVersion, spec.NewVersion, hasRevision)
+		match := m.buildRenameMatch(category, pkgName, filename, baseVersion, spec.NewVersion, hasRevision)
 		match.OldPath = filepath.Join(pkgPath, filename)
 		match.NewPath = filepath.Join(pkgPath, match.NewFilename)
-		matches = append(matches, match)
+
+		if pattern.IsExact() {
+			matches = append(matches, match)
+			continue
+		}
+
+		// Pattern match: keep only the highest matching ebuild
+		if highest == "" || ebuild.CompareVersions(version, highest) > 0 {
+			highest = version
+			matches = []RenameMatch{match}
+		}
 	}
 
 	return matches, nil
 }
 
-// matchEbuild checks if an ebuild filename matches the old version.
-// Returns (matched, hasRevision) where:
-// - matched: true if the base version (without revision) equals oldVersion
+// parseEbuildVersion extracts the version from an ebuild filename.
+// Returns (version, baseVersion, hasRevision, ok) where:
+// - version: the full version including any revision suffix
+// - baseVersion: the version without revision suffix
 // - hasRevision: true if the filename has a revision suffix (-rN)
-func (m *EbuildMatcher) matchEbuild(filename, pkgName, oldVersion string) (bool, bool) {
-	// Parse the ebuild filename to extract version
+// - ok: false if the filename does not belong to pkgName
+func (m *EbuildMatcher) parseEbuildVersion(filename, pkgName string) (string, string, bool, bool) {
 	// Expected format: pkgName-version.ebuild
 	prefix := pkgName + "-"
 	suffix := ".ebuild"
 
 	if !strings.HasPrefix(filename, prefix) || !strings.HasSuffix(filename, suffix) {
-		return false, false
+		return "", "", false, false
 	}
 
 	// Extract version from filename
@@ -197,8 +217,7 @@ func (m *EbuildMatcher) matchEbuild(filename, pkgName, oldVersion string) (bool,
 		baseVersion = revisionRegex.ReplaceAllString(version, "")
 	}
 
-	// Match if base version equals old version
-	return baseVersion == oldVersion, hasRevision
+	return version, baseVersion, hasRevision, true
 }
 
 // buildRenameMatch creates a RenameMatch from matched ebuild information.
@@ -210,6 +229,7 @@ func (m *EbuildMatcher) buildRenameMatch(category, pkgName, oldFilename, oldVers
 	return RenameMatch{
 		Category:    category,
 		Package:     pkgName,
+		OldVersion:  oldVersion,
 		OldFilename: oldFilename,
 		NewFilename: newFilename,
 		HasRevision: hasRevision,
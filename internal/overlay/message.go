@@ -0,0 +1,245 @@
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+// Commit message styles
+const (
+	StyleBentoo   = "bentoo"   // add(cat/pkg-1.0), up(cat/pkg-1.0 -> 2.0)
+	StyleGentoo   = "gentoo"   // cat/pkg: add 2.0, drop 1.0
+	StyleTemplate = "template" // User-defined Go text/template
+)
+
+var (
+	// ErrUnknownStyle indicates an unsupported commit message style
+	ErrUnknownStyle = errors.New("unknown commit message style")
+	// ErrEmptyMessage indicates a message template produced no subject
+	ErrEmptyMessage = errors.New("commit message template produced an empty message")
+)
+
+// MessageOptions configures commit message generation
+type MessageOptions struct {
+	Style    string // One of the Style* constants; empty means StyleBentoo
+	Template string // Template text for StyleTemplate
+	Body     bool   // Append a body listing changed files
+	Package  string // category/package all staged entries belong to, if known; named when no ebuild changed
+	SignOff  bool   // Append a Signed-off-by trailer
+	User     string // Sign-off name
+	Email    string // Sign-off email
+}
+
+// MessageData is the data passed to user-defined message templates
+type MessageData struct {
+	Changes []Change // Ebuild changes, as returned by AnalyzeChanges
+	Files   []string // All staged paths
+}
+
+// MessageOptionsFromConfig builds MessageOptions from the overlay commit configuration.
+// The sign-off identity is taken from the git user settings.
+func MessageOptionsFromConfig(cfg *config.Config) MessageOptions {
+	return MessageOptions{
+		Style:    cfg.Overlay.Commit.Style,
+		Template: cfg.Overlay.Commit.Template,
+		Body:     cfg.Overlay.Commit.Body,
+		SignOff:  cfg.Overlay.Commit.SignOff,
+		User:     cfg.Git.User,
+		Email:    cfg.Git.Email,
+	}
+}
+
+// FormatMessage generates a complete commit message for the staged entries
+// using the configured style, optional file list body and sign-off trailer.
+func FormatMessage(staged []git.StatusEntry, opts MessageOptions) (string, error) {
+	subject, err := formatSubject(AnalyzeChanges(staged), staged, opts)
+	if err != nil {
+		return "", err
+	}
+
+	message := subject
+	if opts.Body && len(staged) > 0 {
+		message += "\n\n" + formatFileList(staged)
+	}
+
+	return ApplySignOff(message, opts), nil
+}
+
+// formatSubject generates the message subject in the configured style
+func formatSubject(changes []Change, staged []git.StatusEntry, opts MessageOptions) (string, error) {
+	switch opts.Style {
+	case "", StyleBentoo:
+		if len(changes) == 0 && opts.Package != "" {
+			// Only non-ebuild files changed; name the package explicitly
+			return "update: " + opts.Package, nil
+		}
+		return GenerateMessage(changes), nil
+	case StyleGentoo:
+		return gentooSubject(changes, staged), nil
+	case StyleTemplate:
+		return executeMessageTemplate(opts.Template, changes, staged)
+	default:
+		return "", fmt.Errorf("%w: %q (expected %s, %s or %s)", ErrUnknownStyle, opts.Style, StyleBentoo, StyleGentoo, StyleTemplate)
+	}
+}
+
+// GenerateGentooMessage generates a subject in the Gentoo convention:
+// "cat/pkg: add 2.0, drop 1.0". Multiple packages are joined with "; ".
+func GenerateGentooMessage(changes []Change) string {
+	return gentooSubject(changes, nil)
+}
+
+// gentooSubject generates a Gentoo-style subject. When there are no ebuild
+// changes, the package is named if all staged files belong to one package.
+func gentooSubject(changes []Change, staged []git.StatusEntry) string {
+	if len(changes) == 0 {
+		if key := commonPackageKey(staged); key != "" {
+			return key + ": update files"
+		}
+		return "update files"
+	}
+
+	type actions struct {
+		add, drop, update []string
+	}
+	byPackage := make(map[string]*actions)
+	for _, c := range changes {
+		key := c.Category + "/" + c.Package
+		if byPackage[key] == nil {
+			byPackage[key] = &actions{}
+		}
+		a := byPackage[key]
+		switch c.Type {
+		case Add:
+			a.add = append(a.add, c.Version)
+		case Del:
+			a.drop = append(a.drop, c.Version)
+		case Mod:
+			a.update = append(a.update, c.Version)
+		case Up, Down:
+			a.add = append(a.add, c.Version)
+			a.drop = append(a.drop, c.OldVersion)
+		}
+	}
+
+	keys := make([]string, 0, len(byPackage))
+	for key := range byPackage {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	summaries := make([]string, 0, len(keys))
+	for _, key := range keys {
+		a := byPackage[key]
+		var parts []string
+		for _, action := range []struct {
+			verb     string
+			versions []string
+		}{{"add", a.add}, {"drop", a.drop}, {"update", a.update}} {
+			if len(action.versions) > 0 {
+				parts = append(parts, action.verb+" "+strings.Join(sortVersions(action.versions), ", "))
+			}
+		}
+		summaries = append(summaries, key+": "+strings.Join(parts, ", "))
+	}
+
+	return strings.Join(summaries, "; ")
+}
+
+// sortVersions returns the versions sorted in ascending version order
+func sortVersions(versions []string) []string {
+	sorted := append([]string(nil), versions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return ebuild.CompareVersions(sorted[i], sorted[j]) < 0
+	})
+	return sorted
+}
+
+// commonPackageKey returns the category/package shared by all entries, or ""
+func commonPackageKey(entries []git.StatusEntry) string {
+	key := ""
+	for _, e := range entries {
+		k := packageKey(e.FilePath)
+		if k == "" || (key != "" && k != key) {
+			return ""
+		}
+		key = k
+	}
+	return key
+}
+
+// messageTemplateFuncs are available in user-defined message templates
+var messageTemplateFuncs = template.FuncMap{
+	"join":   strings.Join,
+	"bentoo": GenerateMessage,
+	"gentoo": GenerateGentooMessage,
+}
+
+// executeMessageTemplate renders a user-defined template over the changes
+func executeMessageTemplate(text string, changes []Change, staged []git.StatusEntry) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("%w: style %q requires overlay.commit.template", ErrEmptyMessage, StyleTemplate)
+	}
+
+	tmpl, err := template.New("message").Funcs(messageTemplateFuncs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parsing commit message template: %w", err)
+	}
+
+	data := MessageData{Changes: changes}
+	for _, e := range staged {
+		data.Files = append(data.Files, e.FilePath)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("executing commit message template: %w", err)
+	}
+
+	message := strings.TrimSpace(sb.String())
+	if message == "" {
+		return "", ErrEmptyMessage
+	}
+	return message, nil
+}
+
+// formatFileList formats the staged entries as a message body
+func formatFileList(staged []git.StatusEntry) string {
+	lines := make([]string, 0, len(staged)+1)
+	lines = append(lines, "Files:")
+	for _, e := range staged {
+		path := e.FilePath
+		if e.OrigPath != "" {
+			path = e.OrigPath + " -> " + e.FilePath
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s", strings.ToLower(StatusLabel(e.Status)), path))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ApplySignOff appends a Signed-off-by trailer when sign-off is enabled.
+// The trailer is not duplicated if the message already carries it.
+func ApplySignOff(message string, opts MessageOptions) string {
+	if !opts.SignOff || opts.User == "" || opts.Email == "" {
+		return message
+	}
+
+	trailer := fmt.Sprintf("Signed-off-by: %s <%s>", opts.User, opts.Email)
+	if strings.Contains(message, trailer) {
+		return message
+	}
+
+	// Join an existing trailer block instead of starting a new paragraph
+	lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+	if len(lines) > 1 && strings.HasPrefix(lines[len(lines)-1], "Signed-off-by: ") {
+		return strings.TrimRight(message, "\n") + "\n" + trailer
+	}
+	return strings.TrimRight(message, "\n") + "\n\n" + trailer
+}
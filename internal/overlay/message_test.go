@@ -0,0 +1,140 @@
+package overlay
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+func TestFormatMessageStyles(t *testing.T) {
+	bump := []git.StatusEntry{
+		{Status: "A", FilePath: "net-misc/foo/foo-2.0.ebuild"},
+		{Status: "D", FilePath: "net-misc/foo/foo-1.0.ebuild"},
+		{Status: "M", FilePath: "net-misc/foo/Manifest"},
+	}
+	multi := []git.StatusEntry{
+		{Status: "A", FilePath: "app-misc/bar/bar-1.10.ebuild"},
+		{Status: "A", FilePath: "app-misc/bar/bar-1.9.ebuild"},
+		{Status: "D", FilePath: "dev-libs/baz/baz-0.1.ebuild"},
+		{Status: "M", FilePath: "dev-libs/baz/baz-0.2.ebuild"},
+	}
+	metadataOnly := []git.StatusEntry{
+		{Status: "M", FilePath: "dev-libs/baz/metadata.xml"},
+	}
+
+	tests := []struct {
+		name    string
+		staged  []git.StatusEntry
+		opts    MessageOptions
+		want    string
+		wantErr error
+	}{
+		{"default is bentoo", bump, MessageOptions{}, "up(net-misc/foo-1.0 -> 2.0)", nil},
+		{"bentoo", bump, MessageOptions{Style: StyleBentoo}, "up(net-misc/foo-1.0 -> 2.0)", nil},
+		{"gentoo bump", bump, MessageOptions{Style: StyleGentoo}, "net-misc/foo: add 2.0, drop 1.0", nil},
+		{"gentoo multiple packages", multi, MessageOptions{Style: StyleGentoo},
+			"app-misc/bar: add 1.9, 1.10; dev-libs/baz: drop 0.1, update 0.2", nil},
+		{"gentoo non-ebuild files", metadataOnly, MessageOptions{Style: StyleGentoo}, "dev-libs/baz: update files", nil},
+		{"bentoo non-ebuild files", metadataOnly, MessageOptions{}, "update: package files", nil},
+		{"bentoo non-ebuild files of a package", metadataOnly, MessageOptions{Package: "dev-libs/baz"}, "update: dev-libs/baz", nil},
+		{"bentoo package with ebuild changes", bump, MessageOptions{Package: "net-misc/foo"}, "up(net-misc/foo-1.0 -> 2.0)", nil},
+		{"template", bump, MessageOptions{Style: StyleTemplate,
+			Template: `{{range .Changes}}{{.Category}}/{{.Package}}: bump to {{.Version}}{{end}}`},
+			"net-misc/foo: bump to 2.0", nil},
+		{"template functions", bump, MessageOptions{Style: StyleTemplate,
+			Template: `[overlay] {{gentoo .Changes}} ({{join .Files " "}})`},
+			"[overlay] net-misc/foo: add 2.0, drop 1.0 (net-misc/foo/foo-2.0.ebuild net-misc/foo/foo-1.0.ebuild net-misc/foo/Manifest)", nil},
+		{"empty template output", bump, MessageOptions{Style: StyleTemplate, Template: `{{if false}}x{{end}}`}, "", ErrEmptyMessage},
+		{"missing template", bump, MessageOptions{Style: StyleTemplate}, "", ErrEmptyMessage},
+		{"unknown style", bump, MessageOptions{Style: "cvs"}, "", ErrUnknownStyle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatMessage(tt.staged, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("FormatMessage() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FormatMessage() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FormatMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatMessageInvalidTemplate(t *testing.T) {
+	_, err := FormatMessage(nil, MessageOptions{Style: StyleTemplate, Template: "{{.Nope"})
+	if err == nil || !strings.Contains(err.Error(), "parsing commit message template") {
+		t.Errorf("expected template parse error, got %v", err)
+	}
+}
+
+func TestFormatMessageBodyAndSignOff(t *testing.T) {
+	staged := []git.StatusEntry{
+		{Status: "A", FilePath: "app-misc/hello/hello-1.0.ebuild"},
+		{Status: "A", FilePath: "app-misc/hello/Manifest"},
+	}
+	opts := MessageOptions{Body: true, SignOff: true, User: "Test User", Email: "test@example.com"}
+
+	got, err := FormatMessage(staged, opts)
+	if err != nil {
+		t.Fatalf("FormatMessage() error = %v", err)
+	}
+
+	want := "add(app-misc/hello-1.0)\n\n" +
+		"Files:\n" +
+		"  added app-misc/hello/hello-1.0.ebuild\n" +
+		"  added app-misc/hello/Manifest\n\n" +
+		"Signed-off-by: Test User <test@example.com>"
+	if got != want {
+		t.Errorf("FormatMessage() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestApplySignOff(t *testing.T) {
+	opts := MessageOptions{SignOff: true, User: "Test User", Email: "test@example.com"}
+	trailer := "Signed-off-by: Test User <test@example.com>"
+
+	tests := []struct {
+		name    string
+		message string
+		opts    MessageOptions
+		want    string
+	}{
+		{"disabled", "fix build", MessageOptions{User: "Test User", Email: "test@example.com"}, "fix build"},
+		{"no identity", "fix build", MessageOptions{SignOff: true}, "fix build"},
+		{"subject only", "fix build", opts, "fix build\n\n" + trailer},
+		{"already signed", "fix build\n\n" + trailer, opts, "fix build\n\n" + trailer},
+		{"existing trailer block", "fix build\n\nSigned-off-by: Other <o@example.com>", opts,
+			"fix build\n\nSigned-off-by: Other <o@example.com>\n" + trailer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplySignOff(tt.message, tt.opts); got != tt.want {
+				t.Errorf("ApplySignOff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageOptionsFromConfig(t *testing.T) {
+	cfg := &config.Config{
+		Overlay: config.OverlayConfig{Commit: config.CommitConfig{Style: StyleGentoo, Body: true, SignOff: true}},
+		Git:     config.GitConfig{User: "Test User", Email: "test@example.com"},
+	}
+
+	opts := MessageOptionsFromConfig(cfg)
+	if opts.Style != StyleGentoo || !opts.Body || !opts.SignOff || opts.User != "Test User" || opts.Email != "test@example.com" {
+		t.Errorf("MessageOptionsFromConfig() = %+v", opts)
+	}
+}
@@ -0,0 +1,191 @@
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+	"github.com/obentoo/bentoolkit/internal/common/provider"
+)
+
+// upstreamMoves holds the package moves of an upstream repository. They are
+// read on first use, so a compare that finds every package by name never
+// reads profiles/updates.
+type upstreamMoves struct {
+	prov    provider.Provider
+	updates *ebuild.Updates
+	err     error
+}
+
+// newUpstreamMoves creates the move lookup of a provider
+func newUpstreamMoves(prov provider.Provider) *upstreamMoves {
+	return &upstreamMoves{prov: prov}
+}
+
+// load reads the moves once. Providers that cannot read files have none.
+func (m *upstreamMoves) load() error {
+	if m.updates != nil || m.err != nil {
+		return m.err
+	}
+
+	reader, ok := m.prov.(provider.FileReader)
+	if !ok {
+		m.updates = &ebuild.Updates{}
+		return nil
+	}
+	m.updates, m.err = provider.ReadUpdates(reader)
+	return m.err
+}
+
+// resolve returns the name upstream moved a category/package to. Moves that
+// cannot be read are treated as none.
+func (m *upstreamMoves) resolve(name string) (string, bool) {
+	if m == nil || m.load() != nil {
+		return name, false
+	}
+	return m.updates.Resolve(name)
+}
+
+// resolveSlot returns the slot upstream moved a package's slot to
+func (m *upstreamMoves) resolveSlot(name, slot string) string {
+	if m == nil || m.load() != nil {
+		return slot
+	}
+	return m.updates.ResolveSlot(name, slot)
+}
+
+// findRemoteVersions returns the upstream versions of a local package and
+// the category/package upstream carries it under. When upstream does not know
+// the local name, the package moves are followed to its current name.
+func findRemoteVersions(prov provider.Provider, moves *upstreamMoves, pkg PackageInfo) ([]string, string, error) {
+	name := pkg.FullName()
+	versions, err := prov.GetPackageVersions(pkg.Category, pkg.Package)
+	if !errors.Is(err, provider.ErrNotFound) {
+		return versions, name, err
+	}
+
+	newName, ok := moves.resolve(name)
+	if !ok {
+		return nil, name, err
+	}
+	category, pkgName, _ := strings.Cut(newName, "/")
+	moved, movedErr := prov.GetPackageVersions(category, pkgName)
+	if errors.Is(movedErr, provider.ErrNotFound) {
+		return nil, name, err
+	}
+	return moved, newName, movedErr
+}
+
+// MovedPackage is a local package that upstream has renamed or moved
+type MovedPackage struct {
+	Category string
+	Package  string
+	NewName  string // category/package upstream moved it to
+
+	// Exists is set when the overlay already has a package under NewName,
+	// and Recorded when the overlay's own profiles/updates has the move
+	Exists   bool
+	Recorded bool
+}
+
+// FullName returns the category/package format
+func (m *MovedPackage) FullName() string {
+	return m.Category + "/" + m.Package
+}
+
+// FindMovedPackages lists the local packages whose name upstream has moved,
+// according to upstream's profiles/updates. Packages upstream still carries
+// under their local name, such as a name reused after a move, are not
+// listed. local holds the overlay's own moves, if any, so moves it already
+// records are marked.
+func FindMovedPackages(localPackages []PackageInfo, prov provider.Provider, local *ebuild.Updates) ([]MovedPackage, error) {
+	moves := newUpstreamMoves(prov)
+	if err := moves.load(); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(localPackages))
+	for _, pkg := range localPackages {
+		names[pkg.FullName()] = true
+	}
+
+	var moved []MovedPackage
+	for _, pkg := range localPackages {
+		newName, ok := moves.updates.Resolve(pkg.FullName())
+		if !ok {
+			continue
+		}
+		_, err := prov.GetPackageVersions(pkg.Category, pkg.Package)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, provider.ErrNotFound) {
+			return nil, err
+		}
+
+		m := MovedPackage{
+			Category: pkg.Category,
+			Package:  pkg.Package,
+			NewName:  newName,
+			Exists:   names[newName],
+		}
+		if local != nil {
+			if recorded, ok := local.Resolve(pkg.FullName()); ok && recorded == newName {
+				m.Recorded = true
+			}
+		}
+		moved = append(moved, m)
+	}
+
+	sort.Slice(moved, func(i, j int) bool {
+		return moved[i].FullName() < moved[j].FullName()
+	})
+	return moved, nil
+}
+
+// FormatMovedPackages formats the moved packages for terminal output,
+// followed by the profiles/updates entries the overlay still lacks
+func FormatMovedPackages(moved []MovedPackage, repo string) string {
+	if len(moved) == 0 {
+		return output.Sprintf(output.Success, "No overlay package has been moved by %s.\n", repo)
+	}
+
+	headers := []string{"Package", "Moved To", "Note"}
+	rows := make([][]string, 0, len(moved))
+	colors := make([]*color.Color, 0, len(moved))
+	var missing []string
+	for _, m := range moved {
+		var notes []string
+		if m.Exists {
+			notes = append(notes, "new name already in overlay")
+		}
+		if m.Recorded {
+			notes = append(notes, "recorded in profiles/updates")
+		} else {
+			missing = append(missing, fmt.Sprintf("move %s %s", m.FullName(), m.NewName))
+		}
+
+		rowColor := output.Warning
+		if m.Recorded {
+			rowColor = output.Info
+		}
+		rows = append(rows, []string{m.FullName(), m.NewName, orDash(strings.Join(notes, ", "))})
+		colors = append(colors, rowColor)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(formatColumnsTable(headers, rows, colors, fmt.Sprintf("Packages Moved by %s", repo), output.Header))
+	sb.WriteString(fmt.Sprintf("\nTotal: %d moved\n", len(moved)))
+
+	if len(missing) > 0 {
+		sb.WriteString(output.Sprintf(output.Info, "\nSuggested %s entries:\n", ebuild.UpdatesDir))
+		for _, line := range missing {
+			sb.WriteString("  " + line + "\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,114 @@
+package overlay
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/provider"
+)
+
+func TestCompareFollowsMoves(t *testing.T) {
+	upstream := t.TempDir()
+	writeEbuilds(t, upstream, map[string]string{
+		"net-misc/hello-ng/hello-ng-2.0.ebuild": "SLOT=\"2\"\nKEYWORDS=\"amd64\"\n",
+		"app-misc/stay/stay-1.0.ebuild":         "SLOT=\"0\"\nKEYWORDS=\"amd64\"\n",
+		"profiles/updates/4Q-2023":              "move app-misc/hello app-misc/hello-ng\n",
+		"profiles/updates/1Q-2024":              "move app-misc/hello-ng net-misc/hello-ng\nslotmove net-misc/hello-ng 0 2\nmove app-misc/gone app-misc/gone-too\n",
+	})
+	overlayPath := t.TempDir()
+	writeEbuilds(t, overlayPath, map[string]string{
+		"app-misc/hello/hello-1.0.ebuild": "SLOT=\"0\"\nKEYWORDS=\"~amd64\"\n",
+		"app-misc/stay/stay-1.0.ebuild":   "SLOT=\"0\"\n",
+		"app-misc/gone/gone-1.0.ebuild":   "SLOT=\"0\"\n",
+	})
+	scan, err := ScanOverlay(overlayPath)
+	if err != nil {
+		t.Fatalf("ScanOverlay() error = %v", err)
+	}
+	prov := &provider.LocalProvider{Path: upstream, RepoName: "gentoo"}
+
+	report, err := CompareWithProvider(scan.Packages, prov, CompareOptions{IncludeSynced: true, IncludeNotInRemote: true})
+	if err != nil {
+		t.Fatalf("CompareWithProvider() error = %v", err)
+	}
+	results := make(map[string]CompareResult)
+	for _, r := range report.Results {
+		results[r.Package] = r
+	}
+	if r := results["hello"]; r.Status != StatusOutdated || r.RemoteVersion != "2.0" || r.RemoteName != "net-misc/hello-ng" {
+		t.Errorf("hello = %+v, want outdated against net-misc/hello-ng-2.0", r)
+	}
+	if r := results["stay"]; r.Status != StatusUpToDate || r.RemoteName != "" {
+		t.Errorf("stay = %+v, want up to date under its own name", r)
+	}
+	// A move to a package upstream no longer has is not followed
+	if r := results["gone"]; r.Status != StatusNotInRemote || r.RemoteName != "" {
+		t.Errorf("gone = %+v, want not in remote", r)
+	}
+	if output := FormatReport(report); !strings.Contains(output, "app-misc/hello -> net-misc/hello-ng") {
+		t.Errorf("output missing the move:\n%s", output)
+	}
+
+	// Slot mode reads the moved ebuilds and follows the slotmove
+	report, err = CompareWithProvider(scan.Packages[1:2], prov, CompareOptions{Slots: true, Arch: "amd64"})
+	if err != nil {
+		t.Fatalf("CompareWithProvider(Slots) error = %v", err)
+	}
+	if len(report.Results) != 1 {
+		t.Fatalf("Results = %+v, want one slot", report.Results)
+	}
+	if r := report.Results[0]; r.Package != "hello" || r.Slot != "2" || r.LocalVersion != "1.0" ||
+		r.RemoteVersion != "2.0" || r.RemoteName != "net-misc/hello-ng" {
+		t.Errorf("Results[0] = %+v, want slot 2 against net-misc/hello-ng-2.0", r)
+	}
+}
+
+func TestFindMovedPackages(t *testing.T) {
+	upstream := t.TempDir()
+	writeEbuilds(t, upstream, map[string]string{
+		"profiles/updates/1Q-2024": "move app-misc/a app-misc/b\nmove app-misc/c net-misc/c\nmove app-misc/d app-misc/e\n" +
+			"move app-misc/f app-misc/g\nmove app-misc/reused app-misc/other\n",
+		"profiles/updates/2Q-2024":          "move app-misc/g app-misc/f\n",
+		"app-misc/f/f-1.0.ebuild":           "",
+		"app-misc/reused/reused-1.0.ebuild": "",
+		"app-misc/other/other-1.0.ebuild":   "",
+	})
+	local := []PackageInfo{
+		{Category: "app-misc", Package: "a"},
+		{Category: "app-misc", Package: "b"},
+		{Category: "app-misc", Package: "c"},
+		{Category: "app-misc", Package: "f"},      // moved away and back
+		{Category: "app-misc", Package: "reused"}, // name reused upstream
+		{Category: "app-misc", Package: "kept"},
+	}
+	recorded := ebuild.ParseUpdates([]byte("move app-misc/c net-misc/c\n"))
+
+	moved, err := FindMovedPackages(local, &provider.LocalProvider{Path: upstream}, recorded)
+	if err != nil {
+		t.Fatalf("FindMovedPackages() error = %v", err)
+	}
+	want := []MovedPackage{
+		{Category: "app-misc", Package: "a", NewName: "app-misc/b", Exists: true},
+		{Category: "app-misc", Package: "c", NewName: "net-misc/c", Recorded: true},
+	}
+	if len(moved) != len(want) {
+		t.Fatalf("FindMovedPackages() = %+v, want %+v", moved, want)
+	}
+	for i := range want {
+		if moved[i] != want[i] {
+			t.Errorf("moved[%d] = %+v, want %+v", i, moved[i], want[i])
+		}
+	}
+
+	output := FormatMovedPackages(moved, "gentoo")
+	if !strings.Contains(output, "move app-misc/a app-misc/b") || strings.Contains(output, "move app-misc/c net-misc/c") {
+		t.Errorf("output should only suggest the unrecorded move:\n%s", output)
+	}
+
+	// Providers that cannot read files have no moves
+	moved, err = FindMovedPackages(local, &fakeProvider{}, nil)
+	if err != nil || len(moved) != 0 {
+		t.Errorf("FindMovedPackages(fake) = %+v, %v", moved, err)
+	}
+}
@@ -0,0 +1,297 @@
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrNotGeneratedMessage indicates a message does not use the bentoo commit grammar
+	ErrNotGeneratedMessage = errors.New("message does not use the add/del/mod/up/down grammar")
+)
+
+// MessageSyntaxError reports a malformed part of a bentoo commit message
+type MessageSyntaxError struct {
+	Text   string // Offending fragment
+	Reason string // Human-readable explanation
+}
+
+// Error implements the error interface.
+func (e *MessageSyntaxError) Error() string {
+	return fmt.Sprintf("invalid commit message: %s: %q", e.Reason, e.Text)
+}
+
+// MessageMismatchError reports a well-formed message that does not describe
+// the staged changes
+type MessageMismatchError struct {
+	Missing []Change // Staged changes absent from the message
+	Extra   []Change // Changes in the message that are not staged
+}
+
+// Error implements the error interface.
+func (e *MessageMismatchError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing "+GenerateMessage(e.Missing))
+	}
+	if len(e.Extra) > 0 {
+		parts = append(parts, "not staged "+GenerateMessage(e.Extra))
+	}
+	return "commit message does not match staged changes: " + strings.Join(parts, "; ")
+}
+
+// groupStartRegex matches the start of a change group, e.g. "up("
+var groupStartRegex = regexp.MustCompile(`^(add|del|mod|up|down)\(`)
+
+// pmsVersionRegex matches a complete PMS package version, e.g. "1.2.3_rc1-r2"
+var pmsVersionRegex = regexp.MustCompile(`^\d+(\.\d+)*[a-z]?((_alpha|_beta|_pre|_rc|_p)\d*)*(-r\d+)?$`)
+
+// ParseMessage parses the subject of a message produced by GenerateMessage
+// back into changes, in the order they appear. Only the first line is read.
+//
+// The default "update: package files" subject parses to no changes. Any other
+// subject outside the grammar returns ErrNotGeneratedMessage; a subject that
+// uses the grammar but is malformed returns a *MessageSyntaxError.
+func ParseMessage(message string) ([]Change, error) {
+	subject, _, _ := strings.Cut(message, "\n")
+	subject = strings.TrimSpace(subject)
+
+	if subject == GenerateMessage(nil) {
+		return nil, nil
+	}
+	if !groupStartRegex.MatchString(subject) {
+		return nil, ErrNotGeneratedMessage
+	}
+
+	groups, err := splitTopLevel(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, group := range groups {
+		m := groupStartRegex.FindStringSubmatch(group)
+		if m == nil || !strings.HasSuffix(group, ")") {
+			return nil, &MessageSyntaxError{Text: group, Reason: "expected <type>(<changes>)"}
+		}
+
+		ct := ChangeType(m[1])
+		body := group[len(m[0]) : len(group)-1]
+		groupChanges, err := parseChangeGroup(ct, body)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, groupChanges...)
+	}
+
+	return changes, nil
+}
+
+// parseChangeGroup parses the comma-separated items inside a type group
+func parseChangeGroup(ct ChangeType, body string) ([]Change, error) {
+	items, err := splitTopLevel(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, item := range items {
+		category, rest, ok := strings.Cut(item, "/")
+		if !ok || category == "" || rest == "" || strings.ContainsAny(category, "{}(), ") {
+			return nil, &MessageSyntaxError{Text: item, Reason: "expected <category>/<package>-<version>"}
+		}
+
+		// category/{a-1.0, b-2.0} groups several packages
+		pkgItems := []string{rest}
+		if strings.HasPrefix(rest, "{") && matchingBrace(rest, 0) == len(rest)-1 {
+			if pkgItems, err = splitTopLevel(rest[1 : len(rest)-1]); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, pkgItem := range pkgItems {
+			itemChanges, err := parsePackageItem(ct, category, pkgItem)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, itemChanges...)
+		}
+	}
+
+	return changes, nil
+}
+
+// parsePackageItem parses "pkg-1.0", "pkg-1.0 -> 2.0" or the variant form
+// "pkg{,-bin}-1.0" into one change per package
+func parsePackageItem(ct ChangeType, category, item string) ([]Change, error) {
+	versionSpec := item
+	names := []string{""}
+
+	// Variant group: base{suffix,...}-version
+	if open := strings.Index(item, "{"); open != -1 {
+		closeIdx := matchingBrace(item, open)
+		if closeIdx == -1 || open == 0 || closeIdx+1 >= len(item) || item[closeIdx+1] != '-' {
+			return nil, &MessageSyntaxError{Text: item, Reason: "expected <package>{<suffixes>}-<version>"}
+		}
+		base := item[:open]
+		names = nil
+		for _, suffix := range strings.Split(item[open+1:closeIdx], ",") {
+			names = append(names, base+strings.TrimSpace(suffix))
+		}
+		versionSpec = item[closeIdx+2:]
+	}
+
+	version, oldVersion := versionSpec, ""
+	if ct == Up || ct == Down {
+		oldPart, newPart, ok := strings.Cut(versionSpec, " -> ")
+		if !ok {
+			return nil, &MessageSyntaxError{Text: item, Reason: fmt.Sprintf("%s requires <old> -> <new>", ct)}
+		}
+		oldVersion, version = oldPart, strings.TrimSpace(newPart)
+	} else if strings.Contains(versionSpec, "->") {
+		return nil, &MessageSyntaxError{Text: item, Reason: fmt.Sprintf("%s does not take a version transition", ct)}
+	}
+
+	var changes []Change
+	for _, name := range names {
+		pkg := name
+		first := version
+		if ct == Up || ct == Down {
+			first = oldVersion
+		}
+
+		// Without variants the package name is fused to the (old) version
+		if name == "" {
+			var ok bool
+			if pkg, first, ok = splitPackageVersion(first); !ok {
+				return nil, &MessageSyntaxError{Text: item, Reason: "missing or invalid version"}
+			}
+		} else if !pmsVersionRegex.MatchString(first) {
+			return nil, &MessageSyntaxError{Text: item, Reason: "missing or invalid version"}
+		}
+
+		c := Change{Type: ct, Category: category, Package: pkg, Version: first}
+		if ct == Up || ct == Down {
+			if !pmsVersionRegex.MatchString(version) {
+				return nil, &MessageSyntaxError{Text: item, Reason: "invalid new version"}
+			}
+			c.OldVersion, c.Version = first, version
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, nil
+}
+
+// splitPackageVersion splits "name-1.0" at the first hyphen followed by a
+// valid PMS version, so names like "font-adobe-100dpi" stay intact
+func splitPackageVersion(s string) (pkg, version string, ok bool) {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '-' && i > 0 && pmsVersionRegex.MatchString(s[i+1:]) {
+			return s[:i], s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// splitTopLevel splits s on ", " separators that are not nested in braces or parentheses
+func splitTopLevel(s string) ([]string, error) {
+	var parts []string
+	depth := 0
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{', '(':
+			depth++
+		case '}', ')':
+			depth--
+			if depth < 0 {
+				return nil, &MessageSyntaxError{Text: s, Reason: "unbalanced brackets"}
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, &MessageSyntaxError{Text: s, Reason: "unbalanced brackets"}
+	}
+	parts = append(parts, strings.TrimSpace(s[start:]))
+
+	for _, p := range parts {
+		if p == "" {
+			return nil, &MessageSyntaxError{Text: s, Reason: "empty item"}
+		}
+	}
+	return parts, nil
+}
+
+// matchingBrace returns the index of the brace closing the one at open, or -1
+func matchingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// LintMessage checks a hand-written message against the staged changes.
+// Free-form messages are accepted; a message using the add/del/mod/up/down
+// grammar must parse and describe exactly the staged changes, otherwise a
+// *MessageSyntaxError or *MessageMismatchError is returned.
+func LintMessage(message string, staged []Change) error {
+	changes, err := ParseMessage(message)
+	if errors.Is(err, ErrNotGeneratedMessage) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The default subject covers commits without ebuild changes
+	if changes == nil {
+		return nil
+	}
+
+	missing, extra := diffChanges(staged, changes)
+	if len(missing) > 0 || len(extra) > 0 {
+		return &MessageMismatchError{Missing: missing, Extra: extra}
+	}
+	return nil
+}
+
+// diffChanges compares two change lists as multisets, returning the changes
+// only in want and the changes only in got
+func diffChanges(want, got []Change) (missing, extra []Change) {
+	counts := make(map[Change]int)
+	for _, c := range got {
+		counts[c]++
+	}
+	for _, c := range want {
+		if counts[c] > 0 {
+			counts[c]--
+			continue
+		}
+		missing = append(missing, c)
+	}
+	for _, c := range got {
+		if counts[c] > 0 {
+			counts[c]--
+			extra = append(extra, c)
+		}
+	}
+	return missing, extra
+}
@@ -0,0 +1,227 @@
+package overlay
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+// genMessageVersion generates PMS versions, favouring a few shared values so
+// that variant groups are formed
+func genMessageVersion() gopter.Gen {
+	return gen.Weighted([]gen.WeightedGen{
+		{Weight: 3, Gen: gen.OneConstOf("1.0", "2.0")},
+		{Weight: 1, Gen: gen.RegexMatch(`^[0-9]{1,3}(\.[0-9]{1,3}){0,2}[a-z]?(_(alpha|beta|pre|rc|p)[0-9]?)?(-r[1-9])?$`)},
+	})
+}
+
+// genMessageChange generates a change over a small pool of packages, with
+// hyphenated names and variant suffixes
+func genMessageChange() gopter.Gen {
+	return gopter.CombineGens(
+		gen.OneConstOf(Add, Del, Mod, Up, Down),
+		gen.OneConstOf("app-misc", "www-client"),
+		gen.Weighted([]gen.WeightedGen{
+			{Weight: 3, Gen: gen.OneConstOf("firefox", "font-adobe")},
+			{Weight: 1, Gen: gen.RegexMatch(`^[a-z][a-z0-9]{1,8}(-[a-z]{2,5})?$`)},
+		}),
+		gen.OneConstOf("", "", "-bin", "-qt6"),
+		genMessageVersion(),
+		genMessageVersion(),
+	).Map(func(values []interface{}) Change {
+		c := Change{
+			Type:     values[0].(ChangeType),
+			Category: values[1].(string),
+			Package:  values[2].(string) + values[3].(string),
+			Version:  values[4].(string),
+		}
+		if c.Type == Up || c.Type == Down {
+			c.OldVersion = values[5].(string)
+		}
+		return c
+	})
+}
+
+// TestParseMessageRoundTrip tests that ParseMessage inverts GenerateMessage
+func TestParseMessageRoundTrip(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 500
+	properties := gopter.NewProperties(parameters)
+
+	properties.Property("ParseMessage(GenerateMessage(changes)) returns the same changes", prop.ForAll(
+		func(changes []Change) bool {
+			message := GenerateMessage(changes)
+			parsed, err := ParseMessage(message)
+			if err != nil {
+				t.Logf("ParseMessage(%q) error = %v", message, err)
+				return false
+			}
+			if missing, extra := diffChanges(changes, parsed); len(missing) > 0 || len(extra) > 0 {
+				t.Logf("message %q: missing %+v, extra %+v", message, missing, extra)
+				return false
+			}
+			return true
+		},
+		gen.SliceOfN(8, genMessageChange()).SuchThat(func(c []Change) bool { return len(c) > 0 }),
+	))
+
+	properties.TestingRun(t)
+}
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected []Change
+	}{
+		{
+			name:     "default subject",
+			message:  "update: package files",
+			expected: nil,
+		},
+		{
+			name:    "single add",
+			message: "add(app-misc/hello-1.0)",
+			expected: []Change{
+				{Type: Add, Category: "app-misc", Package: "hello", Version: "1.0"},
+			},
+		},
+		{
+			name:    "version bump",
+			message: "up(sys-apps/world-1.0 -> 2.0)",
+			expected: []Change{
+				{Type: Up, Category: "sys-apps", Package: "world", Version: "2.0", OldVersion: "1.0"},
+			},
+		},
+		{
+			name:    "multiple types",
+			message: "add(app-misc/new-1.0), del(app-misc/old-1.0)",
+			expected: []Change{
+				{Type: Add, Category: "app-misc", Package: "new", Version: "1.0"},
+				{Type: Del, Category: "app-misc", Package: "old", Version: "1.0"},
+			},
+		},
+		{
+			name:    "packages grouped in braces",
+			message: "add(dev-python/{foo-1.0, bar-2.0_rc1-r1}, net-misc/baz-3)",
+			expected: []Change{
+				{Type: Add, Category: "dev-python", Package: "foo", Version: "1.0"},
+				{Type: Add, Category: "dev-python", Package: "bar", Version: "2.0_rc1-r1"},
+				{Type: Add, Category: "net-misc", Package: "baz", Version: "3"},
+			},
+		},
+		{
+			name:    "variants",
+			message: "up(www-client/firefox{,-bin}-130.0 -> 131.0)",
+			expected: []Change{
+				{Type: Up, Category: "www-client", Package: "firefox", Version: "131.0", OldVersion: "130.0"},
+				{Type: Up, Category: "www-client", Package: "firefox-bin", Version: "131.0", OldVersion: "130.0"},
+			},
+		},
+		{
+			name:    "hyphenated package name with digits",
+			message: "down(media-fonts/font-adobe-100dpi-1.0.4 -> 1.0.3)",
+			expected: []Change{
+				{Type: Down, Category: "media-fonts", Package: "font-adobe-100dpi", Version: "1.0.3", OldVersion: "1.0.4"},
+			},
+		},
+		{
+			name:    "body is ignored",
+			message: "mod(app-misc/hello-1.0)\n\nFiles:\n  app-misc/hello/hello-1.0.ebuild",
+			expected: []Change{
+				{Type: Mod, Category: "app-misc", Package: "hello", Version: "1.0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := ParseMessage(tt.message)
+			if err != nil {
+				t.Fatalf("ParseMessage(%q) error = %v", tt.message, err)
+			}
+			if !reflect.DeepEqual(changes, tt.expected) {
+				t.Errorf("ParseMessage(%q) = %+v, want %+v", tt.message, changes, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseMessageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		syntax  bool // true for *MessageSyntaxError, false for ErrNotGeneratedMessage
+	}{
+		{"free-form subject", "Fix typo in README", false},
+		{"gentoo style", "app-misc/hello: add 1.0", false},
+		{"missing version", "add(app-misc/hello)", true},
+		{"bump without transition", "up(app-misc/hello-1.0)", true},
+		{"transition on add", "add(app-misc/hello-1.0 -> 2.0)", true},
+		{"unbalanced", "add(app-misc/{foo-1.0, bar-2.0)", true},
+		{"missing category", "add(hello-1.0)", true},
+		{"empty item", "add(app-misc/foo-1.0, )", true},
+		{"trailing text", "add(app-misc/foo-1.0) extra", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMessage(tt.message)
+			if tt.syntax {
+				var syntaxErr *MessageSyntaxError
+				if !errors.As(err, &syntaxErr) {
+					t.Errorf("ParseMessage(%q) error = %v, want *MessageSyntaxError", tt.message, err)
+				}
+			} else if !errors.Is(err, ErrNotGeneratedMessage) {
+				t.Errorf("ParseMessage(%q) error = %v, want ErrNotGeneratedMessage", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestLintMessage(t *testing.T) {
+	staged := []Change{
+		{Type: Up, Category: "www-client", Package: "firefox", Version: "131.0", OldVersion: "130.0"},
+		{Type: Add, Category: "app-misc", Package: "hello", Version: "1.0"},
+	}
+
+	tests := []struct {
+		name     string
+		message  string
+		mismatch bool
+		syntax   bool
+	}{
+		{"generated", GenerateMessage(staged), false, false},
+		{"reordered", "up(www-client/firefox-130.0 -> 131.0), add(app-misc/hello-1.0)", false, false},
+		{"free-form", "Bump firefox and add hello", false, false},
+		{"default subject", "update: package files", false, false},
+		{"missing change", "up(www-client/firefox-130.0 -> 131.0)", true, false},
+		{"wrong version", "add(app-misc/hello-1.1), up(www-client/firefox-130.0 -> 131.0)", true, false},
+		{"broken edit", "add(app-misc/hello-1.0), up(www-client/firefox-130.0 131.0)", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := LintMessage(tt.message, staged)
+
+			var mismatchErr *MessageMismatchError
+			var syntaxErr *MessageSyntaxError
+			switch {
+			case tt.mismatch:
+				if !errors.As(err, &mismatchErr) {
+					t.Errorf("LintMessage(%q) error = %v, want *MessageMismatchError", tt.message, err)
+				}
+			case tt.syntax:
+				if !errors.As(err, &syntaxErr) {
+					t.Errorf("LintMessage(%q) error = %v, want *MessageSyntaxError", tt.message, err)
+				}
+			case err != nil:
+				t.Errorf("LintMessage(%q) error = %v, want nil", tt.message, err)
+			}
+		})
+	}
+}
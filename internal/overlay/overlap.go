@@ -0,0 +1,285 @@
+package overlay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+	"github.com/obentoo/bentoolkit/internal/common/provider"
+)
+
+// OverlapAction is the suggested action for a package also carried upstream
+type OverlapAction string
+
+const (
+	// ActionDrop means upstream carries the same or a newer version, so the
+	// overlay copy is redundant
+	ActionDrop OverlapAction = "drop"
+	// ActionKeepNewer means the overlay is ahead of upstream
+	ActionKeepNewer OverlapAction = "keep-newer"
+	// ActionKeepCustom means upstream has the same version but the ebuilds
+	// differ, so the overlay carries local changes
+	ActionKeepCustom OverlapAction = "keep-custom"
+)
+
+// OverlapEntry is a local package that an upstream repository also carries
+type OverlapEntry struct {
+	Category     string
+	Package      string
+	LocalVersion string
+
+	// Repository carries the package at RemoteVersion, under RemoteName
+	// (category/package), which differs from the local name when upstream
+	// moved the package. Repositories lists every repository carrying it.
+	Repository    string
+	RemoteName    string
+	RemoteVersion string
+	Repositories  []string
+
+	Status CompareStatus // Local version against RemoteVersion
+	Moved  bool
+	Action OverlapAction
+}
+
+// FullName returns the category/package format
+func (e *OverlapEntry) FullName() string {
+	return e.Category + "/" + e.Package
+}
+
+// OverlapReport lists the local packages found upstream
+type OverlapReport struct {
+	TotalPackages int
+	Repositories  []string
+	Entries       []OverlapEntry
+	ErrorCount    int
+
+	// Warnings notes repositories whose package moves could not be read
+	Warnings []string
+}
+
+// OverlapOptions configures the overlap search
+type OverlapOptions struct {
+	// ProgressCallback is called for each package processed
+	ProgressCallback func(current, total int, pkg string)
+}
+
+// overlapRepo is an upstream repository with its package moves
+type overlapRepo struct {
+	UpstreamRepo
+	moves *upstreamMoves
+}
+
+// FindOverlap lists the local packages that any of repos also carries,
+// following the package moves recorded in each repository's profiles/updates.
+// Each package is reported against the repository with the highest version;
+// ties go to the repository listed first.
+func FindOverlap(localPackages []PackageInfo, repos []UpstreamRepo, opts OverlapOptions) (*OverlapReport, error) {
+	report := &OverlapReport{
+		TotalPackages: len(localPackages),
+		Entries:       []OverlapEntry{},
+	}
+
+	upstream := make([]overlapRepo, 0, len(repos))
+	for _, repo := range repos {
+		report.Repositories = append(report.Repositories, repo.Name)
+
+		r := overlapRepo{UpstreamRepo: repo, moves: newUpstreamMoves(repo.Provider)}
+		if err := r.moves.load(); err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s: reading package moves: %v", repo.Name, err))
+		}
+		upstream = append(upstream, r)
+	}
+
+	for i, pkg := range localPackages {
+		if opts.ProgressCallback != nil {
+			opts.ProgressCallback(i+1, len(localPackages), pkg.FullName())
+		}
+
+		entry, err := overlapPackage(pkg, upstream)
+		if err != nil {
+			report.ErrorCount++
+			continue
+		}
+		if entry != nil {
+			report.Entries = append(report.Entries, *entry)
+		}
+	}
+
+	sort.Slice(report.Entries, func(i, j int) bool {
+		return report.Entries[i].FullName() < report.Entries[j].FullName()
+	})
+	return report, nil
+}
+
+// overlapPackage looks a package up in every repository. It returns nil when
+// no repository carries it, and an error when a lookup failed and none did.
+func overlapPackage(pkg PackageInfo, repos []overlapRepo) (*OverlapEntry, error) {
+	var best *OverlapEntry
+	var carriers []string
+	var lookupErr error
+
+	for _, repo := range repos {
+		versions, name, err := findRemoteVersions(repo.Provider, repo.moves, pkg)
+		if errors.Is(err, provider.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			lookupErr = err
+			continue
+		}
+		if len(versions) == 0 {
+			continue
+		}
+
+		carriers = append(carriers, repo.Name)
+		remoteLatest := FindLatestVersionFiltered(versions, true)
+		if best != nil && !betterRemote(remoteLatest, best.RemoteVersion) {
+			continue
+		}
+
+		best = &OverlapEntry{
+			Category:      pkg.Category,
+			Package:       pkg.Package,
+			LocalVersion:  pkg.LatestVersion,
+			Repository:    repo.Name,
+			RemoteName:    name,
+			RemoteVersion: remoteLatest,
+			Moved:         name != pkg.FullName(),
+		}
+		best.Status, best.Action = overlapAction(pkg, repo.Provider, name, versions, remoteLatest)
+	}
+
+	if best == nil {
+		return nil, lookupErr
+	}
+	best.Repositories = carriers
+	return best, nil
+}
+
+// overlapAction classifies the local package against the upstream versions
+// of remoteName and suggests what to do with the overlay copy. When upstream
+// has the local version too, the ebuilds are compared to tell a redundant
+// copy from a customized one.
+func overlapAction(pkg PackageInfo, prov provider.Provider, remoteName string, versions []string, remoteLatest string) (CompareStatus, OverlapAction) {
+	// A live-only upstream does not replace released ebuilds
+	if isLiveVersion(remoteLatest) {
+		return StatusNewer, ActionKeepNewer
+	}
+
+	cmp := ebuild.CompareVersions(pkg.LatestVersion, remoteLatest)
+	status := StatusUpToDate
+	switch {
+	case cmp < 0:
+		status = StatusOutdated
+	case cmp > 0:
+		return StatusNewer, ActionKeepNewer
+	}
+
+	for _, v := range versions {
+		if v == pkg.LatestVersion {
+			if customized(pkg, prov, remoteName) {
+				return status, ActionKeepCustom
+			}
+			break
+		}
+	}
+	return status, ActionDrop
+}
+
+// customized reports whether the local ebuild of the latest version differs
+// from upstream's, ignoring comments and blank lines such as the copyright
+// header. Ebuilds that cannot be read are not considered customized.
+func customized(pkg PackageInfo, prov provider.Provider, remoteName string) bool {
+	reader, ok := prov.(provider.FileReader)
+	if !ok || pkg.Path == "" {
+		return false
+	}
+
+	local, err := os.ReadFile(filepath.Join(pkg.Path, pkg.Package+"-"+pkg.LatestVersion+".ebuild"))
+	if err != nil {
+		return false
+	}
+	_, remotePkg, _ := strings.Cut(remoteName, "/")
+	remote, err := reader.ReadFile(remoteName + "/" + remotePkg + "-" + pkg.LatestVersion + ".ebuild")
+	if err != nil {
+		return false
+	}
+	return !bytes.Equal(ebuildBody(local), ebuildBody(remote))
+}
+
+// ebuildBody returns the lines of an ebuild that are not comments or blank,
+// with surrounding whitespace trimmed
+func ebuildBody(content []byte) []byte {
+	var body bytes.Buffer
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	return body.Bytes()
+}
+
+// Counts returns the number of entries suggesting each action
+func (r *OverlapReport) Counts() map[OverlapAction]int {
+	counts := make(map[OverlapAction]int)
+	for _, e := range r.Entries {
+		counts[e.Action]++
+	}
+	return counts
+}
+
+// FormatOverlapReport formats an overlap report for terminal output
+func FormatOverlapReport(report *OverlapReport) string {
+	if len(report.Entries) == 0 {
+		return output.Sprintf(output.Success, "No overlay package is carried by %s.\n", strings.Join(report.Repositories, ", "))
+	}
+
+	headers := []string{"Package", "Category", "Bentoo Version", "Upstream", "Upstream Version", "Status", "Action"}
+	rows := make([][]string, 0, len(report.Entries))
+	colors := make([]*color.Color, 0, len(report.Entries))
+	for _, e := range report.Entries {
+		upstream := e.Repository
+		if e.Moved {
+			upstream += " (moved to " + e.RemoteName + ")"
+		}
+		rows = append(rows, []string{
+			e.Package, e.Category, e.LocalVersion, upstream, e.RemoteVersion, e.Status.String(), string(e.Action),
+		})
+		colors = append(colors, actionColor(e.Action))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(formatColumnsTable(headers, rows, colors, "Packages Carried Upstream", output.Header))
+
+	counts := report.Counts()
+	sb.WriteString(fmt.Sprintf("\nDrop: %s | Keep newer: %s | Keep custom: %s | Total: %d\n",
+		output.Sprint(output.Warning, fmt.Sprintf("%d", counts[ActionDrop])),
+		output.Sprint(output.Info, fmt.Sprintf("%d", counts[ActionKeepNewer])),
+		output.Sprint(output.Success, fmt.Sprintf("%d", counts[ActionKeepCustom])),
+		len(report.Entries)))
+	return sb.String()
+}
+
+// actionColor returns the color of a suggested action
+func actionColor(action OverlapAction) *color.Color {
+	switch action {
+	case ActionDrop:
+		return output.Warning
+	case ActionKeepNewer:
+		return output.Info
+	case ActionKeepCustom:
+		return output.Success
+	default:
+		return nil
+	}
+}
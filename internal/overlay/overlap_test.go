@@ -0,0 +1,122 @@
+package overlay
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/provider"
+)
+
+func TestFindOverlap(t *testing.T) {
+	const hello = "# Copyright 2024 Gentoo Authors\nEAPI=8\nSLOT=\"0\"\n"
+
+	gentoo := t.TempDir()
+	writeEbuilds(t, gentoo, map[string]string{
+		"app-misc/hello/hello-1.0.ebuild":             "# Copyright 2025 Gentoo Authors\n\nEAPI=8\nSLOT=\"0\"\n",
+		"app-misc/custom/custom-1.0.ebuild":           "EAPI=8\nSLOT=\"0\"\n",
+		"app-misc/behind/behind-1.0.ebuild":           "EAPI=8\n",
+		"app-misc/ahead/ahead-3.0.ebuild":             "EAPI=8\n",
+		"net-misc/renamed-new/renamed-new-2.0.ebuild": "EAPI=8\n",
+		"app-misc/live/live-9999.ebuild":              "EAPI=8\n",
+		"profiles/updates/1Q-2024":                    "move app-misc/renamed net-misc/renamed-new\n",
+	})
+	guru := t.TempDir()
+	writeEbuilds(t, guru, map[string]string{
+		"app-misc/ahead/ahead-1.0.ebuild":       "EAPI=8\n",
+		"app-misc/ahead/ahead-2.5.ebuild":       "EAPI=8\n",
+		"app-misc/guruonly/guruonly-1.0.ebuild": "EAPI=8\n",
+	})
+
+	overlayPath := t.TempDir()
+	writeEbuilds(t, overlayPath, map[string]string{
+		"app-misc/hello/hello-1.0.ebuild":       hello,
+		"app-misc/custom/custom-1.0.ebuild":     "EAPI=8\nSLOT=\"0\"\nPATCHES=( local.patch )\n",
+		"app-misc/behind/behind-0.9.ebuild":     "EAPI=8\n",
+		"app-misc/ahead/ahead-4.0.ebuild":       "EAPI=8\n",
+		"app-misc/renamed/renamed-2.0.ebuild":   "EAPI=8\n",
+		"app-misc/live/live-1.0.ebuild":         "EAPI=8\n",
+		"app-misc/guruonly/guruonly-1.1.ebuild": "EAPI=8\n",
+		"app-misc/mine/mine-1.0.ebuild":         "EAPI=8\n",
+	})
+	scan, err := ScanOverlay(overlayPath)
+	if err != nil {
+		t.Fatalf("ScanOverlay() error = %v", err)
+	}
+
+	repos := []UpstreamRepo{
+		{Name: "gentoo", Provider: &provider.LocalProvider{Path: gentoo, RepoName: "gentoo"}},
+		{Name: "guru", Provider: &provider.LocalProvider{Path: guru, RepoName: "guru"}},
+	}
+	report, err := FindOverlap(scan.Packages, repos, OverlapOptions{})
+	if err != nil {
+		t.Fatalf("FindOverlap() error = %v", err)
+	}
+
+	expected := map[string]struct {
+		repo, remoteName, remote string
+		status                   CompareStatus
+		moved                    bool
+		action                   OverlapAction
+	}{
+		"app-misc/ahead":    {"gentoo", "app-misc/ahead", "3.0", StatusNewer, false, ActionKeepNewer},
+		"app-misc/behind":   {"gentoo", "app-misc/behind", "1.0", StatusOutdated, false, ActionDrop},
+		"app-misc/custom":   {"gentoo", "app-misc/custom", "1.0", StatusUpToDate, false, ActionKeepCustom},
+		"app-misc/guruonly": {"guru", "app-misc/guruonly", "1.0", StatusNewer, false, ActionKeepNewer},
+		"app-misc/hello":    {"gentoo", "app-misc/hello", "1.0", StatusUpToDate, false, ActionDrop},
+		"app-misc/live":     {"gentoo", "app-misc/live", "9999", StatusNewer, false, ActionKeepNewer},
+		"app-misc/renamed":  {"gentoo", "net-misc/renamed-new", "2.0", StatusUpToDate, true, ActionDrop},
+	}
+	if len(report.Entries) != len(expected) {
+		t.Errorf("Entries = %+v, want %d", report.Entries, len(expected))
+	}
+	for _, e := range report.Entries {
+		want, ok := expected[e.FullName()]
+		if !ok {
+			t.Errorf("unexpected entry %s", e.FullName())
+			continue
+		}
+		if e.Repository != want.repo || e.RemoteName != want.remoteName || e.RemoteVersion != want.remote ||
+			e.Status != want.status || e.Moved != want.moved || e.Action != want.action {
+			t.Errorf("%s = %+v, want %+v", e.FullName(), e, want)
+		}
+	}
+
+	// Packages carried by both repositories list both
+	for _, e := range report.Entries {
+		if e.Package == "ahead" && strings.Join(e.Repositories, ",") != "gentoo,guru" {
+			t.Errorf("ahead Repositories = %v", e.Repositories)
+		}
+	}
+
+	output := FormatOverlapReport(report)
+	for _, want := range []string{"gentoo (moved to net-misc/renamed-new)", "keep-custom", "Total: 7"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestFindOverlapErrors(t *testing.T) {
+	local := []PackageInfo{
+		{Category: "app-misc", Package: "hello", LatestVersion: "1.0"},
+		{Category: "app-misc", Package: "other", LatestVersion: "1.0"},
+	}
+
+	// A failing repository counts an error unless another carries the package
+	repos := []UpstreamRepo{
+		{Name: "broken", Provider: &fakeProvider{err: provider.ErrRateLimit}},
+		{Name: "gentoo", Provider: &fakeProvider{versions: map[string][]string{"app-misc/hello": {"1.0"}}}},
+	}
+	report, err := FindOverlap(local, repos, OverlapOptions{})
+	if err != nil {
+		t.Fatalf("FindOverlap() error = %v", err)
+	}
+	if report.ErrorCount != 1 || len(report.Entries) != 1 || report.Entries[0].Action != ActionDrop {
+		t.Errorf("report = %+v", report)
+	}
+
+	// Without profiles/updates access there are no moves, but no warning
+	if len(report.Warnings) != 0 {
+		t.Errorf("Warnings = %v", report.Warnings)
+	}
+}
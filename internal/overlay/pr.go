@@ -0,0 +1,293 @@
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+	"github.com/obentoo/bentoolkit/internal/common/provider"
+)
+
+var (
+	// ErrNothingToPropose indicates that there are neither staged changes nor
+	// unpushed commits to open a pull request with
+	ErrNothingToPropose = errors.New("no staged changes or unpushed commits to propose")
+	// ErrPRRepositoryNotSet indicates that overlay.pr.repository is not configured
+	ErrPRRepositoryNotSet = errors.New("pull request repository is not configured: set overlay.pr.repository")
+)
+
+// defaultPRRemote is the git remote topic branches are pushed to by default
+const defaultPRRemote = "fork"
+
+// maxBranchSlug bounds the length of generated topic branch names
+const maxBranchSlug = 50
+
+// PROptions controls how a pull request is opened
+type PROptions struct {
+	Branch   string         // Topic branch name; generated from the title when empty
+	Remote   string         // Git remote the topic branch is pushed to
+	Base     string         // Target branch; defaults to the current upstream branch
+	HeadRepo string         // Fork holding the topic branch (owner/repo); empty for the upstream repository
+	Draft    bool           // Open the pull request as a draft
+	DryRun   bool           // Plan the pull request without branching, pushing or calling the API
+	NoVerify bool           // Skip the pre-commit checks of staged changes
+	QA       QAOptions      // Checks run against staged changes before they are committed
+	Message  MessageOptions // Message options for committing staged changes
+}
+
+// PRResult describes a planned or opened pull request
+type PRResult struct {
+	Branch  string
+	Base    string
+	Commits []OutgoingCommit // Commits on the topic branch, oldest first; staged changes are last
+	Changes []Change         // Package changes across all commits, with chained bumps merged
+	Title   string
+	Body    string
+	Number  int       // Pull request number, once opened
+	URL     string    // Pull request URL, once opened
+	QA      *QAReport // Pre-commit checks of the staged changes; nil when skipped or nothing is staged
+}
+
+// PROptionsFromConfig builds PROptions from the overlay.pr and commit settings
+func PROptionsFromConfig(cfg *config.Config) PROptions {
+	remote := cfg.Overlay.PR.Remote
+	if remote == "" {
+		remote = defaultPRRemote
+	}
+	return PROptions{
+		Remote:   remote,
+		Base:     cfg.Overlay.PR.Base,
+		HeadRepo: cfg.Overlay.PR.Fork,
+		QA:       QAOptionsFromConfig(cfg),
+		Message:  MessageOptionsFromConfig(cfg),
+	}
+}
+
+// PRRepositoryInfo returns the upstream repository pull requests are opened against
+func PRRepositoryInfo(cfg *config.Config) (*provider.RepositoryInfo, error) {
+	pr := cfg.Overlay.PR
+	if pr.Repository == "" {
+		return nil, ErrPRRepositoryNotSet
+	}
+
+	info := &provider.RepositoryInfo{
+		Name:     "overlay",
+		Provider: pr.Provider,
+		URL:      pr.Repository,
+		Token:    pr.Token,
+	}
+	if info.Provider == "" {
+		info.Provider = "github"
+	}
+	if info.Token == "" && info.Provider == "github" {
+		info.Token = cfg.GitHub.Token
+	}
+	return info, nil
+}
+
+// CreatePR opens a pull request with the staged changes and unpushed commits
+func CreatePR(cfg *config.Config, creator provider.PullRequestCreator, opts PROptions) (*PRResult, error) {
+	runner, err := NewExecutor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreatePRWithExecutor(runner, creator, opts)
+}
+
+// CreatePRWithExecutor opens a pull request using the provided GitExecutor.
+// The commits ahead of the upstream branch, plus a commit of any staged
+// changes, are placed on a new topic branch, which is pushed to opts.Remote
+// and proposed against opts.Base. The working tree is left on the topic branch.
+//
+// Staged changes must pass the same QA gate as overlay commit unless
+// opts.NoVerify is set; when they fail, nothing is branched or committed and
+// the result is returned together with an error wrapping ErrQAFailed.
+func CreatePRWithExecutor(executor git.GitExecutor, creator provider.PullRequestCreator, opts PROptions) (*PRResult, error) {
+	upstream, err := executor.UpstreamRef()
+	if err != nil || upstream == "" {
+		return nil, errors.Join(ErrNoUpstream, err)
+	}
+
+	result := &PRResult{Base: opts.Base}
+	if result.Base == "" {
+		// The branch is the part of the tracking branch after the remote
+		remote, err := executor.UpstreamRemote()
+		if err != nil {
+			return nil, err
+		}
+		result.Base = strings.TrimPrefix(upstream, remote+"/")
+	}
+
+	ahead, err := executor.RevList(upstream + "..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range ahead {
+		commit, err := describeOutgoing(executor, hash)
+		if err != nil {
+			return nil, err
+		}
+		result.Commits = append(result.Commits, commit)
+	}
+
+	entries, err := executor.Status()
+	if err != nil {
+		return nil, err
+	}
+	staged := StagedEntries(entries)
+
+	var message string
+	if len(staged) > 0 {
+		if message, err = FormatMessage(staged, opts.Message); err != nil {
+			return nil, err
+		}
+		subject, _, _ := strings.Cut(message, "\n")
+		result.Commits = append(result.Commits, OutgoingCommit{
+			Subject:  subject,
+			Changes:  AnalyzeChanges(staged),
+			Packages: entryPackages(expandRenames(staged)),
+		})
+
+		if !opts.NoVerify {
+			if result.QA, err = RunQAWithExecutor(executor, opts.QA); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(result.Commits) == 0 {
+		return nil, ErrNothingToPropose
+	}
+
+	result.Changes = mergeCommitChanges(result.Commits)
+	if len(result.Commits) == 1 {
+		result.Title = result.Commits[0].Subject
+	} else {
+		result.Title = GenerateMessage(result.Changes)
+	}
+
+	result.Branch = opts.Branch
+	if result.Branch == "" {
+		result.Branch = "bentoo/" + branchSlug(result.Title)
+	}
+
+	if opts.DryRun {
+		result.Body = FormatPRBody(result)
+		return result, nil
+	}
+	if result.QA != nil && result.QA.HasErrors() {
+		return result, fmt.Errorf("%w: pull request not opened", ErrQAFailed)
+	}
+
+	if err := executor.CreateBranch(result.Branch); err != nil {
+		return nil, err
+	}
+	if len(staged) > 0 {
+		if err := executor.Commit(message, opts.Message.User, opts.Message.Email); err != nil {
+			return nil, err
+		}
+		if result.Commits[len(result.Commits)-1].Hash, err = executor.RevParse("HEAD"); err != nil {
+			return nil, err
+		}
+	}
+	result.Body = FormatPRBody(result)
+
+	if err := executor.PushBranch(opts.Remote, result.Branch); err != nil {
+		return nil, err
+	}
+
+	opened, err := creator.CreatePullRequest(provider.PullRequest{
+		Title:    result.Title,
+		Body:     result.Body,
+		Head:     result.Branch,
+		HeadRepo: opts.HeadRepo,
+		Base:     result.Base,
+		Draft:    opts.Draft,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Number = opened.Number
+	result.URL = opened.URL
+	return result, nil
+}
+
+// mergeCommitChanges combines the changes of several commits, merging chained
+// bumps of a package the same way the changelog does
+func mergeCommitChanges(commits []OutgoingCommit) []Change {
+	cl := &Changelog{}
+	for _, c := range commits {
+		for _, change := range c.Changes {
+			cl.add(change, git.CommitInfo{Hash: c.Hash})
+		}
+	}
+
+	changes := make([]Change, len(cl.Items))
+	for i, item := range cl.Items {
+		changes[i] = item.Change
+	}
+	return changes
+}
+
+// branchSlug turns a title into a branch-name-safe slug
+func branchSlug(title string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.':
+			sb.WriteRune(r)
+			dash = false
+		case !dash && sb.Len() > 0:
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+
+	slug := sb.String()
+	if len(slug) > maxBranchSlug {
+		slug = slug[:maxBranchSlug]
+	}
+	slug = strings.Trim(slug, "-.")
+	if slug == "" {
+		return "update"
+	}
+	return slug
+}
+
+// FormatPRBody renders the pull request description: the package changes
+// grouped by type, followed by the commits on the topic branch
+func FormatPRBody(result *PRResult) string {
+	var sb strings.Builder
+
+	if len(result.Changes) > 0 {
+		sb.WriteString("## Changes\n")
+		for _, ct := range changeTypeOrder {
+			var lines []string
+			for _, c := range result.Changes {
+				if c.Type == ct {
+					lines = append(lines, fmt.Sprintf("- %s/%s", c.Category, formatChangelogItem(ChangelogItem{Change: c})))
+				}
+			}
+			if len(lines) > 0 {
+				sb.WriteString(fmt.Sprintf("\n**%s**\n\n%s\n", changeTypeTitles[ct], strings.Join(lines, "\n")))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Commits\n\n")
+	for _, c := range result.Commits {
+		if c.Hash == "" {
+			sb.WriteString(fmt.Sprintf("- %s\n", c.Subject))
+		} else {
+			sb.WriteString(fmt.Sprintf("- %s %s\n", shortHash(c.Hash), c.Subject))
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,255 @@
+package overlay
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+	"github.com/obentoo/bentoolkit/internal/common/provider"
+)
+
+// fakeCreator records the pull request it is asked to open
+type fakeCreator struct {
+	got *provider.PullRequest
+	err error
+}
+
+func (f *fakeCreator) CreatePullRequest(pr provider.PullRequest) (*provider.PullRequestResult, error) {
+	f.got = &pr
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &provider.PullRequestResult{Number: 12, URL: "https://example.com/pull/12"}, nil
+}
+
+// prMock returns a mock with the given unpushed commits and staged entries,
+// recording the git operations the pull request workflow performs
+func prMock(ahead []string, staged []git.StatusEntry, ops *[]string) *git.MockGitRunner {
+	mock := pushMock("/test/overlay", ahead, nil)
+	mock.StatusFunc = func() ([]git.StatusEntry, error) {
+		return staged, nil
+	}
+	mock.CreateBranchFunc = func(name string) error {
+		*ops = append(*ops, "branch "+name)
+		return nil
+	}
+	mock.CommitFunc = func(message, user, email string) error {
+		*ops = append(*ops, "commit "+message)
+		return nil
+	}
+	mock.PushBranchFunc = func(remote, branch string) error {
+		*ops = append(*ops, "push "+remote+" "+branch)
+		return nil
+	}
+	return mock
+}
+
+func TestCreatePRFromCommits(t *testing.T) {
+	var ops []string
+	creator := &fakeCreator{}
+	opts := PROptions{Remote: "fork", HeadRepo: "me/overlay", Draft: true}
+
+	result, err := CreatePRWithExecutor(prMock([]string{"c1", "c2"}, nil, &ops), creator, opts)
+	if err != nil {
+		t.Fatalf("CreatePRWithExecutor() error = %v", err)
+	}
+
+	if result.Title != "add(app-misc/hello-1.0), mod(app-misc/world-2.0)" {
+		t.Errorf("Title = %q, want a message generated from both commits", result.Title)
+	}
+	if result.Branch != "bentoo/add-app-misc-hello-1.0-mod-app-misc-world-2.0" {
+		t.Errorf("Branch = %q", result.Branch)
+	}
+	if result.Base != "main" {
+		t.Errorf("Base = %q, want the upstream branch", result.Base)
+	}
+
+	wantOps := []string{"branch " + result.Branch, "push fork " + result.Branch}
+	if strings.Join(ops, "\n") != strings.Join(wantOps, "\n") {
+		t.Errorf("git operations = %v, want %v", ops, wantOps)
+	}
+
+	pr := creator.got
+	if pr == nil {
+		t.Fatal("no pull request was opened")
+	}
+	if pr.Head != result.Branch || pr.HeadRepo != "me/overlay" || pr.Base != "main" || !pr.Draft {
+		t.Errorf("pull request = %+v", pr)
+	}
+	for _, want := range []string{"**Added**", "- app-misc/hello 1.0", "**Modified**", "## Commits", "- c1 add(app-misc/hello-1.0)", "- c2 Tweak world"} {
+		if !strings.Contains(pr.Body, want) {
+			t.Errorf("body missing %q:\n%s", want, pr.Body)
+		}
+	}
+	if result.Number != 12 || result.URL != "https://example.com/pull/12" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestCreatePRFromStaged(t *testing.T) {
+	var ops []string
+	staged := []git.StatusEntry{
+		{Status: "R ", Index: git.StatusRenamed, FilePath: "dev-lang/go/go-1.23.ebuild", OrigPath: "dev-lang/go/go-1.22.ebuild"},
+	}
+	mock := prMock(nil, staged, &ops)
+	creator := &fakeCreator{}
+
+	result, err := CreatePRWithExecutor(mock, creator, PROptions{Remote: "fork", Branch: "go-bump", Base: "develop"})
+	if err != nil {
+		t.Fatalf("CreatePRWithExecutor() error = %v", err)
+	}
+
+	wantOps := []string{"branch go-bump", "commit up(dev-lang/go-1.22 -> 1.23)", "push fork go-bump"}
+	if strings.Join(ops, "\n") != strings.Join(wantOps, "\n") {
+		t.Errorf("git operations = %v, want %v", ops, wantOps)
+	}
+	if result.Title != "up(dev-lang/go-1.22 -> 1.23)" || creator.got.Base != "develop" {
+		t.Errorf("result = %+v", result)
+	}
+	if len(result.Commits) != 1 || result.Commits[0].Hash != "local00000" {
+		t.Errorf("Commits = %+v, want the new commit's hash", result.Commits)
+	}
+	if !strings.Contains(result.Body, "- dev-lang/go 1.22 -> 1.23") || !strings.Contains(result.Body, "- local00 up(") {
+		t.Errorf("body = %s", result.Body)
+	}
+}
+
+func TestCreatePRRunsQA(t *testing.T) {
+	// A misnamed ebuild fails the filename check
+	staged := []git.StatusEntry{{Status: "A ", Index: git.StatusAdded, FilePath: "app-misc/foo/bar-1.0.ebuild"}}
+
+	var ops []string
+	creator := &fakeCreator{}
+	result, err := CreatePRWithExecutor(prMock(nil, staged, &ops), creator, PROptions{Remote: "fork"})
+	if !errors.Is(err, ErrQAFailed) {
+		t.Fatalf("error = %v, want ErrQAFailed", err)
+	}
+	if len(ops) != 0 || creator.got != nil {
+		t.Errorf("failing checks still performed %v and opened %v", ops, creator.got)
+	}
+	if result == nil || result.QA == nil || !result.QA.HasErrors() {
+		t.Errorf("result = %+v, want the failing QA report", result)
+	}
+
+	ops = nil
+	if _, err := CreatePRWithExecutor(prMock(nil, staged, &ops), creator, PROptions{Remote: "fork", NoVerify: true}); err != nil {
+		t.Fatalf("CreatePRWithExecutor(NoVerify) error = %v", err)
+	}
+	if len(ops) != 3 {
+		t.Errorf("git operations with NoVerify = %v, want branch, commit and push", ops)
+	}
+}
+
+func TestCreatePRMergesChainedBumps(t *testing.T) {
+	var ops []string
+	mock := prMock([]string{"c1"}, []git.StatusEntry{
+		{Status: "R ", Index: git.StatusRenamed, FilePath: "app-misc/hello/hello-1.1.ebuild", OrigPath: "app-misc/hello/hello-1.0.ebuild"},
+	}, &ops)
+
+	result, err := CreatePRWithExecutor(mock, &fakeCreator{}, PROptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("CreatePRWithExecutor() error = %v", err)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].Type != Add || result.Changes[0].Version != "1.1" {
+		t.Errorf("Changes = %+v, want a single add at 1.1", result.Changes)
+	}
+	if result.Title != "add(app-misc/hello-1.1)" {
+		t.Errorf("Title = %q", result.Title)
+	}
+}
+
+func TestCreatePRDryRun(t *testing.T) {
+	var ops []string
+	creator := &fakeCreator{}
+	result, err := CreatePRWithExecutor(prMock([]string{"c1"}, nil, &ops), creator, PROptions{Remote: "fork", DryRun: true})
+	if err != nil {
+		t.Fatalf("CreatePRWithExecutor() error = %v", err)
+	}
+	if len(ops) != 0 || creator.got != nil {
+		t.Errorf("dry run performed %v and opened %v", ops, creator.got)
+	}
+	if result.Body == "" || result.Branch == "" {
+		t.Errorf("dry run result = %+v, want a planned branch and body", result)
+	}
+}
+
+func TestCreatePRErrors(t *testing.T) {
+	t.Run("nothing to propose", func(t *testing.T) {
+		var ops []string
+		_, err := CreatePRWithExecutor(prMock(nil, nil, &ops), &fakeCreator{}, PROptions{})
+		if !errors.Is(err, ErrNothingToPropose) {
+			t.Errorf("error = %v, want ErrNothingToPropose", err)
+		}
+	})
+
+	t.Run("no upstream", func(t *testing.T) {
+		var ops []string
+		mock := prMock([]string{"c1"}, nil, &ops)
+		mock.UpstreamRefFunc = func() (string, error) {
+			return "", git.ErrGitCommand
+		}
+		_, err := CreatePRWithExecutor(mock, &fakeCreator{}, PROptions{})
+		if !errors.Is(err, ErrNoUpstream) {
+			t.Errorf("error = %v, want ErrNoUpstream", err)
+		}
+	})
+
+	t.Run("API failure keeps the pushed branch", func(t *testing.T) {
+		var ops []string
+		result, err := CreatePRWithExecutor(prMock([]string{"c1"}, nil, &ops), &fakeCreator{err: provider.ErrAPIError}, PROptions{Remote: "fork"})
+		if !errors.Is(err, provider.ErrAPIError) {
+			t.Errorf("error = %v, want ErrAPIError", err)
+		}
+		if result == nil || result.Branch == "" {
+			t.Errorf("result = %+v, want the pushed branch", result)
+		}
+	})
+}
+
+func TestBranchSlug(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"add(app-misc/hello-1.0)", "add-app-misc-hello-1.0"},
+		{"up(dev-lang/go-1.22 -> 1.23)", "up-dev-lang-go-1.22-1.23"},
+		{"Fix: stuff!!", "fix-stuff"},
+		{"()", "update"},
+		{strings.Repeat("a", 60), strings.Repeat("a", 50)},
+	}
+
+	for _, tt := range tests {
+		if got := branchSlug(tt.title); got != tt.want {
+			t.Errorf("branchSlug(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestPRConfig(t *testing.T) {
+	cfg := &config.Config{
+		GitHub: config.GitHubConfig{Token: "ghp_global"},
+		Overlay: config.OverlayConfig{
+			PR: config.PRConfig{Repository: "obentoo/bentoo", Fork: "me/bentoo"},
+		},
+	}
+
+	opts := PROptionsFromConfig(cfg)
+	if opts.Remote != "fork" || opts.HeadRepo != "me/bentoo" {
+		t.Errorf("options = %+v, want the default fork remote", opts)
+	}
+
+	info, err := PRRepositoryInfo(cfg)
+	if err != nil {
+		t.Fatalf("PRRepositoryInfo() error = %v", err)
+	}
+	if info.Provider != "github" || info.URL != "obentoo/bentoo" || info.Token != "ghp_global" {
+		t.Errorf("repository = %+v", info)
+	}
+
+	cfg.Overlay.PR = config.PRConfig{}
+	if _, err := PRRepositoryInfo(cfg); !errors.Is(err, ErrPRRepositoryNotSet) {
+		t.Errorf("error = %v, want ErrPRRepositoryNotSet", err)
+	}
+}
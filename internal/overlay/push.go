@@ -2,47 +2,248 @@ package overlay
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/obentoo/bentoolkit/internal/common/config"
 	"github.com/obentoo/bentoolkit/internal/common/git"
+	"github.com/obentoo/bentoolkit/internal/common/output"
 )
 
 var (
 	// ErrUpToDate indicates the repository is already up-to-date with remote
 	ErrUpToDate = errors.New("everything is up-to-date")
+
+	// ErrNoUpstream indicates the current branch has no remote-tracking branch
+	ErrNoUpstream = errors.New("current branch has no upstream branch")
+
+	// ErrRemoteDiverged indicates the remote has commits that are not in HEAD
+	ErrRemoteDiverged = errors.New("remote has diverged")
 )
 
+// DivergedError reports a remote with commits missing from the local branch
+type DivergedError struct {
+	Upstream string // Remote-tracking branch, e.g. "origin/main"
+	Ahead    int    // Local commits not on the remote
+	Behind   int    // Remote commits not in the local branch
+}
+
+// Error implements the error interface.
+func (e *DivergedError) Error() string {
+	return fmt.Sprintf("%s has %d commit(s) not in the local branch (%d local commit(s) to push); run 'bentoo overlay sync' first",
+		e.Upstream, e.Behind, e.Ahead)
+}
+
+// Unwrap returns ErrRemoteDiverged.
+func (e *DivergedError) Unwrap() error {
+	return ErrRemoteDiverged
+}
+
+// OutgoingCommit is a local commit that a push would publish
+type OutgoingCommit struct {
+	Hash     string
+	Subject  string
+	Changes  []Change // Parsed from the subject, or derived from the files changed
+	Packages []string // category/package keys the commit touches
+}
+
 // PushResult contains the result of a Push operation
 type PushResult struct {
-	UpToDate bool   // True if nothing was pushed (already up-to-date)
-	Message  string // Status message from git
+	UpToDate bool             // True if nothing was pushed (already up-to-date)
+	Message  string           // Status message
+	Upstream string           // Remote-tracking branch pushed to, e.g. "origin/main"
+	OldHash  string           // Remote ref before the push
+	NewHash  string           // Remote ref after the push (local HEAD)
+	Outgoing []OutgoingCommit // Commits to push, oldest first
+	QA       *QAReport        // Pre-push checks; nil when verification is skipped
+	Pushed   bool             // True once the remote ref was updated
+}
+
+// FailingCommits returns the outgoing commits touching a package with a
+// blocking QA issue
+func (r *PushResult) FailingCommits() []OutgoingCommit {
+	if r.QA == nil {
+		return nil
+	}
+
+	failing := make(map[string]bool)
+	for _, issue := range r.QA.Issues {
+		if issue.Severity == QAError {
+			failing[issue.Package] = true
+		}
+	}
+
+	var commits []OutgoingCommit
+	for _, c := range r.Outgoing {
+		for _, pkg := range c.Packages {
+			// Overlay-wide issues (hooks) implicate every commit
+			if failing[pkg] || failing[""] {
+				commits = append(commits, c)
+				break
+			}
+		}
+	}
+	return commits
+}
+
+// PushOptions configures push verification
+type PushOptions struct {
+	NoVerify bool      // Skip the pre-push QA checks
+	QA       QAOptions // Checks run against the outgoing packages
+}
+
+// PushOptionsFromConfig builds PushOptions from the overlay configuration
+func PushOptionsFromConfig(cfg *config.Config) PushOptions {
+	return PushOptions{QA: QAOptionsFromConfig(cfg)}
 }
 
 // PushDryRun shows what would be pushed without actually pushing
 func PushDryRun(cfg *config.Config) (string, error) {
-	overlayPath, err := cfg.GetOverlayPath()
+	runner, err := NewExecutor(cfg, git.FeaturePushDryRun)
 	if err != nil {
 		return "", err
 	}
 
-	runner := git.NewGitRunner(overlayPath)
 	return runner.PushDryRun()
 }
 
-// Push pushes committed changes to the remote repository
-// Returns ErrUpToDate if there's nothing to push
+// Push verifies and pushes committed changes to the remote repository,
+// running the configured QA checks against the outgoing packages
 func Push(cfg *config.Config) (*PushResult, error) {
-	overlayPath, err := cfg.GetOverlayPath()
+	return PushWithOptions(cfg, PushOptionsFromConfig(cfg))
+}
+
+// PushWithOptions verifies and pushes committed changes using the given options
+func PushWithOptions(cfg *config.Config, opts PushOptions) (*PushResult, error) {
+	runner, err := NewExecutor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return PushVerifiedWithExecutor(runner, opts)
+}
+
+// PreparePush fetches the remote and describes what a push would publish
+func PreparePush(cfg *config.Config, opts PushOptions) (*PushResult, error) {
+	runner, err := NewExecutor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return PreparePushWithExecutor(runner, opts)
+}
+
+// PreparePushWithExecutor fetches the upstream remote, checks that it has not
+// diverged, and lists the outgoing commits with their changes. Unless
+// opts.NoVerify is set, the QA checks are run against the outgoing packages.
+// A diverged remote returns a *DivergedError.
+func PreparePushWithExecutor(executor git.GitExecutor, opts PushOptions) (*PushResult, error) {
+	upstream, err := executor.UpstreamRef()
+	if err != nil || upstream == "" {
+		return nil, errors.Join(ErrNoUpstream, err)
+	}
+
+	remote, err := executor.UpstreamRemote()
+	if err != nil {
+		return nil, err
+	}
+	if err := executor.Fetch(remote); err != nil {
+		return nil, err
+	}
+
+	result := &PushResult{Upstream: upstream}
+	if result.OldHash, err = executor.RevParse(upstream); err != nil {
+		return nil, err
+	}
+	if result.NewHash, err = executor.RevParse("HEAD"); err != nil {
+		return nil, err
+	}
+
+	behind, err := executor.RevList("HEAD.." + upstream)
+	if err != nil {
+		return nil, err
+	}
+	ahead, err := executor.RevList(upstream + "..HEAD")
 	if err != nil {
 		return nil, err
 	}
+	if len(behind) > 0 {
+		return nil, &DivergedError{Upstream: upstream, Ahead: len(ahead), Behind: len(behind)}
+	}
+	if len(ahead) == 0 {
+		result.UpToDate = true
+		result.Message = "Everything is up-to-date. Nothing to push."
+		return result, nil
+	}
 
-	runner := git.NewGitRunner(overlayPath)
-	return PushWithExecutor(runner)
+	for _, hash := range ahead {
+		commit, err := describeOutgoing(executor, hash)
+		if err != nil {
+			return nil, err
+		}
+		result.Outgoing = append(result.Outgoing, commit)
+	}
+
+	if !opts.NoVerify {
+		if result.QA, err = VerifyRangeWithExecutor(executor, upstream, opts.QA); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Message = fmt.Sprintf("%d commit(s) to push to %s.", len(result.Outgoing), upstream)
+	return result, nil
 }
 
-// PushWithExecutor pushes committed changes using the provided GitExecutor.
+// describeOutgoing classifies a single outgoing commit
+func describeOutgoing(executor git.GitExecutor, hash string) (OutgoingCommit, error) {
+	commit := OutgoingCommit{Hash: hash}
+
+	infos, err := executor.Log(hash, 1)
+	if err != nil {
+		return commit, err
+	}
+	if len(infos) > 0 {
+		commit.Subject = infos[0].Subject
+	}
+
+	entries, err := commitDiff(executor, hash)
+	if err != nil {
+		return commit, err
+	}
+	commit.Packages = entryPackages(expandRenames(entries))
+
+	if changes, err := ParseMessage(commit.Subject); err == nil && changes != nil {
+		commit.Changes = changes
+	} else {
+		commit.Changes = AnalyzeChanges(entries)
+	}
+	return commit, nil
+}
+
+// PushVerifiedWithExecutor prepares a push with PreparePushWithExecutor and,
+// if the outgoing packages pass the QA checks, pushes them. When checks fail,
+// the prepared result is returned together with an error wrapping ErrQAFailed.
+func PushVerifiedWithExecutor(executor git.GitExecutor, opts PushOptions) (*PushResult, error) {
+	result, err := PreparePushWithExecutor(executor, opts)
+	if err != nil || result.UpToDate {
+		return result, err
+	}
+	if result.QA != nil && result.QA.HasErrors() {
+		return result, fmt.Errorf("%w: push refused", ErrQAFailed)
+	}
+
+	pushed, err := PushWithExecutor(executor)
+	if err != nil {
+		return result, err
+	}
+
+	result.Pushed = !pushed.UpToDate
+	result.Message = fmt.Sprintf("Pushed %d commit(s) to %s.", len(result.Outgoing), result.Upstream)
+	return result, nil
+}
+
+// PushWithExecutor pushes committed changes using the provided GitExecutor,
+// without fetching or verification.
 // This function is useful for testing with mock implementations.
 func PushWithExecutor(executor git.GitExecutor) (*PushResult, error) {
 	err := executor.Push()
@@ -65,3 +266,31 @@ func PushWithExecutor(executor git.GitExecutor) (*PushResult, error) {
 		Message:  "Changes pushed successfully.",
 	}, nil
 }
+
+// FormatPushPlan formats the ref update and outgoing commits of a push.
+// Commits touching a package that failed the QA checks are flagged.
+func FormatPushPlan(r *PushResult) string {
+	var sb strings.Builder
+	sb.WriteString(output.Sprintf(output.Header, "%s: %s..%s (%d commit(s))",
+		r.Upstream, shortHash(r.OldHash), shortHash(r.NewHash), len(r.Outgoing)))
+
+	failing := make(map[string]bool)
+	for _, c := range r.FailingCommits() {
+		failing[c.Hash] = true
+	}
+
+	for _, c := range r.Outgoing {
+		marker := " "
+		if failing[c.Hash] {
+			marker = output.Sprint(output.Error, "✗")
+		}
+		sb.WriteString(fmt.Sprintf("\n  %s %s %s", marker, output.Sprint(output.Warning, shortHash(c.Hash)), c.Subject))
+
+		// Show the detected changes when the subject does not describe them
+		if _, err := ParseMessage(c.Subject); err != nil && len(c.Changes) > 0 {
+			sb.WriteString("\n      " + output.Sprint(output.Dim, GenerateMessage(c.Changes)))
+		}
+	}
+
+	return sb.String()
+}
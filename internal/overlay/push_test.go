@@ -2,6 +2,7 @@ package overlay
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/obentoo/bentoolkit/internal/common/config"
@@ -270,3 +271,161 @@ func TestPushErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+// pushMock returns a mock tracking origin/main, with the given commits ahead
+// of and behind the remote and the files on disk committed at HEAD
+func pushMock(overlayPath string, ahead, behind []string) *git.MockGitRunner {
+	mock := qaMock(overlayPath)
+	mock.UpstreamRefFunc = func() (string, error) {
+		return "origin/main", nil
+	}
+	mock.UpstreamRemoteFunc = func() (string, error) {
+		return "origin", nil
+	}
+	mock.RevParseFunc = func(rev string) (string, error) {
+		switch rev {
+		case "origin/main":
+			return "remote0000", nil
+		case "HEAD":
+			return "local00000", nil
+		}
+		return rev, nil
+	}
+	mock.RevListFunc = func(revRange string) ([]string, error) {
+		if revRange == "HEAD..origin/main" {
+			return behind, nil
+		}
+		return ahead, nil
+	}
+	mock.LogFunc = func(rev string, maxCount int) ([]git.CommitInfo, error) {
+		subjects := map[string]string{
+			"c1": "add(app-misc/hello-1.0)",
+			"c2": "Tweak world",
+		}
+		return []git.CommitInfo{{Hash: rev, Subject: subjects[rev]}}, nil
+	}
+	mock.DiffNameStatusFunc = func(from, to string) ([]git.StatusEntry, error) {
+		hello := git.StatusEntry{Status: "A", FilePath: "app-misc/hello/hello-1.0.ebuild"}
+		world := git.StatusEntry{Status: "M", FilePath: "app-misc/world/world-2.0.ebuild"}
+		switch to {
+		case "c1":
+			return []git.StatusEntry{hello}, nil
+		case "c2":
+			return []git.StatusEntry{world}, nil
+		}
+		return []git.StatusEntry{hello, world}, nil
+	}
+	return mock
+}
+
+// TestPreparePushWithExecutor tests divergence checks and outgoing commits
+func TestPreparePushWithExecutor(t *testing.T) {
+	t.Run("lists outgoing commits", func(t *testing.T) {
+		fetched := ""
+		mock := pushMock(t.TempDir(), []string{"c1", "c2"}, nil)
+		mock.FetchFunc = func(remote string) error {
+			fetched = remote
+			return nil
+		}
+
+		result, err := PreparePushWithExecutor(mock, PushOptions{})
+		if err != nil {
+			t.Fatalf("PreparePushWithExecutor() error = %v", err)
+		}
+		if fetched != "origin" {
+			t.Errorf("fetched %q, want origin", fetched)
+		}
+		if result.Upstream != "origin/main" || result.OldHash != "remote0000" || result.NewHash != "local00000" {
+			t.Errorf("ref update = %s %s..%s", result.Upstream, result.OldHash, result.NewHash)
+		}
+		if len(result.Outgoing) != 2 {
+			t.Fatalf("Outgoing = %+v, want 2 commits", result.Outgoing)
+		}
+
+		parsed := result.Outgoing[0]
+		if parsed.Subject != "add(app-misc/hello-1.0)" || len(parsed.Changes) != 1 || parsed.Changes[0].Package != "hello" {
+			t.Errorf("first commit = %+v", parsed)
+		}
+		// A hand-written subject falls back to the files changed
+		derived := result.Outgoing[1]
+		if len(derived.Changes) != 1 || derived.Changes[0].Type != Mod || derived.Packages[0] != "app-misc/world" {
+			t.Errorf("second commit = %+v", derived)
+		}
+		if result.QA == nil || result.QA.HasErrors() {
+			t.Errorf("QA = %+v, want a passing report", result.QA)
+		}
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		result, err := PreparePushWithExecutor(pushMock(t.TempDir(), nil, nil), PushOptions{})
+		if err != nil || !result.UpToDate {
+			t.Errorf("PreparePushWithExecutor() = %+v, %v; want up-to-date", result, err)
+		}
+	})
+
+	t.Run("diverged remote", func(t *testing.T) {
+		_, err := PreparePushWithExecutor(pushMock(t.TempDir(), []string{"c1"}, []string{"r1", "r2"}), PushOptions{})
+
+		var diverged *DivergedError
+		if !errors.As(err, &diverged) || !errors.Is(err, ErrRemoteDiverged) {
+			t.Fatalf("error = %v, want *DivergedError", err)
+		}
+		if diverged.Ahead != 1 || diverged.Behind != 2 {
+			t.Errorf("ahead/behind = %d/%d, want 1/2", diverged.Ahead, diverged.Behind)
+		}
+	})
+
+	t.Run("no upstream", func(t *testing.T) {
+		mock := git.NewMockGitRunner("/test/overlay")
+		if _, err := PreparePushWithExecutor(mock, PushOptions{}); !errors.Is(err, ErrNoUpstream) {
+			t.Errorf("error = %v, want ErrNoUpstream", err)
+		}
+	})
+}
+
+// TestPushVerifiedWithExecutor tests that failing checks refuse the push
+func TestPushVerifiedWithExecutor(t *testing.T) {
+	t.Run("refuses broken Manifest", func(t *testing.T) {
+		overlayPath := t.TempDir()
+		writeQAFile(t, overlayPath, "app-misc/world/Manifest", "DIST world-2.0.tar.gz\n")
+
+		pushed := false
+		mock := pushMock(overlayPath, []string{"c1", "c2"}, nil)
+		mock.PushFunc = func() error {
+			pushed = true
+			return nil
+		}
+
+		result, err := PushVerifiedWithExecutor(mock, PushOptions{})
+		if !errors.Is(err, ErrQAFailed) {
+			t.Fatalf("error = %v, want ErrQAFailed", err)
+		}
+		if pushed {
+			t.Error("Push() was called despite failing checks")
+		}
+		failing := result.FailingCommits()
+		if len(failing) != 1 || failing[0].Hash != "c2" {
+			t.Errorf("FailingCommits() = %+v, want c2", failing)
+		}
+		if plan := FormatPushPlan(result); !strings.Contains(plan, "Tweak world") || !strings.Contains(plan, "mod(app-misc/world-2.0)") {
+			t.Errorf("FormatPushPlan() =\n%s", plan)
+		}
+
+		// --no-verify pushes anyway
+		result, err = PushVerifiedWithExecutor(mock, PushOptions{NoVerify: true})
+		if err != nil || !pushed || !result.Pushed || result.QA != nil {
+			t.Errorf("no-verify push = %+v, %v", result, err)
+		}
+	})
+
+	t.Run("pushes clean commits", func(t *testing.T) {
+		mock := pushMock(t.TempDir(), []string{"c1"}, nil)
+		result, err := PushVerifiedWithExecutor(mock, PushOptions{})
+		if err != nil {
+			t.Fatalf("PushVerifiedWithExecutor() error = %v", err)
+		}
+		if !result.Pushed || result.Message != "Pushed 1 commit(s) to origin/main." {
+			t.Errorf("result = %+v", result)
+		}
+	})
+}
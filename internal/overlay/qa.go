@@ -0,0 +1,477 @@
+package overlay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+)
+
+var (
+	// ErrQAFailed indicates that one or more pre-commit checks failed
+	ErrQAFailed = errors.New("pre-commit checks failed")
+)
+
+// Built-in QA check names, usable in the qa.skip config list
+const (
+	QACheckManifest = "manifest" // Manifest present and staged for new ebuilds, and well-formed before a push
+	QACheckFilename = "filename" // Ebuild path parses as category/package/package-version.ebuild
+	QACheckFiles    = "files"    // Files referenced via ${FILESDIR} exist and are tracked
+	QACheckHook     = "hook"     // External hook commands
+)
+
+// QASeverity indicates whether a QA issue blocks the commit
+type QASeverity int
+
+const (
+	// QAError blocks the commit
+	QAError QASeverity = iota
+	// QAWarning is reported but does not block the commit
+	QAWarning
+)
+
+// String returns a human-readable severity
+func (s QASeverity) String() string {
+	if s == QAWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// QAIssue represents a single problem found by a pre-commit check
+type QAIssue struct {
+	Check    string     // Check name (see QACheck* constants)
+	Severity QASeverity // Whether the issue blocks the commit
+	Package  string     // category/package, empty for overlay-wide issues
+	Path     string     // Offending file path relative to the overlay
+	Message  string     // Human-readable description
+}
+
+// QAReport contains the results of running pre-commit checks
+type QAReport struct {
+	Packages []string  // Staged packages that were checked
+	Issues   []QAIssue // All issues found
+}
+
+// HasErrors returns true if any issue blocks the commit
+func (r *QAReport) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == QAError {
+			return true
+		}
+	}
+	return false
+}
+
+// QAOptions configures which pre-commit checks run
+type QAOptions struct {
+	Skip  []string // Built-in checks to skip
+	Hooks []string // External hook commands
+}
+
+// QAOptionsFromConfig builds QAOptions from the overlay QA configuration
+func QAOptionsFromConfig(cfg *config.Config) QAOptions {
+	return QAOptions{
+		Skip:  cfg.Overlay.QA.Skip,
+		Hooks: cfg.Overlay.QA.Hooks,
+	}
+}
+
+// skipped reports whether a built-in check is disabled
+func (o QAOptions) skipped(check string) bool {
+	for _, s := range o.Skip {
+		if s == check {
+			return true
+		}
+	}
+	return false
+}
+
+// RunQA runs the configured pre-commit checks against the staged packages
+func RunQA(cfg *config.Config) (*QAReport, error) {
+	runner, err := NewExecutor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return RunQAWithExecutor(runner, QAOptionsFromConfig(cfg))
+}
+
+// RunQAWithExecutor runs pre-commit checks using the provided GitExecutor.
+// Only packages with staged changes are checked, against their staged
+// contents, so a partially staged file is checked as it will be committed.
+func RunQAWithExecutor(executor git.GitExecutor, opts QAOptions) (*QAReport, error) {
+	entries, err := executor.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	src := qaSource{
+		overlayPath: executor.WorkDir(),
+		read: func(path string) ([]byte, error) {
+			content, err := executor.Show("", path)
+			return []byte(content), err
+		},
+		untracked: untrackedPaths(entries),
+	}
+	return runQAChecks(src, StagedEntries(entries), opts), nil
+}
+
+// VerifyRangeWithExecutor runs the pre-commit checks against the packages
+// changed between from and HEAD, as done before a push. File contents are
+// read from HEAD, so local changes neither hide nor cause failures, and the
+// Manifest of every changed package must also be well-formed.
+func VerifyRangeWithExecutor(executor git.GitExecutor, from string, opts QAOptions) (*QAReport, error) {
+	changed, err := executor.DiffNameStatus(from, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	src := qaSource{
+		overlayPath: executor.WorkDir(),
+		rev:         "HEAD",
+		read: func(path string) ([]byte, error) {
+			content, err := executor.Show("HEAD", path)
+			return []byte(content), err
+		},
+	}
+	report := runQAChecks(src, changed, opts)
+
+	if !opts.skipped(QACheckManifest) {
+		for _, pkg := range report.Packages {
+			report.Issues = append(report.Issues, checkManifestSyntax(src, pkg)...)
+		}
+	}
+	return report, nil
+}
+
+// qaSource gives the checks the file contents to validate
+type qaSource struct {
+	overlayPath string                            // Overlay working tree, where hooks run
+	rev         string                            // Revision being checked; "" for the index
+	read        func(path string) ([]byte, error) // Contents of a path relative to the overlay
+	untracked   map[string]bool                   // Untracked paths in the working tree
+}
+
+// exists reports whether a path relative to the overlay is present where its
+// contents are read from: the index before a commit, the revision otherwise.
+// The index holds no directories, so before a commit a directory counts as
+// present when it is in the working tree.
+func (s qaSource) exists(path string) bool {
+	if _, err := s.read(path); err == nil {
+		return true
+	}
+	if s.rev != "" {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(s.overlayPath, path))
+	return err == nil && info.IsDir()
+}
+
+// untrackedPaths indexes the untracked paths in status entries
+func untrackedPaths(entries []git.StatusEntry) map[string]bool {
+	untracked := make(map[string]bool)
+	for _, e := range entries {
+		if e.IsUntracked() {
+			untracked[e.FilePath] = true
+		}
+	}
+	return untracked
+}
+
+// runQAChecks runs the configured checks against a set of changed files
+func runQAChecks(src qaSource, changed []git.StatusEntry, opts QAOptions) *QAReport {
+	report := &QAReport{Packages: entryPackages(changed)}
+
+	// Index changed paths for lookups
+	changedPaths := make(map[string]bool)
+	for _, e := range changed {
+		changedPaths[e.FilePath] = true
+	}
+
+	for _, e := range changed {
+		if DetectFileType(e.FilePath) != FileTypeEbuild || normalizeStatus(e.Status) == "D" {
+			continue
+		}
+
+		eb, err := ebuild.ParsePath(e.FilePath)
+		if err != nil {
+			if !opts.skipped(QACheckFilename) {
+				report.Issues = append(report.Issues, QAIssue{
+					Check:    QACheckFilename,
+					Severity: QAError,
+					Package:  packageKey(e.FilePath),
+					Path:     e.FilePath,
+					Message:  "ebuild filename does not match <category>/<package>/<package>-<version>.ebuild",
+				})
+			}
+			continue
+		}
+
+		content, err := src.read(e.FilePath)
+		if err != nil {
+			// Changed but unreadable; git will report it
+			continue
+		}
+
+		if !opts.skipped(QACheckManifest) && normalizeStatus(e.Status) == "A" {
+			report.Issues = append(report.Issues, checkManifest(src, eb, content, changedPaths)...)
+		}
+
+		if !opts.skipped(QACheckFiles) {
+			report.Issues = append(report.Issues, checkFilesDir(src, e.FilePath, eb, content)...)
+		}
+	}
+
+	if len(opts.Hooks) > 0 && len(report.Packages) > 0 {
+		report.Issues = append(report.Issues, runQAHooks(src.overlayPath, opts.Hooks, report.Packages)...)
+	}
+
+	return report
+}
+
+// entryPackages returns the sorted unique category/package keys of entries
+func entryPackages(entries []git.StatusEntry) []string {
+	seen := make(map[string]bool)
+	var packages []string
+	for _, e := range entries {
+		category, pkg, ok := extractPackageInfo(e.FilePath)
+		if !ok || !isCategory(category) {
+			continue
+		}
+		key := category + "/" + pkg
+		if !seen[key] {
+			seen[key] = true
+			packages = append(packages, key)
+		}
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// packageKey returns the category/package prefix of a path, or "" if none
+func packageKey(path string) string {
+	category, pkg, ok := extractPackageInfo(path)
+	if !ok {
+		return ""
+	}
+	return category + "/" + pkg
+}
+
+// srcURIRegex detects a SRC_URI assignment in ebuild content
+var srcURIRegex = regexp.MustCompile(`(?m)^\s*SRC_URI\+?=`)
+
+// checkManifest verifies a new ebuild that fetches sources has a tracked,
+// staged Manifest in its package directory
+func checkManifest(src qaSource, eb *ebuild.Ebuild, content []byte, stagedPaths map[string]bool) []QAIssue {
+	if !srcURIRegex.Match(content) {
+		// No distfiles, no Manifest entries needed
+		return nil
+	}
+
+	manifest := eb.Category + "/" + eb.Package + "/Manifest"
+	issue := QAIssue{Check: QACheckManifest, Package: eb.FullName(), Path: manifest}
+
+	switch {
+	case src.untracked[manifest]:
+		issue.Severity = QAError
+		issue.Message = "Manifest exists but is untracked (git add it)"
+	case !src.exists(manifest):
+		issue.Severity = QAError
+		issue.Message = fmt.Sprintf("Manifest missing for new ebuild %s (run pkgdev manifest)", eb.String())
+	case !stagedPaths[manifest]:
+		issue.Severity = QAWarning
+		issue.Message = fmt.Sprintf("Manifest not updated alongside new ebuild %s", eb.String())
+	default:
+		return nil
+	}
+
+	return []QAIssue{issue}
+}
+
+// manifestEntryTypes are the entry types allowed in a thin or thick Manifest
+var manifestEntryTypes = map[string]bool{"DIST": true, "EBUILD": true, "AUX": true, "MISC": true}
+
+// checkManifestSyntax verifies that every line of a package's Manifest is a
+// well-formed "<TYPE> <file> <size> <HASH> <value>..." entry. A Manifest left
+// with merge conflict markers or truncated lines makes every fetch fail.
+func checkManifestSyntax(src qaSource, pkg string) []QAIssue {
+	manifest := pkg + "/Manifest"
+	content, err := src.read(manifest)
+	if err != nil {
+		// A missing Manifest is reported by checkManifest for new ebuilds
+		return nil
+	}
+
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var reason string
+		fields := strings.Fields(line)
+		switch {
+		case strings.HasPrefix(line, "<<<<<<<") || strings.HasPrefix(line, "=======") || strings.HasPrefix(line, ">>>>>>>"):
+			reason = "unresolved merge conflict"
+		case !manifestEntryTypes[fields[0]]:
+			reason = fmt.Sprintf("unknown entry type %q", fields[0])
+		case len(fields) < 5 || (len(fields)-3)%2 != 0:
+			reason = "expected <type> <file> <size> followed by hash name/value pairs"
+		default:
+			if _, err := strconv.ParseUint(fields[2], 10, 64); err != nil {
+				reason = fmt.Sprintf("invalid size %q", fields[2])
+			}
+		}
+
+		if reason != "" {
+			return []QAIssue{{
+				Check:    QACheckManifest,
+				Severity: QAError,
+				Package:  pkg,
+				Path:     manifest,
+				Message:  fmt.Sprintf("broken Manifest at line %d: %s (regenerate with pkgdev manifest)", i+1, reason),
+			}}
+		}
+	}
+
+	return nil
+}
+
+// filesDirRegex matches ${FILESDIR}/name references, with or without braces/quotes
+var filesDirRegex = regexp.MustCompile(`\$\{?FILESDIR\}?"?/([A-Za-z0-9._+/${}-]+)`)
+
+// checkFilesDir verifies that files referenced via ${FILESDIR} exist and are tracked
+func checkFilesDir(src qaSource, ebuildPath string, eb *ebuild.Ebuild, content []byte) []QAIssue {
+	var issues []QAIssue
+	seen := make(map[string]bool)
+
+	for _, m := range filesDirRegex.FindAllSubmatch(content, -1) {
+		name := expandEbuildVars(strings.TrimRight(string(m[1]), "/"), eb)
+		if strings.Contains(name, "$") || seen[name] {
+			// Unresolvable variable reference or already checked
+			continue
+		}
+		seen[name] = true
+
+		rel := eb.Category + "/" + eb.Package + "/files/" + name
+		switch {
+		case src.untracked[rel]:
+			issues = append(issues, QAIssue{
+				Check:    QACheckFiles,
+				Severity: QAError,
+				Package:  eb.FullName(),
+				Path:     ebuildPath,
+				Message:  fmt.Sprintf("references untracked file files/%s (git add it)", name),
+			})
+		case !src.exists(rel):
+			issues = append(issues, QAIssue{
+				Check:    QACheckFiles,
+				Severity: QAError,
+				Package:  eb.FullName(),
+				Path:     ebuildPath,
+				Message:  fmt.Sprintf("references missing file files/%s", name),
+			})
+		}
+	}
+
+	return issues
+}
+
+// expandEbuildVars expands the common PMS filename variables in s
+func expandEbuildVars(s string, eb *ebuild.Ebuild) string {
+	pv := revisionRegex.ReplaceAllString(eb.Version, "")
+	pr := "r0"
+	if m := revisionRegex.FindStringSubmatch(eb.Version); m != nil {
+		pr = "r" + m[1]
+	}
+
+	replacer := strings.NewReplacer(
+		"${PN}", eb.Package, "$PN", eb.Package,
+		"${PF}", eb.Package+"-"+eb.Version, "$PF", eb.Package+"-"+eb.Version,
+		"${PVR}", eb.Version, "$PVR", eb.Version,
+		"${PV}", pv, "$PV", pv,
+		"${PR}", pr, "$PR", pr,
+		"${P}", eb.Package+"-"+pv, "$P", eb.Package+"-"+pv,
+	)
+	return replacer.Replace(s)
+}
+
+// runQAHooks runs each external hook with the staged packages as arguments.
+// Hooks run through sh in the overlay directory; a non-zero exit is an error.
+func runQAHooks(overlayPath string, hooks, packages []string) []QAIssue {
+	var issues []QAIssue
+
+	for _, hook := range hooks {
+		args := append([]string{"-c", hook, "bentoo-qa-hook"}, packages...)
+		cmd := exec.Command("sh", args...)
+		cmd.Dir = overlayPath
+		cmd.Env = append(os.Environ(), "BENTOO_STAGED_PACKAGES="+strings.Join(packages, " "))
+
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+
+		if err := cmd.Run(); err != nil {
+			msg := strings.TrimSpace(out.String())
+			if msg == "" {
+				msg = err.Error()
+			}
+			issues = append(issues, QAIssue{
+				Check:    QACheckHook,
+				Severity: QAError,
+				Message:  fmt.Sprintf("hook %q failed: %s", hook, msg),
+			})
+		}
+	}
+
+	return issues
+}
+
+// FormatQAReport formats a QA report for terminal output
+func FormatQAReport(report *QAReport) string {
+	if len(report.Issues) == 0 {
+		return output.Sprintf(output.Success, "Pre-commit checks passed (%d package(s))", len(report.Packages))
+	}
+
+	var sb strings.Builder
+	errorsCount := 0
+	for _, issue := range report.Issues {
+		if issue.Severity == QAError {
+			errorsCount++
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("Pre-commit checks: %d error(s), %d warning(s)\n",
+		errorsCount, len(report.Issues)-errorsCount))
+
+	for _, issue := range report.Issues {
+		label := output.Sprintf(output.Error, "%-7s", issue.Severity.String())
+		if issue.Severity == QAWarning {
+			label = output.Sprintf(output.Warning, "%-7s", issue.Severity.String())
+		}
+
+		location := issue.Path
+		if location == "" {
+			location = issue.Package
+		}
+		if location != "" {
+			sb.WriteString(fmt.Sprintf("  %s [%s] %s: %s\n", label, issue.Check, location, issue.Message))
+		} else {
+			sb.WriteString(fmt.Sprintf("  %s [%s] %s\n", label, issue.Check, issue.Message))
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
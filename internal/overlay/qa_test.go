@@ -0,0 +1,343 @@
+package overlay
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+// writeQAFile writes a file relative to the overlay, creating parent directories.
+func writeQAFile(t *testing.T, overlayPath, rel, content string) {
+	t.Helper()
+	path := filepath.Join(overlayPath, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", rel, err)
+	}
+}
+
+// qaMock returns a mock whose index and commits hold the files on disk, as
+// if every change were fully staged and committed.
+func qaMock(overlayPath string) *git.MockGitRunner {
+	mock := git.NewMockGitRunner(overlayPath)
+	mock.ShowFunc = func(rev, path string) (string, error) {
+		content, err := os.ReadFile(filepath.Join(overlayPath, path))
+		if err != nil {
+			return "", git.ErrGitCommand
+		}
+		return string(content), nil
+	}
+	return mock
+}
+
+// qaIssuesByCheck groups report issues by check name.
+func qaIssuesByCheck(report *QAReport) map[string][]QAIssue {
+	byCheck := make(map[string][]QAIssue)
+	for _, issue := range report.Issues {
+		byCheck[issue.Check] = append(byCheck[issue.Check], issue)
+	}
+	return byCheck
+}
+
+// TestRunQAPasses tests a clean staged bump.
+func TestRunQAPasses(t *testing.T) {
+	overlayPath := t.TempDir()
+	writeQAFile(t, overlayPath, "app-misc/hello/hello-2.0.ebuild",
+		"SRC_URI=\"https://example.com/${P}.tar.gz\"\nPATCHES=( \"${FILESDIR}\"/${PN}-2.0-fix.patch )\n")
+	writeQAFile(t, overlayPath, "app-misc/hello/Manifest", "DIST hello-2.0.tar.gz 1 BLAKE2B x\n")
+	writeQAFile(t, overlayPath, "app-misc/hello/files/hello-2.0-fix.patch", "patch\n")
+
+	mock := qaMock(overlayPath)
+	mock.StatusFunc = func() ([]git.StatusEntry, error) {
+		return []git.StatusEntry{
+			{Status: "A", FilePath: "app-misc/hello/hello-2.0.ebuild"},
+			{Status: "M", FilePath: "app-misc/hello/Manifest"},
+			{Status: "A", FilePath: "app-misc/hello/files/hello-2.0-fix.patch"},
+			{Status: "D", FilePath: "app-misc/hello/hello-1.0.ebuild"},
+		}, nil
+	}
+
+	report, err := RunQAWithExecutor(mock, QAOptions{})
+	if err != nil {
+		t.Fatalf("RunQAWithExecutor() error = %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("expected no issues, got %+v", report.Issues)
+	}
+	if len(report.Packages) != 1 || report.Packages[0] != "app-misc/hello" {
+		t.Errorf("Packages = %v, want [app-misc/hello]", report.Packages)
+	}
+}
+
+// TestRunQADetectsIssues tests each built-in check.
+func TestRunQADetectsIssues(t *testing.T) {
+	overlayPath := t.TempDir()
+	// New ebuild with SRC_URI but no Manifest, referencing an untracked and a missing file
+	writeQAFile(t, overlayPath, "app-misc/hello/hello-2.0.ebuild",
+		"SRC_URI=\"https://example.com/${P}.tar.gz\"\nPATCHES=(\n\t\"${FILESDIR}\"/untracked.patch\n\t${FILESDIR}/missing.patch\n)\n")
+	writeQAFile(t, overlayPath, "app-misc/hello/files/untracked.patch", "patch\n")
+	// New ebuild whose Manifest exists but was not staged
+	writeQAFile(t, overlayPath, "app-misc/world/world-1.1.ebuild", "SRC_URI=\"https://example.com/${P}.tar.gz\"\n")
+	writeQAFile(t, overlayPath, "app-misc/world/Manifest", "DIST world-1.1.tar.gz 1 BLAKE2B x\n")
+	// Misnamed ebuild
+	writeQAFile(t, overlayPath, "app-misc/foo/bar-1.0.ebuild", "\n")
+
+	mock := qaMock(overlayPath)
+	mock.StatusFunc = func() ([]git.StatusEntry, error) {
+		return []git.StatusEntry{
+			{Status: "A", FilePath: "app-misc/hello/hello-2.0.ebuild"},
+			{Status: "??", FilePath: "app-misc/hello/files/untracked.patch"},
+			{Status: "A", FilePath: "app-misc/world/world-1.1.ebuild"},
+			{Status: "A", FilePath: "app-misc/foo/bar-1.0.ebuild"},
+		}, nil
+	}
+
+	report, err := RunQAWithExecutor(mock, QAOptions{})
+	if err != nil {
+		t.Fatalf("RunQAWithExecutor() error = %v", err)
+	}
+	if !report.HasErrors() {
+		t.Fatal("expected blocking errors")
+	}
+
+	byCheck := qaIssuesByCheck(report)
+	if len(byCheck[QACheckFilename]) != 1 {
+		t.Errorf("filename issues = %+v, want 1", byCheck[QACheckFilename])
+	}
+	if len(byCheck[QACheckFiles]) != 2 {
+		t.Errorf("files issues = %+v, want 2", byCheck[QACheckFiles])
+	}
+
+	var missingManifest, staleManifest bool
+	for _, issue := range byCheck[QACheckManifest] {
+		switch {
+		case issue.Package == "app-misc/hello" && issue.Severity == QAError:
+			missingManifest = true
+		case issue.Package == "app-misc/world" && issue.Severity == QAWarning:
+			staleManifest = true
+		}
+	}
+	if !missingManifest || !staleManifest {
+		t.Errorf("manifest issues = %+v", byCheck[QACheckManifest])
+	}
+
+	formatted := FormatQAReport(report)
+	if !strings.Contains(formatted, "untracked.patch") {
+		t.Errorf("FormatQAReport() missing untracked file:\n%s", formatted)
+	}
+}
+
+// TestRunQASkipAndHooks tests skipping checks and running external hooks.
+func TestRunQASkipAndHooks(t *testing.T) {
+	overlayPath := t.TempDir()
+	writeQAFile(t, overlayPath, "app-misc/foo/bar-1.0.ebuild", "\n")
+
+	mock := qaMock(overlayPath)
+	mock.StatusFunc = func() ([]git.StatusEntry, error) {
+		return []git.StatusEntry{{Status: "A", FilePath: "app-misc/foo/bar-1.0.ebuild"}}, nil
+	}
+
+	report, err := RunQAWithExecutor(mock, QAOptions{
+		Skip:  []string{QACheckFilename},
+		Hooks: []string{`test "$1" = app-misc/foo`, `echo "lint failed for $BENTOO_STAGED_PACKAGES"; exit 1`},
+	})
+	if err != nil {
+		t.Fatalf("RunQAWithExecutor() error = %v", err)
+	}
+
+	if len(report.Issues) != 1 {
+		t.Fatalf("expected exactly 1 issue (failing hook), got %+v", report.Issues)
+	}
+	if issue := report.Issues[0]; issue.Check != QACheckHook || !strings.Contains(issue.Message, "lint failed for app-misc/foo") {
+		t.Errorf("unexpected hook issue: %+v", issue)
+	}
+}
+
+// diskReader reads files relative to dir
+func diskReader(dir string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(dir, path))
+	}
+}
+
+// TestRunQAReadsIndex tests that a partially staged ebuild is checked as staged.
+func TestRunQAReadsIndex(t *testing.T) {
+	overlayPath := t.TempDir()
+	// The working tree dropped the patch, but the staged version still uses it
+	writeQAFile(t, overlayPath, "app-misc/hello/hello-2.0.ebuild", "EAPI=8\n")
+
+	mock := git.NewMockGitRunner(overlayPath)
+	mock.StatusFunc = func() ([]git.StatusEntry, error) {
+		return []git.StatusEntry{{Status: "AM", FilePath: "app-misc/hello/hello-2.0.ebuild"}}, nil
+	}
+	var shown []string
+	mock.ShowFunc = func(rev, path string) (string, error) {
+		shown = append(shown, rev+":"+path)
+		if path != "app-misc/hello/hello-2.0.ebuild" {
+			return "", git.ErrGitCommand
+		}
+		return "EAPI=8\nPATCHES=( \"${FILESDIR}\"/fix.patch )\n", nil
+	}
+
+	report, err := RunQAWithExecutor(mock, QAOptions{})
+	if err != nil {
+		t.Fatalf("RunQAWithExecutor() error = %v", err)
+	}
+	if len(shown) == 0 || shown[0] != ":app-misc/hello/hello-2.0.ebuild" {
+		t.Errorf("Show() calls = %v, want the ebuild read from the index", shown)
+	}
+	if issues := qaIssuesByCheck(report)[QACheckFiles]; len(issues) != 1 || !strings.Contains(issues[0].Message, "fix.patch") {
+		t.Errorf("files issues = %+v, want the staged patch reference", issues)
+	}
+}
+
+// TestRunQAExistenceFromIndex tests that before a commit files count as present
+// when they are staged, whatever the working tree holds.
+func TestRunQAExistenceFromIndex(t *testing.T) {
+	overlayPath := t.TempDir()
+	ebuildPath := "app-misc/hello/hello-2.0.ebuild"
+	ebuildContent := "SRC_URI=\"https://example.com/${P}.tar.gz\"\nPATCHES=( \"${FILESDIR}\"/fix.patch )\n"
+	// The Manifest was unstaged but is still on disk; the staged patch was
+	// deleted from disk
+	writeQAFile(t, overlayPath, ebuildPath, ebuildContent)
+	writeQAFile(t, overlayPath, "app-misc/hello/Manifest", "DIST hello-2.0.tar.gz 1 BLAKE2B aa\n")
+	index := map[string]string{
+		ebuildPath:                       ebuildContent,
+		"app-misc/hello/files/fix.patch": "patch\n",
+	}
+
+	mock := git.NewMockGitRunner(overlayPath)
+	mock.StatusFunc = func() ([]git.StatusEntry, error) {
+		return []git.StatusEntry{
+			{Status: "A ", FilePath: ebuildPath},
+			{Status: "AD", FilePath: "app-misc/hello/files/fix.patch"},
+		}, nil
+	}
+	mock.ShowFunc = func(rev, path string) (string, error) {
+		content, ok := index[path]
+		if rev != "" || !ok {
+			return "", git.ErrGitCommand
+		}
+		return content, nil
+	}
+
+	report, err := RunQAWithExecutor(mock, QAOptions{})
+	if err != nil {
+		t.Fatalf("RunQAWithExecutor() error = %v", err)
+	}
+	issues := qaIssuesByCheck(report)
+	if m := issues[QACheckManifest]; len(m) != 1 || !strings.Contains(m[0].Message, "Manifest missing") {
+		t.Errorf("manifest issues = %+v, want the unstaged Manifest reported missing", m)
+	}
+	if f := issues[QACheckFiles]; len(f) != 0 {
+		t.Errorf("files issues = %+v, want the staged patch accepted", f)
+	}
+}
+
+// TestCheckManifestSyntax tests detection of malformed Manifest files.
+func TestCheckManifestSyntax(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		reason   string // Expected message fragment, empty for a valid Manifest
+	}{
+		{"valid", "DIST foo-1.0.tar.gz 1024 BLAKE2B aa SHA512 bb\nEBUILD foo-1.0.ebuild 10 SHA512 cc\n\n", ""},
+		{"conflict markers", "<<<<<<< HEAD\nDIST foo-1.0.tar.gz 1 BLAKE2B aa\n=======\n", "line 1: unresolved merge conflict"},
+		{"unknown type", "DIST foo-1.0.tar.gz 1 BLAKE2B aa\nDISTX foo 1 BLAKE2B aa\n", `line 2: unknown entry type "DISTX"`},
+		{"truncated", "DIST foo-1.0.tar.gz 1024 BLAKE2B\n", "line 1: expected"},
+		{"bad size", "DIST foo-1.0.tar.gz big BLAKE2B aa\n", `invalid size "big"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlayPath := t.TempDir()
+			writeQAFile(t, overlayPath, "app-misc/foo/Manifest", tt.manifest)
+
+			issues := checkManifestSyntax(qaSource{read: diskReader(overlayPath)}, "app-misc/foo")
+			if tt.reason == "" {
+				if len(issues) != 0 {
+					t.Errorf("expected no issues, got %+v", issues)
+				}
+				return
+			}
+			if len(issues) != 1 || issues[0].Severity != QAError || !strings.Contains(issues[0].Message, tt.reason) {
+				t.Errorf("issues = %+v, want one error containing %q", issues, tt.reason)
+			}
+		})
+	}
+
+	if issues := checkManifestSyntax(qaSource{read: diskReader(t.TempDir())}, "app-misc/none"); issues != nil {
+		t.Errorf("missing Manifest issues = %+v, want none", issues)
+	}
+}
+
+// TestVerifyRangeWithExecutor tests checks against the committed contents.
+func TestVerifyRangeWithExecutor(t *testing.T) {
+	overlayPath := t.TempDir()
+	// The working tree holds a fixed Manifest that was never committed
+	writeQAFile(t, overlayPath, "app-misc/hello/Manifest", "DIST hello-2.0.tar.gz 1 BLAKE2B x\n")
+	committed := map[string]string{
+		"app-misc/hello/hello-2.0.ebuild": "SRC_URI=\"https://example.com/${P}.tar.gz\"\n",
+		"app-misc/hello/Manifest":         "DIST hello-2.0.tar.gz 1 BLAKE2B x\n>>>>>>> upstream\n",
+	}
+
+	var diffFrom, diffTo string
+	mock := git.NewMockGitRunner(overlayPath)
+	mock.DiffNameStatusFunc = func(from, to string) ([]git.StatusEntry, error) {
+		diffFrom, diffTo = from, to
+		return []git.StatusEntry{
+			{Status: "A", FilePath: "app-misc/hello/hello-2.0.ebuild"},
+			{Status: "M", FilePath: "app-misc/hello/Manifest"},
+		}, nil
+	}
+	mock.ShowFunc = func(rev, path string) (string, error) {
+		content, ok := committed[path]
+		if rev != "HEAD" || !ok {
+			return "", git.ErrGitCommand
+		}
+		return content, nil
+	}
+
+	report, err := VerifyRangeWithExecutor(mock, "origin/main", QAOptions{})
+	if err != nil {
+		t.Fatalf("VerifyRangeWithExecutor() error = %v", err)
+	}
+	if diffFrom != "origin/main" || diffTo != "HEAD" {
+		t.Errorf("diffed %s..%s, want origin/main..HEAD", diffFrom, diffTo)
+	}
+	if len(report.Issues) != 1 || !strings.Contains(report.Issues[0].Message, "unresolved merge conflict") {
+		t.Errorf("issues = %+v, want the committed broken Manifest", report.Issues)
+	}
+
+	// A Manifest that exists only on disk does not satisfy a pushed ebuild
+	delete(committed, "app-misc/hello/Manifest")
+	report, _ = VerifyRangeWithExecutor(mock, "origin/main", QAOptions{})
+	if issues := qaIssuesByCheck(report)[QACheckManifest]; len(issues) != 1 || !strings.Contains(issues[0].Message, "Manifest missing") {
+		t.Errorf("manifest issues = %+v, want the uncommitted Manifest reported missing", issues)
+	}
+}
+
+// TestRunQASkipsManifestSyntax tests that Manifest syntax is a push-only check.
+func TestRunQASkipsManifestSyntax(t *testing.T) {
+	overlayPath := t.TempDir()
+	writeQAFile(t, overlayPath, "app-misc/hello/hello-1.0-r1.ebuild", "EAPI=8\n")
+	writeQAFile(t, overlayPath, "app-misc/hello/Manifest", "DIST hello-1.0.tar.gz\n")
+
+	mock := qaMock(overlayPath)
+	mock.StatusFunc = func() ([]git.StatusEntry, error) {
+		return []git.StatusEntry{{Status: "A", FilePath: "app-misc/hello/hello-1.0-r1.ebuild"}}, nil
+	}
+
+	report, err := RunQAWithExecutor(mock, QAOptions{})
+	if err != nil {
+		t.Fatalf("RunQAWithExecutor() error = %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("issues = %+v, want none before a commit", report.Issues)
+	}
+}
@@ -47,22 +47,24 @@ func (e *ConflictError) Error() string {
 type RenameSpec struct {
 	Category       string // "*" for all categories, or specific category
 	PackagePattern string // Glob pattern for package names
-	OldVersion     string // Exact old version to match
+	OldVersion     string // Old version: exact, glob ("1.24.*") or constraint ("<1.26")
 	NewVersion     string // New version to rename to
 }
 
 // RenameOptions controls rename behavior.
 type RenameOptions struct {
-	DryRun     bool // Simulate without executing
-	SkipPrompt bool // Skip confirmation prompts
-	NoManifest bool // Skip Manifest updates
-	Force      bool // Proceed despite warnings
+	DryRun      bool // Simulate without executing
+	SkipPrompt  bool // Skip confirmation prompts
+	NoManifest  bool // Skip Manifest updates
+	Force       bool // Proceed despite warnings
+	KeepPartial bool // Keep completed renames when a later step fails (no rollback)
 }
 
 // RenameMatch represents a single ebuild to be renamed.
 type RenameMatch struct {
 	Category    string // e.g., "media-plugins"
 	Package     string // e.g., "gst-plugins-base"
+	OldVersion  string // Matched base version, e.g., "1.24.11"
 	OldFilename string // e.g., "gst-plugins-base-1.24.11-r1.ebuild"
 	NewFilename string // e.g., "gst-plugins-base-1.26.10.ebuild"
 	OldPath     string // Full path to old file
@@ -78,6 +80,7 @@ type RenameResult struct {
 	VersionFiles    []VersionFile    // Version-specific files detected
 	Conflicts       []Conflict       // Target files that already exist
 	ManifestUpdates []ManifestUpdate // Manifest update results
+	RolledBack      []RenameMatch    // Renames reverted after a failure
 }
 
 // RenameError represents a failed rename operation.
@@ -127,6 +130,12 @@ func ShouldBlockForVersionFiles(versionFiles []VersionFile, force bool) bool {
 // RenamePreview finds matching ebuilds and detects potential issues without executing.
 // Used to show a preview before confirmation.
 func RenamePreview(cfg *config.Config, spec *RenameSpec) (*RenameResult, error) {
+	return RenamePreviewBatch(cfg, []*RenameSpec{spec})
+}
+
+// RenamePreviewBatch is like RenamePreview but combines several specs
+// (e.g., from a mapping file) into a single preview.
+func RenamePreviewBatch(cfg *config.Config, specs []*RenameSpec) (*RenameResult, error) {
 	result := &RenameResult{}
 
 	// Get overlay path from config
@@ -135,15 +144,8 @@ func RenamePreview(cfg *config.Config, spec *RenameSpec) (*RenameResult, error)
 		return nil, ErrOverlayPathNotSet
 	}
 
-	// Validate pattern
-	validator := NewPatternValidator()
-	if err := validator.Validate(spec.PackagePattern); err != nil {
-		return nil, err
-	}
-
 	// Find matching ebuilds
-	matcher := NewEbuildMatcher(overlayPath)
-	matches, err := matcher.Match(spec)
+	matches, err := matchRenameSpecs(overlayPath, specs)
 	if err != nil {
 		return nil, err
 	}
@@ -156,20 +158,60 @@ func RenamePreview(cfg *config.Config, spec *RenameSpec) (*RenameResult, error)
 
 	// Detect version-specific files
 	detector := NewVersionFilesDetector(overlayPath)
-	versionFiles := detector.Detect(matches, spec.OldVersion)
-	result.VersionFiles = versionFiles
+	result.VersionFiles = detector.Detect(matches, "")
 
 	// Check for conflicts (target files that already exist)
+	result.Conflicts = detectConflicts(matches)
+
+	return result, nil
+}
+
+// matchRenameSpecs validates each spec and collects all matching ebuilds.
+// An ebuild matched by more than one spec is reported as an error, since
+// renaming it twice would be ambiguous.
+func matchRenameSpecs(overlayPath string, specs []*RenameSpec) ([]RenameMatch, error) {
+	var matches []RenameMatch
+
+	validator := NewPatternValidator()
+	matcher := NewEbuildMatcher(overlayPath)
+	seen := make(map[string]bool)
+
+	for _, spec := range specs {
+		// Validate pattern
+		if err := validator.Validate(spec.PackagePattern); err != nil {
+			return nil, err
+		}
+
+		specMatches, err := matcher.Match(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range specMatches {
+			if seen[match.OldPath] {
+				return nil, fmt.Errorf("%s/%s/%s is matched by more than one rename spec",
+					match.Category, match.Package, match.OldFilename)
+			}
+			seen[match.OldPath] = true
+			matches = append(matches, match)
+		}
+	}
+
+	return matches, nil
+}
+
+// detectConflicts returns matches whose target file already exists.
+func detectConflicts(matches []RenameMatch) []Conflict {
+	var conflicts []Conflict
 	for _, match := range matches {
 		if _, err := os.Stat(match.NewPath); err == nil {
-			result.Conflicts = append(result.Conflicts, Conflict{
+			conflicts = append(conflicts, Conflict{
 				Match:    match,
 				Existing: match.NewPath,
 			})
 		}
 	}
-
-	return result, nil
+	return conflicts
 }
 
 // FormatRenamePreview formats the preview for display before confirmation.
@@ -213,6 +255,12 @@ func FormatRenamePreview(result *RenameResult, isGlobalSearch bool) string {
 // It validates the pattern, finds matching ebuilds, detects version files,
 // and performs the rename operation (or simulates it in dry-run mode).
 func Rename(cfg *config.Config, spec *RenameSpec, opts *RenameOptions) (*RenameResult, error) {
+	return RenameBatch(cfg, []*RenameSpec{spec}, opts)
+}
+
+// RenameBatch performs a single rename operation covering several specs,
+// so that a scripted bulk bump is previewed, checked and executed as one unit.
+func RenameBatch(cfg *config.Config, specs []*RenameSpec, opts *RenameOptions) (*RenameResult, error) {
 	result := &RenameResult{}
 
 	// Get overlay path from config
@@ -221,15 +269,8 @@ func Rename(cfg *config.Config, spec *RenameSpec, opts *RenameOptions) (*RenameR
 		return nil, ErrOverlayPathNotSet
 	}
 
-	// Validate pattern
-	validator := NewPatternValidator()
-	if err := validator.Validate(spec.PackagePattern); err != nil {
-		return nil, err
-	}
-
 	// Find matching ebuilds
-	matcher := NewEbuildMatcher(overlayPath)
-	matches, err := matcher.Match(spec)
+	matches, err := matchRenameSpecs(overlayPath, specs)
 	if err != nil {
 		return nil, err
 	}
@@ -242,7 +283,7 @@ func Rename(cfg *config.Config, spec *RenameSpec, opts *RenameOptions) (*RenameR
 
 	// Detect version-specific files
 	detector := NewVersionFilesDetector(overlayPath)
-	versionFiles := detector.Detect(matches, spec.OldVersion)
+	versionFiles := detector.Detect(matches, "")
 	result.VersionFiles = versionFiles
 
 	// Check if version files should block the operation
@@ -251,14 +292,7 @@ func Rename(cfg *config.Config, spec *RenameSpec, opts *RenameOptions) (*RenameR
 	}
 
 	// Check for conflicts (target files that already exist)
-	for _, match := range matches {
-		if _, err := os.Stat(match.NewPath); err == nil {
-			result.Conflicts = append(result.Conflicts, Conflict{
-				Match:    match,
-				Existing: match.NewPath,
-			})
-		}
-	}
+	result.Conflicts = detectConflicts(matches)
 
 	// If conflicts exist and not forcing, return early
 	if len(result.Conflicts) > 0 && !opts.Force {
@@ -270,9 +304,19 @@ func Rename(cfg *config.Config, spec *RenameSpec, opts *RenameOptions) (*RenameR
 		return result, nil
 	}
 
-	// Perform the actual rename operations
+	// Keep today's best-effort behavior when explicitly requested
+	if opts.KeepPartial {
+		return renamePartial(result, matches, overlayPath, opts)
+	}
+
+	return renameTransactional(result, matches, overlayPath, opts)
+}
+
+// renamePartial renames each match independently, recording failures
+// and leaving successful renames in place.
+func renamePartial(result *RenameResult, matches []RenameMatch, overlayPath string, opts *RenameOptions) (*RenameResult, error) {
 	for _, match := range matches {
-		err := os.Rename(match.OldPath, match.NewPath)
+		err := renameFile(match.OldPath, match.NewPath)
 		if err != nil {
 			result.Failed = append(result.Failed, RenameError{
 				Match:   match,
@@ -285,12 +329,58 @@ func Rename(cfg *config.Config, spec *RenameSpec, opts *RenameOptions) (*RenameR
 
 	// Update Manifests unless --no-manifest is set
 	if !opts.NoManifest && len(result.Renamed) > 0 {
-		result.ManifestUpdates = updateManifests(result.Renamed, overlayPath)
+		result.ManifestUpdates = manifestUpdater(result.Renamed, overlayPath)
 	}
 
 	return result, nil
 }
 
+// renameTransactional renames all matches as a single unit. If any rename
+// or Manifest update fails, every moved file and touched Manifest is
+// restored and a *RollbackError is returned.
+func renameTransactional(result *RenameResult, matches []RenameMatch, overlayPath string, opts *RenameOptions) (*RenameResult, error) {
+	tx, err := newRenameTransaction(matches, overlayPath, !opts.NoManifest)
+	if err != nil {
+		return result, err
+	}
+
+	var cause error
+	for _, match := range matches {
+		if err := tx.apply(match); err != nil {
+			result.Failed = append(result.Failed, RenameError{
+				Match:   match,
+				Message: err.Error(),
+			})
+			cause = fmt.Errorf("%s/%s: %w", match.Category, match.Package, err)
+			break
+		}
+	}
+
+	// Update Manifests unless --no-manifest is set
+	if cause == nil && !opts.NoManifest {
+		result.ManifestUpdates = manifestUpdater(tx.applied, overlayPath)
+		for _, u := range result.ManifestUpdates {
+			if !u.Success {
+				cause = fmt.Errorf("updating Manifest for %s/%s: %s", u.Category, u.Package, u.Error)
+				break
+			}
+		}
+	}
+
+	if cause == nil {
+		result.Renamed = tx.applied
+		return result, nil
+	}
+
+	result.RolledBack = tx.applied
+	failures := tx.rollback()
+	return result, &RollbackError{
+		Cause:            cause,
+		RolledBack:       len(tx.applied),
+		RollbackFailures: failures,
+	}
+}
+
 // updateManifests updates Manifest files for renamed packages using pkgdev.
 // Returns a slice of ManifestUpdate with the results.
 func updateManifests(renamed []RenameMatch, overlayPath string) []ManifestUpdate {
@@ -427,6 +517,13 @@ func FormatRenameResult(result *RenameResult, dryRun bool) string {
 		}
 	}
 
+	if len(result.RolledBack) > 0 {
+		sb.WriteString(fmt.Sprintf("\nRolled back %d rename(s):\n", len(result.RolledBack)))
+		for _, match := range result.RolledBack {
+			sb.WriteString(fmt.Sprintf("  %s/%s: %s restored\n", match.Category, match.Package, match.OldFilename))
+		}
+	}
+
 	if len(result.Conflicts) > 0 {
 		sb.WriteString(fmt.Sprintf("\nConflicts: %d target file(s) already exist:\n", len(result.Conflicts)))
 		for _, c := range result.Conflicts {
@@ -1170,7 +1170,8 @@ func TestShouldBlockForVersionFilesEdgeCases(t *testing.T) {
 }
 
 // TestRenameWithManifestUpdate tests Rename with manifest update enabled.
-// This test will fail if pkgdev is not installed, which is expected.
+// The Manifest update fails if pkgdev is not installed, so KeepPartial is set
+// to keep the rename regardless of the Manifest outcome.
 func TestRenameWithManifestUpdate(t *testing.T) {
 	overlayPath := setupRenameTestOverlay(t)
 	defer os.RemoveAll(overlayPath)
@@ -1189,7 +1190,8 @@ func TestRenameWithManifestUpdate(t *testing.T) {
 	}
 
 	opts := &RenameOptions{
-		NoManifest: false, // Enable manifest update
+		NoManifest:  false, // Enable manifest update
+		KeepPartial: true,
 	}
 
 	result, err := Rename(cfg, spec, opts)
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+	"github.com/obentoo/bentoolkit/internal/common/provider"
 )
 
 // PackageInfo represents information about a package in an overlay
@@ -15,6 +16,7 @@ type PackageInfo struct {
 	Package       string   // e.g., "vscode"
 	Versions      []string // All versions found
 	LatestVersion string   // Most recent version
+	Path          string   // Package directory
 }
 
 // ScanResult contains the results of scanning an overlay
@@ -22,6 +24,9 @@ type ScanResult struct {
 	OverlayPath string
 	Packages    []PackageInfo
 	Errors      []ScanError
+
+	// Updates holds the package moves of the overlay's own profiles/updates
+	Updates *ebuild.Updates
 }
 
 // ScanError represents an error encountered during scanning
@@ -97,6 +102,16 @@ func ScanOverlay(overlayPath string) (*ScanResult, error) {
 		return result.Packages[i].Package < result.Packages[j].Package
 	})
 
+	updates, err := provider.ReadUpdates(&provider.LocalProvider{Path: overlayPath})
+	if err != nil {
+		result.Errors = append(result.Errors, ScanError{
+			Path:    filepath.Join(overlayPath, ebuild.UpdatesDir),
+			Message: err.Error(),
+		})
+		updates = &ebuild.Updates{}
+	}
+	result.Updates = updates
+
 	return result, nil
 }
 
@@ -141,7 +156,7 @@ func scanCategory(categoryPath, categoryName string) ([]PackageInfo, []ScanError
 func scanPackage(pkgPath, category, pkgName string) (*PackageInfo, []ScanError) {
 	var errors []ScanError
 
-	entries, err := os.ReadDir(pkgPath)
+	versions, err := ebuild.ScanPackageDir(pkgPath, category, pkgName)
 	if err != nil {
 		errors = append(errors, ScanError{
 			Path:    pkgPath,
@@ -150,34 +165,6 @@ func scanPackage(pkgPath, category, pkgName string) (*PackageInfo, []ScanError)
 		return nil, errors
 	}
 
-	var versions []string
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		filename := entry.Name()
-		if !strings.HasSuffix(filename, ".ebuild") {
-			continue
-		}
-
-		// Construct a fake path for the parser
-		fakePath := filepath.Join(category, pkgName, filename)
-		eb, err := ebuild.ParsePath(fakePath)
-		if err != nil {
-			// Not a valid ebuild, skip silently
-			continue
-		}
-
-		// Validate package name matches directory
-		if eb.Package != pkgName {
-			continue
-		}
-
-		versions = append(versions, eb.Version)
-	}
-
 	// No ebuilds found
 	if len(versions) == 0 {
 		return nil, errors
@@ -191,6 +178,7 @@ func scanPackage(pkgPath, category, pkgName string) (*PackageInfo, []ScanError)
 		Package:       pkgName,
 		Versions:      versions,
 		LatestVersion: latestVersion,
+		Path:          pkgPath,
 	}, errors
 }
 
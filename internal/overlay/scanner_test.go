@@ -97,6 +97,21 @@ func TestScanOverlaySkipsSpecialDirs(t *testing.T) {
 	}
 }
 
+func TestScanOverlayReadsUpdates(t *testing.T) {
+	tempDir := t.TempDir()
+	createPackage(t, tempDir, "app-misc", "hello", []string{"1.0"})
+	os.MkdirAll(filepath.Join(tempDir, "profiles", "updates"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "profiles", "updates", "1Q-2024"), []byte("move app-misc/old app-misc/hello\n"), 0644)
+
+	result, err := ScanOverlay(tempDir)
+	if err != nil {
+		t.Fatalf("ScanOverlay failed: %v", err)
+	}
+	if name, ok := result.Updates.Resolve("app-misc/old"); !ok || name != "app-misc/hello" {
+		t.Errorf("Updates.Resolve(app-misc/old) = %s, %v", name, ok)
+	}
+}
+
 func TestScanOverlayEmptyOverlay(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "bentoo-test-overlay-*")
 	if err != nil {
@@ -0,0 +1,161 @@
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+)
+
+var (
+	// ErrNothingStaged indicates there are no staged changes to split
+	ErrNothingStaged = errors.New("no staged changes to commit")
+)
+
+// CommitPlan describes one commit of a split commit operation
+type CommitPlan struct {
+	Category string   // Package category, empty for overlay-wide files
+	Package  string   // Package name, "root" for overlay-wide files
+	Message  string   // Generated commit message
+	Paths    []string // Staged paths included in this commit
+}
+
+// Name returns the category/package of the plan, or "root"
+func (p CommitPlan) Name() string {
+	if p.Category == "" {
+		return p.Package
+	}
+	return p.Category + "/" + p.Package
+}
+
+// SplitResult contains the outcome of a split commit operation
+type SplitResult struct {
+	Committed []CommitPlan // Plans committed, in order
+}
+
+// SplitCommitError indicates a split commit failed and earlier commits
+// from the same operation were undone. The staging area is left as it was.
+type SplitCommitError struct {
+	Plan     CommitPlan // The plan whose commit failed
+	Index    int        // 0-based index of the failed plan
+	Cause    error      // Underlying commit error
+	Reverted int        // Number of earlier commits undone
+	ResetErr error      // Non-nil if undoing earlier commits failed
+}
+
+// Error implements the error interface.
+func (e *SplitCommitError) Error() string {
+	msg := fmt.Sprintf("commit %d (%s) failed: %v", e.Index+1, e.Plan.Name(), e.Cause)
+	if e.ResetErr != nil {
+		return msg + fmt.Sprintf("; undoing %d earlier commit(s) failed: %v", e.Reverted, e.ResetErr)
+	}
+	return msg + fmt.Sprintf("; undid %d earlier commit(s), staging area unchanged", e.Reverted)
+}
+
+// Unwrap returns the underlying cause.
+func (e *SplitCommitError) Unwrap() error {
+	return e.Cause
+}
+
+// PlanSplitCommits groups staged entries by category/package and generates
+// one commit message per group. Plans are ordered by category, then package.
+func PlanSplitCommits(staged []git.StatusEntry, opts MessageOptions) ([]CommitPlan, error) {
+	entriesByPath := make(map[string]git.StatusEntry, len(staged))
+	for _, e := range staged {
+		entriesByPath[e.FilePath] = e
+	}
+
+	var plans []CommitPlan
+	for _, ps := range GroupStatusEntries(staged) {
+		plan := CommitPlan{Category: ps.Category, Package: ps.Package}
+
+		var groupEntries []git.StatusEntry
+		for _, fc := range ps.Changes {
+			entry := entriesByPath[fc.Path]
+			plan.Paths = append(plan.Paths, fc.Path)
+			if entry.IsRename() {
+				// The source removal belongs to the same commit
+				plan.Paths = append(plan.Paths, entry.OrigPath)
+			}
+			groupEntries = append(groupEntries, entry)
+		}
+
+		groupOpts := opts
+		if ps.Category != "" {
+			groupOpts.Package = plan.Name()
+		}
+		message, err := FormatMessage(groupEntries, groupOpts)
+		if err != nil {
+			return nil, err
+		}
+		plan.Message = message
+
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+// CommitSplit creates one commit per plan from the current staging area
+func CommitSplit(cfg *config.Config, plans []CommitPlan) (*SplitResult, error) {
+	runner, err := NewExecutor(cfg, git.FeatureCommitPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	return CommitSplitWithExecutor(cfg, plans, runner)
+}
+
+// CommitSplitWithExecutor creates one commit per plan using the provided GitExecutor.
+// Each commit contains only the staged state of the plan's paths. If any commit
+// fails, HEAD is reset to where it was before the first commit, so the operation
+// either creates every planned commit or none.
+func CommitSplitWithExecutor(cfg *config.Config, plans []CommitPlan, executor git.GitExecutor) (*SplitResult, error) {
+	if len(plans) == 0 {
+		return nil, ErrNothingStaged
+	}
+
+	origHead, err := executor.RevParse("HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SplitResult{}
+	for i, plan := range plans {
+		if err := executor.CommitPaths(plan.Message, cfg.Git.User, cfg.Git.Email, plan.Paths); err != nil {
+			splitErr := &SplitCommitError{Plan: plan, Index: i, Cause: err, Reverted: len(result.Committed)}
+			if len(result.Committed) > 0 {
+				splitErr.ResetErr = executor.ResetSoft(origHead)
+			}
+			return nil, splitErr
+		}
+		result.Committed = append(result.Committed, plan)
+	}
+
+	return result, nil
+}
+
+// FormatSplitPlan formats the planned commits for preview
+func FormatSplitPlan(plans []CommitPlan) string {
+	var sb strings.Builder
+
+	sb.WriteString(output.Sprintf(output.Header, "%d commit(s) planned:", len(plans)))
+	sb.WriteString("\n")
+	for i, plan := range plans {
+		subject, body, _ := strings.Cut(plan.Message, "\n")
+		sb.WriteString(fmt.Sprintf("\n  %d. %s\n", i+1, output.Sprint(output.Info, subject)))
+		for _, line := range strings.Split(strings.TrimPrefix(body, "\n"), "\n") {
+			if line != "" {
+				sb.WriteString("     " + line + "\n")
+			}
+		}
+		for _, path := range plan.Paths {
+			sb.WriteString("       " + output.Sprint(output.Dim, path) + "\n")
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
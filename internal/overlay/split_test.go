@@ -0,0 +1,156 @@
+package overlay
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+func TestPlanSplitCommits(t *testing.T) {
+	staged := []git.StatusEntry{
+		{Status: "A", FilePath: "net-misc/foo/foo-2.0.ebuild"},
+		{Status: "D", FilePath: "net-misc/foo/foo-1.0.ebuild"},
+		{Status: "M", FilePath: "net-misc/foo/Manifest"},
+		{Status: "A", FilePath: "app-misc/bar/bar-1.0.ebuild"},
+		{Status: "M", FilePath: "dev-libs/baz/metadata.xml"},
+		{Status: "M", FilePath: "README.md"},
+	}
+
+	plans, err := PlanSplitCommits(staged, MessageOptions{})
+	if err != nil {
+		t.Fatalf("PlanSplitCommits() error = %v", err)
+	}
+
+	want := []CommitPlan{
+		{Category: "", Package: "root", Message: "update: package files", Paths: []string{"README.md"}},
+		{Category: "app-misc", Package: "bar", Message: "add(app-misc/bar-1.0)", Paths: []string{"app-misc/bar/bar-1.0.ebuild"}},
+		{Category: "dev-libs", Package: "baz", Message: "update: dev-libs/baz", Paths: []string{"dev-libs/baz/metadata.xml"}},
+		{Category: "net-misc", Package: "foo", Message: "up(net-misc/foo-1.0 -> 2.0)", Paths: []string{
+			"net-misc/foo/foo-2.0.ebuild", "net-misc/foo/foo-1.0.ebuild", "net-misc/foo/Manifest",
+		}},
+	}
+
+	if len(plans) != len(want) {
+		t.Fatalf("PlanSplitCommits() returned %d plans, want %d: %+v", len(plans), len(want), plans)
+	}
+	for i := range want {
+		if plans[i].Name() != want[i].Name() {
+			t.Errorf("plan %d name = %q, want %q", i, plans[i].Name(), want[i].Name())
+		}
+		if plans[i].Message != want[i].Message {
+			t.Errorf("plan %d message = %q, want %q", i, plans[i].Message, want[i].Message)
+		}
+		if !reflect.DeepEqual(plans[i].Paths, want[i].Paths) {
+			t.Errorf("plan %d paths = %v, want %v", i, plans[i].Paths, want[i].Paths)
+		}
+	}
+}
+
+func TestCommitSplitWithExecutor(t *testing.T) {
+	cfg := &config.Config{Git: config.GitConfig{User: "Test User", Email: "test@example.com"}}
+	plans := []CommitPlan{
+		{Category: "app-misc", Package: "bar", Message: "add(app-misc/bar-1.0)", Paths: []string{"app-misc/bar/bar-1.0.ebuild"}},
+		{Category: "net-misc", Package: "foo", Message: "mod(net-misc/foo-1.0)", Paths: []string{"net-misc/foo/foo-1.0.ebuild"}},
+		{Category: "sys-apps", Package: "qux", Message: "mod(sys-apps/qux-3.0)", Paths: []string{"sys-apps/qux/qux-3.0.ebuild"}},
+	}
+
+	t.Run("creates commits in order", func(t *testing.T) {
+		var messages []string
+		var paths [][]string
+		mock := git.NewMockGitRunner("/test/overlay")
+		mock.RevParseFunc = func(rev string) (string, error) { return "abc123", nil }
+		mock.CommitPathsFunc = func(message, user, email string, p []string) error {
+			if user != "Test User" || email != "test@example.com" {
+				t.Errorf("unexpected author %s <%s>", user, email)
+			}
+			messages = append(messages, message)
+			paths = append(paths, p)
+			return nil
+		}
+		mock.ResetSoftFunc = func(commit string) error {
+			t.Error("ResetSoft should not be called on success")
+			return nil
+		}
+
+		result, err := CommitSplitWithExecutor(cfg, plans, mock)
+		if err != nil {
+			t.Fatalf("CommitSplitWithExecutor() error = %v", err)
+		}
+		if len(result.Committed) != 3 {
+			t.Errorf("committed %d plans, want 3", len(result.Committed))
+		}
+		for i, plan := range plans {
+			if messages[i] != plan.Message || !reflect.DeepEqual(paths[i], plan.Paths) {
+				t.Errorf("commit %d = %q %v, want %q %v", i, messages[i], paths[i], plan.Message, plan.Paths)
+			}
+		}
+	})
+
+	t.Run("failure undoes earlier commits", func(t *testing.T) {
+		calls := 0
+		var resetTo string
+		mock := git.NewMockGitRunner("/test/overlay")
+		mock.RevParseFunc = func(rev string) (string, error) { return "abc123", nil }
+		mock.CommitPathsFunc = func(message, user, email string, p []string) error {
+			calls++
+			if calls == 3 {
+				return errors.New("hook rejected commit")
+			}
+			return nil
+		}
+		mock.ResetSoftFunc = func(commit string) error {
+			resetTo = commit
+			return nil
+		}
+
+		result, err := CommitSplitWithExecutor(cfg, plans, mock)
+		if result != nil {
+			t.Errorf("expected nil result on failure, got %+v", result)
+		}
+
+		var splitErr *SplitCommitError
+		if !errors.As(err, &splitErr) {
+			t.Fatalf("expected *SplitCommitError, got %v", err)
+		}
+		if splitErr.Index != 2 || splitErr.Reverted != 2 || splitErr.ResetErr != nil {
+			t.Errorf("unexpected error details: %+v", splitErr)
+		}
+		if resetTo != "abc123" {
+			t.Errorf("ResetSoft(%q), want original HEAD abc123", resetTo)
+		}
+	})
+
+	t.Run("first commit failure leaves HEAD alone", func(t *testing.T) {
+		mock := git.NewMockGitRunner("/test/overlay")
+		mock.RevParseFunc = func(rev string) (string, error) { return "abc123", nil }
+		mock.CommitPathsFunc = func(message, user, email string, p []string) error {
+			return errors.New("failed")
+		}
+		mock.ResetSoftFunc = func(commit string) error {
+			t.Error("ResetSoft should not be called when nothing was committed")
+			return nil
+		}
+
+		if _, err := CommitSplitWithExecutor(cfg, plans, mock); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("no plans", func(t *testing.T) {
+		mock := git.NewMockGitRunner("/test/overlay")
+		if _, err := CommitSplitWithExecutor(cfg, nil, mock); !errors.Is(err, ErrNothingStaged) {
+			t.Errorf("expected ErrNothingStaged, got %v", err)
+		}
+	})
+
+	t.Run("unresolvable HEAD", func(t *testing.T) {
+		mock := git.NewMockGitRunner("/test/overlay")
+		mock.RevParseFunc = func(rev string) (string, error) { return "", errors.New("unknown revision: HEAD") }
+		if _, err := CommitSplitWithExecutor(cfg, plans, mock); err == nil {
+			t.Error("expected error when HEAD cannot be resolved")
+		}
+	})
+}
@@ -26,6 +26,7 @@ type FileChange struct {
 	Type   FileType // ebuild, manifest, metadata, files, other
 	Name   string   // filename
 	Status string   // Added, Modified, Deleted, Renamed, Untracked
+	Path   string   // path relative to the overlay root
 }
 
 // PackageStatus represents the status of changes for a single package
@@ -41,6 +42,9 @@ var statusLabelMap = map[string]string{
 	"M":  "Modified",
 	"D":  "Deleted",
 	"R":  "Renamed",
+	"C":  "Copied",
+	"T":  "Modified",
+	"U":  "Conflict",
 	"??": "Untracked",
 	"AM": "Added",
 	"MM": "Modified",
@@ -132,6 +136,7 @@ func GroupStatusEntries(entries []git.StatusEntry) []PackageStatus {
 			Type:   DetectFileType(entry.FilePath),
 			Name:   filename,
 			Status: StatusLabel(entry.Status),
+			Path:   entry.FilePath,
 		}
 
 		packageMap[key].Changes = append(packageMap[key].Changes, change)
@@ -156,12 +161,11 @@ func GroupStatusEntries(entries []git.StatusEntry) []PackageStatus {
 
 // Status retrieves and groups the current git status for the overlay
 func Status(cfg *config.Config) ([]PackageStatus, error) {
-	overlayPath, err := cfg.GetOverlayPath()
+	runner, err := NewExecutor(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	runner := git.NewGitRunner(overlayPath)
 	return StatusWithExecutor(runner)
 }
 
@@ -176,6 +180,87 @@ func StatusWithExecutor(executor git.GitExecutor) ([]PackageStatus, error) {
 	return GroupStatusEntries(entries), nil
 }
 
+// BranchStatus describes the checked-out branch relative to its upstream
+type BranchStatus struct {
+	Branch   string // Empty when HEAD is detached
+	Upstream string // Empty when the branch has no upstream
+	Ahead    int    // Local commits not in the upstream
+	Behind   int    // Upstream commits not in the local branch
+}
+
+// GetBranchStatus returns the branch and tracking state of the overlay
+func GetBranchStatus(cfg *config.Config) (*BranchStatus, error) {
+	runner, err := NewExecutor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return BranchStatusWithExecutor(runner)
+}
+
+// BranchStatusWithExecutor returns the branch and tracking state using the
+// provided GitExecutor. A missing upstream is not an error.
+func BranchStatusWithExecutor(executor git.GitExecutor) (*BranchStatus, error) {
+	branch, err := executor.CurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+
+	bs := &BranchStatus{Branch: branch}
+	if branch == "" {
+		return bs, nil
+	}
+
+	upstream, err := executor.UpstreamRef()
+	if err != nil || upstream == "" {
+		return bs, nil
+	}
+	bs.Upstream = upstream
+	if bs.Ahead, bs.Behind, err = executor.AheadBehind(upstream); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+// FormatBranchStatus formats the branch line shown above the package status
+func FormatBranchStatus(bs *BranchStatus) string {
+	if bs.Branch == "" {
+		return output.Sprintf(output.Warning, "HEAD detached")
+	}
+
+	line := "On branch " + output.Sprint(output.Info, bs.Branch)
+	if bs.Upstream == "" {
+		return line
+	}
+
+	switch {
+	case bs.Ahead > 0 && bs.Behind > 0:
+		line += output.Sprintf(output.Warning, " (diverged from %s: %d ahead, %d behind)", bs.Upstream, bs.Ahead, bs.Behind)
+	case bs.Ahead > 0:
+		line += fmt.Sprintf(" (%d ahead of %s)", bs.Ahead, bs.Upstream)
+	case bs.Behind > 0:
+		line += fmt.Sprintf(" (%d behind %s)", bs.Behind, bs.Upstream)
+	default:
+		line += output.Sprintf(output.Dim, " (up to date with %s)", bs.Upstream)
+	}
+	return line
+}
+
+// Diff returns the textual diff of local changes in the overlay
+func Diff(cfg *config.Config, opts git.DiffOptions) (string, error) {
+	runner, err := NewExecutor(cfg, git.FeatureDiff)
+	if err != nil {
+		return "", err
+	}
+
+	return DiffWithExecutor(runner, opts)
+}
+
+// DiffWithExecutor returns the textual diff of local changes using the provided GitExecutor
+func DiffWithExecutor(executor git.GitExecutor, opts git.DiffOptions) (string, error) {
+	return executor.Diff(opts)
+}
+
 // FormatStatus formats package statuses into a human-readable string with colors
 func FormatStatus(statuses []PackageStatus) string {
 	if len(statuses) == 0 {
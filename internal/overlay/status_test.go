@@ -457,3 +457,70 @@ func TestStatusLabelMappingWithMock(t *testing.T) {
 		})
 	}
 }
+
+// TestBranchStatusWithExecutor tests branch and tracking state detection
+func TestBranchStatusWithExecutor(t *testing.T) {
+	tests := []struct {
+		name     string
+		branch   string
+		upstream string
+		ahead    int
+		behind   int
+		want     BranchStatus
+		contains string
+	}{
+		{"detached", "", "", 0, 0, BranchStatus{}, "HEAD detached"},
+		{"no upstream", "topic", "", 0, 0, BranchStatus{Branch: "topic"}, "On branch"},
+		{"up to date", "main", "origin/main", 0, 0, BranchStatus{Branch: "main", Upstream: "origin/main"}, "up to date with origin/main"},
+		{"ahead", "main", "origin/main", 2, 0, BranchStatus{Branch: "main", Upstream: "origin/main", Ahead: 2}, "2 ahead of origin/main"},
+		{"diverged", "main", "origin/main", 1, 3, BranchStatus{Branch: "main", Upstream: "origin/main", Ahead: 1, Behind: 3}, "1 ahead, 3 behind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := git.NewMockGitRunner("/test/overlay")
+			mock.CurrentBranchFunc = func() (string, error) {
+				return tt.branch, nil
+			}
+			mock.UpstreamRefFunc = func() (string, error) {
+				if tt.upstream == "" {
+					return "", git.ErrGitCommand
+				}
+				return tt.upstream, nil
+			}
+			mock.AheadBehindFunc = func(upstream string) (int, int, error) {
+				return tt.ahead, tt.behind, nil
+			}
+
+			bs, err := BranchStatusWithExecutor(mock)
+			if err != nil {
+				t.Fatalf("BranchStatusWithExecutor() error = %v", err)
+			}
+			if *bs != tt.want {
+				t.Errorf("BranchStatusWithExecutor() = %+v, want %+v", *bs, tt.want)
+			}
+			if got := FormatBranchStatus(bs); !strings.Contains(got, tt.contains) {
+				t.Errorf("FormatBranchStatus() = %q, want it to contain %q", got, tt.contains)
+			}
+		})
+	}
+}
+
+// TestDiffWithExecutor tests that diff options reach the executor
+func TestDiffWithExecutor(t *testing.T) {
+	var got git.DiffOptions
+	mock := git.NewMockGitRunner("/test/overlay")
+	mock.DiffFunc = func(opts git.DiffOptions) (string, error) {
+		got = opts
+		return "+KEYWORDS=\"~amd64\"\n", nil
+	}
+
+	opts := git.DiffOptions{Staged: true, Paths: []string{"app-misc/hello"}}
+	diff, err := DiffWithExecutor(mock, opts)
+	if err != nil || diff != "+KEYWORDS=\"~amd64\"\n" {
+		t.Errorf("DiffWithExecutor() = %q, %v", diff, err)
+	}
+	if !got.Staged || len(got.Paths) != 1 || got.Paths[0] != "app-misc/hello" {
+		t.Errorf("executor received %+v, want %+v", got, opts)
+	}
+}
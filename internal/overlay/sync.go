@@ -2,6 +2,7 @@ package overlay
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/obentoo/bentoolkit/internal/common/config"
@@ -13,22 +14,70 @@ var (
 	ErrSyncConflict = errors.New("merge conflicts detected")
 	// ErrNoRemote indicates no remote is configured
 	ErrNoRemote = errors.New("no remote configured")
+	// ErrInvalidStrategy indicates an unknown sync strategy
+	ErrInvalidStrategy = errors.New("invalid sync strategy")
+	// ErrNoSyncInProgress indicates there is no stopped merge or rebase to continue or abort
+	ErrNoSyncInProgress = errors.New("no merge or rebase in progress")
 )
 
+// SyncStrategy selects how upstream changes are integrated
+type SyncStrategy string
+
+const (
+	SyncMerge  SyncStrategy = "merge"   // Merge upstream, creating a merge commit if histories diverged
+	SyncRebase SyncStrategy = "rebase"  // Replay local commits on top of upstream
+	SyncFFOnly SyncStrategy = "ff-only" // Only fast-forward; fail if histories diverged
+)
+
+// ParseSyncStrategy parses a strategy name. An empty name means SyncMerge.
+func ParseSyncStrategy(s string) (SyncStrategy, error) {
+	switch SyncStrategy(s) {
+	case "", SyncMerge:
+		return SyncMerge, nil
+	case SyncRebase, SyncFFOnly:
+		return SyncStrategy(s), nil
+	}
+	return "", fmt.Errorf("%w: %q (expected merge, rebase or ff-only)", ErrInvalidStrategy, s)
+}
+
+// SyncOptions configures a sync operation
+type SyncOptions struct {
+	Remote    string       // Remote to fetch from
+	Strategy  SyncStrategy // How to integrate upstream changes
+	Autostash bool         // Stash local changes around the sync
+}
+
 // SyncResult contains sync operation results
 type SyncResult struct {
-	Success       bool     // True if sync completed without conflicts
-	CommitsPulled int      // Number of commits pulled from upstream
-	Conflicts     []string // List of conflicting file paths
-	Message       string   // Human-readable status message
+	Success       bool             `json:"success"`             // True if sync completed without conflicts
+	CommitsPulled int              `json:"commits_pulled"`      // Number of commits pulled from upstream
+	Conflicts     []string         `json:"conflicts,omitempty"` // List of conflicting file paths
+	Message       string           `json:"message"`             // Human-readable status message
+	Strategy      SyncStrategy     `json:"strategy"`            // Strategy used
+	Packages      []string         `json:"packages,omitempty"`  // category/package entries touched by incoming commits
+	Operation     git.Operation    `json:"operation,omitempty"` // Merge or rebase left stopped on conflicts, if any
+	Upstream      *UpstreamSummary `json:"upstream,omitempty"`  // Per-commit classification of incoming changes
+	head          string           // Pre-sync HEAD
+	incoming      []string         // Incoming upstream commits, oldest first
 }
 
-// Sync fetches and merges upstream changes from the configured remote.
-// It performs a fetch followed by a merge operation.
-func Sync(cfg *config.Config) (*SyncResult, error) {
-	overlayPath, err := cfg.GetOverlayPath()
+// ManifestConflicts returns the conflicting paths that are Manifest files.
+// These should be regenerated rather than merged by hand.
+func (r *SyncResult) ManifestConflicts() []string {
+	var manifests []string
+	for _, c := range r.Conflicts {
+		if DetectFileType(c) == FileTypeManifest {
+			manifests = append(manifests, c)
+		}
+	}
+	return manifests
+}
+
+// SyncOptionsFromConfig builds SyncOptions from the overlay configuration
+func SyncOptionsFromConfig(cfg *config.Config) (SyncOptions, error) {
+	strategy, err := ParseSyncStrategy(cfg.Overlay.Sync.Strategy)
 	if err != nil {
-		return nil, err
+		return SyncOptions{}, err
 	}
 
 	remote := cfg.Overlay.Remote
@@ -36,44 +85,230 @@ func Sync(cfg *config.Config) (*SyncResult, error) {
 		remote = "origin"
 	}
 
-	runner := git.NewGitRunner(overlayPath)
-	return SyncWithRunner(runner, remote)
+	return SyncOptions{
+		Remote:    remote,
+		Strategy:  strategy,
+		Autostash: cfg.Overlay.Sync.AutostashEnabled(),
+	}, nil
+}
+
+// Sync fetches and integrates upstream changes from the configured remote,
+// using the strategy and autostash settings from overlay.sync.
+func Sync(cfg *config.Config) (*SyncResult, error) {
+	opts, err := SyncOptionsFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return SyncWithOptions(cfg, opts)
+}
+
+// SyncWithOptions fetches and integrates upstream changes using the given options
+func SyncWithOptions(cfg *config.Config, opts SyncOptions) (*SyncResult, error) {
+	feature := git.FeatureMerge
+	if opts.Strategy == SyncRebase {
+		feature = git.FeatureRebase
+	}
+	runner, err := NewExecutor(cfg, feature)
+	if err != nil {
+		return nil, err
+	}
+
+	return SyncWithExecutor(runner, opts)
 }
 
 // SyncWithRunner performs sync using a provided GitExecutor.
 // This allows for testing with mock implementations.
 func SyncWithRunner(runner git.GitExecutor, remote string) (*SyncResult, error) {
-	if remote == "" {
+	return SyncWithExecutor(runner, SyncOptions{Remote: remote, Strategy: SyncMerge})
+}
+
+// SyncWithExecutor fetches from the remote and integrates its HEAD using the
+// configured strategy. Conflicts are reported in the result, leaving the merge
+// or rebase stopped so it can be continued or aborted.
+func SyncWithExecutor(runner git.GitExecutor, opts SyncOptions) (*SyncResult, error) {
+	if opts.Remote == "" {
 		return nil, ErrNoRemote
 	}
+	if opts.Strategy == "" {
+		opts.Strategy = SyncMerge
+	}
 
 	// Fetch changes from remote
-	if err := runner.Fetch(remote); err != nil {
+	if err := runner.Fetch(opts.Remote); err != nil {
 		return nil, err
 	}
 
-	// Merge the remote tracking branch
-	branch := remote + "/HEAD"
-	err := runner.Merge(branch)
+	upstream := opts.Remote + "/HEAD"
+	result := &SyncResult{Strategy: opts.Strategy}
 
-	if err != nil {
+	// Describe the incoming changes before integrating them
+	if err := describeIncoming(runner, upstream, result); err != nil {
+		return nil, err
+	}
+
+	if err := integrate(runner, upstream, opts); err != nil {
 		// Check if the error indicates merge conflicts
-		errStr := err.Error()
-		if isConflictError(errStr) {
-			conflicts := parseConflicts(errStr)
-			return &SyncResult{
-				Success:   false,
-				Conflicts: conflicts,
-				Message:   "Merge conflicts detected. Please resolve conflicts manually.",
-			}, nil
+		if !isConflictError(err.Error()) {
+			return nil, err
+		}
+		return conflictResult(runner, result, err), nil
+	}
+
+	result.Success = true
+	if len(result.incoming) > 0 {
+		// The summary is informational; a failure here does not undo the sync
+		if summary, err := SummarizeUpstream(runner, result.head, result.incoming); err == nil {
+			result.Upstream = summary
+		}
+	}
+	if result.CommitsPulled == 0 && len(result.Packages) == 0 {
+		result.Message = "Sync completed successfully."
+	} else {
+		result.Message = fmt.Sprintf("Sync completed successfully: %d commit(s) pulled, %d package(s) touched.",
+			result.CommitsPulled, len(result.Packages))
+	}
+	return result, nil
+}
+
+// integrate applies upstream to the current branch using the selected strategy
+func integrate(runner git.GitExecutor, upstream string, opts SyncOptions) error {
+	switch opts.Strategy {
+	case SyncRebase:
+		return runner.Rebase(upstream, opts.Autostash)
+	case SyncFFOnly:
+		return runner.MergeWithOptions(upstream, git.MergeOptions{FFOnly: true, Autostash: opts.Autostash})
+	case SyncMerge:
+		if opts.Autostash {
+			return runner.MergeWithOptions(upstream, git.MergeOptions{Autostash: true})
+		}
+		return runner.Merge(upstream)
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidStrategy, opts.Strategy)
+}
+
+// describeIncoming records the number of upstream commits not yet in HEAD
+// and the packages they touch. It does nothing for an unborn HEAD.
+func describeIncoming(runner git.GitExecutor, upstream string, result *SyncResult) error {
+	head, err := runner.RevParse("HEAD")
+	if err != nil || head == "" {
+		return nil
+	}
+	upstreamHead, err := runner.RevParse(upstream)
+	if err != nil {
+		return err
+	}
+
+	commits, err := runner.RevList(head + ".." + upstreamHead)
+	if err != nil {
+		return err
+	}
+	result.head = head
+	result.incoming = commits
+	result.CommitsPulled = len(commits)
+	if len(commits) == 0 {
+		return nil
+	}
+
+	base, err := runner.MergeBase(head, upstreamHead)
+	if err != nil {
+		return err
+	}
+	entries, err := runner.DiffNameStatus(base, upstreamHead)
+	if err != nil {
+		return err
+	}
+	result.Packages = entryPackages(expandRenames(entries))
+	return nil
+}
+
+// conflictResult builds the result for a sync stopped on conflicts
+func conflictResult(runner git.GitExecutor, result *SyncResult, err error) *SyncResult {
+	result.Success = false
+	result.Conflicts = parseConflicts(err.Error())
+
+	// Prefer the index's view of unmerged paths when available
+	if entries, statusErr := runner.Status(); statusErr == nil {
+		var unmerged []string
+		for _, e := range entries {
+			if e.IsUnmerged() {
+				unmerged = append(unmerged, e.FilePath)
+			}
+		}
+		if len(unmerged) > 0 {
+			result.Conflicts = unmerged
 		}
+	}
+
+	if op, opErr := runner.InProgress(); opErr == nil {
+		result.Operation = op
+	}
+
+	result.Message = "Merge conflicts detected. Please resolve conflicts manually."
+	if result.Operation == git.OperationRebase {
+		result.Message = "Rebase stopped on conflicts. Please resolve conflicts manually."
+	}
+	return result
+}
+
+// SyncContinue resumes a sync stopped on conflicts
+func SyncContinue(cfg *config.Config) (*SyncResult, error) {
+	runner, err := NewExecutor(cfg, git.FeatureMerge, git.FeatureRebase)
+	if err != nil {
 		return nil, err
 	}
 
-	return &SyncResult{
-		Success: true,
-		Message: "Sync completed successfully.",
-	}, nil
+	return SyncContinueWithExecutor(runner)
+}
+
+// SyncContinueWithExecutor resumes a stopped merge or rebase after the
+// conflicts were resolved and staged. New conflicts are reported in the result.
+func SyncContinueWithExecutor(runner git.GitExecutor) (*SyncResult, error) {
+	op, err := runner.InProgress()
+	if err != nil {
+		return nil, err
+	}
+	if op == git.OperationNone {
+		return nil, ErrNoSyncInProgress
+	}
+
+	result := &SyncResult{Strategy: SyncMerge}
+	if op == git.OperationRebase {
+		result.Strategy = SyncRebase
+	}
+
+	if err := runner.Continue(op); err != nil {
+		if !isConflictError(err.Error()) {
+			return nil, err
+		}
+		return conflictResult(runner, result, err), nil
+	}
+
+	result.Success = true
+	result.Message = fmt.Sprintf("Sync completed successfully (%s continued).", op)
+	return result, nil
+}
+
+// SyncAbort aborts a sync stopped on conflicts
+func SyncAbort(cfg *config.Config) error {
+	runner, err := NewExecutor(cfg, git.FeatureMerge, git.FeatureRebase)
+	if err != nil {
+		return err
+	}
+
+	return SyncAbortWithExecutor(runner)
+}
+
+// SyncAbortWithExecutor aborts a stopped merge or rebase, restoring the
+// pre-sync state including any autostashed local changes
+func SyncAbortWithExecutor(runner git.GitExecutor) error {
+	op, err := runner.InProgress()
+	if err != nil {
+		return err
+	}
+	if op == git.OperationNone {
+		return ErrNoSyncInProgress
+	}
+	return runner.Abort(op)
 }
 
 // isConflictError checks if an error message indicates merge conflicts
@@ -83,6 +318,8 @@ func isConflictError(errStr string) bool {
 		"Automatic merge failed",
 		"fix conflicts",
 		"Merge conflict",
+		"could not apply",
+		"Resolve all conflicts",
 	}
 
 	for _, indicator := range conflictIndicators {
@@ -2,12 +2,17 @@ package overlay
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"github.com/obentoo/bentoolkit/internal/common/config"
 	"github.com/obentoo/bentoolkit/internal/common/git"
 )
 
@@ -206,3 +211,232 @@ func TestSyncMergeNonConflictError(t *testing.T) {
 		t.Errorf("Expected merge error to be propagated, got: %v", err)
 	}
 }
+
+// TestSyncStrategies verifies each strategy calls the matching git operation
+func TestSyncStrategies(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  SyncStrategy
+		autostash bool
+		wantCall  string
+	}{
+		{"merge without autostash", SyncMerge, false, "merge origin/HEAD"},
+		{"merge with autostash", SyncMerge, true, "merge-opts origin/HEAD ff=false autostash=true"},
+		{"rebase", SyncRebase, true, "rebase origin/HEAD autostash=true"},
+		{"rebase without autostash", SyncRebase, false, "rebase origin/HEAD autostash=false"},
+		{"ff-only", SyncFFOnly, false, "merge-opts origin/HEAD ff=true autostash=false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			mock := git.NewMockGitRunner("/test/overlay")
+			mock.MergeFunc = func(branch string) error {
+				calls = append(calls, "merge "+branch)
+				return nil
+			}
+			mock.MergeWithOptionsFunc = func(branch string, opts git.MergeOptions) error {
+				calls = append(calls, fmt.Sprintf("merge-opts %s ff=%v autostash=%v", branch, opts.FFOnly, opts.Autostash))
+				return nil
+			}
+			mock.RebaseFunc = func(upstream string, autostash bool) error {
+				calls = append(calls, fmt.Sprintf("rebase %s autostash=%v", upstream, autostash))
+				return nil
+			}
+
+			result, err := SyncWithExecutor(mock, SyncOptions{Remote: "origin", Strategy: tt.strategy, Autostash: tt.autostash})
+			if err != nil {
+				t.Fatalf("SyncWithExecutor() error = %v", err)
+			}
+			if !result.Success || result.Strategy != tt.strategy {
+				t.Errorf("unexpected result %+v", result)
+			}
+			if len(calls) != 1 || calls[0] != tt.wantCall {
+				t.Errorf("calls = %v, want [%s]", calls, tt.wantCall)
+			}
+		})
+	}
+}
+
+// TestSyncReportsIncomingChanges verifies the commit count and touched packages
+func TestSyncReportsIncomingChanges(t *testing.T) {
+	mock := git.NewMockGitRunner("/test/overlay")
+	head := "local"
+	mock.RevParseFunc = func(rev string) (string, error) {
+		return map[string]string{"HEAD": head, "origin/HEAD": "remote"}[rev], nil
+	}
+	mock.RebaseFunc = func(upstream string, autostash bool) error {
+		head = "remote"
+		return nil
+	}
+	mock.RevListFunc = func(revRange string) ([]string, error) {
+		if revRange != "local..remote" {
+			t.Errorf("RevList(%q), want local..remote", revRange)
+		}
+		return []string{"c1", "c2", "c3"}, nil
+	}
+	mock.MergeBaseFunc = func(a, b string) (string, error) { return "base", nil }
+	mock.DiffNameStatusFunc = func(from, to string) ([]git.StatusEntry, error) {
+		// Per-commit diffs for the upstream summary
+		if from == to+"^" {
+			return []git.StatusEntry{
+				{Status: "A", Index: git.StatusAdded, FilePath: "net-misc/foo/foo-2.0.ebuild"},
+			}, nil
+		}
+		if from != "base" || to != "remote" {
+			t.Errorf("DiffNameStatus(%q, %q), want base, remote", from, to)
+		}
+		return []git.StatusEntry{
+			{Status: "A", Index: git.StatusAdded, FilePath: "net-misc/foo/foo-2.0.ebuild"},
+			{Status: "R", Index: git.StatusRenamed, FilePath: "app-misc/new/new-1.0.ebuild", OrigPath: "app-misc/old/old-1.0.ebuild"},
+			{Status: "M", Index: git.StatusModified, FilePath: "profiles/package.mask"},
+		}, nil
+	}
+
+	result, err := SyncWithExecutor(mock, SyncOptions{Remote: "origin", Strategy: SyncRebase})
+	if err != nil {
+		t.Fatalf("SyncWithExecutor() error = %v", err)
+	}
+	if result.CommitsPulled != 3 {
+		t.Errorf("CommitsPulled = %d, want 3", result.CommitsPulled)
+	}
+	want := []string{"app-misc/new", "app-misc/old", "net-misc/foo"}
+	if !reflect.DeepEqual(result.Packages, want) {
+		t.Errorf("Packages = %v, want %v", result.Packages, want)
+	}
+	if result.Upstream == nil || len(result.Upstream.Commits) != 3 {
+		t.Errorf("Upstream = %+v, want three summarized commits", result.Upstream)
+	}
+}
+
+// TestSyncConflictGuidance verifies conflicts are read from the index and
+// Manifest conflicts are singled out
+func TestSyncConflictGuidance(t *testing.T) {
+	mock := git.NewMockGitRunner("/test/overlay")
+	mock.RebaseFunc = func(upstream string, autostash bool) error {
+		return errors.New("error: could not apply abc123... up(net-misc/foo-1.0 -> 2.0)")
+	}
+	mock.SetStatusOutput("u UU N... 100644 100644 100644 100644 h1 h2 h3 net-misc/foo/Manifest\x00" +
+		"u AA N... 100644 100644 100644 100644 h1 h2 h3 net-misc/foo/foo-2.0.ebuild\x00" +
+		"1 M. N... 100644 100644 100644 h1 h2 net-misc/bar/bar-1.0.ebuild\x00")
+	mock.InProgressFunc = func() (git.Operation, error) { return git.OperationRebase, nil }
+
+	result, err := SyncWithExecutor(mock, SyncOptions{Remote: "origin", Strategy: SyncRebase})
+	if err != nil {
+		t.Fatalf("SyncWithExecutor() error = %v", err)
+	}
+	if result.Success {
+		t.Fatal("expected Success=false")
+	}
+	if result.Operation != git.OperationRebase {
+		t.Errorf("Operation = %q, want rebase", result.Operation)
+	}
+	wantConflicts := []string{"net-misc/foo/Manifest", "net-misc/foo/foo-2.0.ebuild"}
+	if !reflect.DeepEqual(result.Conflicts, wantConflicts) {
+		t.Errorf("Conflicts = %v, want %v", result.Conflicts, wantConflicts)
+	}
+	if got := result.ManifestConflicts(); !reflect.DeepEqual(got, []string{"net-misc/foo/Manifest"}) {
+		t.Errorf("ManifestConflicts() = %v", got)
+	}
+}
+
+// TestSyncContinueAndAbort verifies --continue and --abort act on the stopped operation
+func TestSyncContinueAndAbort(t *testing.T) {
+	t.Run("nothing in progress", func(t *testing.T) {
+		mock := git.NewMockGitRunner("/test/overlay")
+		if _, err := SyncContinueWithExecutor(mock); !errors.Is(err, ErrNoSyncInProgress) {
+			t.Errorf("continue: expected ErrNoSyncInProgress, got %v", err)
+		}
+		if err := SyncAbortWithExecutor(mock); !errors.Is(err, ErrNoSyncInProgress) {
+			t.Errorf("abort: expected ErrNoSyncInProgress, got %v", err)
+		}
+	})
+
+	t.Run("continue rebase", func(t *testing.T) {
+		var continued git.Operation
+		mock := git.NewMockGitRunner("/test/overlay")
+		mock.InProgressFunc = func() (git.Operation, error) { return git.OperationRebase, nil }
+		mock.ContinueFunc = func(op git.Operation) error {
+			continued = op
+			return nil
+		}
+
+		result, err := SyncContinueWithExecutor(mock)
+		if err != nil {
+			t.Fatalf("SyncContinueWithExecutor() error = %v", err)
+		}
+		if !result.Success || result.Strategy != SyncRebase || continued != git.OperationRebase {
+			t.Errorf("unexpected result %+v (continued %q)", result, continued)
+		}
+	})
+
+	t.Run("continue stops on next conflict", func(t *testing.T) {
+		mock := git.NewMockGitRunner("/test/overlay")
+		mock.InProgressFunc = func() (git.Operation, error) { return git.OperationRebase, nil }
+		mock.ContinueFunc = func(op git.Operation) error {
+			return errors.New("CONFLICT (content): Merge conflict in app-misc/foo/Manifest")
+		}
+
+		result, err := SyncContinueWithExecutor(mock)
+		if err != nil {
+			t.Fatalf("SyncContinueWithExecutor() error = %v", err)
+		}
+		if result.Success || len(result.Conflicts) != 1 {
+			t.Errorf("expected one conflict, got %+v", result)
+		}
+	})
+
+	t.Run("abort merge", func(t *testing.T) {
+		var aborted git.Operation
+		mock := git.NewMockGitRunner("/test/overlay")
+		mock.InProgressFunc = func() (git.Operation, error) { return git.OperationMerge, nil }
+		mock.AbortFunc = func(op git.Operation) error {
+			aborted = op
+			return nil
+		}
+
+		if err := SyncAbortWithExecutor(mock); err != nil {
+			t.Fatalf("SyncAbortWithExecutor() error = %v", err)
+		}
+		if aborted != git.OperationMerge {
+			t.Errorf("aborted %q, want merge", aborted)
+		}
+	})
+}
+
+func TestParseSyncStrategy(t *testing.T) {
+	for _, s := range []string{"", "merge", "rebase", "ff-only"} {
+		if _, err := ParseSyncStrategy(s); err != nil {
+			t.Errorf("ParseSyncStrategy(%q) error = %v", s, err)
+		}
+	}
+	if _, err := ParseSyncStrategy("squash"); !errors.Is(err, ErrInvalidStrategy) {
+		t.Errorf("expected ErrInvalidStrategy, got %v", err)
+	}
+}
+
+func TestSyncRefusedByGoGitBackend(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"profiles", "metadata"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &config.Config{
+		Overlay: config.OverlayConfig{Path: dir, Remote: "origin"},
+		Git:     config.GitConfig{Backend: git.BackendGoGit},
+	}
+
+	for _, strategy := range []SyncStrategy{SyncMerge, SyncRebase, SyncFFOnly} {
+		_, err := SyncWithOptions(cfg, SyncOptions{Remote: "origin", Strategy: strategy})
+		if !errors.Is(err, git.ErrUnsupported) {
+			t.Errorf("SyncWithOptions(%s) error = %v, want ErrUnsupported", strategy, err)
+		}
+	}
+	if _, err := SyncContinue(cfg); !errors.Is(err, git.ErrUnsupported) {
+		t.Errorf("SyncContinue() error = %v, want ErrUnsupported", err)
+	}
+	if err := SyncAbort(cfg); !errors.Is(err, git.ErrUnsupported) {
+		t.Errorf("SyncAbort() error = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,150 @@
+// Package overlay provides business logic for overlay management operations.
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// renameFile and manifestUpdater are replaceable in tests to simulate failures.
+var (
+	renameFile      = os.Rename
+	manifestUpdater = updateManifests
+)
+
+// RollbackError indicates that a rename operation failed and every change
+// it had made was reverted. If some changes could not be reverted, they are
+// listed in RollbackFailures and the overlay needs manual attention.
+type RollbackError struct {
+	Cause            error    // The failure that triggered the rollback
+	RolledBack       int      // Number of file moves reverted
+	RollbackFailures []string // Paths that could not be restored
+}
+
+// Error implements the error interface.
+func (e *RollbackError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("rename failed: %v", e.Cause))
+	if len(e.RollbackFailures) == 0 {
+		sb.WriteString(fmt.Sprintf("; rolled back %d rename(s), overlay left unchanged", e.RolledBack))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("; rollback incomplete, %d path(s) need manual attention:", len(e.RollbackFailures)))
+	for _, f := range e.RollbackFailures {
+		sb.WriteString("\n  " + f)
+	}
+	return sb.String()
+}
+
+// Unwrap returns the underlying cause.
+func (e *RollbackError) Unwrap() error {
+	return e.Cause
+}
+
+// fileSnapshot holds the original state of a file touched by a transaction.
+type fileSnapshot struct {
+	exists bool
+	data   []byte
+	mode   os.FileMode
+}
+
+// renameTransaction records every change made by a rename so it can be reverted.
+type renameTransaction struct {
+	applied   []RenameMatch            // Moves performed, in order
+	snapshots map[string]*fileSnapshot // Original content of overwritten targets and Manifests
+	order     []string                 // Snapshot paths in capture order
+}
+
+// newRenameTransaction stages a transaction for the given matches.
+// It verifies every source exists and snapshots every file the rename may
+// overwrite (forced conflict targets and package Manifests) before anything moves.
+func newRenameTransaction(matches []RenameMatch, overlayPath string, withManifests bool) (*renameTransaction, error) {
+	tx := &renameTransaction{snapshots: make(map[string]*fileSnapshot)}
+
+	for _, match := range matches {
+		if _, err := os.Stat(match.OldPath); err != nil {
+			return nil, fmt.Errorf("staging %s: %w", match.OldFilename, err)
+		}
+		if err := tx.snapshot(match.NewPath); err != nil {
+			return nil, err
+		}
+		if withManifests {
+			manifest := filepath.Join(overlayPath, match.Category, match.Package, "Manifest")
+			if err := tx.snapshot(manifest); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return tx, nil
+}
+
+// snapshot records the current state of path, once.
+func (tx *renameTransaction) snapshot(path string) error {
+	if _, done := tx.snapshots[path]; done {
+		return nil
+	}
+
+	snap := &fileSnapshot{}
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("staging %s: %w", path, err)
+		}
+		snap.exists = true
+		snap.data = data
+		snap.mode = info.Mode().Perm()
+	case !os.IsNotExist(err):
+		return fmt.Errorf("staging %s: %w", path, err)
+	}
+
+	tx.snapshots[path] = snap
+	tx.order = append(tx.order, path)
+	return nil
+}
+
+// apply performs a single move and records it.
+func (tx *renameTransaction) apply(match RenameMatch) error {
+	if err := renameFile(match.OldPath, match.NewPath); err != nil {
+		return err
+	}
+	tx.applied = append(tx.applied, match)
+	return nil
+}
+
+// rollback reverts all applied moves in reverse order, then restores every
+// snapshotted file. It returns the paths that could not be restored.
+func (tx *renameTransaction) rollback() []string {
+	var failures []string
+
+	for i := len(tx.applied) - 1; i >= 0; i-- {
+		match := tx.applied[i]
+		if err := os.Rename(match.NewPath, match.OldPath); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", match.OldPath, err))
+		}
+	}
+
+	for _, path := range tx.order {
+		if err := tx.snapshots[path].restore(path); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", path, err))
+		}
+	}
+
+	return failures
+}
+
+// restore writes the snapshot back to path, removing files that did not exist.
+func (s *fileSnapshot) restore(path string) error {
+	if !s.exists {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(path, s.data, s.mode)
+}
@@ -0,0 +1,146 @@
+package overlay
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/config"
+)
+
+// TestRenameRollbackOnRenameFailure tests that a failed rename restores
+// every ebuild that had already been moved.
+func TestRenameRollbackOnRenameFailure(t *testing.T) {
+	overlayPath := setupRenameTestOverlay(t)
+	defer os.RemoveAll(overlayPath)
+
+	createRenameTestEbuild(t, overlayPath, "media-plugins", "gst-plugins-bad", "1.24.11")
+	createRenameTestEbuild(t, overlayPath, "media-plugins", "gst-plugins-base", "1.24.11")
+	createRenameTestEbuild(t, overlayPath, "media-plugins", "gst-plugins-good", "1.24.11")
+
+	// Fail on the last rename
+	origRename := renameFile
+	defer func() { renameFile = origRename }()
+	renameFile = func(oldPath, newPath string) error {
+		if filepath.Base(oldPath) == "gst-plugins-good-1.24.11.ebuild" {
+			return errors.New("simulated failure")
+		}
+		return os.Rename(oldPath, newPath)
+	}
+
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+	spec := &RenameSpec{Category: "media-plugins", PackagePattern: "gst-plugins-*", OldVersion: "1.24.11", NewVersion: "1.26.10"}
+
+	result, err := Rename(cfg, spec, &RenameOptions{NoManifest: true})
+
+	var rbErr *RollbackError
+	if !errors.As(err, &rbErr) {
+		t.Fatalf("Rename() error = %v, want *RollbackError", err)
+	}
+	if rbErr.RolledBack != 2 || len(rbErr.RollbackFailures) != 0 {
+		t.Errorf("RollbackError = %+v, want 2 rolled back and no failures", rbErr)
+	}
+	if len(result.Renamed) != 0 || len(result.RolledBack) != 2 || len(result.Failed) != 1 {
+		t.Errorf("result renamed=%d rolledBack=%d failed=%d, want 0/2/1",
+			len(result.Renamed), len(result.RolledBack), len(result.Failed))
+	}
+
+	for _, pkg := range []string{"gst-plugins-bad", "gst-plugins-base", "gst-plugins-good"} {
+		pkgDir := filepath.Join(overlayPath, "media-plugins", pkg)
+		if _, err := os.Stat(filepath.Join(pkgDir, pkg+"-1.24.11.ebuild")); err != nil {
+			t.Errorf("%s: original ebuild not restored", pkg)
+		}
+		if _, err := os.Stat(filepath.Join(pkgDir, pkg+"-1.26.10.ebuild")); err == nil {
+			t.Errorf("%s: renamed ebuild left behind", pkg)
+		}
+	}
+}
+
+// TestRenameRollbackOnManifestFailure tests that Manifest failures restore
+// ebuilds, Manifests and forcibly overwritten targets.
+func TestRenameRollbackOnManifestFailure(t *testing.T) {
+	overlayPath := setupRenameTestOverlay(t)
+	defer os.RemoveAll(overlayPath)
+
+	createRenameTestEbuild(t, overlayPath, "app-misc", "hello", "1.0.0")
+	createRenameTestEbuild(t, overlayPath, "app-misc", "world", "1.0.0")
+
+	pkgDir := filepath.Join(overlayPath, "app-misc", "hello")
+	manifest := filepath.Join(pkgDir, "Manifest")
+	if err := os.WriteFile(manifest, []byte("DIST hello-1.0.0.tar.gz 1 BLAKE2B x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Existing target overwritten by --force
+	target := filepath.Join(pkgDir, "hello-2.0.0.ebuild")
+	if err := os.WriteFile(target, []byte("# original 2.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	origUpdater := manifestUpdater
+	defer func() { manifestUpdater = origUpdater }()
+	manifestUpdater = func(renamed []RenameMatch, overlayPath string) []ManifestUpdate {
+		var updates []ManifestUpdate
+		for _, m := range renamed {
+			path := filepath.Join(overlayPath, m.Category, m.Package, "Manifest")
+			os.WriteFile(path, []byte("DIST changed\n"), 0644)
+			updates = append(updates, ManifestUpdate{Category: m.Category, Package: m.Package, Success: m.Package == "hello", Error: "boom"})
+		}
+		return updates
+	}
+
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+	specs := []*RenameSpec{
+		{Category: "app-misc", PackagePattern: "hello", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+		{Category: "app-misc", PackagePattern: "world", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+	}
+
+	_, err := RenameBatch(cfg, specs, &RenameOptions{Force: true})
+	var rbErr *RollbackError
+	if !errors.As(err, &rbErr) {
+		t.Fatalf("RenameBatch() error = %v, want *RollbackError", err)
+	}
+
+	if data, _ := os.ReadFile(manifest); string(data) != "DIST hello-1.0.0.tar.gz 1 BLAKE2B x\n" {
+		t.Errorf("hello Manifest not restored: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(overlayPath, "app-misc", "world", "Manifest")); err == nil {
+		t.Error("world Manifest created by the failed update was not removed")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "# original 2.0.0\n" {
+		t.Errorf("overwritten target not restored: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(pkgDir, "hello-1.0.0.ebuild")); err != nil {
+		t.Error("hello-1.0.0.ebuild not restored")
+	}
+}
+
+// TestRenameKeepPartial tests that --keep-partial keeps completed renames.
+func TestRenameKeepPartial(t *testing.T) {
+	overlayPath := setupRenameTestOverlay(t)
+	defer os.RemoveAll(overlayPath)
+
+	createRenameTestEbuild(t, overlayPath, "media-plugins", "gst-plugins-base", "1.24.11")
+	createRenameTestEbuild(t, overlayPath, "media-plugins", "gst-plugins-good", "1.24.11")
+
+	origRename := renameFile
+	defer func() { renameFile = origRename }()
+	renameFile = func(oldPath, newPath string) error {
+		if filepath.Base(oldPath) == "gst-plugins-good-1.24.11.ebuild" {
+			return errors.New("simulated failure")
+		}
+		return os.Rename(oldPath, newPath)
+	}
+
+	cfg := &config.Config{Overlay: config.OverlayConfig{Path: overlayPath}}
+	spec := &RenameSpec{Category: "media-plugins", PackagePattern: "gst-plugins-*", OldVersion: "1.24.11", NewVersion: "1.26.10"}
+
+	result, err := Rename(cfg, spec, &RenameOptions{NoManifest: true, KeepPartial: true})
+	if err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if len(result.Renamed) != 1 || len(result.Failed) != 1 || len(result.RolledBack) != 0 {
+		t.Errorf("result renamed=%d failed=%d rolledBack=%d, want 1/1/0",
+			len(result.Renamed), len(result.Failed), len(result.RolledBack))
+	}
+}
@@ -0,0 +1,142 @@
+package overlay
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/git"
+	"github.com/obentoo/bentoolkit/internal/common/output"
+)
+
+// UpstreamCommit holds the classified changes of a single incoming commit
+type UpstreamCommit struct {
+	Hash    string   `json:"hash"`
+	Changes []Change `json:"changes"`
+}
+
+// PackageSummary holds the changes an upstream range made to one package
+type PackageSummary struct {
+	Package string   `json:"package"` // category/package
+	Changes []Change `json:"changes"` // Unique changes, in commit order
+}
+
+// UpstreamSummary describes what changed upstream between two commits
+type UpstreamSummary struct {
+	From     string           `json:"from"`     // Pre-sync HEAD
+	To       string           `json:"to"`       // Post-sync HEAD
+	Commits  []UpstreamCommit `json:"commits"`  // Incoming commits, oldest first
+	Packages []PackageSummary `json:"packages"` // Changes grouped by package, sorted by name
+}
+
+// Count returns the number of unique package changes of the given type
+func (s *UpstreamSummary) Count(ct ChangeType) int {
+	n := 0
+	for _, p := range s.Packages {
+		for _, c := range p.Changes {
+			if c.Type == ct {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// SummarizeUpstream classifies the commits in from..HEAD with AnalyzeChanges.
+// Only commits listed in incoming are considered, so local commits replayed by
+// a rebase and the merge commit created by the sync itself are left out.
+func SummarizeUpstream(runner git.GitExecutor, from string, incoming []string) (*UpstreamSummary, error) {
+	to, err := runner.RevParse("HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := runner.RevList(from + ".." + to)
+	if err != nil {
+		return nil, err
+	}
+
+	isIncoming := make(map[string]bool, len(incoming))
+	for _, c := range incoming {
+		isIncoming[c] = true
+	}
+
+	summary := &UpstreamSummary{From: from, To: to}
+	byPackage := make(map[string]*PackageSummary)
+	for _, hash := range commits {
+		if !isIncoming[hash] {
+			continue
+		}
+
+		entries, err := runner.DiffNameStatus(hash+"^", hash)
+		if err != nil {
+			return nil, err
+		}
+
+		changes := AnalyzeChanges(entries)
+		summary.Commits = append(summary.Commits, UpstreamCommit{Hash: hash, Changes: changes})
+
+		for _, c := range changes {
+			key := c.Category + "/" + c.Package
+			ps, ok := byPackage[key]
+			if !ok {
+				ps = &PackageSummary{Package: key}
+				byPackage[key] = ps
+			}
+			if !containsChange(ps.Changes, c) {
+				ps.Changes = append(ps.Changes, c)
+			}
+		}
+	}
+
+	for _, ps := range byPackage {
+		summary.Packages = append(summary.Packages, *ps)
+	}
+	sort.Slice(summary.Packages, func(i, j int) bool {
+		return summary.Packages[i].Package < summary.Packages[j].Package
+	})
+
+	return summary, nil
+}
+
+// containsChange reports whether changes already holds c
+func containsChange(changes []Change, c Change) bool {
+	for _, existing := range changes {
+		if existing == c {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatUpstreamSummary formats an upstream summary for terminal output
+func FormatUpstreamSummary(s *UpstreamSummary) string {
+	if len(s.Packages) == 0 {
+		return output.Sprint(output.Dim, "No ebuild changes upstream.")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(output.Sprintf(output.Header, "Upstream changes (%d commit(s)): %d added, %d removed, %d bumped, %d downgraded, %d modified",
+		len(s.Commits), s.Count(Add), s.Count(Del), s.Count(Up), s.Count(Down), s.Count(Mod)))
+	sb.WriteString("\n")
+
+	for _, p := range s.Packages {
+		parts := make([]string, 0, len(p.Changes))
+		for _, c := range p.Changes {
+			parts = append(parts, formatUpstreamChange(c))
+		}
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", output.Sprint(output.Info, p.Package), strings.Join(parts, ", ")))
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// formatUpstreamChange formats a single change without its package name
+func formatUpstreamChange(c Change) string {
+	switch c.Type {
+	case Up, Down:
+		return fmt.Sprintf("%s %s -> %s", c.Type, c.OldVersion, c.Version)
+	default:
+		return fmt.Sprintf("%s %s", c.Type, c.Version)
+	}
+}
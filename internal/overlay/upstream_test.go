@@ -0,0 +1,114 @@
+package overlay
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/git"
+)
+
+// upstreamMock returns a mock whose history from "pre" to "new" holds the given
+// per-commit diffs, in order
+func upstreamMock(commits []string, diffs map[string][]git.StatusEntry) *git.MockGitRunner {
+	mock := git.NewMockGitRunner("/test/overlay")
+	mock.RevParseFunc = func(rev string) (string, error) {
+		return "new", nil
+	}
+	mock.RevListFunc = func(revRange string) ([]string, error) {
+		return commits, nil
+	}
+	mock.DiffNameStatusFunc = func(from, to string) ([]git.StatusEntry, error) {
+		return diffs[to], nil
+	}
+	return mock
+}
+
+func TestSummarizeUpstream(t *testing.T) {
+	diffs := map[string][]git.StatusEntry{
+		"c1": {
+			{Status: "R", Index: git.StatusRenamed, FilePath: "net-misc/foo/foo-2.0.ebuild", OrigPath: "net-misc/foo/foo-1.0.ebuild"},
+			{Status: "M", Index: git.StatusModified, FilePath: "net-misc/foo/Manifest"},
+		},
+		"local": {
+			{Status: "A", Index: git.StatusAdded, FilePath: "app-misc/mine/mine-1.0.ebuild"},
+		},
+		"c2": {
+			{Status: "A", Index: git.StatusAdded, FilePath: "app-misc/bar/bar-1.0.ebuild"},
+			{Status: "D", Index: git.StatusDeleted, FilePath: "dev-libs/old/old-0.1.ebuild"},
+		},
+		// An upstream merge repeating c1's bump must not duplicate it
+		"m1": {
+			{Status: "R", Index: git.StatusRenamed, FilePath: "net-misc/foo/foo-2.0.ebuild", OrigPath: "net-misc/foo/foo-1.0.ebuild"},
+		},
+	}
+	mock := upstreamMock([]string{"c1", "local", "c2", "m1"}, diffs)
+
+	summary, err := SummarizeUpstream(mock, "pre", []string{"c1", "c2", "m1"})
+	if err != nil {
+		t.Fatalf("SummarizeUpstream() error = %v", err)
+	}
+
+	if summary.From != "pre" || summary.To != "new" {
+		t.Errorf("range = %s..%s, want pre..new", summary.From, summary.To)
+	}
+
+	var hashes []string
+	for _, c := range summary.Commits {
+		hashes = append(hashes, c.Hash)
+	}
+	if !reflect.DeepEqual(hashes, []string{"c1", "c2", "m1"}) {
+		t.Errorf("commits = %v, want [c1 c2 m1] (local commit excluded)", hashes)
+	}
+
+	want := []PackageSummary{
+		{Package: "app-misc/bar", Changes: []Change{{Type: Add, Category: "app-misc", Package: "bar", Version: "1.0"}}},
+		{Package: "dev-libs/old", Changes: []Change{{Type: Del, Category: "dev-libs", Package: "old", Version: "0.1"}}},
+		{Package: "net-misc/foo", Changes: []Change{{Type: Up, Category: "net-misc", Package: "foo", Version: "2.0", OldVersion: "1.0"}}},
+	}
+	if !reflect.DeepEqual(summary.Packages, want) {
+		t.Errorf("packages = %+v\nwant %+v", summary.Packages, want)
+	}
+
+	if summary.Count(Add) != 1 || summary.Count(Del) != 1 || summary.Count(Up) != 1 {
+		t.Errorf("unexpected counts: add=%d del=%d up=%d", summary.Count(Add), summary.Count(Del), summary.Count(Up))
+	}
+
+	formatted := FormatUpstreamSummary(summary)
+	for _, s := range []string{"1 added", "1 removed", "1 bumped", "net-misc/foo: up 1.0 -> 2.0", "app-misc/bar: add 1.0"} {
+		if !strings.Contains(formatted, s) {
+			t.Errorf("formatted summary missing %q:\n%s", s, formatted)
+		}
+	}
+}
+
+func TestSyncCarriesUpstreamSummary(t *testing.T) {
+	mock := upstreamMock([]string{"c1"}, map[string][]git.StatusEntry{
+		"c1": {{Status: "A", Index: git.StatusAdded, FilePath: "app-misc/bar/bar-1.0.ebuild"}},
+	})
+	mock.RevParseFunc = func(rev string) (string, error) {
+		return map[string]string{"HEAD": "pre", "origin/HEAD": "c1"}[rev], nil
+	}
+	mock.MergeFunc = func(branch string) error {
+		// After the fast-forward, HEAD points at the upstream commit
+		mock.RevParseFunc = func(rev string) (string, error) { return "c1", nil }
+		return nil
+	}
+
+	result, err := SyncWithRunner(mock, "origin")
+	if err != nil {
+		t.Fatalf("SyncWithRunner() error = %v", err)
+	}
+	if result.Upstream == nil {
+		t.Fatal("expected upstream summary")
+	}
+	if len(result.Upstream.Packages) != 1 || result.Upstream.Packages[0].Package != "app-misc/bar" {
+		t.Errorf("unexpected summary %+v", result.Upstream)
+	}
+}
+
+func TestFormatUpstreamSummaryEmpty(t *testing.T) {
+	if got := FormatUpstreamSummary(&UpstreamSummary{}); !strings.Contains(got, "No ebuild changes") {
+		t.Errorf("FormatUpstreamSummary() = %q", got)
+	}
+}
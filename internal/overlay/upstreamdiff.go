@@ -0,0 +1,458 @@
+package overlay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/output"
+	"github.com/obentoo/bentoolkit/internal/common/provider"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// FileDiff is the unified diff of one package file against upstream
+type FileDiff struct {
+	Path string // Relative to the package directory, e.g. "files/fix.patch"
+	Diff string // Unified diff; empty when the files are the same
+}
+
+// PackageDiff compares the latest local ebuild of a package, its
+// metadata.xml and files/ with those of an upstream version
+type PackageDiff struct {
+	Category      string
+	Package       string
+	LocalVersion  string
+	RemoteName    string // category/package upstream carries it under
+	RemoteVersion string
+	Files         []FileDiff // Only the files that differ
+
+	// ebuild and files hold the upstream ebuild and files/ contents, by path
+	// relative to files/, for ImportUpstreamEbuild
+	ebuild []byte
+	files  map[string][]byte
+}
+
+// FullName returns the category/package format
+func (d *PackageDiff) FullName() string {
+	return d.Category + "/" + d.Package
+}
+
+// DiffOptions configures DiffPackage
+type DiffOptions struct {
+	// Version is the upstream version to compare with; empty means the
+	// latest released one
+	Version string
+}
+
+// DiffPackage fetches the upstream ebuild, metadata.xml and files/ of a
+// local package through the provider and diffs them against the overlay's
+// latest ebuild. Package moves are followed as in compare. The provider must
+// implement provider.FileReader.
+func DiffPackage(pkg PackageInfo, prov provider.Provider, opts DiffOptions) (*PackageDiff, error) {
+	reader, ok := prov.(provider.FileReader)
+	if !ok {
+		return nil, fmt.Errorf("provider %s cannot read repository files", prov.GetName())
+	}
+
+	versions, remoteName, err := findRemoteVersions(prov, newUpstreamMoves(prov), pkg)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", provider.ErrNotFound, pkg.FullName())
+	}
+
+	remoteVersion := opts.Version
+	if remoteVersion == "" {
+		remoteVersion = FindLatestVersionFiltered(versions, true)
+	} else if !slices.Contains(versions, remoteVersion) {
+		return nil, fmt.Errorf("%w: %s-%s", provider.ErrNotFound, remoteName, remoteVersion)
+	}
+
+	_, remotePkg, _ := strings.Cut(remoteName, "/")
+	d := &PackageDiff{
+		Category:      pkg.Category,
+		Package:       pkg.Package,
+		LocalVersion:  pkg.LatestVersion,
+		RemoteName:    remoteName,
+		RemoteVersion: remoteVersion,
+		files:         make(map[string][]byte),
+	}
+
+	// Ebuild
+	localEbuild := pkg.Package + "-" + pkg.LatestVersion + ".ebuild"
+	remoteEbuild := remotePkg + "-" + remoteVersion + ".ebuild"
+	local, err := os.ReadFile(filepath.Join(pkg.Path, localEbuild))
+	if err != nil {
+		return nil, err
+	}
+	if d.ebuild, err = reader.ReadFile(remoteName + "/" + remoteEbuild); err != nil {
+		return nil, err
+	}
+	d.addFile(remoteEbuild,
+		unifiedDiff("a/"+pkg.FullName()+"/"+localEbuild, "b/"+remoteName+"/"+remoteEbuild, local, d.ebuild))
+
+	// metadata.xml
+	if err := d.diffFile(reader, pkg, "metadata.xml"); err != nil {
+		return nil, err
+	}
+
+	// files/, on both sides
+	localFiles, err := listLocalFiles(filepath.Join(pkg.Path, "files"))
+	if err != nil {
+		return nil, err
+	}
+	remoteFiles, err := listRemoteFiles(reader, remoteName+"/files")
+	if err != nil {
+		return nil, err
+	}
+	names := append(localFiles, remoteFiles...)
+	sort.Strings(names)
+	for i, name := range names {
+		if i > 0 && names[i-1] == name {
+			continue
+		}
+		if err := d.diffFile(reader, pkg, "files/"+name); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// diffFile diffs a file of the package directory, missing on either side
+// or not, and keeps the upstream content of files/ entries
+func (d *PackageDiff) diffFile(reader provider.FileReader, pkg PackageInfo, name string) error {
+	local, err := os.ReadFile(filepath.Join(pkg.Path, filepath.FromSlash(name)))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	localName := "a/" + pkg.FullName() + "/" + name
+	if err != nil {
+		localName = "/dev/null"
+	}
+
+	remote, err := reader.ReadFile(d.RemoteName + "/" + name)
+	if err != nil && !errors.Is(err, provider.ErrNotFound) {
+		return err
+	}
+	remoteName := "b/" + d.RemoteName + "/" + name
+	if err != nil {
+		remoteName = "/dev/null"
+	} else if rel, ok := strings.CutPrefix(name, "files/"); ok {
+		d.files[rel] = remote
+	}
+
+	d.addFile(name, unifiedDiff(localName, remoteName, local, remote))
+	return nil
+}
+
+// addFile records the diff of a file when there is one
+func (d *PackageDiff) addFile(name, diff string) {
+	if diff != "" {
+		d.Files = append(d.Files, FileDiff{Path: name, Diff: diff})
+	}
+}
+
+// listLocalFiles returns the files under dir, by slash-separated path
+// relative to it. A missing directory has none.
+func listLocalFiles(dir string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(dir, func(p string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			rel, _ := filepath.Rel(dir, p)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return names, err
+}
+
+// listRemoteFiles returns the files under a repository directory, by path
+// relative to it. A missing directory has none.
+func listRemoteFiles(reader provider.FileReader, dir string) ([]string, error) {
+	entries, err := reader.ListDir(dir)
+	if errors.Is(err, provider.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		sub, isDir := strings.CutSuffix(entry, "/")
+		if !isDir {
+			names = append(names, entry)
+			continue
+		}
+		nested, err := listRemoteFiles(reader, path.Join(dir, sub))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range nested {
+			names = append(names, sub+"/"+name)
+		}
+	}
+	return names, nil
+}
+
+// FormatPackageDiff formats a package diff for terminal output, coloring
+// removed and added lines
+func FormatPackageDiff(d *PackageDiff) string {
+	var sb strings.Builder
+	sb.WriteString(output.Sprintf(output.Header, "%s-%s (overlay) vs %s-%s (upstream)\n",
+		d.FullName(), d.LocalVersion, d.RemoteName, d.RemoteVersion))
+
+	if len(d.Files) == 0 {
+		sb.WriteString(output.Sprintf(output.Success, "No differences.\n"))
+		return sb.String()
+	}
+
+	for _, f := range d.Files {
+		sb.WriteString("\n")
+		for _, line := range strings.SplitAfter(f.Diff, "\n") {
+			switch {
+			case line == "":
+			case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+				sb.WriteString(output.Sprint(output.Header, line))
+			case strings.HasPrefix(line, "@@"):
+				sb.WriteString(output.Sprint(output.Info, line))
+			case strings.HasPrefix(line, "-"):
+				sb.WriteString(output.Sprint(output.Deleted, line))
+			case strings.HasPrefix(line, "+"):
+				sb.WriteString(output.Sprint(output.Added, line))
+			default:
+				sb.WriteString(line)
+			}
+		}
+	}
+	sb.WriteString(fmt.Sprintf("\n%d file(s) differ\n", len(d.Files)))
+	return sb.String()
+}
+
+// ImportResult describes what ImportUpstreamEbuild wrote
+type ImportResult struct {
+	Ebuild string   // Path of the new ebuild
+	Added  []string // files/ entries copied from upstream
+	Kept   []string // files/ entries that differ from upstream and were left as is
+}
+
+// ImportUpstreamEbuild copies the upstream ebuild of a diff into the
+// package directory as a new version, under the local package name, along
+// with the files/ entries the overlay does not have yet. Local files/ entries
+// are never overwritten, and an existing ebuild is an error.
+func ImportUpstreamEbuild(pkgPath string, d *PackageDiff) (*ImportResult, error) {
+	if d.ebuild == nil {
+		return nil, fmt.Errorf("no upstream ebuild fetched for %s", d.FullName())
+	}
+
+	target := filepath.Join(pkgPath, d.Package+"-"+d.RemoteVersion+".ebuild")
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("%s already exists", target)
+	}
+
+	names := make([]string, 0, len(d.files))
+	for name := range d.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := &ImportResult{Ebuild: target}
+	for _, name := range names {
+		dest := filepath.Join(pkgPath, "files", filepath.FromSlash(name))
+		local, err := os.ReadFile(dest)
+		if err == nil {
+			if !bytes.Equal(local, d.files[name]) {
+				result.Kept = append(result.Kept, name)
+			}
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(dest, d.files[name], 0644); err != nil {
+			return nil, err
+		}
+		result.Added = append(result.Added, name)
+	}
+
+	if err := os.WriteFile(target, d.ebuild, 0644); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// diffOp is one line of an edit script: ' ' kept, '-' removed or '+' added
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns the unified diff turning a into b, with fromName and
+// toName as file headers, or "" when they are the same
+func unifiedDiff(fromName, toName string, a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	if bytes.IndexByte(a, 0) >= 0 || bytes.IndexByte(b, 0) >= 0 {
+		return fmt.Sprintf("Binary files %s and %s differ\n", fromName, toName)
+	}
+
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	// aLine and bLine are the line numbers reached before ops[i]
+	aLine, bLine := 1, 1
+	for i := 0; i < len(ops); {
+		// Skip to the next change
+		for i < len(ops) && ops[i].kind == ' ' {
+			aLine, bLine = aLine+1, bLine+1
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		// Extend the hunk over changes closer than twice the context
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				break
+			}
+			end = run
+		}
+
+		start := max(i-diffContext, 0)
+		stop := min(end+diffContext, len(ops))
+		aStart, bStart := aLine-(i-start), bLine-(i-start)
+
+		var body strings.Builder
+		aCount, bCount := 0, 0
+		for _, op := range ops[start:stop] {
+			body.WriteByte(op.kind)
+			body.WriteString(op.line)
+			body.WriteByte('\n')
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		sb.WriteString(body.String())
+
+		for _, op := range ops[i:stop] {
+			if op.kind != '+' {
+				aLine++
+			}
+			if op.kind != '-' {
+				bLine++
+			}
+		}
+		i = stop
+	}
+	return sb.String()
+}
+
+// hunkRange formats the line range of a hunk side. An empty side starts at
+// the line before, and a single line omits the count, as diff -u does.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d,%d", start, count)
+	}
+}
+
+// splitLines splits content into lines without their newline
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+// diffLines computes a shortest edit script from a to b through their
+// longest common subsequence. The common prefix and suffix are trimmed
+// first, which keeps the table small for similar ebuilds.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			ops = append(ops, diffOp{' ', x[i]})
+			i, j = i+1, j+1
+		case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', x[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', y[j]})
+			j++
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
@@ -0,0 +1,134 @@
+package overlay
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obentoo/bentoolkit/internal/common/provider"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n"
+
+	want := `--- a/x
++++ b/x
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -13,3 +13,4 @@
+ 13
+ 14
+ 15
++16
+`
+	if got := unifiedDiff("a/x", "b/x", []byte(a), []byte(b)); got != want {
+		t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+
+	tests := []struct {
+		name, a, b, want string
+	}{
+		{"same", "x\n", "x\n", ""},
+		{"added file", "", "x\ny\n", "--- /dev/null\n+++ b/x\n@@ -0,0 +1,2 @@\n+x\n+y\n"},
+		{"removed file", "x\n", "", "--- a/x\n+++ /dev/null\n@@ -1 +0,0 @@\n-x\n"},
+		{"binary", "a\x00", "b\x00", "Binary files a/x and b/x differ\n"},
+	}
+	for _, tc := range tests {
+		from, to := "a/x", "b/x"
+		if tc.a == "" {
+			from = "/dev/null"
+		}
+		if tc.b == "" {
+			to = "/dev/null"
+		}
+		if got := unifiedDiff(from, to, []byte(tc.a), []byte(tc.b)); got != tc.want {
+			t.Errorf("%s: unifiedDiff() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDiffPackage(t *testing.T) {
+	upstream := t.TempDir()
+	writeEbuilds(t, upstream, map[string]string{
+		"net-misc/hello/hello-1.0.ebuild":      "EAPI=8\nSLOT=\"0\"\n",
+		"net-misc/hello/hello-2.0.ebuild":      "EAPI=8\nSLOT=\"0\"\nPATCHES=( \"${FILESDIR}\"/fix.patch )\n",
+		"net-misc/hello/metadata.xml":          "<pkgmetadata/>\n",
+		"net-misc/hello/files/fix.patch":       "upstream fix\n",
+		"net-misc/hello/files/conf/hello.conf": "port=1\n",
+		"net-misc/hello/files/hello-1.0.patch": "same\n",
+		"profiles/updates/1Q-2024":             "move app-misc/hello net-misc/hello\n",
+	})
+	overlayPath := t.TempDir()
+	writeEbuilds(t, overlayPath, map[string]string{
+		"app-misc/hello/hello-1.0.ebuild":      "EAPI=8\nSLOT=\"0\"\n",
+		"app-misc/hello/metadata.xml":          "<pkgmetadata/>\n",
+		"app-misc/hello/files/hello-1.0.patch": "same\n",
+		"app-misc/hello/files/fix.patch":       "local fix\n",
+		"app-misc/hello/files/local.patch":     "ours\n",
+	})
+	scan, err := ScanOverlay(overlayPath)
+	if err != nil {
+		t.Fatalf("ScanOverlay() error = %v", err)
+	}
+	pkg := scan.Packages[0]
+	prov := &provider.LocalProvider{Path: upstream}
+
+	d, err := DiffPackage(pkg, prov, DiffOptions{})
+	if err != nil {
+		t.Fatalf("DiffPackage() error = %v", err)
+	}
+	if d.RemoteName != "net-misc/hello" || d.RemoteVersion != "2.0" {
+		t.Errorf("DiffPackage() against %s-%s, want net-misc/hello-2.0", d.RemoteName, d.RemoteVersion)
+	}
+	var paths []string
+	for _, f := range d.Files {
+		paths = append(paths, f.Path)
+	}
+	if got := strings.Join(paths, " "); got != "hello-2.0.ebuild files/conf/hello.conf files/fix.patch files/local.patch" {
+		t.Errorf("Files = %s", got)
+	}
+	if !strings.Contains(d.Files[0].Diff, "--- a/app-misc/hello/hello-1.0.ebuild\n+++ b/net-misc/hello/hello-2.0.ebuild\n") ||
+		!strings.Contains(d.Files[0].Diff, "+PATCHES=") {
+		t.Errorf("ebuild diff =\n%s", d.Files[0].Diff)
+	}
+	if !strings.Contains(FormatPackageDiff(d), "4 file(s) differ") {
+		t.Errorf("FormatPackageDiff() =\n%s", FormatPackageDiff(d))
+	}
+
+	// A given version must exist upstream
+	if _, err := DiffPackage(pkg, prov, DiffOptions{Version: "3.0"}); err == nil {
+		t.Error("DiffPackage(3.0) succeeded")
+	}
+
+	// Import writes the new ebuild and missing files, keeping local ones
+	result, err := ImportUpstreamEbuild(pkg.Path, d)
+	if err != nil {
+		t.Fatalf("ImportUpstreamEbuild() error = %v", err)
+	}
+	if result.Ebuild != filepath.Join(pkg.Path, "hello-2.0.ebuild") ||
+		strings.Join(result.Added, " ") != "conf/hello.conf" || strings.Join(result.Kept, " ") != "fix.patch" {
+		t.Errorf("ImportUpstreamEbuild() = %+v", result)
+	}
+	if content, _ := os.ReadFile(result.Ebuild); !strings.Contains(string(content), "PATCHES=") {
+		t.Errorf("imported ebuild = %q", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(pkg.Path, "files", "fix.patch")); string(content) != "local fix\n" {
+		t.Errorf("local fix.patch overwritten: %q", content)
+	}
+	if _, err := ImportUpstreamEbuild(pkg.Path, d); err == nil {
+		t.Error("second ImportUpstreamEbuild() succeeded")
+	}
+
+	// Providers that cannot read files are refused
+	if _, err := DiffPackage(pkg, &fakeProvider{}, DiffOptions{}); err == nil {
+		t.Error("DiffPackage(fake) succeeded")
+	}
+}
@@ -24,6 +24,7 @@ func NewVersionFilesDetector(overlayPath string) *VersionFilesDetector {
 // Detect scans for version-specific files in package directories.
 // It checks the files/ subdirectory of each unique category/package
 // in the matches and returns all files containing the old version string.
+// A match's own OldVersion takes precedence over oldVersion when set.
 func (d *VersionFilesDetector) Detect(matches []RenameMatch, oldVersion string) []VersionFile {
 	var versionFiles []VersionFile
 
@@ -31,7 +32,12 @@ func (d *VersionFilesDetector) Detect(matches []RenameMatch, oldVersion string)
 	processed := make(map[string]bool)
 
 	for _, match := range matches {
-		key := match.Category + "/" + match.Package
+		version := oldVersion
+		if match.OldVersion != "" {
+			version = match.OldVersion
+		}
+
+		key := match.Category + "/" + match.Package + "@" + version
 		if processed[key] {
 			continue
 		}
@@ -41,7 +47,7 @@ func (d *VersionFilesDetector) Detect(matches []RenameMatch, oldVersion string)
 		filesDir := filepath.Join(d.overlayPath, match.Category, match.Package, "files")
 
 		// Scan the files directory for version-specific files
-		found := d.scanFilesDir(filesDir, match.Category, match.Package, oldVersion)
+		found := d.scanFilesDir(filesDir, match.Category, match.Package, version)
 		versionFiles = append(versionFiles, found...)
 	}
 
@@ -0,0 +1,157 @@
+// Package overlay provides business logic for overlay management operations.
+package overlay
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/obentoo/bentoolkit/internal/common/ebuild"
+)
+
+// VersionPatternKind identifies how a version pattern is matched.
+type VersionPatternKind int
+
+const (
+	// VersionExact matches a single version string exactly
+	VersionExact VersionPatternKind = iota
+	// VersionGlob matches versions using shell glob syntax (e.g., "1.24.*")
+	VersionGlob
+	// VersionConstraint matches versions using comparison operators (e.g., "<1.26")
+	VersionConstraint
+)
+
+// versionOperators lists supported constraint operators.
+// Two-character operators must come first so they are matched before their prefixes.
+var versionOperators = []string{"<=", ">=", "!=", "<", ">", "="}
+
+// versionConstraint is a single operator/version pair such as ">=1.24".
+type versionConstraint struct {
+	Op      string
+	Version string
+}
+
+// VersionPattern matches ebuild base versions (without revision suffix).
+//
+// Supported forms:
+//   - Exact:      "1.24.11"
+//   - Glob:       "1.24.*", "1.2?.1"
+//   - Constraint: "<1.26", ">=1.24,<1.26" (comma-separated constraints are ANDed)
+type VersionPattern struct {
+	Raw         string
+	Kind        VersionPatternKind
+	constraints []versionConstraint
+}
+
+// VersionPatternError indicates an unparseable version pattern.
+type VersionPatternError struct {
+	Pattern string
+	Reason  string
+}
+
+// Error implements the error interface.
+func (e *VersionPatternError) Error() string {
+	return fmt.Sprintf("invalid version pattern '%s': %s", e.Pattern, e.Reason)
+}
+
+// ParseVersionPattern parses a version pattern string.
+func ParseVersionPattern(pattern string) (*VersionPattern, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, &VersionPatternError{Pattern: pattern, Reason: "pattern cannot be empty"}
+	}
+
+	// Constraint: starts with a comparison operator
+	if strings.ContainsAny(pattern[:1], "<>=!") {
+		var constraints []versionConstraint
+		for _, part := range strings.Split(pattern, ",") {
+			c, err := parseVersionConstraint(strings.TrimSpace(part))
+			if err != nil {
+				return nil, &VersionPatternError{Pattern: pattern, Reason: err.Error()}
+			}
+			constraints = append(constraints, c)
+		}
+
+		// A single "=" constraint is just an exact match
+		if len(constraints) == 1 && constraints[0].Op == "=" {
+			return &VersionPattern{Raw: constraints[0].Version, Kind: VersionExact}, nil
+		}
+
+		return &VersionPattern{Raw: pattern, Kind: VersionConstraint, constraints: constraints}, nil
+	}
+
+	// Glob: contains shell wildcards
+	if strings.ContainsAny(pattern, "*?[") {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, &VersionPatternError{Pattern: pattern, Reason: "malformed glob"}
+		}
+		return &VersionPattern{Raw: pattern, Kind: VersionGlob}, nil
+	}
+
+	return &VersionPattern{Raw: pattern, Kind: VersionExact}, nil
+}
+
+// parseVersionConstraint parses a single "<op><version>" constraint.
+func parseVersionConstraint(s string) (versionConstraint, error) {
+	for _, op := range versionOperators {
+		if !strings.HasPrefix(s, op) {
+			continue
+		}
+		version := strings.TrimSpace(strings.TrimPrefix(s, op))
+		if version == "" {
+			return versionConstraint{}, fmt.Errorf("missing version after '%s'", op)
+		}
+		if strings.ContainsAny(version, "*?[<>=!") {
+			return versionConstraint{}, fmt.Errorf("unexpected characters in '%s'", version)
+		}
+		return versionConstraint{Op: op, Version: version}, nil
+	}
+	return versionConstraint{}, fmt.Errorf("constraint '%s' must start with one of %s", s, strings.Join(versionOperators, " "))
+}
+
+// IsExact returns true if the pattern matches a single literal version.
+func (p *VersionPattern) IsExact() bool {
+	return p.Kind == VersionExact
+}
+
+// Match reports whether a base version (without revision suffix) satisfies the pattern.
+func (p *VersionPattern) Match(version string) bool {
+	switch p.Kind {
+	case VersionGlob:
+		matched, err := filepath.Match(p.Raw, version)
+		return err == nil && matched
+	case VersionConstraint:
+		for _, c := range p.constraints {
+			if !c.satisfied(version) {
+				return false
+			}
+		}
+		return true
+	default:
+		return version == p.Raw
+	}
+}
+
+// satisfied reports whether version satisfies the constraint.
+func (c versionConstraint) satisfied(version string) bool {
+	cmp := ebuild.CompareVersions(version, c.Version)
+	switch c.Op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "!=":
+		return cmp != 0
+	default:
+		return cmp == 0
+	}
+}
+
+// String returns the original pattern text.
+func (p *VersionPattern) String() string {
+	return p.Raw
+}
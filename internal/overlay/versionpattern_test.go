@@ -0,0 +1,124 @@
+package overlay
+
+import (
+	"os"
+	"testing"
+)
+
+// TestParseVersionPattern tests pattern kind detection and parse errors.
+func TestParseVersionPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		wantKind VersionPatternKind
+		wantErr  bool
+	}{
+		{"1.24.11", VersionExact, false},
+		{"=1.24.11", VersionExact, false},
+		{"1.24.*", VersionGlob, false},
+		{"1.2?.1", VersionGlob, false},
+		{"<1.26", VersionConstraint, false},
+		{">=1.24,<1.26", VersionConstraint, false},
+		{"!=1.24.10", VersionConstraint, false},
+		{"", VersionExact, true},
+		{"<", VersionExact, true},
+		{">=1.24,", VersionExact, true},
+		{"<1.2*", VersionExact, true},
+		{"1.24.[", VersionExact, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			p, err := ParseVersionPattern(tt.pattern)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseVersionPattern(%q) expected error, got kind %v", tt.pattern, p.Kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVersionPattern(%q) error = %v", tt.pattern, err)
+			}
+			if p.Kind != tt.wantKind {
+				t.Errorf("ParseVersionPattern(%q) kind = %v, want %v", tt.pattern, p.Kind, tt.wantKind)
+			}
+		})
+	}
+}
+
+// TestVersionPatternMatch tests matching against base versions.
+func TestVersionPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		version string
+		want    bool
+	}{
+		{"1.24.11", "1.24.11", true},
+		{"1.24.11", "1.24.10", false},
+		{"=1.24.11", "1.24.11", true},
+		{"1.24.*", "1.24.10", true},
+		{"1.24.*", "1.24.11", true},
+		{"1.24.*", "1.26.0", false},
+		{"<1.26", "1.24.11", true},
+		{"<1.26", "1.26", false},
+		{"<1.26", "1.26_rc1", true},
+		{"<=1.26", "1.26", true},
+		{">1.24.10", "1.24.11", true},
+		{">=1.24,<1.26", "1.25.3", true},
+		{">=1.24,<1.26", "1.23.9", false},
+		{"!=1.24.10", "1.24.10", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.version, func(t *testing.T) {
+			p, err := ParseVersionPattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("ParseVersionPattern(%q) error = %v", tt.pattern, err)
+			}
+			if got := p.Match(tt.version); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMatcherVersionPatternSelectsHighest tests that glob and constraint
+// patterns pick only the highest matching ebuild per package.
+func TestMatcherVersionPatternSelectsHighest(t *testing.T) {
+	overlayPath := setupMatcherTestOverlay(t)
+	defer os.RemoveAll(overlayPath)
+
+	createMatcherTestEbuild(t, overlayPath, "media-plugins", "gst-plugins-base", "1.24.10")
+	createMatcherTestEbuild(t, overlayPath, "media-plugins", "gst-plugins-base", "1.24.11")
+	createMatcherTestEbuild(t, overlayPath, "media-plugins", "gst-plugins-good", "1.24.10")
+	createMatcherTestEbuild(t, overlayPath, "media-plugins", "gst-plugins-good", "1.24.10-r2")
+	createMatcherTestEbuild(t, overlayPath, "media-plugins", "gst-plugins-good", "1.26.0")
+
+	for _, oldVersion := range []string{"1.24.*", "<1.26"} {
+		t.Run(oldVersion, func(t *testing.T) {
+			matcher := NewEbuildMatcher(overlayPath)
+			matches, err := matcher.Match(&RenameSpec{
+				Category:       "media-plugins",
+				PackagePattern: "gst-plugins-*",
+				OldVersion:     oldVersion,
+				NewVersion:     "1.26.10",
+			})
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+
+			got := make(map[string]RenameMatch)
+			for _, m := range matches {
+				got[m.Package] = m
+			}
+			if len(matches) != 2 {
+				t.Fatalf("Match() got %d matches, want 2: %+v", len(matches), matches)
+			}
+			if m := got["gst-plugins-base"]; m.OldFilename != "gst-plugins-base-1.24.11.ebuild" || m.OldVersion != "1.24.11" {
+				t.Errorf("gst-plugins-base matched %s (version %s)", m.OldFilename, m.OldVersion)
+			}
+			if m := got["gst-plugins-good"]; m.OldFilename != "gst-plugins-good-1.24.10-r2.ebuild" || !m.HasRevision {
+				t.Errorf("gst-plugins-good matched %s", m.OldFilename)
+			}
+		})
+	}
+}